@@ -0,0 +1,185 @@
+package apierrors
+
+import (
+	"log"
+	"net/http"
+	"sort"
+
+	"be-geoffray/localization"
+	"be-geoffray/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Error is an error of the API catalog: a stable code clients can rely on and the HTTP status it is answered with
+// Its message is the "errors.<code>" translation, rendered in the language of the request
+type Error struct {
+	Code   string         // Machine-readable code, e.g. "event_not_found"; never changes once released
+	Status int            // HTTP status of the response
+	Args   map[string]any // Arguments of the message, e.g. the name of a missing field
+	Fields gin.H          // Extra fields of the response body, left untranslated
+}
+
+func (e Error) Error() string {
+	return e.Code
+}
+
+// MessageKey returns the translation key of the message
+func (e Error) MessageKey() string {
+	return "errors." + e.Code
+}
+
+// WithArg returns a copy of the error with an argument of its message
+func (e Error) WithArg(name string, value any) Error {
+	args := make(map[string]any, len(e.Args)+1)
+	for k, v := range e.Args {
+		args[k] = v
+	}
+	args[name] = value
+	e.Args = args
+	return e
+}
+
+// WithField returns a copy of the error with an extra field in the response body
+func (e Error) WithField(name string, value any) Error {
+	fields := make(gin.H, len(e.Fields)+1)
+	for k, v := range e.Fields {
+		fields[k] = v
+	}
+	fields[name] = value
+	e.Fields = fields
+	return e
+}
+
+// WithDetails returns a copy of the error with technical details, such as the validation errors of a request body
+func (e Error) WithDetails(details any) Error {
+	return e.WithField("details", details)
+}
+
+// catalog lists every error defined below
+var catalog []Error
+
+// define adds an error to the catalog
+func define(code string, status int) Error {
+	err := Error{Code: code, Status: status}
+	catalog = append(catalog, err)
+	return err
+}
+
+// Catalog returns every error of the catalog, sorted by code
+func Catalog() []Error {
+	sorted := append([]Error(nil), catalog...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Code < sorted[j].Code })
+	return sorted
+}
+
+// Authentication and permissions
+var (
+	Unauthenticated      = define("unauthenticated", http.StatusUnauthorized)
+	TokenRequired        = define("token_required", http.StatusUnauthorized)
+	InvalidToken         = define("invalid_token", http.StatusUnauthorized)
+	InvalidFirebaseToken = define("invalid_firebase_token", http.StatusUnauthorized)
+	AdminRequired        = define("admin_required", http.StatusForbidden)
+	EventAccessDenied    = define("event_access_denied", http.StatusForbidden)
+	EventCreatorOnly     = define("event_creator_only", http.StatusForbidden)
+	NotParticipant       = define("not_participant", http.StatusForbidden)
+	SuggestionOwnerOnly  = define("suggestion_owner_only", http.StatusForbidden)
+	MessageAuthorOnly    = define("message_author_only", http.StatusForbidden)
+	GifteeProfileHidden  = define("giftee_profile_hidden", http.StatusForbidden)
+	InviteEmailMismatch  = define("invite_email_mismatch", http.StatusForbidden)
+)
+
+// Invalid requests
+var (
+	InvalidRequest      = define("invalid_request", http.StatusBadRequest)
+	MissingField        = define("missing_field", http.StatusBadRequest) // Args: field
+	InvalidField        = define("invalid_field", http.StatusBadRequest) // Args: field
+	NoFieldsToUpdate    = define("no_fields_to_update", http.StatusBadRequest)
+	UnsupportedLanguage = define("unsupported_language", http.StatusBadRequest) // Args: language
+	InvalidDateRange    = define("invalid_date_range", http.StatusBadRequest)
+	InvalidURL          = define("invalid_url", http.StatusBadRequest)
+	InvalidCursor       = define("invalid_cursor", http.StatusBadRequest)
+	InvalidEmoji        = define("invalid_emoji", http.StatusBadRequest)
+	InvalidPollOptions  = define("invalid_poll_options", http.StatusBadRequest)
+	InvalidAttachments  = define("invalid_attachments", http.StatusBadRequest)
+	TooManyAttachments  = define("too_many_attachments", http.StatusBadRequest)
+	UnsupportedFileType = define("unsupported_file_type", http.StatusBadRequest)
+	EmptyFile           = define("empty_file", http.StatusBadRequest)
+	FileTooLarge        = define("file_too_large", http.StatusRequestEntityTooLarge)
+	InvalidTranslations = define("invalid_translations", http.StatusUnprocessableEntity)
+	InvalidPrompts      = define("invalid_prompt_templates", http.StatusUnprocessableEntity)
+)
+
+// Missing resources
+var (
+	EventNotFound         = define("event_not_found", http.StatusNotFound)
+	SuggestionNotFound    = define("suggestion_not_found", http.StatusNotFound)
+	VoteNotFound          = define("vote_not_found", http.StatusNotFound)
+	UserNotFound          = define("user_not_found", http.StatusNotFound)
+	InviteNotFound        = define("invite_not_found", http.StatusNotFound)
+	InvitationNotFound    = define("invitation_not_found", http.StatusNotFound)
+	AttachmentNotFound    = define("attachment_not_found", http.StatusNotFound)
+	PollNotFound          = define("poll_not_found", http.StatusNotFound)
+	MessageNotFound       = define("message_not_found", http.StatusNotFound)
+	ReactionNotFound      = define("reaction_not_found", http.StatusNotFound)
+	NotificationNotFound  = define("notification_not_found", http.StatusNotFound)
+	GifteeProfileNotFound = define("giftee_profile_not_found", http.StatusNotFound)
+	WebhookNotFound       = define("webhook_not_found", http.StatusNotFound)
+	DeliveryNotFound      = define("delivery_not_found", http.StatusNotFound)
+	TranslationNotFound   = define("translation_not_found", http.StatusNotFound)
+)
+
+// Conflicts with the state of a resource
+var (
+	MessageDeleted        = define("message_deleted", http.StatusConflict)
+	InviteAlreadyAccepted = define("invite_already_accepted", http.StatusConflict)
+	AlreadyParticipant    = define("already_participant", http.StatusConflict)
+	InviteExpired         = define("invite_expired", http.StatusGone)
+)
+
+// Failures of the server or of the services it relies on
+var (
+	AIQuotaExceeded     = define(services.AIQuotaExceededCode, http.StatusTooManyRequests)
+	AIGenerationFailed  = define("ai_generation_failed", http.StatusInternalServerError)
+	RealtimeUnavailable = define("realtime_unavailable", http.StatusServiceUnavailable)
+	InternalError       = define("internal_error", http.StatusInternalServerError)
+)
+
+// translate formats a message of the translations, replaced in tests
+var translate = func(languageCode, key string, args map[string]any) (string, error) {
+	return localization.NewService().Format(languageCode, key, args)
+}
+
+// Respond answers the request with err and aborts it
+// The body is {"code": "...", "error": "<message in the request language>"} along with the fields of err
+func Respond(c *gin.Context, err Error) {
+	c.AbortWithStatusJSON(err.Status, Body(c, err))
+}
+
+// Body returns the response body of err, for the responses Respond cannot write, such as stream events
+func Body(c *gin.Context, err Error) gin.H {
+	body := make(gin.H, len(err.Fields)+2)
+	for k, v := range err.Fields {
+		body[k] = v
+	}
+	body["code"] = err.Code
+	body["error"] = Message(RequestLanguage(c), err)
+	return body
+}
+
+// Message renders the message of err in a language, falling back to the code when it has no translation
+func Message(languageCode string, err Error) string {
+	message, formatErr := translate(languageCode, err.MessageKey(), err.Args)
+	if formatErr != nil {
+		log.Printf("Error formatting the message of %s: %v", err.Code, formatErr)
+		return err.Code
+	}
+	return message
+}
+
+// RequestLanguage returns the language set by LanguageMiddleware, or the one of the Accept-Language header
+func RequestLanguage(c *gin.Context) string {
+	if languageCode := c.GetString(localization.LanguageContextKey); languageCode != "" {
+		return languageCode
+	}
+	return localization.DetectLanguage(c.GetHeader("Accept-Language"))
+}
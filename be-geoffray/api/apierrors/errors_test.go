@@ -0,0 +1,168 @@
+package apierrors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"be-geoffray/localization"
+	"be-geoffray/models"
+	"github.com/gin-gonic/gin"
+)
+
+// readTranslations reads the translation files of a language
+func readTranslations(t *testing.T, languageCode string) models.TranslationMap {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "localization", "translations", languageCode+".json"))
+	if err != nil {
+		t.Fatalf("failed to read the %s translations: %v", languageCode, err)
+	}
+	translations := models.TranslationMap{}
+	if err := json.Unmarshal(data, &translations); err != nil {
+		t.Fatalf("failed to parse the %s translations: %v", languageCode, err)
+	}
+	return translations
+}
+
+// useTranslationFiles formats messages from the translation files for the duration of the test
+func useTranslationFiles(t *testing.T) {
+	catalogs := map[string]models.TranslationMap{
+		"en": readTranslations(t, "en"),
+		"fr": readTranslations(t, "fr"),
+	}
+	previous := translate
+	translate = func(languageCode, key string, args map[string]any) (string, error) {
+		pattern, ok := catalogs[languageCode][key]
+		if !ok {
+			return "", localization.ErrMissingTranslation
+		}
+		return localization.FormatMessage(languageCode, pattern, args)
+	}
+	t.Cleanup(func() { translate = previous })
+}
+
+func TestCatalogHasMessages(t *testing.T) {
+	args := map[string]any{"field": "name", "language": "de"}
+	seen := map[string]bool{}
+	for _, languageCode := range []string{"en", "fr"} {
+		translations := readTranslations(t, languageCode)
+		for _, err := range Catalog() {
+			if languageCode == "en" && seen[err.Code] {
+				t.Errorf("code %q is defined twice", err.Code)
+			}
+			seen[err.Code] = true
+
+			pattern, ok := translations[err.MessageKey()]
+			if !ok {
+				t.Errorf("missing %s message for %q", languageCode, err.Code)
+				continue
+			}
+			if _, formatErr := localization.FormatMessage(languageCode, pattern, args); formatErr != nil {
+				t.Errorf("invalid %s message for %q: %v", languageCode, err.Code, formatErr)
+			}
+		}
+	}
+}
+
+func TestRespond(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useTranslationFiles(t)
+
+	tests := []struct {
+		name        string
+		language    string
+		header      string
+		err         Error
+		wantStatus  int
+		wantMessage string
+		wantFields  map[string]any
+	}{
+		{
+			name:        "language of the context",
+			language:    "fr",
+			err:         EventNotFound,
+			wantStatus:  http.StatusNotFound,
+			wantMessage: "Événement introuvable",
+		},
+		{
+			name:        "accept-language header",
+			header:      "fr-FR,fr;q=0.9",
+			err:         MissingField.WithArg("field", "title"),
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "Le champ title est obligatoire",
+		},
+		{
+			name:        "default language",
+			err:         AlreadyParticipant.WithField("event_id", "event-1"),
+			wantStatus:  http.StatusConflict,
+			wantMessage: "You are already a participant in this event",
+			wantFields:  map[string]any{"event_id": "event-1"},
+		},
+		{
+			name:        "details",
+			language:    "en",
+			err:         InvalidRequest.WithDetails("name is required"),
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "Invalid request",
+			wantFields:  map[string]any{"details": "name is required"},
+		},
+		{
+			name:        "missing translation falls back to the code",
+			language:    "en",
+			err:         Error{Code: "not_in_catalog", Status: http.StatusTeapot},
+			wantStatus:  http.StatusTeapot,
+			wantMessage: "not_in_catalog",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				c.Request.Header.Set("Accept-Language", tt.header)
+			}
+			if tt.language != "" {
+				c.Set(localization.LanguageContextKey, tt.language)
+			}
+
+			Respond(c, tt.err)
+
+			if !c.IsAborted() {
+				t.Error("expected the request to be aborted")
+			}
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to parse the body: %v", err)
+			}
+			if body["code"] != tt.err.Code {
+				t.Errorf("expected code %q, got %v", tt.err.Code, body["code"])
+			}
+			if body["error"] != tt.wantMessage {
+				t.Errorf("expected message %q, got %v", tt.wantMessage, body["error"])
+			}
+			for name, want := range tt.wantFields {
+				if body[name] != want {
+					t.Errorf("expected %s %v, got %v", name, want, body[name])
+				}
+			}
+		})
+	}
+}
+
+func TestWithArgDoesNotChangeTheCatalog(t *testing.T) {
+	withArg := MissingField.WithArg("field", "title").WithField("extra", true)
+	if len(MissingField.Args) != 0 || len(MissingField.Fields) != 0 {
+		t.Errorf("expected the catalog error to be unchanged, got %+v", MissingField)
+	}
+	if withArg.Args["field"] != "title" || withArg.Fields["extra"] != true {
+		t.Errorf("expected the copy to have its argument and field, got %+v", withArg)
+	}
+}
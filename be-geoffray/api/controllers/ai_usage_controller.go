@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"be-geoffray/api/apierrors"
+	"be-geoffray/models"
+	"be-geoffray/services"
+	"github.com/gin-gonic/gin"
+)
+
+// respondAIQuotaError answers with 429 and the quota error code when err comes from an exhausted AI quota
+// It returns false, without responding, for any other error
+func respondAIQuotaError(c *gin.Context, err error) bool {
+	var quotaErr *services.AIQuotaError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+	c.Header("Retry-After", fmt.Sprintf("%d", int(time.Until(quotaErr.ResetsAt).Seconds())+1))
+	apierrors.Respond(c, apierrors.AIQuotaExceeded.
+		WithField("scope", quotaErr.Subject).
+		WithField("period", quotaErr.Period).
+		WithField("limit", quotaErr.Limit).
+		WithField("resets_at", quotaErr.ResetsAt))
+	return true
+}
+
+// parseUsageTime accepts a date (2006-01-02) or an RFC 3339 timestamp
+func parseUsageTime(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// parseUsageRange reads the from and to query parameters, defaulting to the last 30 days
+// It responds with 400 and returns false when they are invalid
+func parseUsageRange(c *gin.Context) (time.Time, time.Time, bool) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+
+	if value := c.Query("from"); value != "" {
+		parsed, err := parseUsageTime(value)
+		if err != nil {
+			apierrors.Respond(c, apierrors.InvalidField.WithArg("field", "from"))
+			return from, to, false
+		}
+		from = parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := parseUsageTime(value)
+		if err != nil {
+			apierrors.Respond(c, apierrors.InvalidField.WithArg("field", "to"))
+			return from, to, false
+		}
+		to = parsed
+	}
+	if !from.Before(to) {
+		apierrors.Respond(c, apierrors.InvalidDateRange)
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// GetAIUsage handles aggregating the language model usage for administrators
+// Query parameters: from and to (default: the last 30 days), group_by (user, event, purpose, model, provider or day)
+func GetAIUsage(c *gin.Context) {
+	from, to, ok := parseUsageRange(c)
+	if !ok {
+		return
+	}
+
+	groupBy := c.DefaultQuery("group_by", "purpose")
+
+	usageService := services.NewLLMUsageService()
+	groups, err := usageService.GetUsageSummary(from, to, groupBy)
+	if err != nil {
+		if err.Error() == "invalid grouping" {
+			apierrors.Respond(c, apierrors.InvalidField.WithArg("field", "group_by"))
+			return
+		}
+		fmt.Printf("Error aggregating AI usage: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	// Totals over every group, latency weighted by the number of calls
+	var totals models.LLMUsageSummary
+	var latencyCalls int
+	for _, group := range groups {
+		totals.Calls += group.Calls
+		totals.FailedCalls += group.FailedCalls
+		totals.RejectedCalls += group.RejectedCalls
+		totals.PromptTokens += group.PromptTokens
+		totals.CompletionTokens += group.CompletionTokens
+		totals.TotalTokens += group.TotalTokens
+		calls := group.Calls - group.RejectedCalls
+		totals.AvgLatencyMs += group.AvgLatencyMs * float64(calls)
+		latencyCalls += calls
+	}
+	if latencyCalls > 0 {
+		totals.AvgLatencyMs /= float64(latencyCalls)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":     from,
+		"to":       to,
+		"group_by": groupBy,
+		"totals":   totals,
+		"groups":   groups,
+	})
+}
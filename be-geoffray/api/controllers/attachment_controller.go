@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"be-geoffray/api/apierrors"
+	"be-geoffray/config"
+	"be-geoffray/services"
+	"github.com/gin-gonic/gin"
+)
+
+// UploadEventAttachment stores a file sent as the "file" field of a multipart form
+// The returned attachment ID is then passed in attachment_ids when creating the message
+func UploadEventAttachment(c *gin.Context) {
+	eventID := c.Param("id")
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	maxSize := config.GetConfig().MaxAttachmentSize
+	// Leave room for the multipart envelope; the service enforces the exact file size
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			apierrors.Respond(c, apierrors.FileTooLarge)
+			return
+		}
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "file"))
+		return
+	}
+	if fileHeader.Size > maxSize {
+		apierrors.Respond(c, apierrors.FileTooLarge)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		apierrors.Respond(c, apierrors.InvalidRequest)
+		return
+	}
+	defer file.Close()
+
+	attachment, err := services.NewAttachmentService().Upload(eventID, userID, fileHeader.Filename, file, maxSize)
+	if err != nil {
+		switch err.Error() {
+		case "file too large":
+			apierrors.Respond(c, apierrors.FileTooLarge)
+		case "unsupported file type":
+			apierrors.Respond(c, apierrors.UnsupportedFileType)
+		case "file is empty":
+			apierrors.Respond(c, apierrors.EmptyFile)
+		default:
+			fmt.Printf("Error uploading attachment: %v\n", err)
+			apierrors.Respond(c, apierrors.InternalError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// GetEventAttachment serves an attachment, or its thumbnail with ?thumbnail=true, to the participants of the event
+func GetEventAttachment(c *gin.Context) {
+	eventID := c.Param("id")
+	if _, ok := requireUserID(c); !ok {
+		return
+	}
+
+	attachmentService := services.NewAttachmentService()
+	attachment, err := attachmentService.GetAttachment(eventID, c.Param("attachment_id"))
+	if err != nil {
+		if err.Error() == "attachment not found" {
+			apierrors.Respond(c, apierrors.AttachmentNotFound)
+			return
+		}
+		fmt.Printf("Error fetching attachment: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	reader, contentType, err := attachmentService.Open(attachment, c.Query("thumbnail") == "true")
+	if err != nil {
+		switch err.Error() {
+		case "attachment not found", "thumbnail not found":
+			apierrors.Respond(c, apierrors.AttachmentNotFound)
+		default:
+			fmt.Printf("Error opening attachment: %v\n", err)
+			apierrors.Respond(c, apierrors.InternalError)
+		}
+		return
+	}
+	defer reader.Close()
+
+	// Only images are displayed inline; other files are always downloaded so they cannot run in the app's origin
+	disposition := "attachment"
+	if contentType == "image/jpeg" || contentType == "image/png" || contentType == "image/gif" || contentType == "image/webp" {
+		disposition = "inline"
+	}
+	contentLength := attachment.SizeBytes
+	if c.Query("thumbnail") == "true" {
+		contentLength = -1
+	}
+	c.DataFromReader(http.StatusOK, contentLength, contentType, reader, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, max-age=86400",
+	})
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"be-geoffray/api/apierrors"
+	"be-geoffray/api/middlewares"
+	"be-geoffray/models"
+	"be-geoffray/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ChatStreamRequest represents the request body for streaming an agent reply
+type ChatStreamRequest struct {
+	ChatID  string `json:"chat_id" binding:"required"` // The event whose discussion the agent answers in
+	Message string `json:"message" binding:"required"`
+}
+
+// chatStreamChunk mirrors the OpenAI streaming chunk format expected by the frontend
+type chatStreamChunk struct {
+	ID      string                  `json:"id"`
+	Object  string                  `json:"object"`
+	Created int64                   `json:"created"`
+	Choices []chatStreamChunkChoice `json:"choices"`
+	// MessageID is set on the final chunk to the ID of the persisted agent message
+	MessageID string `json:"message_id,omitempty"`
+}
+
+type chatStreamChunkChoice struct {
+	Index        int               `json:"index"`
+	Delta        map[string]string `json:"delta"`
+	FinishReason *string           `json:"finish_reason"`
+}
+
+// StreamChat streams the agent's reply to a message as server-sent events
+// The user message and the final reply are stored in the event discussion
+func StreamChat(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierrors.Respond(c, apierrors.Unauthenticated)
+		return
+	}
+
+	var req ChatStreamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierrors.Respond(c, apierrors.InvalidRequest)
+		return
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "message"))
+		return
+	}
+
+	eventID := req.ChatID
+
+	if !middlewares.AuthorizeEventAccess(c, eventID, services.EventActionParticipate) {
+		return
+	}
+
+	// Refuse before storing the message when the user or the event has no AI tokens left
+	usageScope := services.LLMUsageScope{UserID: userID.(string), EventID: eventID, Purpose: services.LLMPurposeAgent}
+	if err := services.NewLLMUsageService().CheckQuota(usageScope); err != nil {
+		if respondAIQuotaError(c, err) {
+			return
+		}
+		fmt.Printf("Error checking AI quota: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	user, err := services.GetUserByID(c, userID.(string))
+	if err != nil {
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	// Store the user's message first so it is part of the agent context
+	message := models.EventMessage{
+		ID:        uuid.NewString(),
+		EventID:   eventID,
+		UserID:    userID.(string),
+		Content:   req.Message,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		User:      user,
+		ForAgent:  true,
+	}
+	if err := services.CreateEventMessage(c, &message); err != nil {
+		fmt.Printf("Error saving chat stream message: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	history, err := services.GetAgentMessages(c, eventID)
+	if err != nil {
+		fmt.Printf("Error getting agent messages: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+	prompt, err := services.BuildAgentMessages(eventID, history)
+	if err != nil {
+		fmt.Printf("Error building agent context: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable proxy buffering
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	chunkID := "chatcmpl-" + message.ID
+	created := time.Now().Unix()
+	writeChunk := func(chunk chatStreamChunk) error {
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", data); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+
+	// The request context is cancelled when the client disconnects, which aborts the upstream stream
+	ctx := services.WithLLMUsageScope(c.Request.Context(), usageScope)
+	response, err := services.GetLLMClient().Stream(ctx, services.LLMRequest{Messages: prompt, MaxTokens: 2000}, func(delta string) error {
+		return writeChunk(chatStreamChunk{
+			ID:      chunkID,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Choices: []chatStreamChunkChoice{{Index: 0, Delta: map[string]string{"content": delta}}},
+		})
+	})
+
+	if ctx.Err() != nil {
+		// Client went away: the partial reply is discarded
+		fmt.Printf("Chat stream for event %s cancelled by client\n", eventID)
+		return
+	}
+
+	if err != nil {
+		fmt.Printf("Error streaming agent reply for event %s: %v\n", eventID, err)
+		data, _ := json.Marshal(apierrors.Body(c, apierrors.AIGenerationFailed))
+		fmt.Fprintf(c.Writer, "event: error\ndata: %s\n\n", data)
+		fmt.Fprint(c.Writer, "data: [DONE]\n\n")
+		c.Writer.Flush()
+		return
+	}
+
+	// Persist the assembled reply as an agent message answering the user's message
+	agentMessage, err := services.CreateAgentMessage(c, eventID, response.Message.Content, &message.ID)
+	if err != nil {
+		fmt.Printf("Error saving agent reply for event %s: %v\n", eventID, err)
+	}
+
+	stop := "stop"
+	final := chatStreamChunk{
+		ID:      chunkID,
+		Object:  "chat.completion.chunk",
+		Created: created,
+		Choices: []chatStreamChunkChoice{{Index: 0, Delta: map[string]string{}, FinishReason: &stop}},
+	}
+	if agentMessage != nil {
+		final.MessageID = agentMessage.ID
+	}
+	writeChunk(final)
+
+	fmt.Fprint(c.Writer, "data: [DONE]\n\n")
+	c.Writer.Flush()
+}
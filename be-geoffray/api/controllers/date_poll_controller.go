@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"be-geoffray/api/apierrors"
+	"be-geoffray/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetEventDatePolls returns the date polls of an event with the votes of each date
+func GetEventDatePolls(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	polls, err := services.NewDatePollService().GetEventDatePolls(c.Param("id"), userID)
+	if err != nil {
+		fmt.Printf("Error fetching date polls: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	c.JSON(http.StatusOK, polls)
+}
+
+// VoteEventDatePoll replaces the caller's choices in a date poll
+func VoteEventDatePoll(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		OptionIDs []string `json:"option_ids"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
+		return
+	}
+
+	eventID := c.Param("id")
+	datePollService := services.NewDatePollService()
+	if err := datePollService.VoteDatePoll(eventID, c.Param("poll_id"), userID, request.OptionIDs); err != nil {
+		switch err.Error() {
+		case "poll not found":
+			apierrors.Respond(c, apierrors.PollNotFound)
+		case "invalid poll options":
+			apierrors.Respond(c, apierrors.InvalidPollOptions)
+		default:
+			fmt.Printf("Error voting on date poll: %v\n", err)
+			apierrors.Respond(c, apierrors.InternalError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Vote saved"})
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"be-geoffray/api/apierrors"
+	"be-geoffray/api/middlewares"
+	"be-geoffray/localization"
+	"be-geoffray/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetDiscussionSummary summarizes the messages of an event the caller has not read yet
+// The language comes from the "lang" query parameter or the Accept-Language header.
+// The giftee profile only informs the summary when the caller may see it.
+func GetDiscussionSummary(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	eventID := c.Param("id")
+
+	language := c.Query("lang")
+	if language == "" {
+		language = localization.DetectLanguage(c.GetHeader("Accept-Language"))
+	}
+
+	surprise, err := services.NewGifteeProfileService().IsSurprise(eventID)
+	if err != nil {
+		fmt.Printf("Error checking surprise mode: %v\n", err)
+		apierrors.Respond(c, apierrors.AIGenerationFailed)
+		return
+	}
+	relationship, _ := c.Get(middlewares.EventRelationshipKey)
+	withGifteeProfile := services.CanViewGifteeProfile(relationship.(services.EventRelationship), surprise)
+
+	summary, err := services.NewDiscussionSummaryService().SummarizeUnreadMessages(eventID, userID, language, withGifteeProfile)
+	if err != nil {
+		if respondAIQuotaError(c, err) {
+			return
+		}
+		fmt.Printf("Error summarizing discussion: %v\n", err)
+		apierrors.Respond(c, apierrors.AIGenerationFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
@@ -2,7 +2,6 @@ package controllers
 
 import (
 	"crypto/rand"
-	"database/sql"
 	"encoding/hex"
 	"log"
 	"net/http"
@@ -10,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"be-geoffray/api/apierrors"
 	"be-geoffray/config"
 	"be-geoffray/db"
 	"be-geoffray/models"
@@ -32,13 +32,13 @@ func CreateEvent(c *gin.Context) {
 	// Get the user ID from the authenticated context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
 	var input CreateEventInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
 		return
 	}
 
@@ -57,7 +57,8 @@ func CreateEvent(c *gin.Context) {
 	)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Printf("Error creating event: %v", err)
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
@@ -90,17 +91,17 @@ type PendingInvitation struct {
 }
 
 func GetEventByID(c *gin.Context) {
-	// Get the user ID from the authenticated context
-	userID, exists := c.Get("user_id")
+	// Access to the event is checked by the route's policy middleware
+	_, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
 	// Get the event ID from the URL parameter
 	eventID := c.Param("id")
 	if eventID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Event ID is required"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "event_id"))
 		return
 	}
 
@@ -108,9 +109,9 @@ func GetEventByID(c *gin.Context) {
 	eventService := services.NewEventService()
 
 	// Get the event and participants using the service
-	event, participants, err := eventService.GetEventByID(eventID, userID.(string))
+	event, participants, err := eventService.GetEventByID(eventID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		apierrors.Respond(c, apierrors.EventNotFound)
 		return
 	}
 
@@ -174,44 +175,24 @@ func generateInviteCode() string {
 // If the user exists, they are added as a participant
 // If the user doesn't exist, an invitation is created
 func InviteParticipant(c *gin.Context) {
-	// Get the user ID from the authenticated context
-	userID, exists := c.Get("user_id")
+	// Access to the event is checked by the route's policy middleware
+	_, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
 	// Get the event ID from the URL parameter
 	eventID := c.Param("id")
 	if eventID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Event ID is required"})
-		return
-	}
-
-	// Verify that the event exists and the user has permission to invite
-	var creatorID string
-	eventQuery := `SELECT creator_id FROM events WHERE id = $1`
-	err := db.DB.QueryRow(eventQuery, eventID).Scan(&creatorID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify event"})
-		}
-		return
-	}
-
-	// Only the creator can invite participants for now
-	// This could be expanded to allow participants to invite others
-	if creatorID != userID.(string) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only the event creator can invite participants"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "event_id"))
 		return
 	}
 
 	// Parse the request body
 	var input InviteParticipantInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
 		return
 	}
 
@@ -223,7 +204,7 @@ func InviteParticipant(c *gin.Context) {
 		// Simple email validation
 		emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
 		if !emailRegex.MatchString(input.Identifier) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email format"})
+			apierrors.Respond(c, apierrors.InvalidField.WithArg("field", "email"))
 			return
 		}
 	}
@@ -232,7 +213,7 @@ func InviteParticipant(c *gin.Context) {
 	var existingUserID string
 	userQuery := `SELECT id FROM users WHERE email = $1`
 
-	err = db.DB.QueryRow(userQuery, input.Identifier).Scan(&existingUserID)
+	err := db.DB.QueryRow(userQuery, input.Identifier).Scan(&existingUserID)
 
 	// If the user exists
 	if err == nil {
@@ -254,7 +235,7 @@ func InviteParticipant(c *gin.Context) {
 		// Add the user as a participant with 'pending' status
 		_, err = db.DB.Exec(`INSERT INTO event_participants (event_id, user_id, status) VALUES ($1, $2, $3)`, eventID, existingUserID, "pending")
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add participant"})
+			apierrors.Respond(c, apierrors.InternalError)
 			return
 		}
 
@@ -332,7 +313,7 @@ func InviteParticipant(c *gin.Context) {
 
 	if err != nil {
 		log.Println("Error creating invitation:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invitation"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
@@ -350,10 +331,10 @@ func InviteParticipant(c *gin.Context) {
 
 // RescindInvitation deletes a pending invitation
 func RescindInvitation(c *gin.Context) {
-	// Get the user ID from the authenticated context
-	userID, exists := c.Get("user_id")
+	// Access to the event is checked by the route's policy middleware
+	_, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
@@ -362,26 +343,7 @@ func RescindInvitation(c *gin.Context) {
 	email := c.Param("email")
 
 	if eventID == "" || email == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Event ID and email are required"})
-		return
-	}
-
-	// Verify that the event exists and the user is the creator
-	var creatorID string
-	eventQuery := `SELECT creator_id FROM events WHERE id = $1`
-	err := db.DB.QueryRow(eventQuery, eventID).Scan(&creatorID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify event"})
-		}
-		return
-	}
-
-	// Only the creator can rescind invitations
-	if creatorID != userID.(string) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only the event creator can rescind invitations"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "email"))
 		return
 	}
 
@@ -393,13 +355,13 @@ func RescindInvitation(c *gin.Context) {
 	result, err := db.DB.Exec(deleteQuery, eventID, email)
 	if err != nil {
 		log.Printf("Error deleting invitation: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete invitation"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+		apierrors.Respond(c, apierrors.InvitationNotFound)
 		return
 	}
 
@@ -414,7 +376,7 @@ func GetUserEvents(c *gin.Context) {
 	// Get the user ID from the authenticated context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
@@ -424,7 +386,8 @@ func GetUserEvents(c *gin.Context) {
 	// Get the user's events using the service
 	events, err := eventService.GetUserEvents(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Printf("Error fetching events of user %s: %v", userID, err)
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
@@ -432,38 +395,19 @@ func GetUserEvents(c *gin.Context) {
 }
 
 // DeleteEvent handles the deletion of an event
-// Only the event creator can delete the event
+// Only the event creator can delete the event (see the route's access policy)
 func DeleteEvent(c *gin.Context) {
-	// Get the user ID from the authenticated context
-	userID, exists := c.Get("user_id")
+	// Access to the event is checked by the route's policy middleware
+	_, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
 	// Get the event ID from the URL parameter
 	eventID := c.Param("id")
 	if eventID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Event ID is required"})
-		return
-	}
-
-	// Verify that the event exists and the user is the creator
-	var creatorID string
-	eventQuery := `SELECT creator_id FROM events WHERE id = $1`
-	err := db.DB.QueryRow(eventQuery, eventID).Scan(&creatorID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify event"})
-		}
-		return
-	}
-
-	// Only the creator can delete the event
-	if creatorID != userID.(string) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only the event creator can delete this event"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "event_id"))
 		return
 	}
 
@@ -471,10 +415,14 @@ func DeleteEvent(c *gin.Context) {
 	eventService := services.NewEventService()
 
 	// Delete the event using the service
-	err = eventService.DeleteEvent(eventID)
+	err := eventService.DeleteEvent(eventID)
+	if err != nil && err.Error() == "event not found" {
+		apierrors.Respond(c, apierrors.EventNotFound)
+		return
+	}
 	if err != nil {
 		log.Printf("Error deleting event %s: %v", eventID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete event"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
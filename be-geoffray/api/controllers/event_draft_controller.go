@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"be-geoffray/api/apierrors"
+	"be-geoffray/services"
+	"github.com/gin-gonic/gin"
+)
+
+// DraftEventTexts drafts a description and an invitation message for an event with the language model
+// Nothing is saved: the organizer applies the description they keep with UpdateEvent
+func DraftEventTexts(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	// The body is optional, every field has a default
+	var request services.EventDraftRequest
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
+		return
+	}
+
+	event, _, err := services.NewEventService().GetEventByID(c.Param("id"))
+	if err != nil {
+		apierrors.Respond(c, apierrors.EventNotFound)
+		return
+	}
+
+	draft, err := services.NewEventDraftService().DraftEvent(event, userID, request)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidEventDraftRequest) {
+			apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
+			return
+		}
+		if respondAIQuotaError(c, err) {
+			return
+		}
+		fmt.Printf("Error drafting event texts: %v\n", err)
+		apierrors.Respond(c, apierrors.AIGenerationFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, draft)
+}
@@ -3,8 +3,11 @@ package controllers
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"be-geoffray/api/apierrors"
 	"be-geoffray/models"
 	"be-geoffray/services"
 	"github.com/gin-gonic/gin"
@@ -19,53 +22,94 @@ type EventMessageRequest struct {
 func GetEventMessages(c *gin.Context) {
 	eventID := c.Param("id")
 	if eventID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "event_id is required"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "event_id"))
 		return
 	}
 
-	messages, err := services.GetEventMessages(c, eventID)
+	limit := 0
+	if rawLimit := c.Query("limit"); rawLimit != "" {
+		parsedLimit, err := strconv.Atoi(rawLimit)
+		if err != nil || parsedLimit <= 0 {
+			apierrors.Respond(c, apierrors.InvalidField.WithArg("field", "limit"))
+			return
+		}
+		limit = parsedLimit
+	}
+
+	page, err := services.GetEventMessages(c, eventID, c.Query("before"), c.Query("after"), limit)
 	if err != nil {
-		fmt.Printf("Error getting event messages: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch err.Error() {
+		case "only one of before and after can be used", "cursor not found":
+			apierrors.Respond(c, apierrors.InvalidCursor.WithDetails(err.Error()))
+		default:
+			fmt.Printf("Error getting event messages: %v\n", err)
+			apierrors.Respond(c, apierrors.InternalError)
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, messages)
+	// Fetching the latest page means the user has caught up with the discussion
+	if c.Query("before") == "" && !page.HasMoreAfter && len(page.Messages) > 0 {
+		lastMessage := page.Messages[len(page.Messages)-1]
+		if err := services.MarkEventMessagesRead(eventID, c.GetString("user_id"), lastMessage.ID); err != nil {
+			fmt.Printf("Error updating read marker: %v\n", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// GetEventMessageThread returns the thread a message belongs to, starting from its root message
+func GetEventMessageThread(c *gin.Context) {
+	eventID := c.Param("id")
+	if _, ok := requireUserID(c); !ok {
+		return
+	}
+
+	thread, err := services.GetEventMessageThread(c, eventID, c.Param("message_id"))
+	if err != nil {
+		respondEventMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, thread)
 }
 
 func CreateEventMessage(c *gin.Context) {
 	eventID := c.Param("id")
 	if eventID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "event_id is required"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "event_id"))
 		return
 	}
 
 	var req struct {
-		Content  string  `json:"content" binding:"required"`
-		ParentID *string `json:"parent_id,omitempty"`
+		Content       string   `json:"content"`
+		ParentID      *string  `json:"parent_id,omitempty"`
+		AttachmentIDs []string `json:"attachment_ids,omitempty"` // Files uploaded beforehand to the event
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		apierrors.Respond(c, apierrors.InvalidRequest)
 		return
 	}
 
-	if req.Content == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "content is required"})
+	// A message may consist of attachments only
+	if req.Content == "" && len(req.AttachmentIDs) == 0 {
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "content"))
 		return
 	}
 
 	// Get user ID from context (set by JWT middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
 	// Fetch user using the user service
 	user, err := services.GetUserByID(c, userID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user data"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
@@ -84,8 +128,17 @@ func CreateEventMessage(c *gin.Context) {
 	isForAgent := services.IsMessageForAgent(req.Content)
 	message.ForAgent = isForAgent
 
-	if err := services.CreateEventMessage(c, &message); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := services.CreateEventMessageWithAttachments(c, &message, req.AttachmentIDs); err != nil {
+		if err.Error() == "invalid attachments" {
+			apierrors.Respond(c, apierrors.InvalidAttachments)
+			return
+		}
+		if strings.HasPrefix(err.Error(), "too many attachments") {
+			apierrors.Respond(c, apierrors.TooManyAttachments.WithDetails(err.Error()))
+			return
+		}
+		fmt.Printf("Error creating event message: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
@@ -110,15 +163,193 @@ func processAgentMessage(c *gin.Context, eventID string, messageID string) {
 func GetAgentMessagesForEvent(c *gin.Context) {
 	eventID := c.Param("id")
 	if eventID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "event_id is required"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "event_id"))
 		return
 	}
 
 	messages, err := services.GetAgentMessages(c, eventID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		fmt.Printf("Error getting agent messages: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
 	c.JSON(http.StatusOK, messages)
 }
+
+// respondEventMessageError maps message edition errors to HTTP responses
+func respondEventMessageError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "message not found":
+		apierrors.Respond(c, apierrors.MessageNotFound)
+	case "reaction not found":
+		apierrors.Respond(c, apierrors.ReactionNotFound)
+	case "only the author can edit this message", "only the author can delete this message":
+		apierrors.Respond(c, apierrors.MessageAuthorOnly)
+	case "message has been deleted":
+		apierrors.Respond(c, apierrors.MessageDeleted)
+	case "content is required":
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "content"))
+	case "invalid emoji":
+		apierrors.Respond(c, apierrors.InvalidEmoji)
+	default:
+		fmt.Printf("Error handling event message: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+	}
+}
+
+// requireUserID returns the authenticated user; access to the event is checked by the route's policy middleware
+func requireUserID(c *gin.Context) (string, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierrors.Respond(c, apierrors.Unauthenticated)
+		return "", false
+	}
+	return userID.(string), true
+}
+
+// UpdateEventMessage lets the author edit a message
+func UpdateEventMessage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierrors.Respond(c, apierrors.Unauthenticated)
+		return
+	}
+
+	var req struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierrors.Respond(c, apierrors.InvalidRequest)
+		return
+	}
+
+	message, err := services.UpdateEventMessage(c.Param("id"), c.Param("message_id"), userID.(string), req.Content)
+	if err != nil {
+		respondEventMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, message)
+}
+
+// DeleteEventMessage lets the author delete a message (replies are kept)
+func DeleteEventMessage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierrors.Respond(c, apierrors.Unauthenticated)
+		return
+	}
+
+	if err := services.DeleteEventMessage(c.Param("id"), c.Param("message_id"), userID.(string)); err != nil {
+		respondEventMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message deleted successfully"})
+}
+
+// GetEventMessageHistory returns the previous versions of an edited message
+func GetEventMessageHistory(c *gin.Context) {
+	eventID := c.Param("id")
+	if _, ok := requireUserID(c); !ok {
+		return
+	}
+
+	edits, err := services.GetEventMessageEdits(eventID, c.Param("message_id"))
+	if err != nil {
+		respondEventMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, edits)
+}
+
+// AddEventMessageReaction adds an emoji reaction to a message
+func AddEventMessageReaction(c *gin.Context) {
+	eventID := c.Param("id")
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Emoji string `json:"emoji" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierrors.Respond(c, apierrors.InvalidRequest)
+		return
+	}
+
+	if err := services.AddMessageReaction(eventID, c.Param("message_id"), userID, req.Emoji); err != nil {
+		respondEventMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reaction added", "emoji": req.Emoji})
+}
+
+// RemoveEventMessageReaction removes an emoji reaction from a message
+func RemoveEventMessageReaction(c *gin.Context) {
+	eventID := c.Param("id")
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := services.RemoveMessageReaction(eventID, c.Param("message_id"), userID, c.Param("emoji")); err != nil {
+		respondEventMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reaction removed"})
+}
+
+// MarkEventMessagesRead moves the user's read marker to a message, or to the latest message when none is given
+func MarkEventMessagesRead(c *gin.Context) {
+	eventID := c.Param("id")
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		MessageID string `json:"message_id"`
+	}
+	// The body is optional
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierrors.Respond(c, apierrors.InvalidRequest)
+			return
+		}
+	}
+
+	if err := services.MarkEventMessagesRead(eventID, userID, req.MessageID); err != nil {
+		respondEventMessageError(c, err)
+		return
+	}
+
+	unreadCount, err := services.GetUnreadMessageCount(eventID, userID)
+	if err != nil {
+		respondEventMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread_count": unreadCount})
+}
+
+// GetEventMessageSeenBy returns the participants who have read a message
+func GetEventMessageSeenBy(c *gin.Context) {
+	eventID := c.Param("id")
+	if _, ok := requireUserID(c); !ok {
+		return
+	}
+
+	seenBy, err := services.GetMessageSeenBy(eventID, c.Param("message_id"))
+	if err != nil {
+		respondEventMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, seenBy)
+}
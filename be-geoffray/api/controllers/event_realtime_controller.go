@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"be-geoffray/api/apierrors"
+	"be-geoffray/api/middlewares"
+	"be-geoffray/config"
+	"be-geoffray/services"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsWriteWait is the time allowed to write a frame to the client
+	wsWriteWait = 10 * time.Second
+	// wsPongWait is the time allowed to read the next pong from the client
+	wsPongWait = 60 * time.Second
+	// wsPingPeriod must be shorter than wsPongWait
+	wsPingPeriod = 50 * time.Second
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     checkWebSocketOrigin,
+	// Browsers drop the connection unless the server selects one of the offered subprotocols
+	Subprotocols: []string{middlewares.WebSocketAuthProtocol},
+}
+
+// checkWebSocketOrigin only accepts handshakes from the frontend in release mode
+func checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || gin.Mode() != gin.ReleaseMode {
+		return true
+	}
+	return strings.EqualFold(strings.TrimSuffix(origin, "/"), strings.TrimSuffix(config.GetConfig().FrontendURL, "/"))
+}
+
+// EventWebSocket streams realtime updates of an event (messages, suggestions, votes and RSVPs)
+// Only users who can view the event get here, see the route's access policy
+// The connection is closed when the access token expires or when the user can no longer view the event,
+// which is checked again on every ping and whenever an RSVP changes
+func EventWebSocket(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierrors.Respond(c, apierrors.Unauthenticated)
+		return
+	}
+
+	eventID := c.Param("id")
+	if eventID == "" {
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "event_id"))
+		return
+	}
+
+	realtimeService := services.GetRealtimeService()
+	if realtimeService == nil {
+		apierrors.Respond(c, apierrors.RealtimeUnavailable)
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader already wrote an error response
+		log.Printf("Error upgrading WebSocket for event %s: %v", eventID, err)
+		return
+	}
+	defer conn.Close()
+
+	frames, unsubscribe := realtimeService.Subscribe(eventID)
+	defer unsubscribe()
+
+	// Read loop: clients don't send frames, but reading is required to process pongs and closes
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	// A nil channel never fires, for tokens without expiry
+	var expired <-chan time.Time
+	if expiresAt, ok := c.Get(middlewares.TokenExpiresAtKey); ok {
+		expiry := time.NewTimer(time.Until(expiresAt.(time.Time)))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+
+	for {
+		select {
+		case frame, ok := <-frames:
+			if !ok {
+				return
+			}
+			if frame.Type == string(services.LifecycleParticipantRSVP) && !canStillViewEvent(eventID, userID.(string)) {
+				closeWebSocket(conn, "event access revoked")
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteJSON(frame); err != nil {
+				return
+			}
+		case <-expired:
+			closeWebSocket(conn, "token expired")
+			return
+		case <-ticker.C:
+			if !canStillViewEvent(eventID, userID.(string)) {
+				closeWebSocket(conn, "event access revoked")
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// canStillViewEvent checks the access of a connected user again, keeping the connection when the check fails
+func canStillViewEvent(eventID, userID string) bool {
+	relationship, err := middlewares.ResolveEventRelationship(eventID, userID)
+	if err != nil {
+		log.Printf("Error checking WebSocket access of user %s to event %s: %v", userID, eventID, err)
+		return true
+	}
+	return relationship.Can(services.EventActionView)
+}
+
+// closeWebSocket tells the client why the connection ends before it is closed
+func closeWebSocket(conn *websocket.Conn, reason string) {
+	message := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason)
+	conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(wsWriteWait))
+}
@@ -2,10 +2,14 @@ package controllers
 
 import (
 	"database/sql"
+	"fmt"
 	"net/http"
 	"time"
 
+	"be-geoffray/api/apierrors"
+	"be-geoffray/api/middlewares"
 	"be-geoffray/models"
+	"be-geoffray/services"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -31,7 +35,7 @@ func (gc *GiftController) GetCategories(c *gin.Context) {
 
 	rows, err := gc.DB.Query(query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch giftee personas"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 	defer rows.Close()
@@ -57,12 +61,32 @@ func (gc *GiftController) GetCategories(c *gin.Context) {
 	c.JSON(http.StatusOK, personas)
 }
 
+// GetOccasions retrieves all active occasion types, named in the language of the request
+func (gc *GiftController) GetOccasions(c *gin.Context) {
+	occasions, err := services.LoadOccasionTypes(gc.DB)
+	if err != nil {
+		fmt.Printf("Error getting occasion types: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	language := apierrors.RequestLanguage(c)
+	for i := range occasions {
+		occasions[i].Name = services.LocalizedOccasionName(occasions[i], language)
+	}
+
+	c.JSON(http.StatusOK, occasions)
+}
+
 // GetSuggestions retrieves gift suggestions for an event
 func (gc *GiftController) GetSuggestions(c *gin.Context) {
 	eventID := c.Query("event_id")
 
 	if eventID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "event_id is required"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "event_id"))
+		return
+	}
+	if !middlewares.AuthorizeEventAccess(c, eventID, services.EventActionView) {
 		return
 	}
 
@@ -76,7 +100,7 @@ func (gc *GiftController) GetSuggestions(c *gin.Context) {
 
 	rows, err := gc.DB.Query(query, eventID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch gift suggestions"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 	defer rows.Close()
@@ -104,37 +128,51 @@ func (gc *GiftController) GetSuggestions(c *gin.Context) {
 		suggestions = append(suggestions, suggestion)
 	}
 
+	if err := services.LoadGiftSuggestionTranslations(suggestions); err != nil {
+		fmt.Printf("Error loading gift suggestion translations: %v\n", err)
+	}
+
 	c.JSON(http.StatusOK, suggestions)
 }
 
-// TrackSelection tracks a user's gift category selection
+// TrackSelection tracks a user's giftee persona (and occasion) selection
+// The choices feed the default persona and occasion recommended when creating an event
 func (gc *GiftController) TrackSelection(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
 	var request struct {
-		CategoryID string  `json:"category_id" binding:"required"`
+		PersonaID  string  `json:"persona_id"`
+		CategoryID string  `json:"category_id"` // Former name of persona_id
+		OccasionID *string `json:"occasion_id"`
 		EventID    *string `json:"event_id"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
+		return
+	}
+	if request.PersonaID == "" {
+		request.PersonaID = request.CategoryID
+	}
+	if request.PersonaID == "" {
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "persona_id"))
 		return
 	}
 
 	// Create selection record
 	selectionID := uuid.New().String()
 	query := `
-		INSERT INTO gift_selections (id, user_id, category_id, event_id, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO gift_selections (id, user_id, persona_id, occasion_id, event_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 
-	_, err := gc.DB.Exec(query, selectionID, userID, request.CategoryID, request.EventID, time.Now())
+	_, err := gc.DB.Exec(query, selectionID, userID, request.PersonaID, request.OccasionID, request.EventID, time.Now())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to track selection"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
@@ -4,8 +4,11 @@ import (
 	"database/sql"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"be-geoffray/api/apierrors"
+	"be-geoffray/api/middlewares"
 	"be-geoffray/models"
 	"be-geoffray/services"
 
@@ -36,7 +39,7 @@ func (gec *GiftEventController) CreateEventWithGifts(c *gin.Context) {
 	// Get user ID from context (set by JWT middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
@@ -49,17 +52,22 @@ func (gec *GiftEventController) CreateEventWithGifts(c *gin.Context) {
 		Banner        string  `json:"banner"`
 		GifteePersona string  `json:"giftee_persona" binding:"required"`
 		EventOccasion string  `json:"event_occasion" binding:"required"`
+		SurpriseMode  bool    `json:"surprise_mode"`
+		Language      string  `json:"language"` // Language suggestions are generated in, French when empty
+
+		// GifteeProfile is optional and already used by the first generation
+		GifteeProfile *models.GifteeProfile `json:"giftee_profile"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
 		return
 	}
 
 	// Parse start date
 	startDate, err := time.Parse(time.RFC3339, req.StartDate)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format"})
+		apierrors.Respond(c, apierrors.InvalidField.WithArg("field", "start_date"))
 		return
 	}
 
@@ -68,12 +76,25 @@ func (gec *GiftEventController) CreateEventWithGifts(c *gin.Context) {
 	if req.EndDate != nil && *req.EndDate != "" {
 		parsed, err := time.Parse(time.RFC3339, *req.EndDate)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format"})
+			apierrors.Respond(c, apierrors.InvalidField.WithArg("field", "end_date"))
 			return
 		}
 		endDate = &parsed
 	}
 
+	language, err := eventLanguage(req.Language)
+	if err != nil {
+		apierrors.Respond(c, apierrors.UnsupportedLanguage.WithArg("language", req.Language))
+		return
+	}
+
+	if req.GifteeProfile != nil {
+		if err := services.NormalizeGifteeProfile(req.GifteeProfile); err != nil {
+			apierrors.Respond(c, apierrors.InvalidField.WithArg("field", "giftee_profile").WithDetails(err.Error()))
+			return
+		}
+	}
+
 	// Create event with gift information
 	eventID := uuid.NewString()
 	event := models.Event{
@@ -91,6 +112,8 @@ func (gec *GiftEventController) CreateEventWithGifts(c *gin.Context) {
 		ParticipantsCount: 1, // Creator is automatically a participant
 		GifteePersona:     req.GifteePersona,
 		EventOccasion:     req.EventOccasion,
+		SurpriseMode:      req.SurpriseMode,
+		Language:          language,
 	}
 
 	// Insert event into database
@@ -98,20 +121,20 @@ func (gec *GiftEventController) CreateEventWithGifts(c *gin.Context) {
 		INSERT INTO events (
 			id, created_at, updated_at, title, creator_id, description, 
 			start_date, end_date, active, banner, location, participants_count,
-			giftee_persona, event_occasion
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			giftee_persona, event_occasion, surprise_mode, language
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 	`
 
 	_, err = gec.DB.Exec(query,
 		event.ID, event.CreatedAt, event.UpdatedAt, event.Title, event.CreatorID,
 		event.Description, event.StartDate, event.EndDate, event.Active,
 		event.Banner, event.Location, event.ParticipantsCount,
-		event.GifteePersona, event.EventOccasion,
+		event.GifteePersona, event.EventOccasion, event.SurpriseMode, event.Language,
 	)
 
 	if err != nil {
 		fmt.Printf("Error creating event: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
@@ -129,9 +152,17 @@ func (gec *GiftEventController) CreateEventWithGifts(c *gin.Context) {
 		// Continue even if this fails - event creation is more important
 	}
 
+	// Save the giftee profile before generation so that it shapes the first suggestions
+	if req.GifteeProfile != nil {
+		req.GifteeProfile.EventID = eventID
+		if _, err := services.NewGifteeProfileService().SaveGifteeProfile(req.GifteeProfile, userID.(string)); err != nil {
+			fmt.Printf("Error saving giftee profile: %v\n", err)
+		}
+	}
+
 	// Insert static gift synchronously (fast ~20ms) so user sees content immediately
 	var staticSuggestion *models.GiftSuggestion
-	staticSuggestion, err = gec.StaticGiftService.GetStaticGiftSuggestion(event.GifteePersona, event.EventOccasion)
+	staticSuggestion, err = gec.StaticGiftService.GetStaticGiftSuggestion(event.GifteePersona, event.EventOccasion, event.Language)
 	if err == nil && staticSuggestion != nil {
 		staticSuggestion.ID = uuid.NewString()
 		staticSuggestion.EventID = event.ID
@@ -142,8 +173,8 @@ func (gec *GiftEventController) CreateEventWithGifts(c *gin.Context) {
 			INSERT INTO gift_suggestions (
 				id, event_id, owner_id, name_en, name_fr, description_en, description_fr,
 				price_range, category, url, creation_mode, generated_at, created_at, updated_at,
-				amazon_asin, amazon_affiliate_url, amazon_price, amazon_region, amazon_last_updated
-			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+				amazon_asin, amazon_affiliate_url, amazon_price, amazon_region, amazon_last_updated, prompt_version
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
 		`
 		_, insertErr := gec.DB.Exec(staticQuery,
 			staticSuggestion.ID, staticSuggestion.EventID, staticSuggestion.OwnerID,
@@ -154,12 +185,17 @@ func (gec *GiftEventController) CreateEventWithGifts(c *gin.Context) {
 			staticSuggestion.CreatedAt, staticSuggestion.UpdatedAt,
 			staticSuggestion.AmazonASIN, staticSuggestion.AmazonAffiliateURL,
 			staticSuggestion.AmazonPrice, staticSuggestion.AmazonRegion, staticSuggestion.AmazonLastUpdated,
+			staticSuggestion.PromptVersion,
 		)
 		if insertErr != nil {
 			fmt.Printf("Error inserting static gift suggestion: %v\n", insertErr)
 			staticSuggestion = nil // Clear so AI knows to generate 3 instead of 2
 		} else {
+			if err := services.SaveGiftSuggestionTranslations(staticSuggestion); err != nil {
+				fmt.Printf("Error storing static gift translations: %v\n", err)
+			}
 			fmt.Printf("Inserted static gift suggestion '%s' for event %s\n", staticSuggestion.NameEN, event.ID)
+			services.EmitLifecycleEvent(services.LifecycleSuggestionCreated, event.ID, "", *staticSuggestion)
 		}
 	} else {
 		fmt.Printf("No static gift found for event %s (persona=%s, occasion=%s): %v\n",
@@ -174,7 +210,8 @@ func (gec *GiftEventController) CreateEventWithGifts(c *gin.Context) {
 
 // generateGiftSuggestionsForEvent generates and stores gift suggestions for an event
 // Uses static-first approach: 1 curated static suggestion + 2 AI-generated suggestions
-func (gec *GiftEventController) generateGiftSuggestionsForEvent(event models.Event) {
+// preferences, learnt from the votes on earlier suggestions, may be nil
+func (gec *GiftEventController) generateGiftSuggestionsForEvent(event models.Event, preferences *services.GiftPreferenceProfile) {
 	// Fetch existing suggestions for this event to avoid duplicates
 	existingSuggestions, err := gec.fetchExistingSuggestions(event.ID)
 	if err != nil {
@@ -186,7 +223,7 @@ func (gec *GiftEventController) generateGiftSuggestionsForEvent(event models.Eve
 	var allSuggestions []models.GiftSuggestion
 
 	// Step 1: Try to get a static (curated) suggestion first
-	staticSuggestion, err := gec.StaticGiftService.GetStaticGiftSuggestion(event.GifteePersona, event.EventOccasion)
+	staticSuggestion, err := gec.StaticGiftService.GetStaticGiftSuggestion(event.GifteePersona, event.EventOccasion, event.Language)
 	if err != nil {
 		fmt.Printf("No static gift found for event %s (persona=%s, occasion=%s): %v\n",
 			event.ID, event.GifteePersona, event.EventOccasion, err)
@@ -212,8 +249,12 @@ func (gec *GiftEventController) generateGiftSuggestionsForEvent(event models.Eve
 		EventDate:        event.StartDate.Format("2006-01-02"),
 		Location:         event.Location,
 		Description:      event.Description,
-		Language:         "fr", // Default to French, could be made dynamic
+		Language:         event.Language,
 		SingleSuggestion: false,
+		EventID:          event.ID,
+		UserID:           event.CreatorID,
+		Preferences:      preferences,
+		Giftee:           services.LoadGifteeProfileForGeneration(event.ID),
 	}
 
 	// Generate AI suggestions using Mistral with similarity checking
@@ -239,8 +280,8 @@ func (gec *GiftEventController) generateGiftSuggestionsForEvent(event models.Eve
 			INSERT INTO gift_suggestions (
 				id, event_id, owner_id, name_en, name_fr, description_en, description_fr,
 				price_range, category, url, creation_mode, generated_at, created_at, updated_at,
-				amazon_asin, amazon_affiliate_url, amazon_price, amazon_region, amazon_last_updated
-			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+				amazon_asin, amazon_affiliate_url, amazon_price, amazon_region, amazon_last_updated, prompt_version
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
 		`
 
 		_, err := gec.DB.Exec(query,
@@ -249,12 +290,17 @@ func (gec *GiftEventController) generateGiftSuggestionsForEvent(event models.Eve
 			suggestion.Category, suggestion.URL, suggestion.CreationMode, suggestion.GeneratedAt,
 			suggestion.CreatedAt, suggestion.UpdatedAt,
 			suggestion.AmazonASIN, suggestion.AmazonAffiliateURL, suggestion.AmazonPrice,
-			suggestion.AmazonRegion, suggestion.AmazonLastUpdated,
+			suggestion.AmazonRegion, suggestion.AmazonLastUpdated, suggestion.PromptVersion,
 		)
 
 		if err != nil {
 			fmt.Printf("Error storing gift suggestion %s: %v\n", suggestion.ID, err)
+			continue
+		}
+		if err := services.SaveGiftSuggestionTranslations(&suggestion); err != nil {
+			fmt.Printf("Error storing gift suggestion translations %s: %v\n", suggestion.ID, err)
 		}
+		services.EmitLifecycleEvent(services.LifecycleSuggestionCreated, suggestion.EventID, "", suggestion)
 	}
 
 	staticCount := 0
@@ -297,8 +343,11 @@ func (gec *GiftEventController) generateAIGiftSuggestionsForEvent(event models.E
 		EventDate:        event.StartDate.Format("2006-01-02"),
 		Location:         event.Location,
 		Description:      event.Description,
-		Language:         "fr", // Default to French, could be made dynamic
+		Language:         event.Language,
 		SingleSuggestion: false,
+		EventID:          event.ID,
+		UserID:           event.CreatorID,
+		Giftee:           services.LoadGifteeProfileForGeneration(event.ID),
 	}
 
 	// Generate AI suggestions using Mistral with similarity checking
@@ -319,8 +368,8 @@ func (gec *GiftEventController) generateAIGiftSuggestionsForEvent(event models.E
 			INSERT INTO gift_suggestions (
 				id, event_id, owner_id, name_en, name_fr, description_en, description_fr,
 				price_range, category, url, creation_mode, generated_at, created_at, updated_at,
-				amazon_asin, amazon_affiliate_url, amazon_price, amazon_region, amazon_last_updated
-			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+				amazon_asin, amazon_affiliate_url, amazon_price, amazon_region, amazon_last_updated, prompt_version
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
 		`
 
 		_, err := gec.DB.Exec(query,
@@ -332,11 +381,17 @@ func (gec *GiftEventController) generateAIGiftSuggestionsForEvent(event models.E
 			aiSuggestions[i].CreatedAt, aiSuggestions[i].UpdatedAt,
 			aiSuggestions[i].AmazonASIN, aiSuggestions[i].AmazonAffiliateURL,
 			aiSuggestions[i].AmazonPrice, aiSuggestions[i].AmazonRegion, aiSuggestions[i].AmazonLastUpdated,
+			aiSuggestions[i].PromptVersion,
 		)
 
 		if err != nil {
 			fmt.Printf("Error storing AI gift suggestion %s: %v\n", aiSuggestions[i].ID, err)
+			continue
+		}
+		if err := services.SaveGiftSuggestionTranslations(&aiSuggestions[i]); err != nil {
+			fmt.Printf("Error storing AI gift suggestion translations %s: %v\n", aiSuggestions[i].ID, err)
 		}
+		services.EmitLifecycleEvent(services.LifecycleSuggestionCreated, event.ID, "", aiSuggestions[i])
 	}
 
 	fmt.Printf("Generated and stored %d AI gift suggestions for event %s\n", len(aiSuggestions), event.ID)
@@ -434,7 +489,7 @@ func (gec *GiftEventController) fetchExistingSuggestionsExcluding(eventID, exclu
 func (gec *GiftEventController) GetEventGiftSuggestions(c *gin.Context) {
 	eventID := c.Param("id")
 	if eventID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Event ID is required"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "event_id"))
 		return
 	}
 
@@ -450,6 +505,7 @@ func (gec *GiftEventController) GetEventGiftSuggestions(c *gin.Context) {
 			gs.id, gs.event_id, gs.owner_id, gs.name_en, gs.name_fr, gs.description_en, gs.description_fr,
 			gs.price_range, gs.category, gs.url, gs.prompt, gs.creation_mode, gs.generated_at, gs.created_at, gs.updated_at,
 			gs.amazon_asin, gs.amazon_affiliate_url, gs.amazon_price, gs.amazon_region, gs.amazon_last_updated,
+			gs.prompt_version,
 			COALESCE(upvotes.count, 0) as upvote_count,
 			COALESCE(downvotes.count, 0) as downvote_count,
 			user_vote.vote_type as user_vote
@@ -475,7 +531,7 @@ func (gec *GiftEventController) GetEventGiftSuggestions(c *gin.Context) {
 	rows, err := gec.DB.Query(query, eventID, userIDStr)
 	if err != nil {
 		fmt.Printf("Error querying gift suggestions with votes: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve gift suggestions"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 	defer rows.Close()
@@ -495,6 +551,7 @@ func (gec *GiftEventController) GetEventGiftSuggestions(c *gin.Context) {
 			&suggestion.Category, &url, &prompt, &suggestion.CreationMode, &suggestion.GeneratedAt,
 			&suggestion.CreatedAt, &suggestion.UpdatedAt,
 			&amazonASIN, &amazonAffiliateURL, &amazonPrice, &amazonRegion, &amazonLastUpdated,
+			&suggestion.PromptVersion,
 			&suggestion.UpvoteCount, &suggestion.DownvoteCount, &userVote,
 		)
 		if err != nil {
@@ -539,6 +596,26 @@ func (gec *GiftEventController) GetEventGiftSuggestions(c *gin.Context) {
 		suggestions = append(suggestions, suggestion)
 	}
 
+	if err := services.LoadGiftSuggestionTranslations(suggestions); err != nil {
+		fmt.Printf("Error loading gift suggestion translations: %v\n", err)
+	}
+
+	// Suggestions missing the requested language are translated once, then served from the database
+	if lang := c.Query("lang"); lang != "" {
+		language, ok := services.NormalizeGiftLanguage(lang)
+		if !ok {
+			apierrors.Respond(c, apierrors.UnsupportedLanguage.WithArg("language", lang))
+			return
+		}
+		ctx := services.WithLLMUsageScope(c.Request.Context(), services.LLMUsageScope{
+			UserID: userIDStr, EventID: eventID, Purpose: services.LLMPurposeTranslation,
+		})
+		if err := services.NewGiftTranslationService().TranslateGiftSuggestions(ctx, suggestions, language); err != nil {
+			// The suggestions are still listed, in the languages they have
+			fmt.Printf("Error translating gift suggestions of event %s to %s: %v\n", eventID, language, err)
+		}
+	}
+
 	c.JSON(http.StatusOK, suggestions)
 }
 
@@ -546,74 +623,154 @@ func (gec *GiftEventController) GetEventGiftSuggestions(c *gin.Context) {
 func (gec *GiftEventController) RegenerateEventGiftSuggestions(c *gin.Context) {
 	eventID := c.Param("id")
 	if eventID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Event ID is required"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "event_id"))
 		return
 	}
 
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
-	// Get event details and verify user is the creator
+	// Get event details (only the creator gets here, see the route's access policy)
 	var event models.Event
 	query := `
 		SELECT id, title, creator_id, description, start_date, location, 
-			   giftee_persona, event_occasion
+			   giftee_persona, event_occasion, language
 		FROM events
 		WHERE id = $1
 	`
 
 	err := gec.DB.QueryRow(query, eventID).Scan(
 		&event.ID, &event.Title, &event.CreatorID, &event.Description,
-		&event.StartDate, &event.Location, &event.GifteePersona, &event.EventOccasion,
+		&event.StartDate, &event.Location, &event.GifteePersona, &event.EventOccasion, &event.Language,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+			apierrors.Respond(c, apierrors.EventNotFound)
 			return
 		}
 		fmt.Printf("Error querying event: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
-	// Verify user is the creator
-	if event.CreatorID != userID.(string) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only the event creator can regenerate gift suggestions"})
+	// Refuse before deleting anything when the quotas leave no room for new suggestions
+	quotaScope := services.LLMUsageScope{UserID: event.CreatorID, EventID: event.ID}
+	if err := services.NewLLMUsageService().CheckQuota(quotaScope); err != nil {
+		if respondAIQuotaError(c, err) {
+			return
+		}
+		fmt.Printf("Error checking AI quota: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
-	// Delete existing suggestions
-	deleteQuery := `DELETE FROM gift_suggestions WHERE event_id = $1`
-	_, err = gec.DB.Exec(deleteQuery, eventID)
+	// Learn from the votes of this round and of the earlier ones
+	preferences, err := services.LoadGiftPreferenceProfile(eventID, event.Language)
+	if err != nil {
+		fmt.Printf("Error loading gift preferences for event %s: %v\n", eventID, err)
+		// Regenerate without preferences
+		preferences = nil
+	}
+
+	// Delete existing suggestions, keeping their votes for the next rounds
+	deletedIDs, err := gec.deleteSuggestionsKeepingVotes(eventID)
 	if err != nil {
 		fmt.Printf("Error deleting existing suggestions: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear existing suggestions"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
+	for _, deletedID := range deletedIDs {
+		services.EmitLifecycleEvent(services.LifecycleSuggestionDeleted, eventID, userID.(string), gin.H{"suggestion_id": deletedID})
+	}
 
 	// Generate new suggestions asynchronously
-	go gec.generateGiftSuggestionsForEvent(event)
+	go gec.generateGiftSuggestionsForEvent(event, preferences)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Generating new gift suggestions"})
 }
 
+// deleteSuggestionsKeepingVotes archives the votes on the suggestions of an event, then deletes the suggestions
+// It returns the IDs of the deleted suggestions
+func (gec *GiftEventController) deleteSuggestionsKeepingVotes(eventID string) ([]string, error) {
+	tx, err := gec.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := services.ArchiveGiftVotes(tx, eventID); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(`DELETE FROM gift_suggestions WHERE event_id = $1 RETURNING id`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	var deletedIDs []string
+	for rows.Next() {
+		var deletedID string
+		if err := rows.Scan(&deletedID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		deletedIDs = append(deletedIDs, deletedID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return deletedIDs, nil
+}
+
+// deleteSuggestionKeepingVotes archives the votes on a suggestion, then deletes it
+// It returns the number of deleted suggestions
+func (gec *GiftEventController) deleteSuggestionKeepingVotes(suggestionID string) (int64, error) {
+	tx, err := gec.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := services.ArchiveGiftSuggestionVotes(tx, suggestionID); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(`DELETE FROM gift_suggestions WHERE id = $1`, suggestionID)
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return rowsAffected, nil
+}
+
 // VoteOnSuggestion handles voting on a gift suggestion
 func (gec *GiftEventController) VoteOnSuggestion(c *gin.Context) {
 	suggestionID := c.Param("id")
 	if suggestionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Suggestion ID is required"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "suggestion_id"))
 		return
 	}
 
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
@@ -622,18 +779,21 @@ func (gec *GiftEventController) VoteOnSuggestion(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
 		return
 	}
 
 	// Validate vote type
 	if req.VoteType != "upvote" && req.VoteType != "downvote" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid vote type. Must be 'upvote' or 'downvote'"})
+		apierrors.Respond(c, apierrors.InvalidField.WithArg("field", "vote_type"))
 		return
 	}
 
 	userIDStr := userID.(string)
 
+	// The event the suggestion belongs to was resolved by the access middleware
+	eventID := c.GetString(middlewares.EventIDKey)
+
 	// Check if user already has a vote on this suggestion
 	var existingVoteID string
 	var existingVoteType string
@@ -642,7 +802,7 @@ func (gec *GiftEventController) VoteOnSuggestion(c *gin.Context) {
 
 	if err != nil && err != sql.ErrNoRows {
 		fmt.Printf("Error checking existing vote: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process vote"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
@@ -652,9 +812,10 @@ func (gec *GiftEventController) VoteOnSuggestion(c *gin.Context) {
 		_, err = gec.DB.Exec(deleteQuery, existingVoteID)
 		if err != nil {
 			fmt.Printf("Error deleting vote: %v\n", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove vote"})
+			apierrors.Respond(c, apierrors.InternalError)
 			return
 		}
+		gec.emitVoteChange(eventID, suggestionID, userIDStr, "")
 		c.JSON(http.StatusOK, gin.H{"message": "Vote removed"})
 		return
 	}
@@ -665,9 +826,10 @@ func (gec *GiftEventController) VoteOnSuggestion(c *gin.Context) {
 		_, err = gec.DB.Exec(updateQuery, req.VoteType, existingVoteID)
 		if err != nil {
 			fmt.Printf("Error updating vote: %v\n", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update vote"})
+			apierrors.Respond(c, apierrors.InternalError)
 			return
 		}
+		gec.emitVoteChange(eventID, suggestionID, userIDStr, req.VoteType)
 		c.JSON(http.StatusOK, gin.H{"message": "Vote updated", "vote_type": req.VoteType})
 		return
 	}
@@ -681,10 +843,12 @@ func (gec *GiftEventController) VoteOnSuggestion(c *gin.Context) {
 	_, err = gec.DB.Exec(insertQuery, voteID, suggestionID, userIDStr, req.VoteType)
 	if err != nil {
 		fmt.Printf("Error inserting vote: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record vote"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
+	gec.emitVoteChange(eventID, suggestionID, userIDStr, req.VoteType)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Vote recorded", "vote_type": req.VoteType})
 }
 
@@ -692,14 +856,14 @@ func (gec *GiftEventController) VoteOnSuggestion(c *gin.Context) {
 func (gec *GiftEventController) RemoveVote(c *gin.Context) {
 	suggestionID := c.Param("id")
 	if suggestionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Suggestion ID is required"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "suggestion_id"))
 		return
 	}
 
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
@@ -710,25 +874,57 @@ func (gec *GiftEventController) RemoveVote(c *gin.Context) {
 	result, err := gec.DB.Exec(deleteQuery, suggestionID, userIDStr)
 	if err != nil {
 		fmt.Printf("Error removing vote: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove vote"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No vote found to remove"})
+		apierrors.Respond(c, apierrors.VoteNotFound)
 		return
 	}
 
+	gec.emitVoteChange(c.GetString(middlewares.EventIDKey), suggestionID, userIDStr, "")
+
 	c.JSON(http.StatusOK, gin.H{"message": "Vote removed"})
 }
 
+// emitVoteChange notifies listeners of a vote change along with the new vote counts of the suggestion
+// An empty voteType means the user's vote was removed
+func (gec *GiftEventController) emitVoteChange(eventID, suggestionID, userID, voteType string) {
+	var upvotes, downvotes int
+	voteQuery := `
+		SELECT
+			COUNT(*) FILTER (WHERE vote_type = 'upvote') as upvotes,
+			COUNT(*) FILTER (WHERE vote_type = 'downvote') as downvotes
+		FROM gift_suggestion_votes
+		WHERE suggestion_id = $1
+	`
+	if err := gec.DB.QueryRow(voteQuery, suggestionID).Scan(&upvotes, &downvotes); err != nil {
+		fmt.Printf("Error counting votes for suggestion %s: %v\n", suggestionID, err)
+		return
+	}
+
+	var userVote interface{}
+	if voteType != "" {
+		userVote = voteType
+	}
+
+	services.EmitLifecycleEvent(services.LifecycleVoteCast, eventID, userID, gin.H{
+		"suggestion_id":  suggestionID,
+		"user_id":        userID,
+		"vote_type":      userVote,
+		"upvote_count":   upvotes,
+		"downvote_count": downvotes,
+	})
+}
+
 // CreateGiftSuggestion creates a new gift suggestion (manual or AI-generated)
 func (gec *GiftEventController) CreateGiftSuggestion(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
@@ -744,54 +940,43 @@ func (gec *GiftEventController) CreateGiftSuggestion(c *gin.Context) {
 		URL           string `json:"url"`
 		Prompt        string `json:"prompt"` // For AI mode
 		Language      string `json:"language"`
+
+		// Translations holds texts in any language, next to or instead of the English and French fields
+		Translations map[string]models.GiftTranslation `json:"translations"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
 		return
 	}
 
 	// Validate mode (note: 'static' is only used internally, not via API)
 	if req.Mode != "manual" && req.Mode != "ai" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mode. Must be 'manual' or 'ai'"})
+		apierrors.Respond(c, apierrors.InvalidField.WithArg("field", "mode"))
 		return
 	}
 
-	// Verify event exists and user has access
-	var eventCreatorID string
-	checkQuery := `SELECT creator_id FROM events WHERE id = $1`
-	err := gec.DB.QueryRow(checkQuery, req.EventID).Scan(&eventCreatorID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
-			return
-		}
-		fmt.Printf("Error checking event: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify event"})
+	// Verify the user takes part in the event
+	if !middlewares.AuthorizeEventAccess(c, req.EventID, services.EventActionParticipate) {
 		return
 	}
-
-	// Check if user is a participant
 	userIDStr := userID.(string)
-	var participantCount int
-	participantQuery := `SELECT COUNT(*) FROM event_participants WHERE event_id = $1 AND user_id = $2`
-	gec.DB.QueryRow(participantQuery, req.EventID, userIDStr).Scan(&participantCount)
-
-	if participantCount == 0 && eventCreatorID != userIDStr {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You must be a participant of this event to add suggestions"})
-		return
-	}
 
 	var suggestion models.GiftSuggestion
 
 	if req.Mode == "manual" {
 		// Validate required fields for manual mode
-		if req.NameEN == "" && req.NameFR == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required in at least one language"})
+		translations, language, err := services.BuildGiftTranslations(req.NameEN, req.DescriptionEN, req.NameFR, req.DescriptionFR, req.Translations, req.Language)
+		if err != nil {
+			apierrors.Respond(c, apierrors.InvalidField.WithArg("field", "translations").WithDetails(err.Error()))
+			return
+		}
+		if len(translations) == 0 {
+			apierrors.Respond(c, apierrors.MissingField.WithArg("field", "name"))
 			return
 		}
 		if req.PriceRange == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Price range is required"})
+			apierrors.Respond(c, apierrors.MissingField.WithArg("field", "price_range"))
 			return
 		}
 
@@ -800,66 +985,53 @@ func (gec *GiftEventController) CreateGiftSuggestion(c *gin.Context) {
 			req.URL = gec.URLValidator.SanitizeURL(req.URL)
 			isValid, err := gec.URLValidator.ValidateURL(req.URL)
 			if !isValid {
-				errMsg := "Invalid URL"
+				invalidURL := apierrors.InvalidURL
 				if err != nil {
-					errMsg = fmt.Sprintf("Invalid URL: %s", err.Error())
+					invalidURL = invalidURL.WithDetails(err.Error())
 				}
-				c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+				apierrors.Respond(c, invalidURL)
 				return
 			}
 		}
 
 		// Create manual suggestion
 		suggestion = models.GiftSuggestion{
-			ID:            uuid.NewString(),
-			EventID:       req.EventID,
-			OwnerID:       userIDStr,
-			NameEN:        req.NameEN,
-			NameFR:        req.NameFR,
-			DescriptionEN: req.DescriptionEN,
-			DescriptionFR: req.DescriptionFR,
-			PriceRange:    req.PriceRange,
-			Category:      req.Category,
-			URL:           req.URL,
-			GeneratedAt:   time.Now(),
-			CreatedAt:     time.Now(),
-			UpdatedAt:     time.Now(),
+			ID:           uuid.NewString(),
+			EventID:      req.EventID,
+			OwnerID:      userIDStr,
+			Language:     language,
+			Translations: translations,
+			PriceRange:   req.PriceRange,
+			Category:     req.Category,
+			URL:          req.URL,
+			GeneratedAt:  time.Now(),
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
 		}
 
-		// If only one language provided, copy to the other
-		if suggestion.NameEN == "" {
-			suggestion.NameEN = suggestion.NameFR
-		}
-		if suggestion.NameFR == "" {
-			suggestion.NameFR = suggestion.NameEN
-		}
-		if suggestion.DescriptionEN == "" {
-			suggestion.DescriptionEN = suggestion.DescriptionFR
-		}
-		if suggestion.DescriptionFR == "" {
-			suggestion.DescriptionFR = suggestion.DescriptionEN
-		}
+		// Languages without a text show the one the suggestion was written in
+		services.SyncLegacyGiftFields(&suggestion)
 	} else {
 		// AI mode
 		if req.Prompt == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Prompt is required for AI mode"})
+			apierrors.Respond(c, apierrors.MissingField.WithArg("field", "prompt"))
 			return
 		}
 
 		// Get event details for context
 		var event models.Event
 		eventQuery := `
-			SELECT title, description, start_date, location, giftee_persona, event_occasion
+			SELECT title, description, start_date, location, giftee_persona, event_occasion, language
 			FROM events
 			WHERE id = $1
 		`
 		err := gec.DB.QueryRow(eventQuery, req.EventID).Scan(
 			&event.Title, &event.Description, &event.StartDate,
-			&event.Location, &event.GifteePersona, &event.EventOccasion,
+			&event.Location, &event.GifteePersona, &event.EventOccasion, &event.Language,
 		)
 		if err != nil {
 			fmt.Printf("Error fetching event details: %v\n", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch event details"})
+			apierrors.Respond(c, apierrors.InternalError)
 			return
 		}
 
@@ -882,22 +1054,37 @@ func (gec *GiftEventController) CreateGiftSuggestion(c *gin.Context) {
 			UserPrompt:       req.Prompt,
 			Language:         req.Language,
 			SingleSuggestion: true,
+			EventID:          req.EventID,
+			UserID:           userIDStr,
+			Giftee:           services.LoadGifteeProfileForGeneration(req.EventID),
 		}
 
-		if aiRequest.Language == "" {
-			aiRequest.Language = "en"
+		// Suggestions are generated in the event's language unless another one is asked for
+		if language, ok := services.NormalizeGiftLanguage(req.Language); ok {
+			aiRequest.Language = language
+		} else {
+			aiRequest.Language = event.Language
+		}
+
+		// Steer the suggestion towards what the group voted for
+		aiRequest.Preferences, err = services.LoadGiftPreferenceProfile(req.EventID, aiRequest.Language)
+		if err != nil {
+			fmt.Printf("Error loading gift preferences for event %s: %v\n", req.EventID, err)
 		}
 
 		// Generate single suggestion using Mistral with similarity checking
 		suggestions, err := gec.GiftSuggestionService.GenerateGiftSuggestions(aiRequest, existingSuggestions)
 		if err != nil {
 			fmt.Printf("Error generating gift suggestion: %v\n", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate gift suggestion"})
+			if respondAIQuotaError(c, err) {
+				return
+			}
+			apierrors.Respond(c, apierrors.AIGenerationFailed)
 			return
 		}
 
 		if len(suggestions) == 0 {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "No suggestions generated"})
+			apierrors.Respond(c, apierrors.AIGenerationFailed)
 			return
 		}
 
@@ -924,25 +1111,28 @@ func (gec *GiftEventController) CreateGiftSuggestion(c *gin.Context) {
 		INSERT INTO gift_suggestions (
 			id, event_id, owner_id, name_en, name_fr, description_en, description_fr,
 			price_range, category, url, prompt, creation_mode, generated_at, created_at, updated_at,
-			amazon_asin, amazon_affiliate_url, amazon_price, amazon_region, amazon_last_updated
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+			amazon_asin, amazon_affiliate_url, amazon_price, amazon_region, amazon_last_updated, prompt_version
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
 	`
 
-	_, err = gec.DB.Exec(insertQuery,
+	_, err := gec.DB.Exec(insertQuery,
 		suggestion.ID, suggestion.EventID, suggestion.OwnerID,
 		suggestion.NameEN, suggestion.NameFR,
 		suggestion.DescriptionEN, suggestion.DescriptionFR,
 		suggestion.PriceRange, suggestion.Category, suggestion.URL, suggestion.Prompt, suggestion.CreationMode,
 		suggestion.GeneratedAt, suggestion.CreatedAt, suggestion.UpdatedAt,
 		suggestion.AmazonASIN, suggestion.AmazonAffiliateURL, suggestion.AmazonPrice,
-		suggestion.AmazonRegion, suggestion.AmazonLastUpdated,
+		suggestion.AmazonRegion, suggestion.AmazonLastUpdated, suggestion.PromptVersion,
 	)
 
 	if err != nil {
 		fmt.Printf("Error inserting gift suggestion: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create gift suggestion"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
+	if err := services.SaveGiftSuggestionTranslations(&suggestion); err != nil {
+		fmt.Printf("Error storing gift suggestion translations: %v\n", err)
+	}
 
 	// Initialize vote counts for response
 	suggestion.UpvoteCount = 0
@@ -951,6 +1141,8 @@ func (gec *GiftEventController) CreateGiftSuggestion(c *gin.Context) {
 	// Set IsAffiliateLink based on Amazon URL presence
 	suggestion.IsAffiliateLink = suggestion.AmazonAffiliateURL != nil && *suggestion.AmazonAffiliateURL != ""
 
+	services.EmitLifecycleEvent(services.LifecycleSuggestionCreated, suggestion.EventID, suggestion.OwnerID, suggestion)
+
 	c.JSON(http.StatusCreated, suggestion)
 }
 
@@ -958,14 +1150,14 @@ func (gec *GiftEventController) CreateGiftSuggestion(c *gin.Context) {
 func (gec *GiftEventController) UpdateGiftSuggestion(c *gin.Context) {
 	suggestionID := c.Param("id")
 	if suggestionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Suggestion ID is required"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "suggestion_id"))
 		return
 	}
 
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
@@ -982,48 +1174,56 @@ func (gec *GiftEventController) UpdateGiftSuggestion(c *gin.Context) {
 		Prompt           *string `json:"prompt"`
 		CreationMode     string  `json:"creation_mode"`
 		RegenerateWithAI bool    `json:"regenerate_with_ai"` // If true, regenerate with AI using new prompt
-		Language         string  `json:"language"`           // Language for AI regeneration
+		Language         string  `json:"language"`           // Language for AI regeneration, or the texts were written in
+
+		// Translations holds texts in any language, next to or instead of the English and French fields
+		Translations map[string]models.GiftTranslation `json:"translations"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
 		return
 	}
 
 	// Validate required fields
-	if req.NameEN == "" && req.NameFR == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required in at least one language"})
+	translations, language, err := services.BuildGiftTranslations(req.NameEN, req.DescriptionEN, req.NameFR, req.DescriptionFR, req.Translations, req.Language)
+	if err != nil {
+		apierrors.Respond(c, apierrors.InvalidField.WithArg("field", "translations").WithDetails(err.Error()))
+		return
+	}
+	if len(translations) == 0 {
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "name"))
 		return
 	}
 	if req.PriceRange == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Price range is required"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "price_range"))
 		return
 	}
 
 	// Validate creation_mode if provided
 	if req.CreationMode != "" && req.CreationMode != "manual" && req.CreationMode != "ai" && req.CreationMode != "static" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid creation_mode. Must be 'manual', 'ai', or 'static'"})
+		apierrors.Respond(c, apierrors.InvalidField.WithArg("field", "creation_mode"))
 		return
 	}
 
 	// Check if the suggestion exists and if the user is the owner
 	var ownerID string
 	checkQuery := `SELECT owner_id FROM gift_suggestions WHERE id = $1`
-	err := gec.DB.QueryRow(checkQuery, suggestionID).Scan(&ownerID)
+	err = gec.DB.QueryRow(checkQuery, suggestionID).Scan(&ownerID)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Gift suggestion not found"})
+			apierrors.Respond(c, apierrors.SuggestionNotFound)
 			return
 		}
 		fmt.Printf("Error checking gift suggestion ownership: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify ownership"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
 	// Verify user is the owner
 	if ownerID != userIDStr {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only the suggestion owner can update this suggestion"})
+		apierrors.Respond(c, apierrors.SuggestionOwnerOnly)
 		return
 	}
 
@@ -1032,24 +1232,23 @@ func (gec *GiftEventController) UpdateGiftSuggestion(c *gin.Context) {
 		req.URL = gec.URLValidator.SanitizeURL(req.URL)
 		isValid, err := gec.URLValidator.ValidateURL(req.URL)
 		if !isValid {
-			errMsg := "Invalid URL"
+			invalidURL := apierrors.InvalidURL
 			if err != nil {
-				errMsg = fmt.Sprintf("Invalid URL: %s", err.Error())
+				invalidURL = invalidURL.WithDetails(err.Error())
 			}
-			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+			apierrors.Respond(c, invalidURL)
 			return
 		}
 	}
 
 	// Smart detection: If prompt changed, regenerate with AI
-	nameEN := req.NameEN
-	nameFR := req.NameFR
-	descEN := req.DescriptionEN
-	descFR := req.DescriptionFR
+	texts := models.GiftSuggestion{ID: suggestionID, Language: language, Translations: translations}
 
 	// Amazon fields (only populated when regenerating with AI)
 	var amazonASIN, amazonAffiliateURL, amazonPrice, amazonRegion *string
 	var amazonLastUpdated *time.Time
+	// Prompt template version (only set when regenerating with AI, the current one is kept otherwise)
+	var promptVersion *string
 
 	if req.RegenerateWithAI && req.Prompt != nil && *req.Prompt != "" {
 		fmt.Printf("Regenerating gift suggestion with new prompt for suggestion %s\n", suggestionID)
@@ -1060,24 +1259,24 @@ func (gec *GiftEventController) UpdateGiftSuggestion(c *gin.Context) {
 		err := gec.DB.QueryRow(eventQuery, suggestionID).Scan(&eventID)
 		if err != nil {
 			fmt.Printf("Error fetching event ID: %v\n", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch event information"})
+			apierrors.Respond(c, apierrors.InternalError)
 			return
 		}
 
 		// Fetch the event details for context
 		var event models.Event
 		eventDetailsQuery := `
-			SELECT id, title, description, location, start_date, giftee_persona, event_occasion
+			SELECT id, title, description, location, start_date, giftee_persona, event_occasion, language
 			FROM events
 			WHERE id = $1
 		`
 		err = gec.DB.QueryRow(eventDetailsQuery, eventID).Scan(
 			&event.ID, &event.Title, &event.Description, &event.Location,
-			&event.StartDate, &event.GifteePersona, &event.EventOccasion,
+			&event.StartDate, &event.GifteePersona, &event.EventOccasion, &event.Language,
 		)
 		if err != nil {
 			fmt.Printf("Error fetching event details: %v\n", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch event details"})
+			apierrors.Respond(c, apierrors.InternalError)
 			return
 		}
 
@@ -1100,31 +1299,44 @@ func (gec *GiftEventController) UpdateGiftSuggestion(c *gin.Context) {
 			UserPrompt:       *req.Prompt, // Dereference pointer
 			Language:         req.Language,
 			SingleSuggestion: true,
+			EventID:          eventID,
+			UserID:           userIDStr,
+			Giftee:           services.LoadGifteeProfileForGeneration(eventID),
 		}
 
-		if aiRequest.Language == "" {
-			aiRequest.Language = "en"
+		// Suggestions are generated in the event's language unless another one is asked for
+		if language, ok := services.NormalizeGiftLanguage(req.Language); ok {
+			aiRequest.Language = language
+		} else {
+			aiRequest.Language = event.Language
+		}
+
+		// Steer the suggestion towards what the group voted for
+		aiRequest.Preferences, err = services.LoadGiftPreferenceProfile(eventID, aiRequest.Language)
+		if err != nil {
+			fmt.Printf("Error loading gift preferences for event %s: %v\n", eventID, err)
 		}
 
 		// Generate new suggestion using Mistral with similarity checking
 		suggestions, err := gec.GiftSuggestionService.GenerateGiftSuggestions(aiRequest, existingSuggestions)
 		if err != nil {
 			fmt.Printf("Error regenerating gift suggestion: %v\n", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate gift suggestion with AI"})
+			if respondAIQuotaError(c, err) {
+				return
+			}
+			apierrors.Respond(c, apierrors.AIGenerationFailed)
 			return
 		}
 
 		if len(suggestions) == 0 {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "AI did not generate any suggestions"})
+			apierrors.Respond(c, apierrors.AIGenerationFailed)
 			return
 		}
 
 		// Use the first generated suggestion to update the fields
 		generated := suggestions[0]
-		nameEN = generated.NameEN
-		nameFR = generated.NameFR
-		descEN = generated.DescriptionEN
-		descFR = generated.DescriptionFR
+		texts.Language = generated.Language
+		texts.Translations = generated.Translations
 		req.PriceRange = generated.PriceRange
 		req.Category = generated.Category
 		// Keep the user-provided URL if any, otherwise use generated URL
@@ -1138,24 +1350,15 @@ func (gec *GiftEventController) UpdateGiftSuggestion(c *gin.Context) {
 		amazonPrice = generated.AmazonPrice
 		amazonRegion = generated.AmazonRegion
 		amazonLastUpdated = generated.AmazonLastUpdated
+		promptVersion = generated.PromptVersion
 
-		fmt.Printf("Successfully regenerated suggestion: %s\n", nameEN)
+		fmt.Printf("Successfully regenerated suggestion: %s\n", generated.NameEN)
 	}
 
-	// If only one language provided, copy to the other
-
-	if nameEN == "" {
-		nameEN = nameFR
-	}
-	if nameFR == "" {
-		nameFR = nameEN
-	}
-	if descEN == "" {
-		descEN = descFR
-	}
-	if descFR == "" {
-		descFR = descEN
-	}
+	// Languages without a text show the one the suggestion was written in
+	services.SyncLegacyGiftFields(&texts)
+	nameEN, nameFR := texts.NameEN, texts.NameFR
+	descEN, descFR := texts.DescriptionEN, texts.DescriptionFR
 
 	// Build update query - only include creation_mode if provided
 	var updateQuery string
@@ -1166,41 +1369,46 @@ func (gec *GiftEventController) UpdateGiftSuggestion(c *gin.Context) {
 			UPDATE gift_suggestions
 			SET name_en = $1, name_fr = $2, description_en = $3, description_fr = $4,
 				price_range = $5, category = $6, url = $7, prompt = $8, creation_mode = $9, updated_at = $10,
-				amazon_asin = $11, amazon_affiliate_url = $12, amazon_price = $13, amazon_region = $14, amazon_last_updated = $15
-			WHERE id = $16
+				amazon_asin = $11, amazon_affiliate_url = $12, amazon_price = $13, amazon_region = $14, amazon_last_updated = $15,
+				prompt_version = COALESCE($16, prompt_version)
+			WHERE id = $17
 		`
 		args = []interface{}{
 			nameEN, nameFR, descEN, descFR,
 			req.PriceRange, req.Category, req.URL, req.Prompt, req.CreationMode, time.Now(),
-			amazonASIN, amazonAffiliateURL, amazonPrice, amazonRegion, amazonLastUpdated, suggestionID,
+			amazonASIN, amazonAffiliateURL, amazonPrice, amazonRegion, amazonLastUpdated, promptVersion, suggestionID,
 		}
 	} else {
 		updateQuery = `
 			UPDATE gift_suggestions
 			SET name_en = $1, name_fr = $2, description_en = $3, description_fr = $4,
 				price_range = $5, category = $6, url = $7, prompt = $8, updated_at = $9,
-				amazon_asin = $10, amazon_affiliate_url = $11, amazon_price = $12, amazon_region = $13, amazon_last_updated = $14
-			WHERE id = $15
+				amazon_asin = $10, amazon_affiliate_url = $11, amazon_price = $12, amazon_region = $13, amazon_last_updated = $14,
+				prompt_version = COALESCE($15, prompt_version)
+			WHERE id = $16
 		`
 		args = []interface{}{
 			nameEN, nameFR, descEN, descFR,
 			req.PriceRange, req.Category, req.URL, req.Prompt, time.Now(),
-			amazonASIN, amazonAffiliateURL, amazonPrice, amazonRegion, amazonLastUpdated, suggestionID,
+			amazonASIN, amazonAffiliateURL, amazonPrice, amazonRegion, amazonLastUpdated, promptVersion, suggestionID,
 		}
 	}
 
 	result, err := gec.DB.Exec(updateQuery, args...)
 	if err != nil {
 		fmt.Printf("Error updating gift suggestion: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update suggestion"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Gift suggestion not found"})
+		apierrors.Respond(c, apierrors.SuggestionNotFound)
 		return
 	}
+	if err := services.SaveGiftSuggestionTranslations(&texts); err != nil {
+		fmt.Printf("Error storing gift suggestion translations: %v\n", err)
+	}
 
 	// Fetch and return the updated suggestion
 	var suggestion models.GiftSuggestion
@@ -1210,7 +1418,7 @@ func (gec *GiftEventController) UpdateGiftSuggestion(c *gin.Context) {
 	fetchQuery := `
 		SELECT id, event_id, owner_id, name_en, name_fr, description_en, description_fr,
 			   price_range, category, url, prompt, creation_mode, generated_at, created_at, updated_at,
-			   amazon_asin, amazon_affiliate_url, amazon_price, amazon_region, amazon_last_updated
+			   amazon_asin, amazon_affiliate_url, amazon_price, amazon_region, amazon_last_updated, prompt_version
 		FROM gift_suggestions
 		WHERE id = $1
 	`
@@ -1220,6 +1428,7 @@ func (gec *GiftEventController) UpdateGiftSuggestion(c *gin.Context) {
 		&suggestion.PriceRange, &suggestion.Category, &suggestion.URL, &prompt, &suggestion.CreationMode,
 		&suggestion.GeneratedAt, &suggestion.CreatedAt, &suggestion.UpdatedAt,
 		&fetchAmazonASIN, &fetchAmazonAffiliateURL, &fetchAmazonPrice, &fetchAmazonRegion, &fetchAmazonLastUpdated,
+		&suggestion.PromptVersion,
 	)
 
 	// Handle nullable prompt field
@@ -1247,9 +1456,14 @@ func (gec *GiftEventController) UpdateGiftSuggestion(c *gin.Context) {
 
 	if err != nil {
 		fmt.Printf("Error fetching updated suggestion: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Suggestion updated but failed to fetch"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
+	updated := []models.GiftSuggestion{suggestion}
+	if err := services.LoadGiftSuggestionTranslations(updated); err != nil {
+		fmt.Printf("Error loading gift suggestion translations: %v\n", err)
+	}
+	suggestion = updated[0]
 
 	// Get vote counts
 	var upvotes, downvotes int
@@ -1264,6 +1478,8 @@ func (gec *GiftEventController) UpdateGiftSuggestion(c *gin.Context) {
 	suggestion.UpvoteCount = upvotes
 	suggestion.DownvoteCount = downvotes
 
+	services.EmitLifecycleEvent(services.LifecycleSuggestionUpdated, suggestion.EventID, userIDStr, suggestion)
+
 	c.JSON(http.StatusOK, suggestion)
 }
 
@@ -1271,54 +1487,65 @@ func (gec *GiftEventController) UpdateGiftSuggestion(c *gin.Context) {
 func (gec *GiftEventController) DeleteGiftSuggestion(c *gin.Context) {
 	suggestionID := c.Param("id")
 	if suggestionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Suggestion ID is required"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "suggestion_id"))
 		return
 	}
 
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
 	userIDStr := userID.(string)
 
 	// Check if the suggestion exists and if the user is the owner
-	var ownerID string
-	checkQuery := `SELECT owner_id FROM gift_suggestions WHERE id = $1`
-	err := gec.DB.QueryRow(checkQuery, suggestionID).Scan(&ownerID)
+	var ownerID, eventID string
+	checkQuery := `SELECT owner_id, event_id FROM gift_suggestions WHERE id = $1`
+	err := gec.DB.QueryRow(checkQuery, suggestionID).Scan(&ownerID, &eventID)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Gift suggestion not found"})
+			apierrors.Respond(c, apierrors.SuggestionNotFound)
 			return
 		}
 		fmt.Printf("Error checking gift suggestion ownership: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify ownership"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
 	// Verify user is the owner
 	if ownerID != userIDStr {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only the suggestion owner can delete this suggestion"})
+		apierrors.Respond(c, apierrors.SuggestionOwnerOnly)
 		return
 	}
 
-	// Delete the suggestion (votes will be deleted automatically due to CASCADE)
-	deleteQuery := `DELETE FROM gift_suggestions WHERE id = $1`
-	result, err := gec.DB.Exec(deleteQuery, suggestionID)
+	// Delete the suggestion (votes will be deleted automatically due to CASCADE), keeping its votes for the next generations
+	rowsAffected, err := gec.deleteSuggestionKeepingVotes(suggestionID)
 	if err != nil {
 		fmt.Printf("Error deleting gift suggestion: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete suggestion"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
-
-	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Gift suggestion not found"})
+		apierrors.Respond(c, apierrors.SuggestionNotFound)
 		return
 	}
 
+	services.EmitLifecycleEvent(services.LifecycleSuggestionDeleted, eventID, userIDStr, gin.H{"suggestion_id": suggestionID})
+
 	c.JSON(http.StatusOK, gin.H{"message": "Gift suggestion deleted successfully"})
 }
+
+// eventLanguage validates the language of a new event, French when empty as before events had one
+func eventLanguage(code string) (string, error) {
+	if strings.TrimSpace(code) == "" {
+		return "fr", nil
+	}
+	language, ok := services.NormalizeGiftLanguage(code)
+	if !ok {
+		return "", fmt.Errorf("unsupported language %q", code)
+	}
+	return language, nil
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"be-geoffray/api/apierrors"
+	"be-geoffray/api/middlewares"
+	"be-geoffray/models"
+	"be-geoffray/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetGifteeProfile returns the giftee profile of an event
+// Guests of a surprise event may not read it
+func GetGifteeProfile(c *gin.Context) {
+	eventID := c.Param("id")
+	gifteeProfileService := services.NewGifteeProfileService()
+
+	surprise, err := gifteeProfileService.IsSurprise(eventID)
+	if err != nil {
+		fmt.Printf("Error checking surprise mode: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+	relationship, _ := c.Get(middlewares.EventRelationshipKey)
+	if !services.CanViewGifteeProfile(relationship.(services.EventRelationship), surprise) {
+		apierrors.Respond(c, apierrors.GifteeProfileHidden)
+		return
+	}
+
+	profile, err := gifteeProfileService.GetGifteeProfile(eventID)
+	if err != nil {
+		fmt.Printf("Error fetching giftee profile: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+	if profile == nil {
+		apierrors.Respond(c, apierrors.GifteeProfileNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// SaveGifteeProfile creates or replaces the giftee profile of an event
+func SaveGifteeProfile(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var profile models.GifteeProfile
+	if err := c.ShouldBindJSON(&profile); err != nil {
+		apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
+		return
+	}
+	profile.EventID = c.Param("id")
+
+	saved, err := services.NewGifteeProfileService().SaveGifteeProfile(&profile, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidGifteeProfile) {
+			apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
+			return
+		}
+		fmt.Printf("Error saving giftee profile: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// DeleteGifteeProfile removes the giftee profile of an event
+func DeleteGifteeProfile(c *gin.Context) {
+	if err := services.NewGifteeProfileService().DeleteGifteeProfile(c.Param("id")); err != nil {
+		fmt.Printf("Error deleting giftee profile: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Giftee profile deleted"})
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"be-geoffray/api/apierrors"
 	"be-geoffray/api/middlewares"
 	"be-geoffray/db"
 	"be-geoffray/models"
@@ -79,12 +80,12 @@ func InitializeFirebase() {
 func FirebaseLogin(c *gin.Context) {
 	var input FirebaseLoginRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
 		return
 	}
 
 	if firebaseAuth == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Firebase not initialized"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
@@ -93,7 +94,7 @@ func FirebaseLogin(c *gin.Context) {
 	token, err := firebaseAuth.VerifyIDToken(ctx, input.IDToken)
 	if err != nil {
 		fmt.Printf("Firebase token verification error: %v\n", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Firebase ID token"})
+		apierrors.Respond(c, apierrors.InvalidFirebaseToken)
 		return
 	}
 
@@ -161,7 +162,7 @@ func FirebaseLogin(c *gin.Context) {
 			// User doesn't exist, create new one
 			userExists = false
 		} else if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+			apierrors.Respond(c, apierrors.InternalError)
 			return
 		} else {
 			// User exists by email, update with Firebase UID
@@ -170,12 +171,12 @@ func FirebaseLogin(c *gin.Context) {
 			               WHERE id = $3`
 			_, err = db.DB.Exec(updateQuery, firebaseUID, picture, user.ID)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user with Firebase info"})
+				apierrors.Respond(c, apierrors.InternalError)
 				return
 			}
 		}
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	} else {
 		userExists = true
@@ -221,7 +222,7 @@ func FirebaseLogin(c *gin.Context) {
 		).Scan(&user.ID)
 
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user: " + err.Error()})
+			apierrors.Respond(c, apierrors.InternalError)
 			return
 		}
 
@@ -234,7 +235,7 @@ func FirebaseLogin(c *gin.Context) {
 	tokenResponse, err := middlewares.GenerateTokenPair(user.ID)
 	if err != nil {
 		fmt.Printf("Token generation error: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate tokens: " + err.Error()})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"time"
 
+	"be-geoffray/api/apierrors"
+	"be-geoffray/services"
 	"github.com/gin-gonic/gin"
 )
 
@@ -56,21 +58,18 @@ func (ic *InviteController) ValidateInvite(c *gin.Context) {
 	)
 
 	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Invite not found"})
+		apierrors.Respond(c, apierrors.InviteNotFound)
 		return
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate invite"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
 	// Check if invite is expired
 	if time.Now().After(invite.ExpiresAt) {
-		c.JSON(http.StatusGone, gin.H{
-			"error": "Invite has expired",
-			"valid": false,
-		})
+		apierrors.Respond(c, apierrors.InviteExpired.WithField("valid", false))
 		return
 	}
 
@@ -106,7 +105,7 @@ func (ic *InviteController) AcceptInvite(c *gin.Context) {
 	code := c.Param("code")
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
@@ -114,7 +113,7 @@ func (ic *InviteController) AcceptInvite(c *gin.Context) {
 	var userEmail string
 	err := ic.db.QueryRow("SELECT email FROM users WHERE id = $1", userID).Scan(&userEmail)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user details"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
@@ -142,34 +141,32 @@ func (ic *InviteController) AcceptInvite(c *gin.Context) {
 	)
 
 	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Invite not found"})
+		apierrors.Respond(c, apierrors.InviteNotFound)
 		return
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve invite"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
 	// Check if expired
 	if time.Now().After(invite.ExpiresAt) {
-		c.JSON(http.StatusGone, gin.H{"error": "Invite has expired"})
+		apierrors.Respond(c, apierrors.InviteExpired)
 		return
 	}
 
 	// Check if already accepted
 	if invite.Status == "accepted" {
-		c.JSON(http.StatusConflict, gin.H{"error": "Invite already accepted"})
+		apierrors.Respond(c, apierrors.InviteAlreadyAccepted)
 		return
 	}
 
 	// Validate email matches
 	if invite.Email.Valid && invite.Email.String != userEmail {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":         "This invite was sent to a different email address",
-			"invited_email": invite.Email.String,
-			"your_email":    userEmail,
-		})
+		apierrors.Respond(c, apierrors.InviteEmailMismatch.
+			WithField("invited_email", invite.Email.String).
+			WithField("your_email", userEmail))
 		return
 	}
 
@@ -182,22 +179,19 @@ func (ic *InviteController) AcceptInvite(c *gin.Context) {
 	).Scan(&existingParticipant)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check participation status"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
 	if existingParticipant {
-		c.JSON(http.StatusConflict, gin.H{
-			"error":    "You are already a participant in this event",
-			"event_id": invite.EventID,
-		})
+		apierrors.Respond(c, apierrors.AlreadyParticipant.WithField("event_id", invite.EventID))
 		return
 	}
 
 	// Begin transaction
 	tx, err := ic.db.Begin()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 	defer tx.Rollback()
@@ -211,7 +205,7 @@ func (ic *InviteController) AcceptInvite(c *gin.Context) {
 	)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add participant"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
@@ -224,7 +218,7 @@ func (ic *InviteController) AcceptInvite(c *gin.Context) {
 	)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update invitation"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
@@ -236,16 +230,21 @@ func (ic *InviteController) AcceptInvite(c *gin.Context) {
 	)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
 	// Commit transaction
 	if err = tx.Commit(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
+	services.EmitLifecycleEvent(services.LifecycleParticipantRSVP, invite.EventID, userID.(string), gin.H{
+		"user_id": userID,
+		"status":  "going",
+	})
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":  "Successfully joined event",
 		"event_id": invite.EventID,
@@ -1,9 +1,16 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
+	"be-geoffray/api/apierrors"
 	"be-geoffray/localization"
+	"be-geoffray/models"
 	"github.com/gin-gonic/gin"
 )
 
@@ -33,29 +40,219 @@ func (c *LocalizationController) GetTranslations(ctx *gin.Context) {
 	// Get translations from service
 	translations, err := c.service.GetTranslations(languageCode)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve translations: " + err.Error(),
-		})
+		fmt.Printf("Error retrieving %s translations: %v\n", languageCode, err)
+		apierrors.Respond(ctx, apierrors.InternalError)
 		return
 	}
 
-	// Return translations
-	ctx.JSON(http.StatusOK, translations)
+	// The frontend reads i18next messages, the ICU ones it cannot express stay on the server
+	ctx.JSON(http.StatusOK, &models.LanguageTranslations{
+		LanguageCode: translations.LanguageCode,
+		Version:      translations.Version,
+		Translations: localization.ClientTranslations(translations.LanguageCode, translations.Translations),
+	})
 }
 
-// ImportTranslations handles the request to import translations from JSON files
-func (c *LocalizationController) ImportTranslations(ctx *gin.Context) {
-	// This endpoint is for admin use only, so it should be protected
-	// Import translations from the translations directory
+// ReloadTranslations handles importing the JSON files shipped with the backend again, for administrators
+// Messages edited through the API are kept
+func (c *LocalizationController) ReloadTranslations(ctx *gin.Context) {
 	err := c.service.ImportTranslationsFromJSON("./localization/translations")
+	if errors.Is(err, localization.ErrInvalidMessage) {
+		// The valid messages were imported, the invalid ones are listed for fixing
+		apierrors.Respond(ctx, apierrors.InvalidTranslations.WithDetails(strings.Split(err.Error(), "\n")))
+		return
+	}
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to import translations: " + err.Error(),
-		})
+		fmt.Printf("Error importing translations: %v\n", err)
+		apierrors.Respond(ctx, apierrors.InternalError)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"message": "Translations imported successfully",
-	})
+	c.respondWithVersion(ctx, gin.H{"message": "Translations imported successfully"})
+}
+
+// GetTranslationReport handles listing the keys each language misses or has in excess compared to the default language
+func (c *LocalizationController) GetTranslationReport(ctx *gin.Context) {
+	report, err := c.service.GetTranslationReport()
+	if err != nil {
+		fmt.Printf("Error building translation report: %v\n", err)
+		apierrors.Respond(ctx, apierrors.InternalError)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// ListTranslations handles listing the stored messages of a language with their source, for administrators
+func (c *LocalizationController) ListTranslations(ctx *gin.Context) {
+	languageCode := ctx.Param("lang")
+	translations, err := c.service.ListTranslations(languageCode)
+	if err != nil {
+		fmt.Printf("Error listing %s translations: %v\n", languageCode, err)
+		apierrors.Respond(ctx, apierrors.InternalError)
+		return
+	}
+
+	c.respondWithVersion(ctx, gin.H{"language_code": languageCode, "translations": translations})
+}
+
+// GetTranslation handles reading a single message
+func (c *LocalizationController) GetTranslation(ctx *gin.Context) {
+	translation, err := c.service.GetTranslation(ctx.Param("lang"), ctx.Param("key"))
+	if err != nil {
+		respondTranslationError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, translation)
+}
+
+// SaveTranslation handles creating or updating a single message
+// Body: {"value": "..."}, an ICU message using only the arguments of the default language message
+func (c *LocalizationController) SaveTranslation(ctx *gin.Context) {
+	var req struct {
+		Value string `json:"value" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apierrors.Respond(ctx, apierrors.InvalidRequest.WithDetails(err.Error()))
+		return
+	}
+
+	translation := &models.Translation{
+		LanguageCode: ctx.Param("lang"),
+		Key:          ctx.Param("key"),
+		Value:        req.Value,
+	}
+	if err := c.service.SaveTranslation(translation); err != nil {
+		respondTranslationError(ctx, err)
+		return
+	}
+
+	saved, err := c.service.GetTranslation(translation.LanguageCode, translation.Key)
+	if err != nil {
+		respondTranslationError(ctx, err)
+		return
+	}
+	c.respondWithVersion(ctx, gin.H{"translation": saved})
+}
+
+// DeleteTranslation handles removing a single message
+func (c *LocalizationController) DeleteTranslation(ctx *gin.Context) {
+	if err := c.service.DeleteTranslation(ctx.Param("lang"), ctx.Param("key")); err != nil {
+		respondTranslationError(ctx, err)
+		return
+	}
+
+	c.respondWithVersion(ctx, gin.H{"message": "Translation deleted successfully"})
+}
+
+// ExportTranslations handles downloading the messages of a language
+// Query parameter format: json (default), a flat file like the ones in localization/translations,
+// or xliff, with the default language messages as sources for translators
+func (c *LocalizationController) ExportTranslations(ctx *gin.Context) {
+	languageCode := ctx.Param("lang")
+	translations, err := c.service.ListTranslations(languageCode)
+	if err != nil {
+		fmt.Printf("Error exporting %s translations: %v\n", languageCode, err)
+		apierrors.Respond(ctx, apierrors.InternalError)
+		return
+	}
+	messages := models.TranslationMap{}
+	for _, translation := range translations {
+		messages[translation.Key] = translation.Value
+	}
+
+	switch ctx.DefaultQuery("format", "json") {
+	case "json":
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, languageCode))
+		ctx.JSON(http.StatusOK, messages)
+	case "xliff":
+		defaults, err := c.service.GetTranslations(localization.DefaultLanguage)
+		if err != nil {
+			fmt.Printf("Error exporting %s translations: %v\n", languageCode, err)
+			apierrors.Respond(ctx, apierrors.InternalError)
+			return
+		}
+		data, err := localization.EncodeXLIFF(languageCode, defaults.Translations, messages)
+		if err != nil {
+			fmt.Printf("Error encoding %s translations: %v\n", languageCode, err)
+			apierrors.Respond(ctx, apierrors.InternalError)
+			return
+		}
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xliff"`, languageCode))
+		ctx.Data(http.StatusOK, "application/xliff+xml; charset=utf-8", data)
+	default:
+		apierrors.Respond(ctx, apierrors.InvalidField.WithArg("field", "format"))
+	}
+}
+
+// ImportTranslations handles uploading the messages of a language, in the body
+// Query parameter format: json (default), a flat object of keys and messages, or xliff
+// Nothing is saved when a message is invalid
+func (c *LocalizationController) ImportTranslations(ctx *gin.Context) {
+	languageCode := ctx.Param("lang")
+	data, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		apierrors.Respond(ctx, apierrors.InvalidRequest)
+		return
+	}
+
+	translations := models.TranslationMap{}
+	switch ctx.DefaultQuery("format", "json") {
+	case "json":
+		if err := json.Unmarshal(data, &translations); err != nil {
+			apierrors.Respond(ctx, apierrors.InvalidRequest.WithDetails(err.Error()))
+			return
+		}
+	case "xliff":
+		var targetLanguage string
+		targetLanguage, translations, err = localization.DecodeXLIFF(data)
+		if err != nil {
+			apierrors.Respond(ctx, apierrors.InvalidRequest.WithDetails(err.Error()))
+			return
+		}
+		if targetLanguage != "" && targetLanguage != languageCode {
+			details := fmt.Sprintf("the document translates to %q, not %q", targetLanguage, languageCode)
+			apierrors.Respond(ctx, apierrors.InvalidRequest.WithDetails(details))
+			return
+		}
+	default:
+		apierrors.Respond(ctx, apierrors.InvalidField.WithArg("field", "format"))
+		return
+	}
+
+	changed, err := c.service.ImportTranslations(languageCode, translations)
+	if err != nil {
+		respondTranslationError(ctx, err)
+		return
+	}
+
+	c.respondWithVersion(ctx, gin.H{"imported": len(translations), "changed": changed})
+}
+
+// respondWithVersion answers with the current catalog version added to the body
+func (c *LocalizationController) respondWithVersion(ctx *gin.Context, body gin.H) {
+	version, err := c.service.CatalogVersion()
+	if err != nil {
+		fmt.Printf("Error reading translation catalog version: %v\n", err)
+		apierrors.Respond(ctx, apierrors.InternalError)
+		return
+	}
+	body["version"] = version
+	ctx.JSON(http.StatusOK, body)
+}
+
+// respondTranslationError maps the errors of the localization service to HTTP statuses
+func respondTranslationError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, localization.ErrTranslationNotFound):
+		apierrors.Respond(ctx, apierrors.TranslationNotFound)
+	case errors.Is(err, localization.ErrInvalidLanguageCode), errors.Is(err, localization.ErrInvalidTranslationKey):
+		apierrors.Respond(ctx, apierrors.InvalidRequest.WithDetails(err.Error()))
+	case errors.Is(err, localization.ErrInvalidMessage):
+		apierrors.Respond(ctx, apierrors.InvalidTranslations.WithDetails(strings.Split(err.Error(), "\n")))
+	default:
+		fmt.Printf("Error managing translations: %v\n", err)
+		apierrors.Respond(ctx, apierrors.InternalError)
+	}
 }
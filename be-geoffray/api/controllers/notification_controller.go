@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"be-geoffray/api/apierrors"
+	"be-geoffray/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetNotifications returns the notifications of the authenticated user
+func GetNotifications(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierrors.Respond(c, apierrors.Unauthenticated)
+		return
+	}
+
+	unreadOnly := c.Query("unread") == "true"
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	notificationService := services.NewNotificationService()
+	notifications, err := notificationService.GetNotifications(userID.(string), unreadOnly, limit)
+	if err != nil {
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	unreadCount, err := notificationService.GetUnreadCount(userID.(string))
+	if err != nil {
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"unread_count":  unreadCount,
+	})
+}
+
+// MarkNotificationAsRead marks a notification of the authenticated user as read
+func MarkNotificationAsRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierrors.Respond(c, apierrors.Unauthenticated)
+		return
+	}
+
+	notificationService := services.NewNotificationService()
+	if err := notificationService.MarkAsRead(userID.(string), c.Param("id")); err != nil {
+		if err.Error() == "notification not found" {
+			apierrors.Respond(c, apierrors.NotificationNotFound)
+			return
+		}
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+// MarkAllNotificationsAsRead marks every notification of the authenticated user as read
+func MarkAllNotificationsAsRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierrors.Respond(c, apierrors.Unauthenticated)
+		return
+	}
+
+	notificationService := services.NewNotificationService()
+	if err := notificationService.MarkAllAsRead(userID.(string)); err != nil {
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All notifications marked as read"})
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 
+	"be-geoffray/api/apierrors"
 	"be-geoffray/services"
 	"github.com/gin-gonic/gin"
 )
@@ -17,21 +18,21 @@ func UpdateParticipantStatus(c *gin.Context) {
 	// Get the user ID from the authenticated context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
 	// Get the event ID from the URL
 	eventID := c.Param("id")
 	if eventID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Event ID is required"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "event_id"))
 		return
 	}
 
 	// Parse the input
 	var input UpdateParticipantStatusInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
 		return
 	}
 
@@ -44,11 +45,11 @@ func UpdateParticipantStatus(c *gin.Context) {
 		// Handle different types of errors with appropriate status codes
 		switch err.Error() {
 		case "invalid status: must be 'accepted', 'pending', or 'declined'":
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			apierrors.Respond(c, apierrors.InvalidField.WithArg("field", "status"))
 		case "user is not a participant in this event":
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			apierrors.Respond(c, apierrors.NotParticipant)
 		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update participant status"})
+			apierrors.Respond(c, apierrors.InternalError)
 		}
 		return
 	}
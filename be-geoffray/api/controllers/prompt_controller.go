@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"be-geoffray/api/apierrors"
+	"be-geoffray/prompts"
+	"be-geoffray/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ListPrompts handles listing the loaded prompt template versions for administrators
+func ListPrompts(c *gin.Context) {
+	registry, err := prompts.Default()
+	if err != nil {
+		fmt.Printf("Error loading prompt templates: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"prompts": registry.Variants()})
+}
+
+// ReloadPrompts handles reading the prompt templates again, so that wording and traffic changes apply without a redeploy
+// Invalid templates are rejected and the current ones kept
+func ReloadPrompts(c *gin.Context) {
+	registry, err := prompts.Default()
+	if err != nil {
+		fmt.Printf("Error loading prompt templates: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	if err := registry.Reload(); err != nil {
+		fmt.Printf("Error reloading prompt templates: %v\n", err)
+		apierrors.Respond(c, apierrors.InvalidPrompts.WithDetails(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"prompts": registry.Variants()})
+}
+
+// GetPromptStats handles comparing the votes on AI suggestions per prompt version for administrators
+// Query parameters: from and to (default: the last 30 days)
+func GetPromptStats(c *gin.Context) {
+	from, to, ok := parseUsageRange(c)
+	if !ok {
+		return
+	}
+
+	stats, err := services.GetPromptVersionStats(from, to)
+	if err != nil {
+		fmt.Printf("Error aggregating prompt version stats: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":     from,
+		"to":       to,
+		"versions": stats,
+	})
+}
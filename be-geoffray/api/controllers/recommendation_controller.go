@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"be-geoffray/api/apierrors"
+	"be-geoffray/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetRecommendations handles the "suggested for you" gift ideas and event defaults of the current user
+// Query parameter: limit (default 5, at most 20)
+func GetRecommendations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierrors.Respond(c, apierrors.Unauthenticated)
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if err != nil || limit < 1 {
+		apierrors.Respond(c, apierrors.InvalidField.WithArg("field", "limit"))
+		return
+	}
+
+	recommendations, err := services.NewRecommendationService().GetRecommendations(userID.(string), limit)
+	if err != nil {
+		fmt.Printf("Error building recommendations: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	c.JSON(http.StatusOK, recommendations)
+}
@@ -1,10 +1,12 @@
 package controllers
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 	"time"
 
+	"be-geoffray/api/apierrors"
 	"be-geoffray/services"
 	"github.com/gin-gonic/gin"
 )
@@ -17,6 +19,8 @@ type UpdateEventInput struct {
 	EndDate       *time.Time `json:"end_date"`
 	Location      *string    `json:"location"`
 	RemoveEndDate *bool      `json:"remove_end_date"`
+	SurpriseMode  *bool      `json:"surprise_mode"`
+	Language      *string    `json:"language"`
 }
 
 // UpdateEvent handles updating an existing event's details
@@ -25,27 +29,27 @@ func UpdateEvent(c *gin.Context) {
 	// Get the user ID from the authenticated context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
 	// Get the event ID from the URL parameter
 	eventID := c.Param("id")
 	if eventID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Event ID is required"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "event_id"))
 		return
 	}
 
 	// Parse the request body
 	var input UpdateEventInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
 		return
 	}
 
 	// Ensure at least one field is being updated
-	if input.Title == nil && input.Description == nil && input.StartDate == nil && input.EndDate == nil && input.Location == nil && input.RemoveEndDate == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one field must be provided for update"})
+	if input.Title == nil && input.Description == nil && input.StartDate == nil && input.EndDate == nil && input.Location == nil && input.RemoveEndDate == nil && input.SurpriseMode == nil && input.Language == nil {
+		apierrors.Respond(c, apierrors.NoFieldsToUpdate)
 		return
 	}
 
@@ -73,20 +77,33 @@ func UpdateEvent(c *gin.Context) {
 	if input.Location != nil {
 		updates["location"] = *input.Location
 	}
+	if input.SurpriseMode != nil {
+		updates["surprise_mode"] = *input.SurpriseMode
+	}
+	if input.Language != nil {
+		language, ok := services.NormalizeGiftLanguage(*input.Language)
+		if !ok {
+			apierrors.Respond(c, apierrors.UnsupportedLanguage.WithArg("language", *input.Language))
+			return
+		}
+		updates["language"] = language
+	}
 
 	// Update the event using the service
 	updatedEvent, err := eventService.UpdateEvent(eventID, userID.(string), updates)
 	if err != nil {
 		log.Printf("Error in UpdateEvent: %v", err)
-		statusCode := http.StatusInternalServerError
-		if err.Error() == "event not found" {
-			statusCode = http.StatusNotFound
-		} else if err.Error() == "only the event creator can update this event" {
-			statusCode = http.StatusForbidden
-		} else if err.Error() == "end date cannot be before start date" || err.Error() == "end date cannot be before existing start date" {
-			statusCode = http.StatusBadRequest
+		switch err.Error() {
+		case "event not found":
+			apierrors.Respond(c, apierrors.EventNotFound)
+		case "only the event creator can update this event":
+			apierrors.Respond(c, apierrors.EventCreatorOnly)
+		case "end date cannot be before start date", "end date cannot be before existing start date":
+			apierrors.Respond(c, apierrors.InvalidDateRange)
+		default:
+			fmt.Printf("Error updating event: %v\n", err)
+			apierrors.Respond(c, apierrors.InternalError)
 		}
-		c.JSON(statusCode, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -7,6 +7,7 @@ import (
 	"os"
 	"time"
 
+	"be-geoffray/api/apierrors"
 	"be-geoffray/api/middlewares"
 	"be-geoffray/db"
 	"be-geoffray/models"
@@ -19,13 +20,13 @@ func GetUserProfile(c *gin.Context) {
 	// Get user ID from context (set by JWT middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
 	// Query the database for user information
 	var user models.User
-	query := `SELECT id, first_name, last_name, email, firebase_uid, created_at, updated_at 
+	query := `SELECT id, first_name, last_name, email, firebase_uid, personalized_recommendations, created_at, updated_at 
           FROM users WHERE id = $1`
 
 	err := db.DB.QueryRow(query, userID).Scan(
@@ -34,15 +35,16 @@ func GetUserProfile(c *gin.Context) {
 		&user.LastName,
 		&user.Email,
 		&user.FirebaseUID,
+		&user.PersonalizedRecommendations,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apierrors.Respond(c, apierrors.UserNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+			apierrors.Respond(c, apierrors.InternalError)
 		}
 		return
 	}
@@ -57,7 +59,7 @@ func UpdateUserProfile(c *gin.Context) {
 	// Get user ID from context (set by JWT middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierrors.Respond(c, apierrors.Unauthenticated)
 		return
 	}
 
@@ -66,10 +68,12 @@ func UpdateUserProfile(c *gin.Context) {
 		FirstName *string `json:"first_name,omitempty"`
 		LastName  *string `json:"last_name,omitempty"`
 		Email     *string `json:"email,omitempty"`
+		// Opt in or out of the recommendations learnt from the gift history
+		PersonalizedRecommendations *bool `json:"personalized_recommendations,omitempty"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
 		return
 	}
 
@@ -97,6 +101,12 @@ func UpdateUserProfile(c *gin.Context) {
 		args = append(args, *input.Email)
 	}
 
+	if input.PersonalizedRecommendations != nil {
+		paramCount++
+		query += fmt.Sprintf(", personalized_recommendations = $%d", paramCount)
+		args = append(args, *input.PersonalizedRecommendations)
+	}
+
 	// Password updates are now handled by Firebase, remove this functionality
 
 	// Complete the query with the WHERE clause
@@ -109,9 +119,9 @@ func UpdateUserProfile(c *gin.Context) {
 	err := db.DB.QueryRow(query, args...).Scan(&id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apierrors.Respond(c, apierrors.UserNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+			apierrors.Respond(c, apierrors.InternalError)
 		}
 		return
 	}
@@ -127,21 +137,21 @@ func RefreshToken(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Refresh token is required"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "refresh_token"))
 		return
 	}
 
 	// Validate the refresh token
 	userID, err := middlewares.ValidateRefreshToken(input.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		apierrors.Respond(c, apierrors.InvalidToken.WithDetails(err.Error()))
 		return
 	}
 
 	// Generate a new access token
 	accessToken, err := middlewares.GenerateJWT(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate access token"})
+		apierrors.Respond(c, apierrors.InternalError)
 		return
 	}
 
@@ -156,7 +166,7 @@ func ValidateToken(c *gin.Context) {
 	// Get the Authorization header
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization token required"})
+		apierrors.Respond(c, apierrors.TokenRequired)
 		return
 	}
 
@@ -174,19 +184,13 @@ func ValidateToken(c *gin.Context) {
 	})
 
 	if err != nil || !token.Valid {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"valid": false,
-			"error": "Invalid or expired token",
-		})
+		apierrors.Respond(c, apierrors.InvalidToken.WithField("valid", false))
 		return
 	}
 
 	// Ensure this is an access token, not a refresh token
 	if claims.Type != "access" && claims.Type != "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"valid": false,
-			"error": "Invalid token type",
-		})
+		apierrors.Respond(c, apierrors.InvalidToken.WithField("valid", false))
 		return
 	}
 
@@ -195,10 +199,7 @@ func ValidateToken(c *gin.Context) {
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`
 	err = db.DB.QueryRow(query, claims.UserID).Scan(&userExists)
 	if err != nil || !userExists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"valid": false,
-			"error": "User not found",
-		})
+		apierrors.Respond(c, apierrors.InvalidToken.WithField("valid", false))
 		return
 	}
 
@@ -221,7 +222,7 @@ func Logout(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Refresh token is required"})
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "refresh_token"))
 		return
 	}
 
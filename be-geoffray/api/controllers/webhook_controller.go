@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"be-geoffray/api/apierrors"
+	"be-geoffray/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateWebhookInput represents the request body for registering a webhook
+type CreateWebhookInput struct {
+	URL        string   `json:"url" binding:"required"`
+	EventID    *string  `json:"event_id"`
+	EventTypes []string `json:"event_types" binding:"required"`
+}
+
+// UpdateWebhookInput represents the request body for updating a webhook
+type UpdateWebhookInput struct {
+	URL        *string  `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Active     *bool    `json:"active"`
+}
+
+// respondWebhookError maps webhook service errors to HTTP responses
+func respondWebhookError(c *gin.Context, err error) {
+	message := err.Error()
+	switch {
+	case message == "webhook not found":
+		apierrors.Respond(c, apierrors.WebhookNotFound)
+	case message == "event not found":
+		apierrors.Respond(c, apierrors.EventNotFound)
+	case message == "delivery not found":
+		apierrors.Respond(c, apierrors.DeliveryNotFound)
+	case message == "only the event creator can register event webhooks":
+		apierrors.Respond(c, apierrors.EventCreatorOnly)
+	case errors.Is(err, services.ErrInvalidWebhookURL):
+		apierrors.Respond(c, apierrors.InvalidURL.WithDetails(message))
+	case message == "at least one event type is required":
+		apierrors.Respond(c, apierrors.MissingField.WithArg("field", "event_types"))
+	case strings.HasPrefix(message, "unknown event type"):
+		apierrors.Respond(c, apierrors.InvalidField.WithArg("field", "event_types").WithDetails(message))
+	default:
+		fmt.Printf("Error managing webhook: %v\n", err)
+		apierrors.Respond(c, apierrors.InternalError)
+	}
+}
+
+// CreateWebhook handles registering a new webhook for the authenticated user
+func CreateWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierrors.Respond(c, apierrors.Unauthenticated)
+		return
+	}
+
+	var input CreateWebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
+		return
+	}
+
+	webhookService := services.NewWebhookService()
+	webhook, err := webhookService.CreateWebhook(userID.(string), input.EventID, input.URL, input.EventTypes)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	// The secret is only ever returned here, the receiver needs it to verify signatures
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// GetWebhooks handles listing the webhooks of the authenticated user
+func GetWebhooks(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierrors.Respond(c, apierrors.Unauthenticated)
+		return
+	}
+
+	webhookService := services.NewWebhookService()
+	webhooks, err := webhookService.ListWebhooks(userID.(string))
+	if err != nil {
+		apierrors.Respond(c, apierrors.InternalError)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// GetWebhook handles fetching a single webhook
+func GetWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierrors.Respond(c, apierrors.Unauthenticated)
+		return
+	}
+
+	webhookService := services.NewWebhookService()
+	webhook, err := webhookService.GetWebhook(c.Param("id"), userID.(string))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// UpdateWebhook handles updating the URL, event types or active flag of a webhook
+func UpdateWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierrors.Respond(c, apierrors.Unauthenticated)
+		return
+	}
+
+	var input UpdateWebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierrors.Respond(c, apierrors.InvalidRequest.WithDetails(err.Error()))
+		return
+	}
+
+	webhookService := services.NewWebhookService()
+	webhook, err := webhookService.UpdateWebhook(c.Param("id"), userID.(string), input.URL, input.EventTypes, input.Active)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook handles removing a webhook
+func DeleteWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierrors.Respond(c, apierrors.Unauthenticated)
+		return
+	}
+
+	webhookService := services.NewWebhookService()
+	if err := webhookService.DeleteWebhook(c.Param("id"), userID.(string)); err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetWebhookDeliveries handles listing the delivery log of a webhook
+func GetWebhookDeliveries(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierrors.Respond(c, apierrors.Unauthenticated)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	webhookService := services.NewWebhookService()
+	deliveries, err := webhookService.ListDeliveries(c.Param("id"), userID.(string), limit)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// ReplayWebhookDelivery handles queuing a delivery again with its original payload
+func ReplayWebhookDelivery(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierrors.Respond(c, apierrors.Unauthenticated)
+		return
+	}
+
+	webhookService := services.NewWebhookService()
+	delivery, err := webhookService.ReplayDelivery(c.Param("id"), c.Param("delivery_id"), userID.(string))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
@@ -0,0 +1,22 @@
+package middlewares
+
+import (
+	"be-geoffray/api/apierrors"
+	"be-geoffray/config"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin rejects requests from users missing from the configured admin list (ADMIN_USER_IDS)
+// Requires the JWT middleware to run first
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("user_id")
+		for _, adminID := range config.GetConfig().AdminUserIDs {
+			if userID != "" && userID == adminID {
+				c.Next()
+				return
+			}
+		}
+		apierrors.Respond(c, apierrors.AdminRequired)
+	}
+}
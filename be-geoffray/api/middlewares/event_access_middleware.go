@@ -0,0 +1,106 @@
+package middlewares
+
+import (
+	"be-geoffray/api/apierrors"
+	"be-geoffray/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys set once the caller's access to an event has been resolved
+const (
+	EventIDKey           = "event_id"
+	EventRelationshipKey = "event_relationship"
+)
+
+// Resolvers used by the access middlewares, replaced in tests
+var (
+	ResolveEventRelationship = services.ResolveEventRelationship
+	ResolveSuggestionEventID = services.GetSuggestionEventID
+)
+
+// RequireEventAccess rejects requests on the event in the ":id" path parameter when the caller may not perform the action
+// Requires the JWT middleware to run first
+func RequireEventAccess(action services.EventAction) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !AuthorizeEventAccess(c, c.Param("id"), action) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSuggestionAccess rejects requests on the gift suggestion in the ":id" path parameter
+// when the caller may not perform the action on the event it belongs to
+func RequireSuggestionAccess(action services.EventAction) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		eventID, err := ResolveSuggestionEventID(c.Param("id"))
+		if err != nil {
+			if err.Error() == "suggestion not found" {
+				apierrors.Respond(c, apierrors.SuggestionNotFound)
+			} else {
+				apierrors.Respond(c, apierrors.InternalError)
+			}
+			return
+		}
+
+		relationship, ok := resolveEventRelationship(c, eventID)
+		if !ok {
+			c.Abort()
+			return
+		}
+		// Suggestions of events the caller cannot see do not exist for them
+		if relationship == services.EventRelationshipNone {
+			apierrors.Respond(c, apierrors.SuggestionNotFound)
+			return
+		}
+		if !relationship.Can(action) {
+			apierrors.Respond(c, apierrors.EventAccessDenied)
+			return
+		}
+		c.Next()
+	}
+}
+
+// AuthorizeEventAccess checks that the caller may perform an action on an event, writing the error response otherwise
+// It is used directly by handlers that receive the event ID in their body or query
+func AuthorizeEventAccess(c *gin.Context, eventID string, action services.EventAction) bool {
+	relationship, ok := resolveEventRelationship(c, eventID)
+	if !ok {
+		return false
+	}
+	if relationship == services.EventRelationshipNone {
+		apierrors.Respond(c, apierrors.EventNotFound)
+		return false
+	}
+	if !relationship.Can(action) {
+		apierrors.Respond(c, apierrors.EventAccessDenied)
+		return false
+	}
+	return true
+}
+
+// resolveEventRelationship resolves the caller's relationship to an event, at most once per request
+func resolveEventRelationship(c *gin.Context, eventID string) (services.EventRelationship, bool) {
+	if c.GetString(EventIDKey) == eventID {
+		if relationship, exists := c.Get(EventRelationshipKey); exists {
+			return relationship.(services.EventRelationship), true
+		}
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		apierrors.Respond(c, apierrors.Unauthenticated)
+		return services.EventRelationshipNone, false
+	}
+
+	relationship, err := ResolveEventRelationship(eventID, userID)
+	if err != nil {
+		apierrors.Respond(c, apierrors.InternalError)
+		return services.EventRelationshipNone, false
+	}
+
+	c.Set(EventIDKey, eventID)
+	c.Set(EventRelationshipKey, relationship)
+	return relationship, true
+}
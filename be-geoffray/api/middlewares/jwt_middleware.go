@@ -3,11 +3,11 @@ package middlewares
 import (
 	"errors"
 	"fmt"
-	"net/http"
 	"os"
 	"strings"
 	"time"
 
+	"be-geoffray/api/apierrors"
 	"be-geoffray/db"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -19,6 +19,14 @@ const (
 	RefreshTokenExpiration = 7 * 24 * time.Hour // 7 days
 )
 
+// WebSocketAuthProtocol is the subprotocol that precedes the access token in a WebSocket handshake.
+// Browsers cannot set headers on WebSocket handshakes, so clients open the socket with
+// new WebSocket(url, ["bearer", token]); unlike a query parameter, the header stays out of access logs
+const WebSocketAuthProtocol = "bearer"
+
+// TokenExpiresAtKey is the context key of the expiry of the access token, for connections that outlive the request
+const TokenExpiresAtKey = "token_expires_at"
+
 // Claims structure
 type Claims struct {
 	UserID    string `json:"user_id"`
@@ -149,9 +157,13 @@ func ValidateRefreshToken(tokenString string) (string, error) {
 func JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" && strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+			if token := webSocketProtocolToken(c.Request.Header.Values("Sec-WebSocket-Protocol")); token != "" {
+				authHeader = "Bearer " + token
+			}
+		}
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization token required"})
-			c.Abort()
+			apierrors.Respond(c, apierrors.TokenRequired)
 			return
 		}
 
@@ -163,20 +175,37 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 		})
 
 		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+			apierrors.Respond(c, apierrors.InvalidToken)
 			return
 		}
 
 		// Ensure this is an access token, not a refresh token
 		if claims.Type != "access" && claims.Type != "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token type"})
-			c.Abort()
+			apierrors.Respond(c, apierrors.InvalidToken)
 			return
 		}
 
 		// Store user ID in context for later use
 		c.Set("user_id", claims.UserID)
+		if claims.ExpiresAt != nil {
+			c.Set(TokenExpiresAtKey, claims.ExpiresAt.Time)
+		}
 		c.Next()
 	}
 }
+
+// webSocketProtocolToken returns the token offered right after WebSocketAuthProtocol in the handshake subprotocols
+func webSocketProtocolToken(headers []string) string {
+	var protocols []string
+	for _, header := range headers {
+		for _, protocol := range strings.Split(header, ",") {
+			protocols = append(protocols, strings.TrimSpace(protocol))
+		}
+	}
+	for i := 0; i+1 < len(protocols); i++ {
+		if protocols[i] == WebSocketAuthProtocol {
+			return protocols[i+1]
+		}
+	}
+	return ""
+}
@@ -6,19 +6,19 @@ import (
 )
 
 // LanguageMiddleware detects the user's preferred language and sets it in the context
+// It applies to every route, so that errors are answered in the user's language
 func LanguageMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// First, check if language is specified in query parameter
+		// First, check if language is specified in query parameter ("fr" or "fr-CA")
 		languageCode := c.Query("lang")
 
 		// If not in query, try to detect from Accept-Language header
 		if languageCode == "" {
-			acceptLanguage := c.GetHeader("Accept-Language")
-			languageCode = localization.DetectLanguage(acceptLanguage)
+			languageCode = c.GetHeader("Accept-Language")
 		}
 
 		// Set language in context for later use
-		c.Set("language", languageCode)
+		c.Set(localization.LanguageContextKey, localization.DetectLanguage(languageCode))
 
 		// Continue processing the request
 		c.Next()
@@ -0,0 +1,332 @@
+package routes
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"be-geoffray/api/middlewares"
+	"be-geoffray/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	testEventID      = "11111111-1111-1111-1111-111111111111"
+	testSuggestionID = "22222222-2222-2222-2222-222222222222"
+	testUserID       = "33333333-3333-3333-3333-333333333333"
+	testJWTSecret    = "test-secret"
+)
+
+// accessPolicyRoute is an event-scoped route along with the action it requires
+type accessPolicyRoute struct {
+	method string
+	path   string
+	body   string
+	action services.EventAction
+}
+
+var accessPolicyRoutes = []accessPolicyRoute{
+	// Events
+	{http.MethodGet, "/events/" + testEventID, "", services.EventActionView},
+	{http.MethodPut, "/events/" + testEventID, `{"title":"Birthday"}`, services.EventActionManage},
+	{http.MethodDelete, "/events/" + testEventID, "", services.EventActionManage},
+	{http.MethodPost, "/events/" + testEventID + "/draft", `{"tone":"funny"}`, services.EventActionManage},
+	{http.MethodPost, "/events/" + testEventID + "/participants", `{"identifier":"a@b.co","type":"email"}`, services.EventActionManage},
+	{http.MethodDelete, "/events/" + testEventID + "/invitations/a@b.co", "", services.EventActionManage},
+	{http.MethodPut, "/events/" + testEventID + "/participant-status", `{"status":"accepted"}`, services.EventActionView},
+	{http.MethodGet, "/events/" + testEventID + "/ws", "", services.EventActionView},
+	{http.MethodGet, "/events/" + testEventID + "/summary", "", services.EventActionView},
+	{http.MethodGet, "/events/" + testEventID + "/polls", "", services.EventActionView},
+	{http.MethodPost, "/events/" + testEventID + "/polls/44444444-4444-4444-4444-444444444444/votes", `{"option_ids":[]}`, services.EventActionParticipate},
+	{http.MethodGet, "/events/" + testEventID + "/giftee-profile", "", services.EventActionView},
+	{http.MethodPut, "/events/" + testEventID + "/giftee-profile", `{"interests":["chess"]}`, services.EventActionManage},
+	{http.MethodDelete, "/events/" + testEventID + "/giftee-profile", "", services.EventActionManage},
+
+	// Messages
+	{http.MethodGet, "/events/" + testEventID + "/messages/", "", services.EventActionView},
+	{http.MethodPost, "/events/" + testEventID + "/messages/", `{"content":"hello"}`, services.EventActionParticipate},
+	{http.MethodPut, "/events/" + testEventID + "/messages/m1", `{"content":"hello"}`, services.EventActionParticipate},
+	{http.MethodDelete, "/events/" + testEventID + "/messages/m1", "", services.EventActionParticipate},
+	{http.MethodGet, "/events/" + testEventID + "/messages/m1/history", "", services.EventActionView},
+	{http.MethodGet, "/events/" + testEventID + "/messages/m1/thread", "", services.EventActionView},
+	{http.MethodGet, "/events/" + testEventID + "/messages/m1/seen-by", "", services.EventActionView},
+	{http.MethodPost, "/events/" + testEventID + "/messages/read", `{}`, services.EventActionView},
+	{http.MethodPost, "/events/" + testEventID + "/messages/m1/reactions", `{"emoji":"👍"}`, services.EventActionParticipate},
+	{http.MethodDelete, "/events/" + testEventID + "/messages/m1/reactions/👍", "", services.EventActionParticipate},
+	{http.MethodPost, "/events/" + testEventID + "/attachments/", "", services.EventActionParticipate},
+	{http.MethodGet, "/events/" + testEventID + "/attachments/a1", "", services.EventActionParticipate},
+
+	// Agent chat (event in the body)
+	{http.MethodPost, "/chat/stream", `{"chat_id":"` + testEventID + `","message":"hello"}`, services.EventActionParticipate},
+
+	// Gift suggestions
+	{http.MethodGet, "/api/gifts/suggestions?event_id=" + testEventID, "", services.EventActionView},
+	{http.MethodGet, "/api/events/" + testEventID + "/gift-suggestions", "", services.EventActionView},
+	{http.MethodPost, "/api/events/" + testEventID + "/regenerate-gift-suggestions", "", services.EventActionManage},
+	{http.MethodPost, "/api/gift-suggestions", `{"event_id":"` + testEventID + `","mode":"ai"}`, services.EventActionParticipate},
+
+	// Votes and suggestion edits (event resolved from the suggestion)
+	{http.MethodPost, "/api/gift-suggestions/" + testSuggestionID + "/vote", `{"vote_type":"upvote"}`, services.EventActionParticipate},
+	{http.MethodDelete, "/api/gift-suggestions/" + testSuggestionID + "/vote", "", services.EventActionParticipate},
+	{http.MethodPut, "/api/gift-suggestions/" + testSuggestionID, `{"name_en":"Book","price_range":"$"}`, services.EventActionParticipate},
+	{http.MethodDelete, "/api/gift-suggestions/" + testSuggestionID, "", services.EventActionParticipate},
+}
+
+// newAccessPolicyRouter registers the event-scoped routes the way main does, with stubbed access resolvers
+func newAccessPolicyRouter(t *testing.T, relationship services.EventRelationship) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", testJWTSecret)
+
+	resolveEvent, resolveSuggestion := middlewares.ResolveEventRelationship, middlewares.ResolveSuggestionEventID
+	t.Cleanup(func() {
+		middlewares.ResolveEventRelationship, middlewares.ResolveSuggestionEventID = resolveEvent, resolveSuggestion
+	})
+	middlewares.ResolveEventRelationship = func(eventID, userID string) (services.EventRelationship, error) {
+		if eventID != testEventID || userID != testUserID {
+			return services.EventRelationshipNone, nil
+		}
+		return relationship, nil
+	}
+	middlewares.ResolveSuggestionEventID = func(suggestionID string) (string, error) {
+		if suggestionID != testSuggestionID {
+			return "", errors.New("suggestion not found")
+		}
+		return testEventID, nil
+	}
+
+	router := gin.New()
+	// Handlers reached by allowed requests have no database here: their panics become 500s
+	router.Use(gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, _ any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+
+	protected := router.Group("/")
+	protected.Use(middlewares.JWTAuthMiddleware())
+	RegisterEventRoutes(protected)
+	RegisterEventMessagesRoutes(protected)
+	RegisterChatRoutes(protected)
+	SetupGiftRoutes(router, nil)
+
+	return router
+}
+
+func testAccessToken(t *testing.T) string {
+	t.Helper()
+	return testAccessTokenExpiringIn(t, time.Hour)
+}
+
+func testAccessTokenExpiringIn(t *testing.T, lifetime time.Duration) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &middlewares.Claims{
+		UserID: testUserID,
+		Type:   "access",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(lifetime)),
+		},
+	}).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestEventScopedRoutesEnforceAccessPolicy(t *testing.T) {
+	relationships := []services.EventRelationship{
+		services.EventRelationshipNone,
+		services.EventRelationshipInvited,
+		services.EventRelationshipParticipant,
+		services.EventRelationshipOwner,
+	}
+
+	for _, relationship := range relationships {
+		router := newAccessPolicyRouter(t, relationship)
+		token := testAccessToken(t)
+
+		for _, route := range accessPolicyRoutes {
+			req := httptest.NewRequest(route.method, route.path, strings.NewReader(route.body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			got := recorder.Code
+			switch {
+			case relationship == services.EventRelationshipNone:
+				if got != http.StatusNotFound {
+					t.Errorf("%s %s as %s: status %d, want %d", route.method, route.path, relationship, got, http.StatusNotFound)
+				}
+			case !relationship.Can(route.action):
+				if got != http.StatusForbidden {
+					t.Errorf("%s %s as %s: status %d, want %d", route.method, route.path, relationship, got, http.StatusForbidden)
+				}
+			default:
+				if got == http.StatusUnauthorized || got == http.StatusForbidden || got == http.StatusNotFound {
+					t.Errorf("%s %s as %s: rejected with status %d", route.method, route.path, relationship, got)
+				}
+			}
+		}
+	}
+}
+
+func TestAttachmentsAreNotServedToInvitedUsers(t *testing.T) {
+	router := newAccessPolicyRouter(t, services.EventRelationshipInvited)
+
+	for _, path := range []string{"/attachments/a1", "/attachments/a1?thumbnail=true"} {
+		req := httptest.NewRequest(http.MethodGet, "/events/"+testEventID+path, nil)
+		req.Header.Set("Authorization", "Bearer "+testAccessToken(t))
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		if recorder.Code != http.StatusForbidden {
+			t.Errorf("GET %s as invited: status %d, want %d", path, recorder.Code, http.StatusForbidden)
+		}
+	}
+}
+
+func TestEventScopedRoutesRequireAuthentication(t *testing.T) {
+	router := newAccessPolicyRouter(t, services.EventRelationshipOwner)
+
+	for _, route := range accessPolicyRoutes {
+		req := httptest.NewRequest(route.method, route.path, strings.NewReader(route.body))
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s without token: status %d, want %d", route.method, route.path, recorder.Code, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestSuggestionRoutesHideUnknownSuggestions(t *testing.T) {
+	router := newAccessPolicyRouter(t, services.EventRelationshipOwner)
+	token := testAccessToken(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/gift-suggestions/44444444-4444-4444-4444-444444444444/vote", strings.NewReader(`{"vote_type":"upvote"}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("vote on unknown suggestion: status %d, want %d", recorder.Code, http.StatusNotFound)
+	}
+}
+
+func TestEventWebSocketTakesTheTokenFromTheSubprotocol(t *testing.T) {
+	router := newAccessPolicyRouter(t, services.EventRelationshipParticipant)
+	token := testAccessToken(t)
+
+	tests := []struct {
+		name             string
+		query            string
+		protocols        string
+		wantUnauthorized bool
+	}{
+		{name: "subprotocol", protocols: "bearer, " + token},
+		{name: "query parameter", query: "?token=" + token, wantUnauthorized: true},
+		{name: "subprotocol without token", protocols: "bearer", wantUnauthorized: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/events/"+testEventID+"/ws"+tt.query, nil)
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+			if tt.protocols != "" {
+				req.Header.Set("Sec-WebSocket-Protocol", tt.protocols)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			// The recorder cannot be upgraded, so an authenticated handshake stops with another error
+			if unauthorized := recorder.Code == http.StatusUnauthorized; unauthorized != tt.wantUnauthorized {
+				t.Errorf("status %d, want unauthorized %v", recorder.Code, tt.wantUnauthorized)
+			}
+		})
+	}
+}
+
+func TestEventWebSocketClosesWhenAccessEnds(t *testing.T) {
+	if services.GetRealtimeService() == nil {
+		if err := services.InitRealtimeService("memory"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// dial opens the event socket, revoked making the user a stranger to the event from then on
+	dial := func(t *testing.T, token string, revoked *atomic.Bool) *websocket.Conn {
+		t.Helper()
+		router := newAccessPolicyRouter(t, services.EventRelationshipParticipant)
+		resolve := middlewares.ResolveEventRelationship
+		middlewares.ResolveEventRelationship = func(eventID, userID string) (services.EventRelationship, error) {
+			if revoked.Load() {
+				return services.EventRelationshipNone, nil
+			}
+			return resolve(eventID, userID)
+		}
+		server := httptest.NewServer(router)
+		t.Cleanup(server.Close)
+
+		dialer := websocket.Dialer{Subprotocols: []string{middlewares.WebSocketAuthProtocol, token}}
+		conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/events/"+testEventID+"/ws", nil)
+		if err != nil {
+			t.Fatalf("Dial() error = %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		return conn
+	}
+	closeReason := func(t *testing.T, conn *websocket.Conn) string {
+		t.Helper()
+		for {
+			_, _, err := conn.ReadMessage()
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				return closeErr.Text
+			}
+			if err != nil {
+				t.Fatalf("connection not closed by the server: %v", err)
+			}
+		}
+	}
+
+	t.Run("token expires", func(t *testing.T) {
+		conn := dial(t, testAccessTokenExpiringIn(t, 2*time.Second), &atomic.Bool{})
+		if reason := closeReason(t, conn); reason != "token expired" {
+			t.Errorf("close reason %q, want token expired", reason)
+		}
+	})
+
+	t.Run("access revoked", func(t *testing.T) {
+		revoked := &atomic.Bool{}
+		conn := dial(t, testAccessToken(t), revoked)
+		revoked.Store(true)
+
+		// RSVPs make the server check the access again; they are repeated until the subscription is in place
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			ticker := time.NewTicker(50 * time.Millisecond)
+			defer ticker.Stop()
+			for {
+				services.EmitLifecycleEvent(services.LifecycleParticipantRSVP, testEventID, "someone-else", nil)
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+
+		if reason := closeReason(t, conn); reason != "event access revoked" {
+			t.Errorf("close reason %q, want event access revoked", reason)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"be-geoffray/api/controllers"
+	"be-geoffray/api/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAdminRoutes registers the routes reserved to administrators
+func RegisterAdminRoutes(r *gin.RouterGroup) {
+	admin := r.Group("/admin")
+	admin.Use(middlewares.RequireAdmin())
+
+	admin.GET("/ai-usage", controllers.GetAIUsage) // Aggregated language model spend
+
+	admin.GET("/prompts", controllers.ListPrompts)           // Loaded prompt template versions
+	admin.POST("/prompts/reload", controllers.ReloadPrompts) // Read the prompt templates again
+	admin.GET("/prompts/stats", controllers.GetPromptStats)  // Votes per prompt version
+
+	translations := controllers.NewLocalizationController()
+	admin.GET("/translations/report", translations.GetTranslationReport)      // Missing and extra keys per language
+	admin.POST("/translations/reload", translations.ReloadTranslations)       // Import the JSON files again
+	admin.GET("/translations/:lang", translations.ListTranslations)           // Messages of a language with their source
+	admin.GET("/translations/:lang/export", translations.ExportTranslations)  // ?format=json|xliff
+	admin.POST("/translations/:lang/import", translations.ImportTranslations) // ?format=json|xliff
+	admin.GET("/translations/:lang/keys/:key", translations.GetTranslation)
+	admin.PUT("/translations/:lang/keys/:key", translations.SaveTranslation)
+	admin.DELETE("/translations/:lang/keys/:key", translations.DeleteTranslation)
+}
@@ -0,0 +1,101 @@
+package routes
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"be-geoffray/api/middlewares"
+	"be-geoffray/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminRoutesRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", testJWTSecret)
+
+	cfg := config.GetConfig()
+	adminIDs := cfg.AdminUserIDs
+	t.Cleanup(func() { cfg.AdminUserIDs = adminIDs })
+
+	router := gin.New()
+	// The usage handler has no database here: its panic becomes a 500
+	router.Use(gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, _ any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	protected := router.Group("/")
+	protected.Use(middlewares.JWTAuthMiddleware())
+	RegisterAdminRoutes(protected)
+
+	tests := []struct {
+		name       string
+		adminIDs   []string
+		token      bool
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "anonymous", adminIDs: []string{testUserID}, wantStatus: http.StatusUnauthorized, wantCode: "token_required"},
+		{name: "no admins configured", token: true, wantStatus: http.StatusForbidden, wantCode: "admin_required"},
+		{name: "not an admin", adminIDs: []string{"someone-else"}, token: true, wantStatus: http.StatusForbidden, wantCode: "admin_required"},
+		{name: "admin", adminIDs: []string{"someone-else", testUserID}, token: true, wantStatus: http.StatusInternalServerError},
+	}
+
+	routes := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/admin/ai-usage", ""},
+		{http.MethodGet, "/admin/translations/report", ""},
+		{http.MethodPost, "/admin/translations/reload", ""},
+		{http.MethodGet, "/admin/translations/fr/export?format=xliff", ""},
+		{http.MethodPost, "/admin/translations/fr/import", `{"common.welcome":"Bienvenue"}`},
+		{http.MethodPut, "/admin/translations/fr/keys/common.welcome", `{"value":"Bienvenue"}`},
+		{http.MethodDelete, "/admin/translations/fr/keys/common.welcome", ""},
+	}
+
+	for _, tt := range tests {
+		for _, route := range routes {
+			t.Run(tt.name+" "+route.method+" "+route.path, func(t *testing.T) {
+				cfg.AdminUserIDs = tt.adminIDs
+				req := httptest.NewRequest(route.method, route.path, strings.NewReader(route.body))
+				req.Header.Set("Content-Type", "application/json")
+				if tt.token {
+					req.Header.Set("Authorization", "Bearer "+testAccessToken(t))
+				}
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, req)
+
+				if recorder.Code != tt.wantStatus {
+					t.Errorf("status %d, want %d", recorder.Code, tt.wantStatus)
+				}
+				if tt.wantCode != "" {
+					var body struct {
+						Code string `json:"code"`
+					}
+					if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil || body.Code != tt.wantCode {
+						t.Errorf("code %q, want %q", body.Code, tt.wantCode)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestTranslationImportIsNotPublic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	RegisterLocalizationRoutes(router.Group("/api"))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/translations/import", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("status %d, want %d", recorder.Code, http.StatusNotFound)
+	}
+}
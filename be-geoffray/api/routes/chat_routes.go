@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"be-geoffray/api/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterChatRoutes registers the agent chat routes
+func RegisterChatRoutes(r *gin.RouterGroup) {
+	chat := r.Group("/chat")
+
+	chat.POST("/stream", controllers.StreamChat) // Stream the agent's reply as server-sent events
+}
@@ -2,14 +2,34 @@ package routes
 
 import (
 	"be-geoffray/api/controllers"
+	"be-geoffray/api/middlewares"
+	"be-geoffray/services"
 	"github.com/gin-gonic/gin"
 )
 
 // RegisterEventMessagesRoutes registers all event message-related routes
 func RegisterEventMessagesRoutes(r *gin.RouterGroup) {
+	// Anyone who can see the event can read its discussion; writing requires taking part in it
+	view := middlewares.RequireEventAccess(services.EventActionView)
+	participate := middlewares.RequireEventAccess(services.EventActionParticipate)
+
 	messages := r.Group("/events/:id/messages")
 
 	// Event message routes
-	messages.GET("/", controllers.GetEventMessages)    // Get all messages for an event
-	messages.POST("/", controllers.CreateEventMessage) // Create a new message for an event
+	messages.GET("/", view, controllers.GetEventMessages)                                                 // Get a page of messages for an event (before/after/limit)
+	messages.POST("/", participate, controllers.CreateEventMessage)                                       // Create a new message for an event
+	messages.PUT("/:message_id", participate, controllers.UpdateEventMessage)                             // Edit a message (author only)
+	messages.DELETE("/:message_id", participate, controllers.DeleteEventMessage)                          // Delete a message (author only)
+	messages.GET("/:message_id/history", view, controllers.GetEventMessageHistory)                        // Previous versions of a message
+	messages.GET("/:message_id/thread", view, controllers.GetEventMessageThread)                          // Root message with nested replies
+	messages.GET("/:message_id/seen-by", view, controllers.GetEventMessageSeenBy)                         // Participants who have read a message
+	messages.POST("/read", view, controllers.MarkEventMessagesRead)                                       // Mark messages as read
+	messages.POST("/:message_id/reactions", participate, controllers.AddEventMessageReaction)             // React to a message
+	messages.DELETE("/:message_id/reactions/:emoji", participate, controllers.RemoveEventMessageReaction) // Remove a reaction
+
+	// Attachments are uploaded first, then linked to a message through its attachment_ids
+	// Files are only served to participants, not to guests who have not accepted the invitation
+	attachments := r.Group("/events/:id/attachments")
+	attachments.POST("/", participate, controllers.UploadEventAttachment)           // Upload a file (multipart field "file")
+	attachments.GET("/:attachment_id", participate, controllers.GetEventAttachment) // Download a file, or its thumbnail with ?thumbnail=true
 }
@@ -2,6 +2,8 @@ package routes
 
 import (
 	"be-geoffray/api/controllers"
+	"be-geoffray/api/middlewares"
+	"be-geoffray/services"
 	"github.com/gin-gonic/gin"
 )
 
@@ -9,13 +11,29 @@ import (
 func RegisterEventRoutes(r *gin.RouterGroup) {
 	events := r.Group("/events")
 
+	view := middlewares.RequireEventAccess(services.EventActionView)
+	participate := middlewares.RequireEventAccess(services.EventActionParticipate)
+	manage := middlewares.RequireEventAccess(services.EventActionManage)
+
 	// Event routes
 	events.POST("/", controllers.CreateEvent)
-	events.GET("/me", controllers.GetUserEvents)                               // Get user's events
-	events.GET("/:id", controllers.GetEventByID)                               // Get a specific event by ID
-	events.PUT("/:id", controllers.UpdateEvent)                                // Update an event's details
-	events.DELETE("/:id", controllers.DeleteEvent)                             // Delete an event
-	events.POST("/:id/participants", controllers.InviteParticipant)            // Invite a participant to an event
-	events.DELETE("/:id/invitations/:email", controllers.RescindInvitation)    // Rescind an invitation
-	events.PUT("/:id/participant-status", controllers.UpdateParticipantStatus) // Update participant status
+	events.GET("/me", controllers.GetUserEvents)                                     // Get user's events
+	events.GET("/:id", view, controllers.GetEventByID)                               // Get a specific event by ID
+	events.PUT("/:id", manage, controllers.UpdateEvent)                              // Update an event's details
+	events.DELETE("/:id", manage, controllers.DeleteEvent)                           // Delete an event
+	events.POST("/:id/draft", manage, controllers.DraftEventTexts)                   // AI draft of the description and invitation
+	events.POST("/:id/participants", manage, controllers.InviteParticipant)          // Invite a participant to an event
+	events.DELETE("/:id/invitations/:email", manage, controllers.RescindInvitation)  // Rescind an invitation
+	events.PUT("/:id/participant-status", view, controllers.UpdateParticipantStatus) // Update participant status (invitees answer too)
+	events.GET("/:id/ws", view, controllers.EventWebSocket)                          // Realtime updates over WebSocket
+	events.GET("/:id/summary", view, controllers.GetDiscussionSummary)               // AI summary of the caller's unread messages
+
+	// Date polls are proposed through the agent
+	events.GET("/:id/polls", view, controllers.GetEventDatePolls)                        // Date polls with their votes
+	events.POST("/:id/polls/:poll_id/votes", participate, controllers.VoteEventDatePoll) // Replace the caller's votes in a poll
+
+	// The giftee profile feeds gift generation; surprise events hide it from guests
+	events.GET("/:id/giftee-profile", view, controllers.GetGifteeProfile)         // Giftee profile, organizers only in surprise mode
+	events.PUT("/:id/giftee-profile", manage, controllers.SaveGifteeProfile)      // Create or replace the giftee profile
+	events.DELETE("/:id/giftee-profile", manage, controllers.DeleteGifteeProfile) // Remove the giftee profile
 }
@@ -5,6 +5,7 @@ import (
 
 	"be-geoffray/api/controllers"
 	"be-geoffray/api/middlewares"
+	"be-geoffray/services"
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,8 +20,8 @@ func SetupGiftRoutes(router *gin.Engine, db *sql.DB) {
 		// Get all active gift categories
 		giftRoutes.GET("/categories", giftController.GetCategories)
 
-		// Get gift suggestions for a category
-		giftRoutes.GET("/suggestions", giftController.GetSuggestions)
+		// Get all active occasion types, with their names in every language
+		giftRoutes.GET("/occasions", giftController.GetOccasions)
 	}
 
 	// Protected routes (require authentication)
@@ -29,6 +30,9 @@ func SetupGiftRoutes(router *gin.Engine, db *sql.DB) {
 	{
 		// Track user's category selection
 		protectedGiftRoutes.POST("/track-selection", giftController.TrackSelection)
+
+		// Get gift suggestions of an event (event_id query parameter, checked by the handler)
+		protectedGiftRoutes.GET("/suggestions", giftController.GetSuggestions)
 	}
 
 	// Protected event-with-gifts routes
@@ -38,30 +42,32 @@ func SetupGiftRoutes(router *gin.Engine, db *sql.DB) {
 		// Create event with gift suggestions
 		protectedEventGiftRoutes.POST("/with-gifts", giftEventController.CreateEventWithGifts)
 
-		// Get gift suggestions for a specific event
-		protectedEventGiftRoutes.GET("/:id/gift-suggestions", giftEventController.GetEventGiftSuggestions)
+		// Get gift suggestions for a specific event, translated on demand with ?lang=
+		protectedEventGiftRoutes.GET("/:id/gift-suggestions", middlewares.RequireEventAccess(services.EventActionView), giftEventController.GetEventGiftSuggestions)
 
 		// Regenerate gift suggestions for an event
-		protectedEventGiftRoutes.POST("/:id/regenerate-gift-suggestions", giftEventController.RegenerateEventGiftSuggestions)
+		protectedEventGiftRoutes.POST("/:id/regenerate-gift-suggestions", middlewares.RequireEventAccess(services.EventActionManage), giftEventController.RegenerateEventGiftSuggestions)
 	}
 
 	// Protected gift suggestion voting routes
 	protectedVoteRoutes := router.Group("/api/gift-suggestions")
 	protectedVoteRoutes.Use(middlewares.JWTAuthMiddleware())
 	{
-		// Create a new gift suggestion (manual or AI-generated)
+		participate := middlewares.RequireSuggestionAccess(services.EventActionParticipate)
+
+		// Create a new gift suggestion (manual or AI-generated, event_id checked by the handler)
 		protectedVoteRoutes.POST("", giftEventController.CreateGiftSuggestion)
 
 		// Vote on a gift suggestion (POST to create/update vote)
-		protectedVoteRoutes.POST("/:id/vote", giftEventController.VoteOnSuggestion)
+		protectedVoteRoutes.POST("/:id/vote", participate, giftEventController.VoteOnSuggestion)
 
 		// Remove vote from a gift suggestion
-		protectedVoteRoutes.DELETE("/:id/vote", giftEventController.RemoveVote)
+		protectedVoteRoutes.DELETE("/:id/vote", participate, giftEventController.RemoveVote)
 
 		// Update a gift suggestion (only owner can update)
-		protectedVoteRoutes.PUT("/:id", giftEventController.UpdateGiftSuggestion)
+		protectedVoteRoutes.PUT("/:id", participate, giftEventController.UpdateGiftSuggestion)
 
 		// Delete a gift suggestion (only owner can delete)
-		protectedVoteRoutes.DELETE("/:id", giftEventController.DeleteGiftSuggestion)
+		protectedVoteRoutes.DELETE("/:id", participate, giftEventController.DeleteGiftSuggestion)
 	}
 }
@@ -2,7 +2,6 @@ package routes
 
 import (
 	"be-geoffray/api/controllers"
-	"be-geoffray/api/middlewares"
 	"github.com/gin-gonic/gin"
 )
 
@@ -10,12 +9,7 @@ import (
 func RegisterLocalizationRoutes(router *gin.RouterGroup) {
 	controller := controllers.NewLocalizationController()
 
-	// Apply language middleware to all routes in this group
-	router.Use(middlewares.LanguageMiddleware())
-
 	// Public endpoint to get translations
+	// Imports and edits are admin routes, see RegisterAdminRoutes
 	router.GET("/translations", controller.GetTranslations)
-
-	// Admin endpoint to import translations (should be protected in production)
-	router.POST("/translations/import", controller.ImportTranslations)
 }
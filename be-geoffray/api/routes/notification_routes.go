@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"be-geoffray/api/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterNotificationRoutes registers all notification-related routes
+func RegisterNotificationRoutes(r *gin.RouterGroup) {
+	notifications := r.Group("/notifications")
+
+	notifications.GET("", controllers.GetNotifications)                     // List notifications (?unread=true)
+	notifications.POST("/read-all", controllers.MarkAllNotificationsAsRead) // Mark all notifications as read
+	notifications.POST("/:id/read", controllers.MarkNotificationAsRead)     // Mark a notification as read
+}
@@ -10,4 +10,5 @@ func RegisterUserRoutes(r *gin.RouterGroup) {
 	// User routes
 	r.GET("/profile", controllers.GetUserProfile)
 	r.PUT("/profile", controllers.UpdateUserProfile)
+	r.GET("/recommendations", controllers.GetRecommendations) // Suggested for you, from the user's gift history
 }
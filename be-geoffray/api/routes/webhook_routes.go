@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"be-geoffray/api/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterWebhookRoutes registers all webhook-related routes
+func RegisterWebhookRoutes(r *gin.RouterGroup) {
+	webhooks := r.Group("/webhooks")
+
+	webhooks.POST("", controllers.CreateWebhook)                                            // Register a webhook
+	webhooks.GET("", controllers.GetWebhooks)                                               // List the user's webhooks
+	webhooks.GET("/:id", controllers.GetWebhook)                                            // Get a webhook
+	webhooks.PUT("/:id", controllers.UpdateWebhook)                                         // Update a webhook
+	webhooks.DELETE("/:id", controllers.DeleteWebhook)                                      // Delete a webhook
+	webhooks.GET("/:id/deliveries", controllers.GetWebhookDeliveries)                       // Delivery log
+	webhooks.POST("/:id/deliveries/:delivery_id/replay", controllers.ReplayWebhookDelivery) // Replay a delivery
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"time"
 
 	"be-geoffray/api/controllers"
 	"be-geoffray/api/middlewares"
@@ -9,6 +10,7 @@ import (
 	"be-geoffray/config"
 	"be-geoffray/db"
 	"be-geoffray/localization"
+	"be-geoffray/prompts"
 	"be-geoffray/services"
 	"github.com/gin-gonic/gin"
 )
@@ -33,6 +35,24 @@ func main() {
 		log.Printf("Warning: Failed to sync participant counts on startup: %v", err)
 	}
 
+	// Deliver event lifecycle notifications to registered webhooks
+	webhookService := services.NewWebhookService()
+	services.RegisterLifecycleListener(webhookService.HandleLifecycleEvent)
+	webhookService.StartDeliveryWorker(10 * time.Second)
+
+	// Remove uploaded attachments never linked to a message
+	services.NewAttachmentService().StartCleanupWorker(time.Hour)
+
+	// Push event lifecycle changes to WebSocket clients
+	if err := services.InitRealtimeService(config.GetConfig().RealtimeBroker); err != nil {
+		log.Printf("Warning: Failed to start realtime updates: %v", err)
+	}
+
+	// Load the prompt templates early, so that invalid ones are reported on startup
+	if _, err := prompts.Default(); err != nil {
+		log.Printf("Warning: Failed to load prompt templates: %v", err)
+	}
+
 	// Initialize Gin router (Reads GIN_MODE env var)
 	router := gin.Default()
 
@@ -46,6 +66,9 @@ func main() {
 		log.Println("Skipping Gin CORS middleware in release mode (handled by Ingress)")
 	}
 
+	// Detect the language of every request, in which errors are answered
+	router.Use(middlewares.LanguageMiddleware())
+
 	// Import translations on startup
 	localizationService := localization.NewService()
 	err := localizationService.ImportTranslationsFromJSON("./localization/translations")
@@ -89,6 +112,10 @@ func main() {
 		routes.RegisterUserRoutes(protected)          // Only these routes need authentication
 		routes.RegisterEventRoutes(protected)         // Protected event routes
 		routes.RegisterEventMessagesRoutes(protected) // Protected event messages routes
+		routes.RegisterWebhookRoutes(protected)       // Protected webhook routes
+		routes.RegisterChatRoutes(protected)          // Protected agent chat routes
+		routes.RegisterNotificationRoutes(protected)  // Protected notification routes
+		routes.RegisterAdminRoutes(protected)         // Admin routes (ADMIN_USER_IDS only)
 	}
 
 	// Start server
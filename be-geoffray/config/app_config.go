@@ -3,11 +3,15 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 )
 
 // AppConfig holds all application configuration
 type AppConfig struct {
+	// Environment is "development", "staging" or "production"
+	Environment string
 	FrontendURL string
 	DBHost      string
 	DBPort      string
@@ -15,6 +19,44 @@ type AppConfig struct {
 	DBPassword  string
 	DBName      string
 	JWTSecret   string
+	// RealtimeBroker selects the pub/sub backend for WebSocket updates: "memory" or "postgres"
+	RealtimeBroker string
+	// StorageLocalPath is the directory where uploaded files are stored
+	StorageLocalPath string
+	// MaxAttachmentSize is the maximum size of an uploaded attachment, in bytes
+	MaxAttachmentSize int64
+	// AgentContextMaxTokens is the approximate token budget of the prompt sent to the event agent
+	AgentContextMaxTokens int
+	// LLMProvider selects the language model backend: "mistral", "openai" (any OpenAI-compatible server) or "fake"
+	LLMProvider string
+	// LLMBaseURL is the root of the provider API, ending with a slash
+	LLMBaseURL string
+	// LLMAPIKey authenticates against the provider, it may be empty for local servers
+	LLMAPIKey string
+	// LLMModel is the model used for chat completions
+	LLMModel string
+	// LLMPurposeModels overrides LLMModel for some purposes of the calls, e.g. {"similarity": "mistral-small-latest"}
+	LLMPurposeModels map[string]string
+	// LLMAgentID is the Mistral agent answering the event chat instead of LLMModel when set; other purposes use the models
+	LLMAgentID string
+	// LLMFakeResponses is a JSON file of scripted replies for the fake provider
+	LLMFakeResponses string
+	// AIQuotaUserDailyTokens, AIQuotaUserMonthlyTokens, AIQuotaEventDailyTokens and AIQuotaEventMonthlyTokens
+	// cap the language model tokens a user or an event may consume, 0 means unlimited
+	AIQuotaUserDailyTokens    int
+	AIQuotaUserMonthlyTokens  int
+	AIQuotaEventDailyTokens   int
+	AIQuotaEventMonthlyTokens int
+	// GiftSimilarityDuplicateThreshold is the similarity score from which a gift suggestion is a duplicate
+	GiftSimilarityDuplicateThreshold float64
+	// GiftSimilarityDistinctThreshold is the similarity score below which a gift suggestion is distinct
+	GiftSimilarityDistinctThreshold float64
+	// GiftSimilarityLLMTieBreak asks the language model about scores between the two thresholds
+	GiftSimilarityLLMTieBreak bool
+	// PromptTemplatesDir is the directory of the versioned prompt templates, empty for the built-in ones
+	PromptTemplatesDir string
+	// AdminUserIDs are the users allowed to call the admin endpoints
+	AdminUserIDs []string
 	// Add other config values as needed
 }
 
@@ -30,13 +72,36 @@ func GetConfig() *AppConfig {
 		LoadEnv()
 
 		instance = &AppConfig{
-			FrontendURL: getEnvWithDefault("FRONTEND_URL", "https://localhost:8081"),
-			DBHost:      getEnvWithDefault("DB_HOST", "localhost"),
-			DBPort:      getEnvWithDefault("DB_PORT", "5432"),
-			DBUser:      getEnvWithDefault("DB_USER", "postgres"),
-			DBPassword:  getEnvWithDefault("DB_PASSWORD", ""),
-			DBName:      getEnvWithDefault("DB_NAME", "geoffray_db"),
-			JWTSecret:   getEnvWithDefault("JWT_SECRET", "your_secure_jwt_secret_for_geoffray_app"),
+			Environment:           getEnvWithDefault("ENV", "development"),
+			FrontendURL:           getEnvWithDefault("FRONTEND_URL", "https://localhost:8081"),
+			DBHost:                getEnvWithDefault("DB_HOST", "localhost"),
+			DBPort:                getEnvWithDefault("DB_PORT", "5432"),
+			DBUser:                getEnvWithDefault("DB_USER", "postgres"),
+			DBPassword:            getEnvWithDefault("DB_PASSWORD", ""),
+			DBName:                getEnvWithDefault("DB_NAME", "geoffray_db"),
+			JWTSecret:             getEnvWithDefault("JWT_SECRET", "your_secure_jwt_secret_for_geoffray_app"),
+			RealtimeBroker:        getEnvWithDefault("REALTIME_BROKER", "memory"),
+			StorageLocalPath:      getEnvWithDefault("STORAGE_LOCAL_PATH", "./uploads"),
+			MaxAttachmentSize:     int64(getEnvIntWithDefault("ATTACHMENT_MAX_SIZE_MB", 10)) << 20,
+			AgentContextMaxTokens: getEnvIntWithDefault("AGENT_CONTEXT_MAX_TOKENS", 8000),
+			LLMProvider:           getEnvWithDefault("LLM_PROVIDER", "mistral"),
+			// The Mistral variables remain the defaults so that existing deployments keep working
+			LLMBaseURL:       getEnvWithDefault("LLM_BASE_URL", getEnvWithDefault("MISTRAL_API_URL", "https://api.mistral.ai/")),
+			LLMAPIKey:        getEnvWithDefault("LLM_API_KEY", os.Getenv("MISTRAL_API_KEY")),
+			LLMModel:         getEnvWithDefault("LLM_MODEL", "mistral-large-latest"),
+			LLMPurposeModels: getEnvMap("LLM_PURPOSE_MODELS"),
+			LLMAgentID:       getEnvWithDefault("LLM_AGENT_ID", os.Getenv("MISTRAL_AGENT_ID")),
+			LLMFakeResponses: os.Getenv("LLM_FAKE_RESPONSES"),
+			// Quotas are disabled unless configured
+			AIQuotaUserDailyTokens:           getEnvIntWithDefault("AI_QUOTA_USER_DAILY_TOKENS", 0),
+			AIQuotaUserMonthlyTokens:         getEnvIntWithDefault("AI_QUOTA_USER_MONTHLY_TOKENS", 0),
+			AIQuotaEventDailyTokens:          getEnvIntWithDefault("AI_QUOTA_EVENT_DAILY_TOKENS", 0),
+			AIQuotaEventMonthlyTokens:        getEnvIntWithDefault("AI_QUOTA_EVENT_MONTHLY_TOKENS", 0),
+			AdminUserIDs:                     getEnvListWithDefault("ADMIN_USER_IDS", nil),
+			GiftSimilarityDuplicateThreshold: getEnvFloatWithDefault("GIFT_SIMILARITY_DUPLICATE_THRESHOLD", 0.6),
+			GiftSimilarityDistinctThreshold:  getEnvFloatWithDefault("GIFT_SIMILARITY_DISTINCT_THRESHOLD", 0.35),
+			GiftSimilarityLLMTieBreak:        getEnvBoolWithDefault("GIFT_SIMILARITY_LLM_TIEBREAK", false),
+			PromptTemplatesDir:               getEnvWithDefault("PROMPT_TEMPLATES_DIR", ""),
 			// Initialize other config values here
 		}
 		log.Println("Configuration loaded successfully")
@@ -44,6 +109,60 @@ func GetConfig() *AppConfig {
 	return instance
 }
 
+// IsDevelopment reports whether the application runs on a developer machine
+func (c *AppConfig) IsDevelopment() bool {
+	return c.Environment == "development"
+}
+
+// ModelForPurpose returns the model answering the language model calls of a purpose
+func (c *AppConfig) ModelForPurpose(purpose string) string {
+	if model := c.LLMPurposeModels[purpose]; model != "" {
+		return model
+	}
+	return c.LLMModel
+}
+
+// getEnvIntWithDefault returns the integer value of the environment variable or a default value if not set or invalid
+func getEnvIntWithDefault(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvFloatWithDefault returns the decimal value of the environment variable or a default value if not set or invalid
+func getEnvFloatWithDefault(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvBoolWithDefault returns the boolean value of the environment variable or a default value if not set or invalid
+func getEnvBoolWithDefault(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvListWithDefault returns the comma-separated values of the environment variable or a default value if not set
+func getEnvListWithDefault(key string, defaultValue []string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
+
 // getEnvWithDefault returns the value of the environment variable or a default value if not set
 func getEnvWithDefault(key, defaultValue string) string {
 	value := os.Getenv(key)
@@ -52,3 +171,15 @@ func getEnvWithDefault(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getEnvMap returns the comma-separated key=value pairs of the environment variable, entries without a value are skipped
+func getEnvMap(key string) map[string]string {
+	values := make(map[string]string)
+	for _, entry := range getEnvListWithDefault(key, nil) {
+		name, value, ok := strings.Cut(entry, "=")
+		if name, value = strings.TrimSpace(name), strings.TrimSpace(value); ok && name != "" && value != "" {
+			values[name] = value
+		}
+	}
+	return values
+}
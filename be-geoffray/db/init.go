@@ -15,6 +15,14 @@ import (
 
 var DB *sql.DB
 
+// connString holds the connection string used by InitDB, for components that need their own connection
+var connString string
+
+// ConnectionString returns the connection string used to open DB
+func ConnectionString() string {
+	return connString
+}
+
 // InitDB initializes the database connection using the application config
 func InitDB() {
 	var err error
@@ -52,6 +60,7 @@ func InitDB() {
 			}
 		}
 
+		connString = databaseURL
 		DB, err = sql.Open("postgres", databaseURL)
 		if err != nil {
 			log.Printf("Error opening database connection with DATABASE_URL: %v\n", err)
@@ -71,6 +80,7 @@ func InitDB() {
 			appConfig.DBPort,
 			sslMode,
 		)
+		connString = dsn
 		DB, err = sql.Open("postgres", dsn)
 		if err != nil {
 			log.Printf("Error opening database connection with parameters: %v\n", err)
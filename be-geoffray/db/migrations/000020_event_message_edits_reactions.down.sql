@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_event_message_reactions_message_id;
+DROP TABLE IF EXISTS event_message_reactions;
+
+DROP INDEX IF EXISTS idx_event_message_edits_message_id;
+DROP TABLE IF EXISTS event_message_edits;
+
+ALTER TABLE event_messages DROP CONSTRAINT IF EXISTS event_messages_parent_id_fkey;
+ALTER TABLE event_messages
+ADD CONSTRAINT event_messages_parent_id_fkey
+FOREIGN KEY (parent_id) REFERENCES event_messages(id) ON DELETE CASCADE;
+
+ALTER TABLE event_messages
+DROP COLUMN IF EXISTS edited_at,
+DROP COLUMN IF EXISTS deleted_at;
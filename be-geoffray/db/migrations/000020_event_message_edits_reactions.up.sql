@@ -0,0 +1,34 @@
+-- Support editing, deleting and reacting to event messages
+
+-- Edited marker and tombstone for deleted messages
+ALTER TABLE event_messages
+ADD COLUMN edited_at TIMESTAMP WITH TIME ZONE,
+ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Deleted messages are tombstoned, but make sure replies never cascade with their parent
+ALTER TABLE event_messages DROP CONSTRAINT IF EXISTS event_messages_parent_id_fkey;
+ALTER TABLE event_messages
+ADD CONSTRAINT event_messages_parent_id_fkey
+FOREIGN KEY (parent_id) REFERENCES event_messages(id) ON DELETE SET NULL;
+
+-- Previous versions of edited messages
+CREATE TABLE event_message_edits (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    message_id UUID NOT NULL REFERENCES event_messages(id) ON DELETE CASCADE,
+    previous_content TEXT NOT NULL,
+    edited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    edited_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_event_message_edits_message_id ON event_message_edits(message_id, edited_at);
+
+-- Emoji reactions, one per user and emoji
+CREATE TABLE event_message_reactions (
+    message_id UUID NOT NULL REFERENCES event_messages(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    emoji VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (message_id, user_id, emoji)
+);
+
+CREATE INDEX idx_event_message_reactions_message_id ON event_message_reactions(message_id);
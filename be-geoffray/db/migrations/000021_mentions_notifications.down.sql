@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_notifications_unread;
+DROP INDEX IF EXISTS idx_notifications_user_id;
+DROP TABLE IF EXISTS notifications;
+
+DROP INDEX IF EXISTS idx_event_message_mentions_user_id;
+DROP TABLE IF EXISTS event_message_mentions;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_event_messages_event_created_at;
+DROP TABLE IF EXISTS event_read_markers;
@@ -0,0 +1,12 @@
+-- Last message read by each participant in an event discussion
+CREATE TABLE event_read_markers (
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_read_message_id UUID REFERENCES event_messages(id) ON DELETE SET NULL,
+    last_read_at TIMESTAMP WITH TIME ZONE NOT NULL, -- created_at of the last read message
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (event_id, user_id)
+);
+
+-- Unread counts scan the messages of an event by creation date
+CREATE INDEX idx_event_messages_event_created_at ON event_messages(event_id, created_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_message_attachments_unlinked;
+DROP INDEX IF EXISTS idx_message_attachments_event_id;
+DROP INDEX IF EXISTS idx_message_attachments_message_id;
+DROP TABLE IF EXISTS message_attachments;
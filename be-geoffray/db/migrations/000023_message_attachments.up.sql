@@ -0,0 +1,21 @@
+-- Files attached to event messages
+-- Attachments are uploaded first (message_id is NULL) and linked when the message is posted
+CREATE TABLE message_attachments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    message_id UUID REFERENCES event_messages(id) ON DELETE SET NULL,
+    uploader_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    storage_key VARCHAR(255) NOT NULL,
+    thumbnail_key VARCHAR(255),
+    width INTEGER,
+    height INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_message_attachments_message_id ON message_attachments(message_id);
+CREATE INDEX idx_message_attachments_event_id ON message_attachments(event_id);
+-- Used to clean up uploads that were never attached to a message
+CREATE INDEX idx_message_attachments_unlinked ON message_attachments(created_at) WHERE message_id IS NULL;
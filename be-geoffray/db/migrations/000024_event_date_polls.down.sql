@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS event_date_poll_votes;
+DROP TABLE IF EXISTS event_date_poll_options;
+DROP INDEX IF EXISTS idx_event_date_polls_event_id;
+DROP TABLE IF EXISTS event_date_polls;
@@ -0,0 +1,25 @@
+-- Date polls, proposed by participants or by the agent, to pick when an event happens
+CREATE TABLE event_date_polls (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    question TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_event_date_polls_event_id ON event_date_polls(event_id, created_at DESC);
+
+CREATE TABLE event_date_poll_options (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    poll_id UUID NOT NULL REFERENCES event_date_polls(id) ON DELETE CASCADE,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE (poll_id, starts_at)
+);
+
+-- Participants pick every option that works for them
+CREATE TABLE event_date_poll_votes (
+    option_id UUID NOT NULL REFERENCES event_date_poll_options(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (option_id, user_id)
+);
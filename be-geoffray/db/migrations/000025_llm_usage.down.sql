@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_llm_usage_created_at;
+DROP INDEX IF EXISTS idx_llm_usage_event;
+DROP INDEX IF EXISTS idx_llm_usage_user;
+DROP TABLE IF EXISTS llm_usage;
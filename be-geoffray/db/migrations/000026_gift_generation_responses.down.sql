@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_gift_generation_responses_event;
+DROP TABLE IF EXISTS gift_generation_responses;
@@ -0,0 +1,14 @@
+-- Raw language model replies to gift generation requests, kept for debugging the parsing and validation
+CREATE TABLE IF NOT EXISTS gift_generation_responses (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_id UUID REFERENCES events(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    repair INT NOT NULL DEFAULT 0, -- 0 for the first reply, then the number of the repair round
+    raw_response TEXT NOT NULL,
+    valid_count INT NOT NULL DEFAULT 0,
+    invalid_count INT NOT NULL DEFAULT 0,
+    validation_errors TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_generation_responses_event ON gift_generation_responses(event_id, created_at DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_gift_suggestions_prompt_version;
+ALTER TABLE gift_generation_responses DROP COLUMN IF EXISTS prompt_version;
+ALTER TABLE gift_suggestions DROP COLUMN IF EXISTS prompt_version;
@@ -0,0 +1,5 @@
+-- Version of the prompt template that generated each AI suggestion, to compare votes per version
+ALTER TABLE gift_suggestions ADD COLUMN IF NOT EXISTS prompt_version VARCHAR(50);
+ALTER TABLE gift_generation_responses ADD COLUMN IF NOT EXISTS prompt_version VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_gift_suggestions_prompt_version ON gift_suggestions(prompt_version) WHERE prompt_version IS NOT NULL;
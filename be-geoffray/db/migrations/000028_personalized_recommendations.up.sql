@@ -0,0 +1,2 @@
+-- Users may opt out of the recommendations learnt from their gift history
+ALTER TABLE users ADD COLUMN IF NOT EXISTS personalized_recommendations BOOLEAN NOT NULL DEFAULT true;
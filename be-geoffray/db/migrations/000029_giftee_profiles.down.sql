@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS event_giftee_profiles;
+ALTER TABLE events DROP COLUMN IF EXISTS surprise_mode;
@@ -0,0 +1,16 @@
+-- In surprise mode only the organizers see the giftee profile
+ALTER TABLE events ADD COLUMN IF NOT EXISTS surprise_mode BOOLEAN NOT NULL DEFAULT false;
+
+-- What the organizers know about the person receiving the gifts, fed to gift generation
+CREATE TABLE IF NOT EXISTS event_giftee_profiles (
+    event_id UUID PRIMARY KEY REFERENCES events(id) ON DELETE CASCADE,
+    age_range VARCHAR(20),
+    relationship VARCHAR(50),
+    interests TEXT[] NOT NULL DEFAULT '{}',
+    dislikes TEXT[] NOT NULL DEFAULT '{}',
+    owned_items TEXT[] NOT NULL DEFAULT '{}',
+    clothing_sizes JSONB NOT NULL DEFAULT '{}',
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,14 @@
+-- "Catch me up" summaries of event discussions, cached per range of summarized messages
+CREATE TABLE IF NOT EXISTS event_discussion_summaries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    range_key VARCHAR(64) NOT NULL, -- Hash of the summarized messages and their edits, the language and the audience
+    from_message_id UUID,
+    to_message_id UUID,
+    message_count INT NOT NULL,
+    language VARCHAR(10) NOT NULL,
+    prompt_version VARCHAR(50),
+    summary JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (event_id, range_key)
+);
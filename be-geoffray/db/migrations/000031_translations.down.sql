@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS occasion_type_translations;
+DROP TABLE IF EXISTS static_gift_translations;
+DROP TABLE IF EXISTS gift_suggestion_translations;
+ALTER TABLE gift_suggestions DROP COLUMN IF EXISTS language;
+ALTER TABLE events DROP COLUMN IF EXISTS language;
@@ -0,0 +1,52 @@
+-- Primary language of events: gift suggestions are generated in it, then translated on demand
+-- Existing events keep French, the language suggestions were generated in so far
+ALTER TABLE events ADD COLUMN IF NOT EXISTS language VARCHAR(10) NOT NULL DEFAULT 'fr';
+
+-- Language a suggestion was written or generated in; NULL for the bilingual suggestions written before translations
+ALTER TABLE gift_suggestions ADD COLUMN IF NOT EXISTS language VARCHAR(10);
+
+-- Names and descriptions of gift suggestions, one row per language
+-- name_en/name_fr/description_en/description_fr are kept in sync for older clients
+CREATE TABLE IF NOT EXISTS gift_suggestion_translations (
+    suggestion_id UUID NOT NULL REFERENCES gift_suggestions(id) ON DELETE CASCADE,
+    language VARCHAR(10) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    source VARCHAR(20) NOT NULL DEFAULT 'original', -- 'original' as written or generated, 'machine' when translated on demand
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (suggestion_id, language)
+);
+
+CREATE TABLE IF NOT EXISTS static_gift_translations (
+    static_gift_id UUID NOT NULL REFERENCES static_gifts(id) ON DELETE CASCADE,
+    language VARCHAR(10) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    PRIMARY KEY (static_gift_id, language)
+);
+
+CREATE TABLE IF NOT EXISTS occasion_type_translations (
+    occasion_type_id UUID NOT NULL REFERENCES occasion_types(id) ON DELETE CASCADE,
+    language VARCHAR(10) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    PRIMARY KEY (occasion_type_id, language)
+);
+
+INSERT INTO gift_suggestion_translations (suggestion_id, language, name, description)
+SELECT id, 'en', name_en, description_en FROM gift_suggestions WHERE name_en <> ''
+UNION ALL
+SELECT id, 'fr', name_fr, description_fr FROM gift_suggestions WHERE name_fr <> ''
+ON CONFLICT DO NOTHING;
+
+INSERT INTO static_gift_translations (static_gift_id, language, name, description)
+SELECT id, 'en', name_en, description_en FROM static_gifts WHERE name_en <> ''
+UNION ALL
+SELECT id, 'fr', name_fr, description_fr FROM static_gifts WHERE name_fr <> ''
+ON CONFLICT DO NOTHING;
+
+INSERT INTO occasion_type_translations (occasion_type_id, language, name)
+SELECT id, 'en', name_en FROM occasion_types WHERE name_en <> ''
+UNION ALL
+SELECT id, 'fr', name_fr FROM occasion_types WHERE name_fr <> ''
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS translation_catalog;
+ALTER TABLE translations DROP COLUMN IF EXISTS source;
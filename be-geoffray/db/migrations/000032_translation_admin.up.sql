@@ -0,0 +1,12 @@
+-- Where each message comes from: 'file' when imported from localization/translations, 'admin' when edited through the API
+-- Imports of the files leave the messages edited by administrators untouched
+ALTER TABLE translations ADD COLUMN IF NOT EXISTS source VARCHAR(10) NOT NULL DEFAULT 'file';
+
+-- Version of the whole catalog, bumped on every change so that clients can tell when to fetch translations again
+CREATE TABLE IF NOT EXISTS translation_catalog (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id), -- Single row
+    version BIGINT NOT NULL DEFAULT 1,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO translation_catalog (id) VALUES (TRUE) ON CONFLICT DO NOTHING;
@@ -0,0 +1,18 @@
+-- Votes on the suggestions deleted by a regeneration, so that what a group liked and rejected
+-- keeps steering the following rounds
+CREATE TABLE IF NOT EXISTS gift_vote_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    suggestion_id UUID NOT NULL, -- The deleted suggestion, kept to tell the archived gifts apart
+    name_en VARCHAR(255) NOT NULL,
+    name_fr VARCHAR(255) NOT NULL,
+    description_en TEXT,
+    description_fr TEXT,
+    price_range VARCHAR(50),
+    category VARCHAR(100),
+    upvotes INT NOT NULL DEFAULT 0,
+    downvotes INT NOT NULL DEFAULT 0,
+    archived_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_vote_history_event ON gift_vote_history(event_id, archived_at);
@@ -0,0 +1,283 @@
+package localization
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	"be-geoffray/models"
+)
+
+// Sources of the messages stored in the database
+const (
+	TranslationSourceFile  = "file"  // Imported from the JSON files shipped with the backend
+	TranslationSourceAdmin = "admin" // Written by an administrator, kept over the files on import
+)
+
+var (
+	// ErrTranslationNotFound is returned when a message does not exist in a language
+	ErrTranslationNotFound = errors.New("translation not found")
+	// ErrInvalidLanguageCode is returned for language codes other than "fr" or "pt-BR"
+	ErrInvalidLanguageCode = errors.New("invalid language code")
+	// ErrInvalidTranslationKey is returned for empty keys, keys with spaces or longer than the column
+	ErrInvalidTranslationKey = errors.New("invalid translation key")
+)
+
+var (
+	languageCodePattern   = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,4})?$`)
+	translationKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.\-]{1,255}$`)
+)
+
+// cachedCatalog is the translations of a language as of a catalog version
+type cachedCatalog struct {
+	version      int64
+	translations models.TranslationMap
+}
+
+// catalogCache holds the translations by language, dropped as soon as the catalog version changes
+var catalogCache sync.Map
+
+// CatalogVersion returns the version of the catalog, bumped on every change of a message
+func (s *Service) CatalogVersion() (int64, error) {
+	var version int64
+	if err := s.db.QueryRow("SELECT version FROM translation_catalog").Scan(&version); err != nil {
+		return 0, fmt.Errorf("error querying translation catalog version: %w", err)
+	}
+	return version, nil
+}
+
+// ListTranslations returns the messages of a language with their source, sorted by key
+func (s *Service) ListTranslations(languageCode string) ([]models.Translation, error) {
+	rows, err := s.db.Query(`
+		SELECT id, language_code, key, value, source
+		FROM translations
+		WHERE language_code = $1
+		ORDER BY key
+	`, languageCode)
+	if err != nil {
+		return nil, fmt.Errorf("error querying translations: %w", err)
+	}
+	defer rows.Close()
+
+	translations := []models.Translation{}
+	for rows.Next() {
+		var translation models.Translation
+		if err := rows.Scan(&translation.ID, &translation.LanguageCode, &translation.Key, &translation.Value, &translation.Source); err != nil {
+			return nil, fmt.Errorf("error scanning translation row: %w", err)
+		}
+		translations = append(translations, translation)
+	}
+	return translations, rows.Err()
+}
+
+// GetTranslation returns a single message
+func (s *Service) GetTranslation(languageCode, key string) (*models.Translation, error) {
+	var translation models.Translation
+	err := s.db.QueryRow(`
+		SELECT id, language_code, key, value, source
+		FROM translations
+		WHERE language_code = $1 AND key = $2
+	`, languageCode, key).Scan(&translation.ID, &translation.LanguageCode, &translation.Key, &translation.Value, &translation.Source)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s.%s", ErrTranslationNotFound, languageCode, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying translation: %w", err)
+	}
+	return &translation, nil
+}
+
+// DeleteTranslation removes a message and bumps the catalog version
+// Keys shipped in the JSON files come back on the next import of the files
+func (s *Service) DeleteTranslation(languageCode, key string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting translation transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM translations WHERE language_code = $1 AND key = $2", languageCode, key)
+	if err != nil {
+		return fmt.Errorf("error deleting translation: %w", err)
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return fmt.Errorf("%w: %s.%s", ErrTranslationNotFound, languageCode, key)
+	}
+	if err := bumpCatalogVersion(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ImportTranslations saves the messages of a language as an administrator, e.g. from a translator's file
+// Nothing is saved when a message is invalid; it returns the number of messages that changed
+func (s *Service) ImportTranslations(languageCode string, translations models.TranslationMap) (int64, error) {
+	if err := s.validateImport(languageCode, translations); err != nil {
+		return 0, err
+	}
+	return s.saveTranslations(languageCode, translations, TranslationSourceAdmin)
+}
+
+// validateImport checks the language code, the keys and the messages of a language against the default language
+func (s *Service) validateImport(languageCode string, translations models.TranslationMap) error {
+	if !languageCodePattern.MatchString(languageCode) {
+		return fmt.Errorf("%w: %q", ErrInvalidLanguageCode, languageCode)
+	}
+	for key := range translations {
+		if !translationKeyPattern.MatchString(key) {
+			return fmt.Errorf("%w: %q", ErrInvalidTranslationKey, key)
+		}
+	}
+
+	catalogs := map[string]models.TranslationMap{languageCode: translations}
+	if languageCode != DefaultLanguage {
+		defaults, err := s.GetTranslations(DefaultLanguage)
+		if err != nil {
+			return err
+		}
+		catalogs[DefaultLanguage] = defaults.Translations
+	}
+
+	var errs []error
+	for _, problem := range ValidateTranslations(catalogs) {
+		if problem.LanguageCode == languageCode {
+			errs = append(errs, problem)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// saveTranslations upserts the messages of a language in one transaction, bumping the catalog version when any changed
+// Messages imported from the files never replace the ones written by administrators
+func (s *Service) saveTranslations(languageCode string, translations models.TranslationMap, source string) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting translation transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var changed int64
+	for key, value := range translations {
+		result, err := tx.Exec(`
+			INSERT INTO translations (language_code, key, value, source)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (language_code, key)
+			DO UPDATE SET value = EXCLUDED.value, source = EXCLUDED.source, updated_at = CURRENT_TIMESTAMP
+			WHERE translations.value <> EXCLUDED.value AND (EXCLUDED.source = $5 OR translations.source = $6)
+		`, languageCode, key, value, source, TranslationSourceAdmin, TranslationSourceFile)
+		if err != nil {
+			return 0, fmt.Errorf("error saving translation %s.%s: %w", languageCode, key, err)
+		}
+		rows, _ := result.RowsAffected()
+		changed += rows
+	}
+
+	if changed > 0 {
+		if err := bumpCatalogVersion(tx); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing translations: %w", err)
+	}
+	return changed, nil
+}
+
+// bumpCatalogVersion increments the catalog version within a transaction
+func bumpCatalogVersion(tx *sql.Tx) error {
+	_, err := tx.Exec("UPDATE translation_catalog SET version = version + 1, updated_at = CURRENT_TIMESTAMP")
+	if err != nil {
+		return fmt.Errorf("error bumping translation catalog version: %w", err)
+	}
+	return nil
+}
+
+// GetTranslationReport compares the stored messages of every language with the default language
+func (s *Service) GetTranslationReport() (*models.TranslationReport, error) {
+	version, err := s.CatalogVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query("SELECT language_code, key, value FROM translations")
+	if err != nil {
+		return nil, fmt.Errorf("error querying translations: %w", err)
+	}
+	defer rows.Close()
+
+	catalogs := map[string]models.TranslationMap{DefaultLanguage: {}}
+	for rows.Next() {
+		var languageCode, key, value string
+		if err := rows.Scan(&languageCode, &key, &value); err != nil {
+			return nil, fmt.Errorf("error scanning translation row: %w", err)
+		}
+		if catalogs[languageCode] == nil {
+			catalogs[languageCode] = models.TranslationMap{}
+		}
+		catalogs[languageCode][key] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating translation rows: %w", err)
+	}
+
+	return &models.TranslationReport{
+		DefaultLanguage: DefaultLanguage,
+		Version:         version,
+		Languages:       BuildTranslationReport(catalogs),
+	}, nil
+}
+
+// BuildTranslationReport lists, for each language sorted by code, the keys of the default language it misses,
+// the keys it has that the default language does not, and its invalid messages
+func BuildTranslationReport(catalogs map[string]models.TranslationMap) []models.LanguageReport {
+	invalid := map[string][]models.TranslationIssue{}
+	for _, problem := range ValidateTranslations(catalogs) {
+		invalid[problem.LanguageCode] = append(invalid[problem.LanguageCode], models.TranslationIssue{
+			Key:   problem.Key,
+			Error: problem.Err.Error(),
+		})
+	}
+
+	defaults := catalogs[DefaultLanguage]
+	languages := make([]string, 0, len(catalogs))
+	for languageCode := range catalogs {
+		languages = append(languages, languageCode)
+	}
+	sort.Strings(languages)
+
+	reports := make([]models.LanguageReport, 0, len(languages))
+	for _, languageCode := range languages {
+		translations := catalogs[languageCode]
+		report := models.LanguageReport{
+			LanguageCode: languageCode,
+			KeyCount:     len(translations),
+			Coverage:     1,
+			Missing:      []string{},
+			Extra:        []string{},
+			Invalid:      []models.TranslationIssue{},
+		}
+		for key := range defaults {
+			if _, ok := translations[key]; !ok {
+				report.Missing = append(report.Missing, key)
+			}
+		}
+		for key := range translations {
+			if _, ok := defaults[key]; !ok {
+				report.Extra = append(report.Extra, key)
+			}
+		}
+		sort.Strings(report.Missing)
+		sort.Strings(report.Extra)
+		if len(defaults) > 0 {
+			report.Coverage = float64(len(defaults)-len(report.Missing)) / float64(len(defaults))
+		}
+		if issues, ok := invalid[languageCode]; ok {
+			report.Invalid = issues
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
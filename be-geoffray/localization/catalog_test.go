@@ -0,0 +1,120 @@
+package localization
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"be-geoffray/models"
+)
+
+func TestBuildTranslationReport(t *testing.T) {
+	reports := BuildTranslationReport(map[string]models.TranslationMap{
+		"fr": {
+			"common.welcome": "Bienvenue",
+			"common.legacy":  "Ancien",
+			"event.guests":   "{total, plural, one {# invité} other {# invités}}",
+		},
+		"en": {
+			"common.welcome":  "Welcome",
+			"common.continue": "Continue",
+			"event.guests":    "{count, plural, one {# guest} other {# guests}}",
+			"event.title":     "Event",
+		},
+	})
+
+	if len(reports) != 2 || reports[0].LanguageCode != "en" || reports[1].LanguageCode != "fr" {
+		t.Fatalf("expected the en and fr reports sorted by code, got %+v", reports)
+	}
+
+	en := reports[0]
+	if en.KeyCount != 4 || en.Coverage != 1 || len(en.Missing) != 0 || len(en.Extra) != 0 || len(en.Invalid) != 0 {
+		t.Errorf("expected a complete default language, got %+v", en)
+	}
+
+	fr := reports[1]
+	if !reflect.DeepEqual(fr.Missing, []string{"common.continue", "event.title"}) {
+		t.Errorf("Missing = %v", fr.Missing)
+	}
+	if !reflect.DeepEqual(fr.Extra, []string{"common.legacy"}) {
+		t.Errorf("Extra = %v", fr.Extra)
+	}
+	if fr.Coverage != 0.5 {
+		t.Errorf("Coverage = %v, expected 0.5", fr.Coverage)
+	}
+	if len(fr.Invalid) != 1 || fr.Invalid[0].Key != "event.guests" {
+		t.Errorf("expected the unknown argument of event.guests to be reported, got %+v", fr.Invalid)
+	}
+}
+
+func TestXLIFFRoundTrip(t *testing.T) {
+	defaults := models.TranslationMap{
+		"common.welcome": "Welcome to <Geoffray> & co",
+		"common.guests":  "{count, plural, one {# guest} other {# guests}}",
+	}
+	translations := models.TranslationMap{"common.welcome": "Bienvenue sur <Geoffray> & cie"}
+
+	data, err := EncodeXLIFF("fr", defaults, translations)
+	if err != nil {
+		t.Fatalf("EncodeXLIFF returned an error: %v", err)
+	}
+	document := string(data)
+	for _, expected := range []string{`version="1.2"`, `source-language="en"`, `target-language="fr"`, `<trans-unit id="common.guests">`, "&lt;Geoffray&gt; &amp; cie"} {
+		if !strings.Contains(document, expected) {
+			t.Errorf("expected the document to contain %q:\n%s", expected, document)
+		}
+	}
+
+	// A translator fills in the missing target
+	document = strings.Replace(document, "<source>{count, plural, one {# guest} other {# guests}}</source>",
+		"<source>{count, plural, one {# guest} other {# guests}}</source><target>{count, plural, one {# invité} other {# invités}}</target>", 1)
+
+	languageCode, decoded, err := DecodeXLIFF([]byte(document))
+	if err != nil {
+		t.Fatalf("DecodeXLIFF returned an error: %v", err)
+	}
+	expected := models.TranslationMap{
+		"common.welcome": "Bienvenue sur <Geoffray> & cie",
+		"common.guests":  "{count, plural, one {# invité} other {# invités}}",
+	}
+	if languageCode != "fr" || !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("DecodeXLIFF = %q, %v, expected fr, %v", languageCode, decoded, expected)
+	}
+}
+
+func TestDecodeXLIFFErrors(t *testing.T) {
+	documents := []string{
+		"not xml",
+		`<xliff version="2.0"><file><body></body></file></xliff>`,
+		`<xliff version="1.2"><file><body><trans-unit><source>a</source><target>b</target></trans-unit></body></file></xliff>`,
+	}
+	for _, document := range documents {
+		if _, _, err := DecodeXLIFF([]byte(document)); !errors.Is(err, ErrInvalidXLIFF) {
+			t.Errorf("DecodeXLIFF(%q) error = %v, expected ErrInvalidXLIFF", document, err)
+		}
+	}
+}
+
+func TestImportTranslationsValidation(t *testing.T) {
+	// Invalid imports are rejected before reaching the database
+	service := &Service{}
+	tests := []struct {
+		name         string
+		languageCode string
+		translations models.TranslationMap
+		expected     error
+	}{
+		{"invalid language code", "French", models.TranslationMap{"common.welcome": "Bienvenue"}, ErrInvalidLanguageCode},
+		{"key with spaces", DefaultLanguage, models.TranslationMap{"common welcome": "Welcome"}, ErrInvalidTranslationKey},
+		{"invalid message", DefaultLanguage, models.TranslationMap{"event.guests": "{count, plural, one {# guest}}"}, ErrInvalidMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.ImportTranslations(tt.languageCode, tt.translations); !errors.Is(err, tt.expected) {
+				t.Errorf("ImportTranslations error = %v, expected %v", err, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package localization
+
+import (
+	"sort"
+	"strings"
+
+	"be-geoffray/models"
+)
+
+// i18nextDateStyles are the ICU date and time styles, which Intl.DateTimeFormat names the same way
+var i18nextDateStyles = map[string]bool{"short": true, "medium": true, "long": true, "full": true}
+
+// ClientTranslations converts ICU messages to the i18next format the frontend reads
+//   - {name} becomes {{name}}, numbers and dates use the number and datetime formatters of i18next
+//   - a plural on count becomes one key per category (key_one, key_other, key_zero for =0), # becoming {{count}}
+//   - a selectordinal on count becomes key_ordinal_one, key_ordinal_two...
+//
+// Messages i18next cannot express (select, plurals on another argument, offsets, exact values
+// other than =0, several plurals) are only formatted on the server and left out
+func ClientTranslations(locale string, translations models.TranslationMap) models.TranslationMap {
+	client := make(models.TranslationMap, len(translations))
+	for key, pattern := range translations {
+		message, err := ParseMessage(locale, pattern)
+		if err != nil {
+			continue
+		}
+		converted, ok := message.i18next(key)
+		if !ok {
+			continue
+		}
+		for clientKey, value := range converted {
+			client[clientKey] = value
+		}
+	}
+	return client
+}
+
+// i18next converts the message to i18next keys and values, false when it has no i18next equivalent
+func (m *Message) i18next(key string) (map[string]string, bool) {
+	pluralAt := -1
+	for i, node := range m.nodes {
+		switch node.(type) {
+		case pluralNode:
+			if pluralAt >= 0 {
+				return nil, false
+			}
+			pluralAt = i
+		case selectNode:
+			return nil, false
+		}
+	}
+
+	if pluralAt < 0 {
+		var out strings.Builder
+		if !writeI18nextNodes(&out, m.nodes) {
+			return nil, false
+		}
+		return map[string]string{key: out.String()}, true
+	}
+
+	// i18next picks plural forms from the count option, so the argument must be named count
+	plural := m.nodes[pluralAt].(pluralNode)
+	if plural.name != "count" || plural.offset != 0 {
+		return nil, false
+	}
+
+	selectors := make([]string, 0, len(plural.branches))
+	for selector := range plural.branches {
+		selectors = append(selectors, selector)
+	}
+	sort.Strings(selectors)
+
+	converted := make(map[string]string, len(plural.branches))
+	for _, selector := range selectors {
+		suffix := selector
+		if strings.HasPrefix(selector, "=") {
+			// i18next only knows the exact value 0, through the zero suffix
+			if selector != "=0" || plural.ordinal {
+				return nil, false
+			}
+			suffix = "zero"
+		}
+		if plural.ordinal {
+			suffix = "ordinal_" + suffix
+		}
+
+		var out strings.Builder
+		if !writeI18nextNodes(&out, m.nodes[:pluralAt]) ||
+			!writeI18nextNodes(&out, plural.branches[selector]) ||
+			!writeI18nextNodes(&out, m.nodes[pluralAt+1:]) {
+			return nil, false
+		}
+		converted[key+"_"+suffix] = out.String()
+	}
+	return converted, true
+}
+
+// writeI18nextNodes writes text and arguments with the i18next interpolation syntax, false on nested plurals and selects
+func writeI18nextNodes(out *strings.Builder, nodes []messageNode) bool {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case textNode:
+			out.WriteString(string(n))
+		case poundNode:
+			out.WriteString("{{count}}")
+		case argumentNode:
+			out.WriteString("{{" + n.name + i18nextFormat(n) + "}}")
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// i18nextFormat returns the i18next formatter of an argument, such as ", number(style: percent)"
+func i18nextFormat(node argumentNode) string {
+	switch node.kind {
+	case "number":
+		switch node.style {
+		case "integer":
+			return ", number(maximumFractionDigits: 0)"
+		case "percent":
+			return ", number(style: percent)"
+		}
+		return ", number"
+	case "date", "time":
+		// CLDR patterns such as "d MMMM" have no Intl equivalent, the default format of the locale is used instead
+		if i18nextDateStyles[node.style] {
+			return ", datetime(" + node.kind + "Style: " + node.style + ")"
+		}
+		return ", datetime"
+	}
+	return ""
+}
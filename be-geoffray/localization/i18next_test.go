@@ -0,0 +1,79 @@
+package localization
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"be-geoffray/models"
+)
+
+func TestClientTranslations(t *testing.T) {
+	translations := models.TranslationMap{
+		"common.welcome":             "Welcome to Geoffray",
+		"event.locationConfirmation": "I've noted the location as {location}.",
+		"event.participantsCount":    "{count, plural, =0 {No participants yet} one {# participant} other {# participants}}",
+		"notification.unreadCount":   "You have {count, plural, one {# unread notification} other {# unread notifications}}.",
+		"event.rank":                 "{count, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}",
+		"event.startsOn":             "Starts on {date, date, full} at {date, time, short}",
+		"event.birthday":             "Born on {date, date, d MMMM}",
+		"event.budget":               "{amount, number} euros, {share, number, percent} each",
+		"event.quoted":               "Write '{'name'}' to mention someone",
+		"event.host":                 "{gender, select, female {She} male {He} other {They}} invited you",
+		"event.guests":               "{guests, plural, one {# guest} other {# guests}}",
+		"event.others":               "{count, plural, offset:1 one {You and # other} other {You and # others}}",
+		"event.pair":                 "{count, plural, =2 {A pair} other {# people}}",
+		"event.invalid":              "{count, plural, one {#}",
+	}
+
+	expected := models.TranslationMap{
+		"common.welcome":                 "Welcome to Geoffray",
+		"event.locationConfirmation":     "I've noted the location as {{location}}.",
+		"event.participantsCount_zero":   "No participants yet",
+		"event.participantsCount_one":    "{{count}} participant",
+		"event.participantsCount_other":  "{{count}} participants",
+		"notification.unreadCount_one":   "You have {{count}} unread notification.",
+		"notification.unreadCount_other": "You have {{count}} unread notifications.",
+		"event.rank_ordinal_one":         "{{count}}st",
+		"event.rank_ordinal_two":         "{{count}}nd",
+		"event.rank_ordinal_few":         "{{count}}rd",
+		"event.rank_ordinal_other":       "{{count}}th",
+		"event.startsOn":                 "Starts on {{date, datetime(dateStyle: full)}} at {{date, datetime(timeStyle: short)}}",
+		"event.birthday":                 "Born on {{date, datetime}}",
+		"event.budget":                   "{{amount, number}} euros, {{share, number(style: percent)}} each",
+		"event.quoted":                   "Write {name} to mention someone",
+	}
+
+	if got := ClientTranslations("en", translations); !reflect.DeepEqual(got, expected) {
+		t.Errorf("ClientTranslations() = %v, want %v", got, expected)
+	}
+}
+
+// The frontend uses i18next without the ICU plugin: no message it receives may keep single braces
+func TestClientTranslationsOfTheShippedFiles(t *testing.T) {
+	interpolation := regexp.MustCompile(`\{\{[^{}]+\}\}`)
+	for _, languageCode := range []string{"en", "fr"} {
+		data, err := os.ReadFile(filepath.Join("translations", languageCode+".json"))
+		if err != nil {
+			t.Fatalf("failed to read the %s translations: %v", languageCode, err)
+		}
+		translations := models.TranslationMap{}
+		if err := json.Unmarshal(data, &translations); err != nil {
+			t.Fatalf("failed to parse the %s translations: %v", languageCode, err)
+		}
+
+		client := ClientTranslations(languageCode, translations)
+		for key, value := range client {
+			if strings.ContainsAny(interpolation.ReplaceAllString(value, ""), "{}") {
+				t.Errorf("%s %s still uses the ICU syntax: %q", languageCode, key, value)
+			}
+		}
+		if _, ok := client["event.participantsCount_one"]; !ok {
+			t.Errorf("%s event.participantsCount has no plural forms for the client", languageCode)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package localization
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// localeFormat holds the number and date conventions of a locale, from CLDR
+type localeFormat struct {
+	decimal      string
+	group        string
+	percent      string // Pattern of percentages, %s being the number
+	datePatterns map[string]string
+	timePatterns map[string]string
+	months       [12]string
+	shortMonths  [12]string
+	weekdays     [7]string // From Sunday
+	am, pm       string
+	cardinal     func(i int64, v int, n float64) string // Plural category from the integer digits i and the number v of decimals
+	ordinal      func(i int64, v int, n float64) string
+}
+
+// localeFormats are the locales with their own conventions; the others use English ones
+var localeFormats = map[string]*localeFormat{
+	"en": {
+		decimal: ".",
+		group:   ",",
+		percent: "%s%%",
+		datePatterns: map[string]string{
+			"short":  "M/d/yy",
+			"medium": "MMM d, y",
+			"long":   "MMMM d, y",
+			"full":   "EEEE, MMMM d, y",
+		},
+		timePatterns: map[string]string{
+			"short":  "h:mm a",
+			"medium": "h:mm:ss a",
+			"long":   "h:mm:ss a z",
+			"full":   "h:mm:ss a z",
+		},
+		months:      [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		shortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		weekdays:    [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		am:          "AM",
+		pm:          "PM",
+		cardinal: func(i int64, v int, n float64) string {
+			if i == 1 && v == 0 {
+				return "one"
+			}
+			return "other"
+		},
+		ordinal: func(i int64, v int, n float64) string {
+			if v != 0 {
+				return "other"
+			}
+			switch {
+			case i%10 == 1 && i%100 != 11:
+				return "one"
+			case i%10 == 2 && i%100 != 12:
+				return "two"
+			case i%10 == 3 && i%100 != 13:
+				return "few"
+			}
+			return "other"
+		},
+	},
+	"fr": {
+		decimal: ",",
+		group:   "\u202f", // Narrow no-break space
+		percent: "%s\u202f%%",
+		datePatterns: map[string]string{
+			"short":  "dd/MM/y",
+			"medium": "d MMM y",
+			"long":   "d MMMM y",
+			"full":   "EEEE d MMMM y",
+		},
+		timePatterns: map[string]string{
+			"short":  "HH:mm",
+			"medium": "HH:mm:ss",
+			"long":   "HH:mm:ss z",
+			"full":   "HH:mm:ss z",
+		},
+		months:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		shortMonths: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		weekdays:    [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		am:          "AM",
+		pm:          "PM",
+		cardinal: func(i int64, v int, n float64) string {
+			switch {
+			case i == 0 || i == 1:
+				return "one"
+			case i != 0 && i%1000000 == 0 && v == 0:
+				return "many"
+			}
+			return "other"
+		},
+		ordinal: func(i int64, v int, n float64) string {
+			if n == 1 {
+				return "one"
+			}
+			return "other"
+		},
+	},
+}
+
+// pluralCategories are the categories a plural branch may be named after
+var pluralCategories = []string{"zero", "one", "two", "few", "many", "other"}
+
+// isPluralCategory reports whether key is a CLDR plural category
+func isPluralCategory(key string) bool {
+	for _, category := range pluralCategories {
+		if key == category {
+			return true
+		}
+	}
+	return false
+}
+
+// formatFor returns the conventions of a locale given as "fr" or "fr-CA", English ones when unknown
+func formatFor(locale string) *localeFormat {
+	language := strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(language, "-_"); i != -1 {
+		language = language[:i]
+	}
+	if format, ok := localeFormats[language]; ok {
+		return format
+	}
+	return localeFormats[DefaultLanguage]
+}
+
+// PluralCategory returns the CLDR plural category of a number in a locale: "one", "few", "other"...
+// ordinal selects the rules of ranks ("1st", "2nd") instead of quantities
+func PluralCategory(locale string, n float64, ordinal bool) string {
+	format := formatFor(locale)
+	plain := formatPlainNumber(math.Abs(n))
+	integer, fraction, _ := strings.Cut(plain, ".")
+	i, err := strconv.ParseInt(integer, 10, 64)
+	if err != nil {
+		return "other"
+	}
+	if ordinal {
+		return format.ordinal(i, len(fraction), math.Abs(n))
+	}
+	return format.cardinal(i, len(fraction), math.Abs(n))
+}
+
+// FormatNumber formats a number with the separators of a locale and up to 3 decimals, as ICU does by default
+func FormatNumber(locale string, n float64) string {
+	return formatDecimal(formatFor(locale), n, 3)
+}
+
+// FormatInteger formats a number rounded to an integer with the separators of a locale
+func FormatInteger(locale string, n float64) string {
+	return formatDecimal(formatFor(locale), n, 0)
+}
+
+// FormatPercent formats a ratio as a percentage, 0.25 being "25%" in English and "25 %" in French
+func FormatPercent(locale string, ratio float64) string {
+	format := formatFor(locale)
+	return fmt.Sprintf(format.percent, formatDecimal(format, ratio*100, 0))
+}
+
+// FormatDate formats a date in a locale with the short, medium (default), long or full style,
+// or a pattern of CLDR letters such as "EEEE d MMMM"
+func FormatDate(locale string, t time.Time, style string) string {
+	format := formatFor(locale)
+	pattern, ok := format.datePatterns[style]
+	if !ok {
+		pattern = style
+	}
+	if pattern == "" {
+		pattern = format.datePatterns["medium"]
+	}
+	return formatDatePattern(format, t, pattern)
+}
+
+// FormatTime formats a time of day in a locale with the short (default), medium, long or full style,
+// or a pattern of CLDR letters
+func FormatTime(locale string, t time.Time, style string) string {
+	format := formatFor(locale)
+	pattern, ok := format.timePatterns[style]
+	if !ok {
+		pattern = style
+	}
+	if pattern == "" {
+		pattern = format.timePatterns["short"]
+	}
+	return formatDatePattern(format, t, pattern)
+}
+
+// formatDecimal formats a number with at most maxFraction decimals, trailing zeros removed
+func formatDecimal(format *localeFormat, n float64, maxFraction int) string {
+	plain := strconv.FormatFloat(math.Abs(n), 'f', maxFraction, 64)
+	integer, fraction, _ := strings.Cut(plain, ".")
+	fraction = strings.TrimRight(fraction, "0")
+
+	var out strings.Builder
+	if n < 0 && strings.Trim(plain, "0.") != "" {
+		out.WriteString("-")
+	}
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			out.WriteString(format.group)
+		}
+		out.WriteRune(digit)
+	}
+	if fraction != "" {
+		out.WriteString(format.decimal)
+		out.WriteString(fraction)
+	}
+	return out.String()
+}
+
+// formatPlainNumber formats a number without separators, as in the "=1" branches of plurals
+func formatPlainNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// parsePlainNumber parses a number written without separators
+func parsePlainNumber(text string) (float64, bool) {
+	n, err := strconv.ParseFloat(text, 64)
+	return n, err == nil && !math.IsInf(n, 0) && !math.IsNaN(n)
+}
+
+// formatDatePattern formats a time with a pattern of CLDR letters:
+// y, yy, M, MM, MMM, MMMM, d, dd, E (EEEE for the full name), H, HH, h, hh, m, mm, s, ss, a and z;
+// other characters are copied, and text between apostrophes is copied as is
+func formatDatePattern(format *localeFormat, t time.Time, pattern string) string {
+	var out strings.Builder
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if r == '\'' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '\'' {
+				end++
+			}
+			if end == i+1 {
+				out.WriteRune('\'')
+			} else {
+				out.WriteString(string(runes[i+1 : end]))
+			}
+			i = end + 1
+			continue
+		}
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			out.WriteRune(r)
+			i++
+			continue
+		}
+
+		count := 1
+		for i+count < len(runes) && runes[i+count] == r {
+			count++
+		}
+		i += count
+
+		switch r {
+		case 'y':
+			if count == 2 {
+				out.WriteString(fmt.Sprintf("%02d", t.Year()%100))
+			} else {
+				out.WriteString(strconv.Itoa(t.Year()))
+			}
+		case 'M', 'L':
+			switch {
+			case count >= 4:
+				out.WriteString(format.months[t.Month()-1])
+			case count == 3:
+				out.WriteString(format.shortMonths[t.Month()-1])
+			default:
+				out.WriteString(padNumber(int(t.Month()), count))
+			}
+		case 'd':
+			out.WriteString(padNumber(t.Day(), count))
+		case 'E':
+			weekday := format.weekdays[t.Weekday()]
+			if count < 4 {
+				weekday = string([]rune(weekday)[:3])
+			}
+			out.WriteString(weekday)
+		case 'H':
+			out.WriteString(padNumber(t.Hour(), count))
+		case 'h':
+			hour := t.Hour() % 12
+			if hour == 0 {
+				hour = 12
+			}
+			out.WriteString(padNumber(hour, count))
+		case 'm':
+			out.WriteString(padNumber(t.Minute(), count))
+		case 's':
+			out.WriteString(padNumber(t.Second(), count))
+		case 'a':
+			if t.Hour() < 12 {
+				out.WriteString(format.am)
+			} else {
+				out.WriteString(format.pm)
+			}
+		case 'z':
+			zone, _ := t.Zone()
+			out.WriteString(zone)
+		default:
+			out.WriteString(strings.Repeat(string(r), count))
+		}
+	}
+	return out.String()
+}
+
+// padNumber writes a number with at least width digits
+func padNumber(n, width int) string {
+	return fmt.Sprintf("%0*d", width, n)
+}
@@ -0,0 +1,495 @@
+package localization
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrInvalidMessage is wrapped by the errors of messages that are not valid ICU MessageFormat
+var ErrInvalidMessage = errors.New("invalid message")
+
+// maxMessageDepth bounds the nesting of plural and select arguments
+const maxMessageDepth = 8
+
+// Message is a parsed ICU MessageFormat pattern of a locale, safe for concurrent use
+//
+// Supported syntax:
+//   - {name} inserts an argument, numbers and dates formatted for the locale
+//   - {name, number} with the integer or percent styles
+//   - {name, date} and {name, time} with the short, medium, long or full styles, or a pattern such as "d MMM"
+//   - {name, plural, =0 {...} one {...} other {...}}, with an optional offset:n and # for the number
+//   - {name, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}
+//   - {name, select, female {...} other {...}}
+//   - ” for an apostrophe and '{...}' to quote special characters
+type Message struct {
+	locale string
+	nodes  []messageNode
+}
+
+// messageNode is a piece of a message: text, an argument or the number of the enclosing plural
+type messageNode interface{}
+
+// textNode is literal text
+type textNode string
+
+// poundNode is the # of a plural branch, replaced by its number
+type poundNode struct{}
+
+// argumentNode is a simple, number, date or time argument
+type argumentNode struct {
+	name  string
+	kind  string // "", "number", "date" or "time"
+	style string
+}
+
+// pluralNode chooses a branch from the plural category of a number, or its exact value
+type pluralNode struct {
+	name     string
+	ordinal  bool
+	offset   float64
+	branches map[string][]messageNode // "=2", "one", "other"...
+}
+
+// selectNode chooses a branch from the value of an argument
+type selectNode struct {
+	name     string
+	branches map[string][]messageNode
+}
+
+// ParseMessage parses the pattern of a message of the locale
+func ParseMessage(locale, pattern string) (*Message, error) {
+	p := &messageParser{input: []rune(pattern)}
+	nodes, err := p.parseNodes(false, 0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected '}'")
+	}
+	return &Message{locale: locale, nodes: nodes}, nil
+}
+
+// FormatMessage parses and formats a pattern in one go
+func FormatMessage(locale, pattern string, args map[string]any) (string, error) {
+	message, err := ParseMessage(locale, pattern)
+	if err != nil {
+		return "", err
+	}
+	return message.Format(args)
+}
+
+// Format renders the message with named arguments
+func (m *Message) Format(args map[string]any) (string, error) {
+	var out strings.Builder
+	if err := m.formatNodes(&out, m.nodes, args, nil); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// Arguments returns the names of the arguments used by the message, sorted
+func (m *Message) Arguments() []string {
+	seen := map[string]bool{}
+	var collect func(nodes []messageNode)
+	collect = func(nodes []messageNode) {
+		for _, node := range nodes {
+			switch n := node.(type) {
+			case argumentNode:
+				seen[n.name] = true
+			case pluralNode:
+				seen[n.name] = true
+				for _, branch := range n.branches {
+					collect(branch)
+				}
+			case selectNode:
+				seen[n.name] = true
+				for _, branch := range n.branches {
+					collect(branch)
+				}
+			}
+		}
+	}
+	collect(m.nodes)
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatNodes renders nodes; pound is the number of the enclosing plural, nil outside plurals
+func (m *Message) formatNodes(out *strings.Builder, nodes []messageNode, args map[string]any, pound *float64) error {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case textNode:
+			out.WriteString(string(n))
+		case poundNode:
+			out.WriteString(FormatNumber(m.locale, *pound))
+		case argumentNode:
+			value, ok := args[n.name]
+			if !ok {
+				return fmt.Errorf("missing argument %q", n.name)
+			}
+			text, err := formatArgument(m.locale, n, value)
+			if err != nil {
+				return err
+			}
+			out.WriteString(text)
+		case pluralNode:
+			value, ok := args[n.name]
+			if !ok {
+				return fmt.Errorf("missing argument %q", n.name)
+			}
+			number, ok := toFloat(value)
+			if !ok {
+				return fmt.Errorf("argument %q is not a number", n.name)
+			}
+			branch, found := n.branches["="+formatPlainNumber(number)]
+			if !found {
+				category := PluralCategory(m.locale, number-n.offset, n.ordinal)
+				if branch, found = n.branches[category]; !found {
+					branch = n.branches["other"]
+				}
+			}
+			offset := number - n.offset
+			if err := m.formatNodes(out, branch, args, &offset); err != nil {
+				return err
+			}
+		case selectNode:
+			value, ok := args[n.name]
+			if !ok {
+				return fmt.Errorf("missing argument %q", n.name)
+			}
+			branch, found := n.branches[fmt.Sprint(value)]
+			if !found {
+				branch = n.branches["other"]
+			}
+			if err := m.formatNodes(out, branch, args, pound); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// formatArgument renders a simple, number, date or time argument
+func formatArgument(locale string, node argumentNode, value any) (string, error) {
+	switch node.kind {
+	case "number":
+		number, ok := toFloat(value)
+		if !ok {
+			return "", fmt.Errorf("argument %q is not a number", node.name)
+		}
+		switch node.style {
+		case "integer":
+			return FormatInteger(locale, number), nil
+		case "percent":
+			return FormatPercent(locale, number), nil
+		}
+		return FormatNumber(locale, number), nil
+	case "date", "time":
+		t, ok := toTime(value)
+		if !ok {
+			return "", fmt.Errorf("argument %q is not a time", node.name)
+		}
+		if node.kind == "time" {
+			return FormatTime(locale, t, node.style), nil
+		}
+		return FormatDate(locale, t, node.style), nil
+	}
+
+	if number, ok := toFloat(value); ok {
+		return FormatNumber(locale, number), nil
+	}
+	if t, ok := toTime(value); ok {
+		return FormatDate(locale, t, "medium"), nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// toFloat converts the numeric arguments
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// toTime converts the time arguments
+func toTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v != nil {
+			return *v, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// messageParser reads a pattern rune by rune
+type messageParser struct {
+	input []rune
+	pos   int
+}
+
+// errorf returns a syntax error at the current position
+func (p *messageParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w at offset %d: %s", ErrInvalidMessage, p.pos, fmt.Sprintf(format, args...))
+}
+
+// parseNodes reads text and arguments up to the '}' closing a branch, or the end of the pattern
+func (p *messageParser) parseNodes(inPlural bool, depth int) ([]messageNode, error) {
+	var nodes []messageNode
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, textNode(text.String()))
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.input) {
+		r := p.input[p.pos]
+		switch {
+		case r == '\'':
+			p.parseApostrophe(&text, inPlural)
+		case r == '{':
+			flush()
+			node, err := p.parseArgument(inPlural, depth)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, node)
+		case r == '}':
+			flush()
+			return nodes, nil
+		case r == '#' && inPlural:
+			flush()
+			nodes = append(nodes, poundNode{})
+			p.pos++
+		default:
+			text.WriteRune(r)
+			p.pos++
+		}
+	}
+	flush()
+	return nodes, nil
+}
+
+// parseApostrophe reads ” as an apostrophe and '...' as quoted text when it starts with a special character;
+// other apostrophes, as in "don't", are kept as they are
+func (p *messageParser) parseApostrophe(text *strings.Builder, inPlural bool) {
+	p.pos++
+	if p.pos < len(p.input) && p.input[p.pos] == '\'' {
+		text.WriteRune('\'')
+		p.pos++
+		return
+	}
+	if p.pos >= len(p.input) || !(p.input[p.pos] == '{' || p.input[p.pos] == '}' || (inPlural && p.input[p.pos] == '#')) {
+		text.WriteRune('\'')
+		return
+	}
+
+	// Quoted text runs up to the next single apostrophe, or the end of the pattern
+	for p.pos < len(p.input) {
+		r := p.input[p.pos]
+		p.pos++
+		if r != '\'' {
+			text.WriteRune(r)
+			continue
+		}
+		if p.pos < len(p.input) && p.input[p.pos] == '\'' {
+			text.WriteRune('\'')
+			p.pos++
+			continue
+		}
+		return
+	}
+}
+
+// parseArgument reads an argument, from its '{' to its '}'
+func (p *messageParser) parseArgument(inPlural bool, depth int) (messageNode, error) {
+	if depth >= maxMessageDepth {
+		return nil, p.errorf("arguments are nested too deeply")
+	}
+	p.pos++ // '{'
+	p.skipSpaces()
+	name := p.readWord()
+	if name == "" {
+		return nil, p.errorf("argument name expected")
+	}
+	p.skipSpaces()
+
+	if p.consume('}') {
+		return argumentNode{name: name}, nil
+	}
+	if !p.consume(',') {
+		return nil, p.errorf("',' or '}' expected after argument %q", name)
+	}
+	p.skipSpaces()
+	kind := p.readWord()
+	p.skipSpaces()
+
+	switch kind {
+	case "number", "date", "time":
+		node := argumentNode{name: name, kind: kind}
+		if p.consume(',') {
+			node.style = strings.TrimSpace(p.readUntil('}'))
+			if node.style == "" {
+				return nil, p.errorf("style expected for argument %q", name)
+			}
+		}
+		if !p.consume('}') {
+			return nil, p.errorf("'}' expected after argument %q", name)
+		}
+		if kind == "number" && node.style != "" && node.style != "integer" && node.style != "percent" {
+			return nil, p.errorf("unknown number style %q", node.style)
+		}
+		return node, nil
+	case "plural", "selectordinal":
+		if !p.consume(',') {
+			return nil, p.errorf("',' expected after %s", kind)
+		}
+		node := pluralNode{name: name, ordinal: kind == "selectordinal"}
+		p.skipSpaces()
+		if strings.HasPrefix(string(p.input[p.pos:]), "offset:") {
+			p.pos += len("offset:")
+			p.skipSpaces()
+			offset, ok := parsePlainNumber(p.readWord())
+			if !ok {
+				return nil, p.errorf("number expected after offset:")
+			}
+			node.offset = offset
+		}
+		branches, err := p.parseBranches(name, true, inPlural, depth)
+		if err != nil {
+			return nil, err
+		}
+		node.branches = branches
+		return node, nil
+	case "select":
+		if !p.consume(',') {
+			return nil, p.errorf("',' expected after select")
+		}
+		branches, err := p.parseBranches(name, false, inPlural, depth)
+		if err != nil {
+			return nil, err
+		}
+		return selectNode{name: name, branches: branches}, nil
+	}
+	return nil, p.errorf("unknown argument type %q", kind)
+}
+
+// parseBranches reads the "key {message}" branches of a plural or select up to its closing '}'
+// The # of branches refers to the closest enclosing plural, so it is kept in selects nested in plurals
+func (p *messageParser) parseBranches(name string, plural, inPlural bool, depth int) (map[string][]messageNode, error) {
+	branches := map[string][]messageNode{}
+	for {
+		p.skipSpaces()
+		if p.consume('}') {
+			break
+		}
+		key := p.readWord()
+		if key == "" {
+			return nil, p.errorf("branch name expected in argument %q", name)
+		}
+		if strings.HasPrefix(key, "=") {
+			value, ok := parsePlainNumber(key[1:])
+			if !plural || !ok {
+				return nil, p.errorf("invalid branch %q in argument %q", key, name)
+			}
+			key = "=" + formatPlainNumber(value)
+		} else if plural && !isPluralCategory(key) {
+			return nil, p.errorf("unknown plural category %q in argument %q", key, name)
+		}
+		if _, ok := branches[key]; ok {
+			return nil, p.errorf("branch %q repeated in argument %q", key, name)
+		}
+
+		p.skipSpaces()
+		if !p.consume('{') {
+			return nil, p.errorf("'{' expected after branch %q", key)
+		}
+		nodes, err := p.parseNodes(plural || inPlural, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume('}') {
+			return nil, p.errorf("branch %q of argument %q is not closed", key, name)
+		}
+		branches[key] = nodes
+	}
+	if _, ok := branches["other"]; !ok {
+		return nil, p.errorf("argument %q has no 'other' branch", name)
+	}
+	return branches, nil
+}
+
+// skipSpaces moves past white space
+func (p *messageParser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// consume moves past r if it is the next rune
+func (p *messageParser) consume(r rune) bool {
+	if p.pos < len(p.input) && p.input[p.pos] == r {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// readWord reads an argument name, a keyword or a branch key
+func (p *messageParser) readWord() string {
+	start := p.pos
+	for p.pos < len(p.input) {
+		r := p.input[p.pos]
+		if unicode.IsSpace(r) || strings.ContainsRune("{},#'", r) {
+			break
+		}
+		p.pos++
+	}
+	return string(p.input[start:p.pos])
+}
+
+// readUntil reads up to the given rune, excluded
+func (p *messageParser) readUntil(end rune) string {
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] != end {
+		p.pos++
+	}
+	return string(p.input[start:p.pos])
+}
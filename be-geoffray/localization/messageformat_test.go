@@ -0,0 +1,150 @@
+package localization
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"be-geoffray/models"
+)
+
+func TestFormatMessage(t *testing.T) {
+	date := time.Date(2026, time.March, 5, 14, 7, 0, 0, time.UTC)
+	participants := "{count, plural, =0 {No participants} one {# participant} other {# participants}}"
+
+	tests := []struct {
+		name     string
+		locale   string
+		pattern  string
+		args     map[string]any
+		expected string
+	}{
+		{"plain text", "en", "Welcome to Geoffray", nil, "Welcome to Geoffray"},
+		{"named argument", "en", "Hello {name}!", map[string]any{"name": "Alice"}, "Hello Alice!"},
+		{"exact plural branch", "en", participants, map[string]any{"count": 0}, "No participants"},
+		{"english one", "en", participants, map[string]any{"count": 1}, "1 participant"},
+		{"english other", "en", participants, map[string]any{"count": 1500}, "1,500 participants"},
+		{"french zero is one", "fr", "{count, plural, one {# invité} other {# invités}}", map[string]any{"count": 0}, "0 invité"},
+		{"french decimals", "fr", "{count, plural, one {# kilo} other {# kilos}}", map[string]any{"count": 1.5}, "1,5 kilo"},
+		{"french many", "fr", "{count, plural, one {# euro} many {# d'euros} other {# euros}}", map[string]any{"count": 2000000}, "2 000 000 d'euros"},
+		{"offset", "en", "{count, plural, offset:1 =0 {Nobody} =1 {{host}} one {{host} and # other} other {{host} and # others}}", map[string]any{"count": 3, "host": "Bob"}, "Bob and 2 others"},
+		{"selectordinal", "en", "{rank, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}", map[string]any{"rank": 22}, "22nd"},
+		{"french selectordinal", "fr", "{rank, selectordinal, one {#er} other {#e}}", map[string]any{"rank": 1}, "1er"},
+		{"select", "fr", "{gender, select, female {Elle} male {Il} other {Iel}} a voté", map[string]any{"gender": "female"}, "Elle a voté"},
+		{"select other", "en", "{gender, select, female {She} other {They}}", map[string]any{"gender": "unknown"}, "They"},
+		{"pound in nested select", "en", "{count, plural, other {{kind, select, gift {# gifts} other {# items}}}}", map[string]any{"count": 4, "kind": "gift"}, "4 gifts"},
+		{"apostrophes", "en", "It''s '{quoted}' and I'm {name}", map[string]any{"name": "Eve"}, "It's {quoted} and I'm Eve"},
+		{"quoted pound", "en", "{count, plural, other {'#' # left}}", map[string]any{"count": 2}, "# 2 left"},
+		{"number", "fr", "Budget : {amount, number} €", map[string]any{"amount": 1234.5}, "Budget : 1 234,5 €"},
+		{"integer", "en", "{amount, number, integer}", map[string]any{"amount": 1234.56}, "1,235"},
+		{"percent", "fr", "{ratio, number, percent}", map[string]any{"ratio": 0.25}, "25 %"},
+		{"english date", "en", "{date, date, long} at {date, time, short}", map[string]any{"date": date}, "March 5, 2026 at 2:07 PM"},
+		{"french date", "fr", "le {date, date, full} à {date, time, short}", map[string]any{"date": date}, "le jeudi 5 mars 2026 à 14:07"},
+		{"custom date pattern", "fr", "{date, date, d MMM}", map[string]any{"date": date}, "5 mars"},
+		{"unknown locale uses english", "es", "{count, plural, one {# item} other {# items}}", map[string]any{"count": 1}, "1 item"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := FormatMessage(tt.locale, tt.pattern, tt.args)
+			if err != nil {
+				t.Fatalf("FormatMessage(%q) returned an error: %v", tt.pattern, err)
+			}
+			if result != tt.expected {
+				t.Errorf("FormatMessage(%q) = %q, expected %q", tt.pattern, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseMessageErrors(t *testing.T) {
+	patterns := []string{
+		"Hello {name",
+		"Hello name}",
+		"{}",
+		"{count, plural, one {# item}}",
+		"{count, plural, one {a} one {b} other {c}}",
+		"{count, plural, single {a} other {b}}",
+		"{count, plural, other {# items}",
+		"{amount, number, currency}",
+		"{count, choice, other {x}}",
+		"{gender, select, other}",
+	}
+
+	for _, pattern := range patterns {
+		if _, err := ParseMessage("en", pattern); !errors.Is(err, ErrInvalidMessage) {
+			t.Errorf("ParseMessage(%q) error = %v, expected ErrInvalidMessage", pattern, err)
+		}
+	}
+}
+
+func TestFormatMessageArgumentErrors(t *testing.T) {
+	if _, err := FormatMessage("en", "Hello {name}", nil); err == nil {
+		t.Error("expected an error for a missing argument")
+	}
+	if _, err := FormatMessage("en", "{count, plural, other {#}}", map[string]any{"count": "many"}); err == nil {
+		t.Error("expected an error for a plural argument that is not a number")
+	}
+}
+
+func TestMessageArguments(t *testing.T) {
+	message, err := ParseMessage("en", "{host} invited {count, plural, one {{guest}} other {# guests}} on {date, date}")
+	if err != nil {
+		t.Fatalf("ParseMessage returned an error: %v", err)
+	}
+	expected := []string{"count", "date", "guest", "host"}
+	if got := message.Arguments(); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Arguments() = %v, expected %v", got, expected)
+	}
+}
+
+func TestValidateTranslations(t *testing.T) {
+	problems := ValidateTranslations(map[string]models.TranslationMap{
+		"en": {
+			"event.guests": "{count, plural, one {# guest} other {# guests}}",
+			"event.broken": "{count, plural, one {# guest}}",
+		},
+		"fr": {
+			"event.guests": "{total, plural, one {# invité} other {# invités}}",
+			"event.title":  "Événement de {name}",
+		},
+	})
+
+	if len(problems) != 2 {
+		t.Fatalf("expected 2 problems, got %d: %v", len(problems), problems)
+	}
+	if problems[0].LanguageCode != "en" || problems[0].Key != "event.broken" {
+		t.Errorf("expected the syntax error of en.event.broken first, got %v", problems[0])
+	}
+	if problems[1].LanguageCode != "fr" || problems[1].Key != "event.guests" {
+		t.Errorf("expected the unknown argument of fr.event.guests, got %v", problems[1])
+	}
+	for _, problem := range problems {
+		if !errors.Is(problem, ErrInvalidMessage) {
+			t.Errorf("expected %v to wrap ErrInvalidMessage", problem)
+		}
+	}
+}
+
+func TestTranslationFilesAreValid(t *testing.T) {
+	catalogs := map[string]models.TranslationMap{}
+	for _, languageCode := range []string{"en", "fr"} {
+		data, err := os.ReadFile(filepath.Join("translations", languageCode+".json"))
+		if err != nil {
+			t.Fatalf("failed to read the %s translations: %v", languageCode, err)
+		}
+		translations := models.TranslationMap{}
+		if err := json.Unmarshal(data, &translations); err != nil {
+			t.Fatalf("failed to parse the %s translations: %v", languageCode, err)
+		}
+		catalogs[languageCode] = translations
+	}
+
+	for _, problem := range ValidateTranslations(catalogs) {
+		t.Errorf("invalid translation %v", problem)
+	}
+}
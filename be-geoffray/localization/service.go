@@ -3,11 +3,14 @@ package localization
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
 	"be-geoffray/db"
 	"be-geoffray/models"
@@ -15,8 +18,38 @@ import (
 
 const (
 	DefaultLanguage = "en"
+
+	// LanguageContextKey is the key of the request language in the gin context, set by LanguageMiddleware
+	LanguageContextKey = "language"
 )
 
+// ErrMissingTranslation is returned when a key has no message, not even in the default language
+var ErrMissingTranslation = errors.New("missing translation")
+
+// TranslationError is a message rejected by the validation of translations
+type TranslationError struct {
+	LanguageCode string
+	Key          string
+	Err          error
+}
+
+func (e TranslationError) Error() string {
+	return fmt.Sprintf("%s.%s: %v", e.LanguageCode, e.Key, e.Err)
+}
+
+func (e TranslationError) Unwrap() error {
+	return e.Err
+}
+
+// messageCacheKey identifies a parsed message
+type messageCacheKey struct {
+	locale  string
+	pattern string
+}
+
+// messageCache holds the parsed messages, as the same ones are formatted for every email and notification
+var messageCache sync.Map
+
 // Service handles all localization operations
 type Service struct {
 	db *sql.DB
@@ -30,22 +63,49 @@ func NewService() *Service {
 }
 
 // GetTranslations retrieves all translations for a specific language
+// The database is the reference, as administrators edit it; the JSON files are only read before the first import
 func (s *Service) GetTranslations(languageCode string) (*models.LanguageTranslations, error) {
 	// If language code is empty, use default
 	if languageCode == "" {
 		languageCode = DefaultLanguage
 	}
 
-	// First try to load from JSON file directly for better performance
-	translations, err := s.loadTranslationsFromFile(languageCode)
-	if err == nil && len(translations) > 0 {
-		return &models.LanguageTranslations{
-			LanguageCode: languageCode,
-			Translations: translations,
-		}, nil
+	// Without a database, e.g. in tests and tools, only the files are read
+	var version int64
+	var translations models.TranslationMap
+	if s.db != nil {
+		var err error
+		if version, err = s.CatalogVersion(); err != nil {
+			return nil, err
+		}
+		if translations, err = s.loadTranslationsFromDB(languageCode, version); err != nil {
+			return nil, err
+		}
+	}
+	if len(translations) == 0 {
+		if fromFile, err := s.loadTranslationsFromFile(languageCode); err == nil {
+			translations = fromFile
+		}
+	}
+
+	// If no translations found for the requested language, fall back to default
+	if len(translations) == 0 && languageCode != DefaultLanguage {
+		return s.GetTranslations(DefaultLanguage)
+	}
+
+	return &models.LanguageTranslations{
+		LanguageCode: languageCode,
+		Version:      version,
+		Translations: translations,
+	}, nil
+}
+
+// loadTranslationsFromDB reads the translations of a language, from the cache when the catalog has not changed
+func (s *Service) loadTranslationsFromDB(languageCode string, version int64) (models.TranslationMap, error) {
+	if cached, ok := catalogCache.Load(languageCode); ok && cached.(cachedCatalog).version == version {
+		return cached.(cachedCatalog).translations, nil
 	}
 
-	// If file loading fails, try from database
 	// Query the database for translations
 	rows, err := s.db.Query("SELECT key, value FROM translations WHERE language_code = $1", languageCode)
 	if err != nil {
@@ -54,7 +114,7 @@ func (s *Service) GetTranslations(languageCode string) (*models.LanguageTranslat
 	defer rows.Close()
 
 	// Create a map to store translations
-	translations = make(models.TranslationMap)
+	translations := make(models.TranslationMap)
 
 	// Iterate through rows and populate the map
 	for rows.Next() {
@@ -70,44 +130,126 @@ func (s *Service) GetTranslations(languageCode string) (*models.LanguageTranslat
 		return nil, fmt.Errorf("error iterating translation rows: %w", err)
 	}
 
-	// If no translations found for the requested language, fall back to default
-	if len(translations) == 0 && languageCode != DefaultLanguage {
-		return s.GetTranslations(DefaultLanguage)
+	if len(translations) > 0 {
+		catalogCache.Store(languageCode, cachedCatalog{version: version, translations: translations})
 	}
+	return translations, nil
+}
 
-	return &models.LanguageTranslations{
-		LanguageCode: languageCode,
-		Translations: translations,
-	}, nil
+// Format renders the message of a key in a language with named arguments, e.g.
+// Format("fr", "event.participantsCount", map[string]any{"count": 3}) gives "3 participants"
+// Keys missing in the language fall back to the default language, formatted with its rules
+func (s *Service) Format(languageCode, key string, args map[string]any) (string, error) {
+	if languageCode == "" {
+		languageCode = DefaultLanguage
+	}
+	pattern, locale, err := s.lookupMessage(languageCode, key)
+	if err != nil {
+		return "", err
+	}
+	message, err := compileMessage(locale, pattern)
+	if err != nil {
+		return "", TranslationError{LanguageCode: locale, Key: key, Err: err}
+	}
+	text, err := message.Format(args)
+	if err != nil {
+		return "", TranslationError{LanguageCode: locale, Key: key, Err: err}
+	}
+	return text, nil
 }
 
-// SaveTranslation saves a single translation to the database
-func (s *Service) SaveTranslation(translation *models.Translation) error {
-	// Check if translation already exists
-	var exists bool
-	err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM translations WHERE language_code = $1 AND key = $2)",
-		translation.LanguageCode, translation.Key).Scan(&exists)
+// Translate is Format for templates: it returns the key itself when the message cannot be formatted
+func (s *Service) Translate(languageCode, key string, args map[string]any) string {
+	text, err := s.Format(languageCode, key, args)
 	if err != nil {
-		return fmt.Errorf("error checking if translation exists: %w", err)
+		log.Printf("Error formatting translation %s: %v", key, err)
+		return key
 	}
+	return text
+}
 
-	// If translation exists, update it
-	if exists {
-		_, err = s.db.Exec("UPDATE translations SET value = $1, updated_at = CURRENT_TIMESTAMP WHERE language_code = $2 AND key = $3",
-			translation.Value, translation.LanguageCode, translation.Key)
+// lookupMessage returns the message of a key and the language it was found in
+func (s *Service) lookupMessage(languageCode, key string) (string, string, error) {
+	for _, language := range []string{languageCode, DefaultLanguage} {
+		translations, err := s.GetTranslations(language)
 		if err != nil {
-			return fmt.Errorf("error updating translation: %w", err)
+			return "", "", err
 		}
-	} else {
-		// If translation doesn't exist, insert it
-		_, err = s.db.Exec("INSERT INTO translations (language_code, key, value) VALUES ($1, $2, $3)",
-			translation.LanguageCode, translation.Key, translation.Value)
-		if err != nil {
-			return fmt.Errorf("error inserting translation: %w", err)
+		if pattern, ok := translations.Translations[key]; ok {
+			return pattern, translations.LanguageCode, nil
 		}
 	}
+	return "", "", fmt.Errorf("%w: %s.%s", ErrMissingTranslation, languageCode, key)
+}
+
+// compileMessage parses a message once and reuses it afterwards
+func compileMessage(locale, pattern string) (*Message, error) {
+	cacheKey := messageCacheKey{locale: locale, pattern: pattern}
+	if cached, ok := messageCache.Load(cacheKey); ok {
+		return cached.(*Message), nil
+	}
+	message, err := ParseMessage(locale, pattern)
+	if err != nil {
+		return nil, err
+	}
+	messageCache.Store(cacheKey, message)
+	return message, nil
+}
+
+// ValidateTranslations checks the messages of each language: their syntax, and that the translations
+// only use arguments of the default language message, since callers only pass those
+// Errors are sorted by language and key
+func ValidateTranslations(catalogs map[string]models.TranslationMap) []TranslationError {
+	defaults := map[string]map[string]bool{}
+	for key, pattern := range catalogs[DefaultLanguage] {
+		if message, err := ParseMessage(DefaultLanguage, pattern); err == nil {
+			defaults[key] = map[string]bool{}
+			for _, name := range message.Arguments() {
+				defaults[key][name] = true
+			}
+		}
+	}
+
+	var problems []TranslationError
+	for languageCode, translations := range catalogs {
+		for key, pattern := range translations {
+			message, err := ParseMessage(languageCode, pattern)
+			if err != nil {
+				problems = append(problems, TranslationError{LanguageCode: languageCode, Key: key, Err: err})
+				continue
+			}
+			expected, ok := defaults[key]
+			if !ok || languageCode == DefaultLanguage {
+				continue
+			}
+			for _, name := range message.Arguments() {
+				if !expected[name] {
+					err := fmt.Errorf("%w: argument %q is not in the %s message", ErrInvalidMessage, name, DefaultLanguage)
+					problems = append(problems, TranslationError{LanguageCode: languageCode, Key: key, Err: err})
+					break
+				}
+			}
+		}
+	}
+
+	sort.Slice(problems, func(i, j int) bool {
+		if problems[i].LanguageCode != problems[j].LanguageCode {
+			return problems[i].LanguageCode < problems[j].LanguageCode
+		}
+		return problems[i].Key < problems[j].Key
+	})
+	return problems
+}
 
-	return nil
+// SaveTranslation creates or updates a single message as an administrator, validated against the default language
+// It bumps the catalog version when the message changed
+func (s *Service) SaveTranslation(translation *models.Translation) error {
+	translations := models.TranslationMap{translation.Key: translation.Value}
+	if err := s.validateImport(translation.LanguageCode, translations); err != nil {
+		return err
+	}
+	_, err := s.saveTranslations(translation.LanguageCode, translations, TranslationSourceAdmin)
+	return err
 }
 
 // loadTranslationsFromFile loads translations directly from a JSON file
@@ -128,13 +270,45 @@ func (s *Service) loadTranslationsFromFile(languageCode string) (models.Translat
 }
 
 // ImportTranslationsFromJSON imports translations from JSON files
+// Every file is validated before saving; invalid messages are skipped and returned joined in the error,
+// the valid ones being imported anyway. Messages edited by administrators are kept
 func (s *Service) ImportTranslationsFromJSON(dirPath string) error {
+	catalogs, err := readTranslationFiles(dirPath)
+	if err != nil {
+		return err
+	}
+
+	problems := ValidateTranslations(catalogs)
+	errs := make([]error, 0, len(problems))
+	for _, problem := range problems {
+		delete(catalogs[problem.LanguageCode], problem.Key)
+		errs = append(errs, problem)
+		log.Printf("Rejected translation %v", problem)
+	}
+
+	for languageCode, translations := range catalogs {
+		// Save translations to database
+		changed, err := s.saveTranslations(languageCode, translations, TranslationSourceFile)
+		if err != nil {
+			log.Printf("Error saving %s translations: %v", languageCode, err)
+			continue
+		}
+
+		log.Printf("Imported %s translations from %s (%d changed)", languageCode, dirPath, changed)
+	}
+
+	return errors.Join(errs...)
+}
+
+// readTranslationFiles reads the JSON files of a directory by language code
+func readTranslationFiles(dirPath string) (map[string]models.TranslationMap, error) {
 	// Get all JSON files in the directory
 	files, err := os.ReadDir(dirPath)
 	if err != nil {
-		return fmt.Errorf("error reading translation directory: %w", err)
+		return nil, fmt.Errorf("error reading translation directory: %w", err)
 	}
 
+	catalogs := map[string]models.TranslationMap{}
 	for _, file := range files {
 		// Skip directories and non-JSON files
 		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
@@ -161,23 +335,9 @@ func (s *Service) ImportTranslationsFromJSON(dirPath string) error {
 			log.Printf("Error parsing translation file %s: %v", filePath, err)
 			continue
 		}
-
-		// Save translations to database
-		for key, value := range translations {
-			translation := &models.Translation{
-				LanguageCode: languageCode,
-				Key:          key,
-				Value:        value,
-			}
-			if err := s.SaveTranslation(translation); err != nil {
-				log.Printf("Error saving translation %s.%s: %v", languageCode, key, err)
-			}
-		}
-
-		log.Printf("Imported translations from %s", filePath)
+		catalogs[languageCode] = translations
 	}
-
-	return nil
+	return catalogs, nil
 }
 
 // DetectLanguage detects the preferred language from the Accept-Language header
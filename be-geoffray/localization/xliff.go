@@ -0,0 +1,93 @@
+package localization
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+
+	"be-geoffray/models"
+)
+
+// ErrInvalidXLIFF is returned for files that are not XLIFF 1.2 documents
+var ErrInvalidXLIFF = errors.New("invalid XLIFF document")
+
+// xliffDocument is an XLIFF 1.2 document, the exchange format of translation tools
+type xliffDocument struct {
+	XMLName xml.Name  `xml:"xliff"`
+	Version string    `xml:"version,attr"`
+	Xmlns   string    `xml:"xmlns,attr,omitempty"`
+	File    xliffFile `xml:"file"`
+}
+
+type xliffFile struct {
+	SourceLanguage string      `xml:"source-language,attr"`
+	TargetLanguage string      `xml:"target-language,attr,omitempty"`
+	Datatype       string      `xml:"datatype,attr"`
+	Original       string      `xml:"original,attr"`
+	Units          []xliffUnit `xml:"body>trans-unit"`
+}
+
+// xliffUnit is a message: the default language text as source and the translation, when any, as target
+type xliffUnit struct {
+	ID     string  `xml:"id,attr"`
+	Source string  `xml:"source"`
+	Target *string `xml:"target,omitempty"`
+}
+
+// EncodeXLIFF exports the messages of a language for translators: one unit per key of the default language,
+// with the translation as target when there is one
+func EncodeXLIFF(languageCode string, defaults, translations models.TranslationMap) ([]byte, error) {
+	keys := make([]string, 0, len(defaults))
+	for key := range defaults {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	document := xliffDocument{
+		Version: "1.2",
+		Xmlns:   "urn:oasis:names:tc:xliff:document:1.2",
+		File: xliffFile{
+			SourceLanguage: DefaultLanguage,
+			TargetLanguage: languageCode,
+			Datatype:       "plaintext",
+			Original:       "geoffray",
+		},
+	}
+	for _, key := range keys {
+		unit := xliffUnit{ID: key, Source: defaults[key]}
+		if translation, ok := translations[key]; ok {
+			unit.Target = &translation
+		}
+		document.File.Units = append(document.File.Units, unit)
+	}
+
+	data, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// DecodeXLIFF reads the target language and the translated units of an XLIFF 1.2 document
+// Units without a target are skipped, being left untranslated
+func DecodeXLIFF(data []byte) (string, models.TranslationMap, error) {
+	var document xliffDocument
+	if err := xml.Unmarshal(data, &document); err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidXLIFF, err)
+	}
+	if document.Version != "1.2" {
+		return "", nil, fmt.Errorf("%w: unsupported version %q", ErrInvalidXLIFF, document.Version)
+	}
+
+	translations := models.TranslationMap{}
+	for _, unit := range document.File.Units {
+		if unit.ID == "" {
+			return "", nil, fmt.Errorf("%w: trans-unit without id", ErrInvalidXLIFF)
+		}
+		if unit.Target != nil && *unit.Target != "" {
+			translations[unit.ID] = *unit.Target
+		}
+	}
+	return document.File.TargetLanguage, translations, nil
+}
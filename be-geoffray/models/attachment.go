@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Attachment is a file uploaded to an event discussion
+type Attachment struct {
+	ID           string    `json:"id"`
+	EventID      string    `json:"event_id"`
+	MessageID    *string   `json:"message_id,omitempty"` // nil until the message is posted
+	UploaderID   *string   `json:"uploader_id,omitempty"`
+	FileName     string    `json:"file_name"`
+	ContentType  string    `json:"content_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	Width        *int      `json:"width,omitempty"`  // Images only
+	Height       *int      `json:"height,omitempty"` // Images only
+	URL          string    `json:"url"`
+	ThumbnailURL *string   `json:"thumbnail_url,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	StorageKey   string    `json:"-"`
+	ThumbnailKey *string   `json:"-"`
+}
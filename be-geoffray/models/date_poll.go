@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// DatePoll lets the participants of an event pick among candidate dates
+type DatePoll struct {
+	ID        string           `json:"id"`
+	EventID   string           `json:"event_id"`
+	CreatedBy *string          `json:"created_by,omitempty"` // Nil once the author account is deleted
+	Question  string           `json:"question"`
+	Options   []DatePollOption `json:"options"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// DatePollOption is a candidate date of a poll
+type DatePollOption struct {
+	ID        string    `json:"id"`
+	StartsAt  time.Time `json:"starts_at"`
+	VoteCount int       `json:"vote_count"`
+	VotedByMe bool      `json:"voted_by_me"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// DiscussionSummary is an AI summary of the messages of an event discussion a participant has not read
+type DiscussionSummary struct {
+	EventID        string                 `json:"event_id"`
+	FromMessageID  string                 `json:"from_message_id,omitempty"` // First summarized message, empty when there was nothing new
+	ToMessageID    string                 `json:"to_message_id,omitempty"`   // Last summarized message
+	MessageCount   int                    `json:"message_count"`
+	Language       string                 `json:"language"`
+	Overview       string                 `json:"overview"`
+	Decisions      []string               `json:"decisions"`
+	OpenQuestions  []string               `json:"open_questions"`
+	TopSuggestions []string               `json:"top_suggestions"`
+	Commitments    []DiscussionCommitment `json:"commitments"`
+	PromptVersion  string                 `json:"prompt_version,omitempty"`
+	Cached         bool                   `json:"cached"` // True when served from the cache of the same message range
+	GeneratedAt    time.Time              `json:"generated_at"`
+}
+
+// DiscussionCommitment is something a participant committed to do in the discussion
+type DiscussionCommitment struct {
+	Participant string `json:"participant"`
+	Commitment  string `json:"commitment"`
+}
@@ -19,4 +19,7 @@ type Event struct {
 	ParticipantsCount int        `json:"participants_count"`
 	GifteePersona     string     `json:"giftee_persona,omitempty"`
 	EventOccasion     string     `json:"event_occasion,omitempty"`
+	SurpriseMode      bool       `json:"surprise_mode"`          // Hides the giftee profile from the guests
+	Language          string     `json:"language,omitempty"`     // Language gift suggestions are generated in
+	UnreadCount       *int       `json:"unread_count,omitempty"` // Unread discussion messages, only set when listing the user's events
 }
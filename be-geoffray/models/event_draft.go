@@ -0,0 +1,17 @@
+package models
+
+// EventDraft is an AI-drafted description and invitation message of an event, in one or more languages
+// Organizers apply the description they keep through the event update endpoint
+type EventDraft struct {
+	EventID       string           `json:"event_id"`
+	Tone          string           `json:"tone"`
+	PromptVersion string           `json:"prompt_version"`
+	Texts         []EventDraftText `json:"texts"` // In the requested order of languages
+}
+
+// EventDraftText is the draft of an event in one language
+type EventDraftText struct {
+	Language    string `json:"language"`
+	Description string `json:"description"`
+	Invitation  string `json:"invitation"`
+}
@@ -14,4 +14,55 @@ type EventMessage struct {
 	// New fields for agent interaction
 	IsAgentMessage bool `json:"is_agent_message"` // True if message is from the agent
 	ForAgent       bool `json:"for_agent"`        // True if message is intended for the agent (tagged with @agent)
+	// Edition and deletion
+	EditedAt  *time.Time `json:"edited_at,omitempty"`  // Set when the author edited the message
+	DeletedAt *time.Time `json:"deleted_at,omitempty"` // Set when the message was deleted (content is then empty)
+	// Reactions aggregated per emoji
+	Reactions []MessageReaction `json:"reactions"`
+	// Participants mentioned in the message
+	Mentions []MessageMention `json:"mentions"`
+	// Files attached to the message
+	Attachments []Attachment `json:"attachments"`
+	// Threads
+	ReplyCount int            `json:"reply_count"`       // Number of direct replies that are not deleted
+	Replies    []EventMessage `json:"replies,omitempty"` // Only set when fetching a thread
+}
+
+// EventMessagePage is one page of the timeline of an event, in chronological order
+type EventMessagePage struct {
+	Messages      []EventMessage `json:"messages"`
+	HasMoreBefore bool           `json:"has_more_before"` // Older messages can be fetched with before=<first message id>
+	HasMoreAfter  bool           `json:"has_more_after"`  // Newer messages can be fetched with after=<last message id>
+}
+
+// MessageReaction aggregates the reactions with one emoji on a message
+type MessageReaction struct {
+	Emoji       string   `json:"emoji"`
+	Count       int      `json:"count"`
+	UserIDs     []string `json:"user_ids"`
+	ReactedByMe bool     `json:"reacted_by_me"`
+}
+
+// MessageMention is a participant mentioned in a message
+type MessageMention struct {
+	UserID      string `json:"user_id"`
+	DisplayName string `json:"display_name"`
+	Token       string `json:"token"` // Text of the mention in the content, e.g. "@Marie" or "<@user-id>"
+}
+
+// MessageSeenBy is a participant who has read a message
+type MessageSeenBy struct {
+	UserID    string    `json:"user_id"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	ReadAt    time.Time `json:"read_at"`
+}
+
+// EventMessageEdit is a previous version of an edited message
+type EventMessageEdit struct {
+	ID              string    `json:"id"`
+	MessageID       string    `json:"message_id"`
+	PreviousContent string    `json:"previous_content"`
+	EditedBy        *string   `json:"edited_by,omitempty"`
+	EditedAt        time.Time `json:"edited_at"`
 }
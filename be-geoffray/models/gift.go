@@ -17,34 +17,39 @@ type GifteePersona struct {
 
 // OccasionType represents an event occasion type
 type OccasionType struct {
-	ID          string    `json:"id"`
-	OccasionKey string    `json:"occasion_key"` // Translation key for the occasion name
-	NameEN      string    `json:"name_en"`      // English name
-	NameFR      string    `json:"name_fr"`      // French name
-	Color       string    `json:"color"`        // Hex color for display
-	OrderIndex  int       `json:"order_index"`  // Display order
-	Active      bool      `json:"active"`       // Whether occasion is active
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string            `json:"id"`
+	OccasionKey string            `json:"occasion_key"`    // Translation key for the occasion name
+	NameEN      string            `json:"name_en"`         // English name
+	NameFR      string            `json:"name_fr"`         // French name
+	Names       map[string]string `json:"names,omitempty"` // Names per language code, from occasion_type_translations
+	Name        string            `json:"name,omitempty"`  // Name in the language of the request
+	Color       string            `json:"color"`           // Hex color for display
+	OrderIndex  int               `json:"order_index"`     // Display order
+	Active      bool              `json:"active"`          // Whether occasion is active
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
 }
 
 // GiftSuggestion represents an AI-generated gift suggestion for an event
 type GiftSuggestion struct {
-	ID            string    `json:"id"`
-	EventID       string    `json:"event_id"`
-	OwnerID       string    `json:"owner_id"`         // User ID of who created/owns this suggestion
-	NameEN        string    `json:"name_en"`          // English name
-	NameFR        string    `json:"name_fr"`          // French name
-	DescriptionEN string    `json:"description_en"`   // English description
-	DescriptionFR string    `json:"description_fr"`   // French description
-	PriceRange    string    `json:"price_range"`      // e.g., "$10-$20", "$50+", etc.
-	Category      string    `json:"category"`         // Gift category
-	URL           string    `json:"url,omitempty"`    // Optional URL for purchasing
-	Prompt        *string   `json:"prompt,omitempty"` // Optional AI prompt used to generate this suggestion
-	CreationMode  string    `json:"creation_mode"`    // "manual" or "ai" - how this suggestion was created
-	GeneratedAt   time.Time `json:"generated_at"`     // When this suggestion was generated
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            string                     `json:"id"`
+	EventID       string                     `json:"event_id"`
+	OwnerID       string                     `json:"owner_id"`                 // User ID of who created/owns this suggestion
+	NameEN        string                     `json:"name_en"`                  // English name
+	NameFR        string                     `json:"name_fr"`                  // French name
+	DescriptionEN string                     `json:"description_en"`           // English description
+	DescriptionFR string                     `json:"description_fr"`           // French description
+	PriceRange    string                     `json:"price_range"`              // e.g., "$10-$20", "$50+", etc.
+	Category      string                     `json:"category"`                 // Gift category
+	URL           string                     `json:"url,omitempty"`            // Optional URL for purchasing
+	Prompt        *string                    `json:"prompt,omitempty"`         // Optional AI prompt used to generate this suggestion
+	CreationMode  string                     `json:"creation_mode"`            // "manual" or "ai" - how this suggestion was created
+	PromptVersion *string                    `json:"prompt_version,omitempty"` // Version of the prompt template that generated this suggestion
+	Language      string                     `json:"language,omitempty"`       // Language the suggestion was written or generated in
+	Translations  map[string]GiftTranslation `json:"translations,omitempty"`   // Name and description per language code
+	GeneratedAt   time.Time                  `json:"generated_at"`             // When this suggestion was generated
+	CreatedAt     time.Time                  `json:"created_at"`
+	UpdatedAt     time.Time                  `json:"updated_at"`
 
 	// Amazon affiliate fields
 	AmazonASIN         *string    `json:"amazon_asin,omitempty"`
@@ -62,6 +67,14 @@ type GiftSuggestion struct {
 	UserVote      *string `json:"user_vote,omitempty"` // "upvote", "downvote", or null
 }
 
+// GiftTranslation is the name and description of a gift in one language
+// The name_en/name_fr/description_en/description_fr fields of gifts mirror the English and French translations
+type GiftTranslation struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Source      string `json:"source,omitempty"` // "original", or "machine" when translated on demand
+}
+
 // GiftSuggestionVote represents a user's vote on a gift suggestion
 type GiftSuggestionVote struct {
 	ID           string    `json:"id"`
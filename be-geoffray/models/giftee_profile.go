@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// GifteeProfile describes the person receiving the gifts of an event
+// It is editable by the organizers and hidden from the other guests in surprise mode
+type GifteeProfile struct {
+	EventID       string            `json:"event_id"`
+	AgeRange      string            `json:"age_range,omitempty"`    // e.g. "26-40", see services.GifteeAgeRanges
+	Relationship  string            `json:"relationship,omitempty"` // e.g. "friend", see services.GifteeRelationships
+	Interests     []string          `json:"interests"`              // Interests and hobbies tags
+	Dislikes      []string          `json:"dislikes"`
+	OwnedItems    []string          `json:"owned_items"`    // Things they already own
+	ClothingSizes map[string]string `json:"clothing_sizes"` // Size per kind of clothing, e.g. {"top": "M"}
+	UpdatedBy     *string           `json:"updated_by,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
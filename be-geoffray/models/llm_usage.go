@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// LLMUsage records one language model call and the tokens it consumed
+type LLMUsage struct {
+	ID               string    `json:"id"`
+	UserID           *string   `json:"user_id,omitempty"`
+	EventID          *string   `json:"event_id,omitempty"`
+	Purpose          string    `json:"purpose"` // "generation", "similarity" or "agent"
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	LatencyMs        int       `json:"latency_ms"`
+	Outcome          string    `json:"outcome"` // "success", "error" or "quota_exceeded"
+	Error            *string   `json:"error,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// LLMUsageSummary aggregates the language model calls sharing the same key
+type LLMUsageSummary struct {
+	Key              string  `json:"key"` // Value of the grouping column, empty when unattributed
+	Calls            int     `json:"calls"`
+	FailedCalls      int     `json:"failed_calls"`
+	RejectedCalls    int     `json:"rejected_calls"` // Calls refused by a quota
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	AvgLatencyMs     float64 `json:"avg_latency_ms"`
+}
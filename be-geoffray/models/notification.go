@@ -0,0 +1,19 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Notification is an in-app notification addressed to a user
+type Notification struct {
+	ID        string          `json:"id"`
+	UserID    string          `json:"user_id"`
+	Type      string          `json:"type"` // e.g. "mention"
+	EventID   *string         `json:"event_id,omitempty"`
+	MessageID *string         `json:"message_id,omitempty"`
+	ActorID   *string         `json:"actor_id,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	ReadAt    *time.Time      `json:"read_at,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
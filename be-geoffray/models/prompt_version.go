@@ -0,0 +1,12 @@
+package models
+
+// PromptVersionStats compares the votes on the AI suggestions generated by one prompt template version
+type PromptVersionStats struct {
+	Version      string  `json:"version"` // Empty for suggestions generated before prompts were versioned
+	Suggestions  int     `json:"suggestions"`
+	Events       int     `json:"events"`
+	Upvotes      int     `json:"upvotes"`
+	Downvotes    int     `json:"downvotes"`
+	UpvoteRatio  float64 `json:"upvote_ratio"`   // Share of upvotes among the votes, 0 without votes
+	VotesPerGift float64 `json:"votes_per_gift"` // Average number of votes per suggestion
+}
@@ -0,0 +1,32 @@
+package models
+
+// GiftRecommendation is a gift idea suggested to a user from their gift history
+type GiftRecommendation struct {
+	NameEN        string  `json:"name_en"`
+	NameFR        string  `json:"name_fr"`
+	DescriptionEN string  `json:"description_en"`
+	DescriptionFR string  `json:"description_fr"`
+	PriceRange    string  `json:"price_range"`
+	Category      string  `json:"category"`
+	URL           string  `json:"url,omitempty"`
+	PersonaKey    string  `json:"persona_key"`
+	OccasionKey   string  `json:"occasion_key"`
+	Score         float64 `json:"score"`
+	ReasonEN      string  `json:"reason_en"` // Why it is recommended, e.g. "Because you upvoted 3 Kitchen gifts"
+	ReasonFR      string  `json:"reason_fr"`
+}
+
+// EventDefaults are the persona and occasion proposed when the user creates an event
+type EventDefaults struct {
+	GifteePersona string `json:"giftee_persona"`
+	EventOccasion string `json:"event_occasion"`
+	ReasonEN      string `json:"reason_en"`
+	ReasonFR      string `json:"reason_fr"`
+}
+
+// UserRecommendations are the personalized recommendations of a user
+type UserRecommendations struct {
+	Enabled     bool                 `json:"enabled"`            // False when the user opted out
+	Defaults    *EventDefaults       `json:"defaults,omitempty"` // Nil without enough history
+	Suggestions []GiftRecommendation `json:"suggestions"`
+}
@@ -3,9 +3,10 @@ package models
 // Translation represents a localized string in the database
 type Translation struct {
 	ID           string `json:"id"`
-	LanguageCode string `json:"language_code"` // e.g., "en", "fr"
-	Key          string `json:"key"`           // e.g., "welcome_message"
-	Value        string `json:"value"`         // The translated string
+	LanguageCode string `json:"language_code"`    // e.g., "en", "fr"
+	Key          string `json:"key"`              // e.g., "welcome_message"
+	Value        string `json:"value"`            // The translated string
+	Source       string `json:"source,omitempty"` // "file" or "admin"
 }
 
 // TranslationMap is a map of translation keys to their values
@@ -14,5 +15,29 @@ type TranslationMap map[string]string
 // LanguageTranslations represents all translations for a specific language
 type LanguageTranslations struct {
 	LanguageCode string         `json:"language_code"`
+	Version      int64          `json:"version"` // Catalog version, bumped on every change
 	Translations TranslationMap `json:"translations"`
 }
+
+// TranslationReport compares the keys of each language with the default language
+type TranslationReport struct {
+	DefaultLanguage string           `json:"default_language"`
+	Version         int64            `json:"version"`
+	Languages       []LanguageReport `json:"languages"`
+}
+
+// LanguageReport lists the keys a language misses or has in excess compared to the default language
+type LanguageReport struct {
+	LanguageCode string             `json:"language_code"`
+	KeyCount     int                `json:"key_count"`
+	Coverage     float64            `json:"coverage"` // Share of the default language keys translated, from 0 to 1
+	Missing      []string           `json:"missing"`
+	Extra        []string           `json:"extra"`
+	Invalid      []TranslationIssue `json:"invalid"`
+}
+
+// TranslationIssue is a message that is not valid or uses unknown arguments
+type TranslationIssue struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
@@ -10,4 +10,7 @@ type User struct {
 	FirebaseUID string    `json:"firebase_uid,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// PersonalizedRecommendations is false when the user opted out of the recommendations learnt from their history
+	PersonalizedRecommendations bool `json:"personalized_recommendations"`
 }
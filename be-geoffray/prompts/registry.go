@@ -0,0 +1,246 @@
+package prompts
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"be-geoffray/config"
+)
+
+// Names of the prompts used by the application
+const (
+	GiftSuggestions   = "gift_suggestions"
+	GiftSimilarity    = "gift_similarity"
+	GiftTranslation   = "gift_translation"
+	EventDraft        = "event_draft"
+	DiscussionSummary = "discussion_summary"
+)
+
+// manifestFile lists the versions of every prompt, next to the template files
+const manifestFile = "manifest.json"
+
+//go:embed templates
+var embedded embed.FS
+
+// Selector describes the request a prompt is rendered for
+// Empty fields of a variant's match apply to any value
+type Selector struct {
+	Persona  string `json:"persona,omitempty"`
+	Occasion string `json:"occasion,omitempty"`
+	Language string `json:"language,omitempty"`
+}
+
+// specificity is the number of fields a variant's match constrains
+func (s Selector) specificity() int {
+	count := 0
+	for _, value := range []string{s.Persona, s.Occasion, s.Language} {
+		if value != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// matches reports whether a request is covered by this match
+func (s Selector) matches(request Selector) bool {
+	return (s.Persona == "" || strings.EqualFold(s.Persona, request.Persona)) &&
+		(s.Occasion == "" || strings.EqualFold(s.Occasion, request.Occasion)) &&
+		(s.Language == "" || strings.EqualFold(s.Language, request.Language))
+}
+
+// Variant is one version of a prompt template
+// Variants matching the same requests share the traffic in proportion to their weight
+type Variant struct {
+	Prompt  string   `json:"prompt"`
+	Version string   `json:"version"`
+	File    string   `json:"file"`
+	Weight  int      `json:"weight"`
+	Match   Selector `json:"match"`
+
+	template *template.Template
+}
+
+// Render executes the template with the given data
+func (v *Variant) Render(data interface{}) (string, error) {
+	var out strings.Builder
+	if err := v.template.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt %s %s: %w", v.Prompt, v.Version, err)
+	}
+	return out.String(), nil
+}
+
+// templateFuncs are the helpers available to every prompt template
+var templateFuncs = template.FuncMap{
+	"inc":  func(i int) int { return i + 1 },
+	"join": strings.Join,
+}
+
+// Registry holds the prompt variants loaded from a template directory
+type Registry struct {
+	mu       sync.RWMutex
+	source   string
+	variants map[string][]*Variant
+}
+
+// Load parses the manifest and the templates of a file system
+func Load(fsys fs.FS) (map[string][]*Variant, error) {
+	data, err := fs.ReadFile(fsys, manifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt manifest: %w", err)
+	}
+
+	var manifest map[string][]*Variant
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid prompt manifest: %w", err)
+	}
+
+	for name, variants := range manifest {
+		versions := make(map[string]bool)
+		for _, variant := range variants {
+			if variant.Version == "" || variant.File == "" {
+				return nil, fmt.Errorf("prompt %s has a variant without version or file", name)
+			}
+			if versions[variant.Version] {
+				return nil, fmt.Errorf("prompt %s has version %s twice", name, variant.Version)
+			}
+			versions[variant.Version] = true
+			if variant.Weight < 0 {
+				return nil, fmt.Errorf("prompt %s %s has a negative weight", name, variant.Version)
+			}
+
+			source, err := fs.ReadFile(fsys, variant.File)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read prompt %s %s: %w", name, variant.Version, err)
+			}
+			variant.Prompt = name
+			variant.template, err = template.New(variant.File).Option("missingkey=error").Funcs(templateFuncs).Parse(string(source))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse prompt %s %s: %w", name, variant.Version, err)
+			}
+		}
+	}
+	return manifest, nil
+}
+
+// NewRegistry creates a registry loading its templates from dir, or from the embedded defaults when dir is empty
+func NewRegistry(dir string) (*Registry, error) {
+	registry := &Registry{source: dir}
+	if err := registry.Reload(); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// Reload reads the templates again, keeping the current ones if the new ones are invalid
+func (r *Registry) Reload() error {
+	var fsys fs.FS
+	if r.source == "" {
+		sub, err := fs.Sub(embedded, "templates")
+		if err != nil {
+			return err
+		}
+		fsys = sub
+	} else {
+		fsys = os.DirFS(r.source)
+	}
+
+	variants, err := Load(fsys)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.variants = variants
+	r.mu.Unlock()
+	return nil
+}
+
+// Select picks the variant of a prompt to use for a request
+// The variants with the most specific match win; traffic is split between them by weight,
+// using key so that the same key (e.g. an event ID) always gets the same version
+func (r *Registry) Select(name string, request Selector, key string) (*Variant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var candidates []*Variant
+	best := -1
+	for _, variant := range r.variants[name] {
+		if variant.Weight == 0 || !variant.Match.matches(request) {
+			continue
+		}
+		specificity := variant.Match.specificity()
+		if specificity > best {
+			best = specificity
+			candidates = nil
+		}
+		if specificity == best {
+			candidates = append(candidates, variant)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no template for prompt %s", name)
+	}
+
+	total := 0
+	for _, variant := range candidates {
+		total += variant.Weight
+	}
+	var point int
+	if key == "" {
+		point = rand.Intn(total)
+	} else {
+		hash := fnv.New32a()
+		hash.Write([]byte(name + ":" + key))
+		point = int(hash.Sum32() % uint32(total))
+	}
+	for _, variant := range candidates {
+		if point < variant.Weight {
+			return variant, nil
+		}
+		point -= variant.Weight
+	}
+	return candidates[len(candidates)-1], nil
+}
+
+// Variants returns every loaded variant, sorted by prompt and version
+func (r *Registry) Variants() []Variant {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var variants []Variant
+	for _, list := range r.variants {
+		for _, variant := range list {
+			variants = append(variants, *variant)
+		}
+	}
+	sort.Slice(variants, func(i, j int) bool {
+		if variants[i].Prompt != variants[j].Prompt {
+			return variants[i].Prompt < variants[j].Prompt
+		}
+		return variants[i].Version < variants[j].Version
+	})
+	return variants
+}
+
+var (
+	defaultRegistry *Registry
+	defaultOnce     sync.Once
+	defaultErr      error
+)
+
+// Default returns the registry configured by PROMPT_TEMPLATES_DIR
+func Default() (*Registry, error) {
+	defaultOnce.Do(func() {
+		defaultRegistry, defaultErr = NewRegistry(config.GetConfig().PromptTemplatesDir)
+	})
+	return defaultRegistry, defaultErr
+}
@@ -0,0 +1,136 @@
+package prompts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+const testManifest = `{
+  "greeting": [
+    {"version": "v1", "file": "greeting.v1.tmpl", "weight": 50},
+    {"version": "v2", "file": "greeting.v2.tmpl", "weight": 50},
+    {"version": "fr", "file": "greeting.fr.tmpl", "weight": 100, "match": {"language": "fr"}},
+    {"version": "retired", "file": "greeting.v1.tmpl", "weight": 0}
+  ]
+}`
+
+func testRegistry(t *testing.T) *Registry {
+	t.Helper()
+	variants, err := Load(fstest.MapFS{
+		"manifest.json":    {Data: []byte(testManifest)},
+		"greeting.v1.tmpl": {Data: []byte("Hello {{.Name}}")},
+		"greeting.v2.tmpl": {Data: []byte("Hi {{.Name}}!")},
+		"greeting.fr.tmpl": {Data: []byte("Bonjour {{.Name}}")},
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return &Registry{variants: variants}
+}
+
+func TestRegistrySelect(t *testing.T) {
+	registry := testRegistry(t)
+
+	variant, err := registry.Select("greeting", Selector{Language: "FR"}, "event-1")
+	if err != nil || variant.Version != "fr" {
+		t.Fatalf("Select() for French = %v, %v, want the fr variant", variant, err)
+	}
+	rendered, err := variant.Render(map[string]string{"Name": "Ana"})
+	if err != nil || rendered != "Bonjour Ana" {
+		t.Errorf("Render() = %q, %v", rendered, err)
+	}
+
+	// The same key always gets the same version, and both versions get traffic
+	counts := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("event-%d", i)
+		first, err := registry.Select("greeting", Selector{Language: "en"}, key)
+		if err != nil {
+			t.Fatalf("Select() error = %v", err)
+		}
+		again, _ := registry.Select("greeting", Selector{Language: "en"}, key)
+		if first.Version != again.Version {
+			t.Fatalf("key %s got versions %s and %s", key, first.Version, again.Version)
+		}
+		counts[first.Version]++
+	}
+	if counts["v1"] < 60 || counts["v2"] < 60 || counts["retired"] != 0 {
+		t.Errorf("traffic split %v, want about half on v1 and v2 and none on the retired version", counts)
+	}
+
+	if _, err := registry.Select("unknown", Selector{}, ""); err == nil {
+		t.Error("Select() of an unknown prompt succeeded")
+	}
+}
+
+func TestLoadRejectsInvalidManifests(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"missing file": {
+			"manifest.json": {Data: []byte(`{"p": [{"version": "v1", "file": "p.tmpl", "weight": 1}]}`)},
+		},
+		"duplicate version": {
+			"manifest.json": {Data: []byte(`{"p": [{"version": "v1", "file": "p.tmpl"}, {"version": "v1", "file": "p.tmpl"}]}`)},
+			"p.tmpl":        {Data: []byte("x")},
+		},
+		"template syntax": {
+			"manifest.json": {Data: []byte(`{"p": [{"version": "v1", "file": "p.tmpl", "weight": 1}]}`)},
+			"p.tmpl":        {Data: []byte("{{.Name")},
+		},
+	}
+
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(fsys); err == nil {
+				t.Error("Load() succeeded")
+			}
+		})
+	}
+}
+
+func TestRegistryReloadKeepsValidTemplates(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(manifestFile, `{"p": [{"version": "v1", "file": "p.tmpl", "weight": 1}]}`)
+	write("p.tmpl", "first")
+
+	registry, err := NewRegistry(dir)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	write("p.tmpl", "{{broken")
+	if err := registry.Reload(); err == nil {
+		t.Fatal("Reload() of an invalid template succeeded")
+	}
+	write("p.tmpl", "second")
+	if err := registry.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	variant, _ := registry.Select("p", Selector{}, "")
+	if rendered, _ := variant.Render(nil); rendered != "second" {
+		t.Errorf("rendered %q after reload, want the new template", rendered)
+	}
+}
+
+func TestEmbeddedTemplates(t *testing.T) {
+	registry, err := NewRegistry("")
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	for _, name := range []string{GiftSuggestions, GiftSimilarity, GiftTranslation, EventDraft, DiscussionSummary} {
+		if _, err := registry.Select(name, Selector{Language: "en"}, ""); err != nil {
+			t.Errorf("no default template for %s: %v", name, err)
+		}
+	}
+	if variants := registry.Variants(); len(variants) == 0 || variants[0].Prompt != DiscussionSummary {
+		t.Errorf("Variants() = %+v", variants)
+	}
+}
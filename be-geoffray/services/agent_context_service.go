@@ -0,0 +1,202 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"be-geoffray/config"
+	"be-geoffray/db"
+	"be-geoffray/models"
+)
+
+const (
+	// agentContextTopSuggestions is how many of the best voted suggestions are described to the agent
+	agentContextTopSuggestions = 5
+	// agentSummarySnippetLength is the length kept of each omitted message in the summary of older turns
+	agentSummarySnippetLength = 80
+	// agentMinMessageTokens is the least a message costs in the context: 4 tokens of framing when kept,
+	// and at least 3 tokens for its line (such as "- user: ok") when quoted in the summary of older turns
+	agentMinMessageTokens = 3
+)
+
+// agentHistoryLimit returns how many recent messages can make it into a context of the given token budget
+// Older messages would be cut by fitConversationToBudget anyway, so they are not loaded
+func agentHistoryLimit(budget int) int {
+	return budget/agentMinMessageTokens + 1
+}
+
+// estimateTokens approximates the number of tokens of a text, about four characters per token
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// estimateMessageTokens approximates the tokens of a message, counting a few for its role and framing
+func estimateMessageTokens(message MistralMessage) int {
+	return estimateTokens(message.Content) + 4
+}
+
+// truncateToTokens cuts a text so that it fits in the given number of tokens
+func truncateToTokens(text string, tokens int) string {
+	if estimateTokens(text) <= tokens {
+		return text
+	}
+	runes := []rune(text)
+	limit := tokens * 4
+	if limit > len(runes) {
+		limit = len(runes)
+	}
+	if limit <= 3 {
+		return ""
+	}
+	return string(runes[:limit-3]) + "..."
+}
+
+// agentEventContext is what the agent is told about the event before the conversation
+type agentEventContext struct {
+	Event              models.Event
+	Participants       []Participant
+	PendingInvitations int
+	TopSuggestions     []models.GiftSuggestion
+}
+
+// buildAgentSystemPrompt describes the event to the agent
+func buildAgentSystemPrompt(ctx agentEventContext) string {
+	var b strings.Builder
+	b.WriteString("You are the assistant of a group organizing an event on Geoffray. Use the information below to answer, and the tools to act on the event.\n\n")
+
+	event := ctx.Event
+	fmt.Fprintf(&b, "Event: %s\n", event.Title)
+	if event.EndDate != nil && !event.EndDate.Equal(event.StartDate) {
+		fmt.Fprintf(&b, "Date: %s to %s\n", event.StartDate.Format("Monday 2 January 2006 15:04"), event.EndDate.Format("Monday 2 January 2006 15:04"))
+	} else {
+		fmt.Fprintf(&b, "Date: %s\n", event.StartDate.Format("Monday 2 January 2006 15:04"))
+	}
+	if event.Location != "" {
+		fmt.Fprintf(&b, "Location: %s\n", event.Location)
+	}
+	if event.EventOccasion != "" {
+		fmt.Fprintf(&b, "Occasion: %s\n", event.EventOccasion)
+	}
+	if event.GifteePersona != "" {
+		fmt.Fprintf(&b, "Gift recipient persona: %s\n", event.GifteePersona)
+	}
+	if event.Description != "" {
+		fmt.Fprintf(&b, "Description: %s\n", event.Description)
+	}
+
+	b.WriteString("\nGuests:\n")
+	b.WriteString(FormatRSVPSummary(ctx.Participants, ctx.PendingInvitations))
+	b.WriteString("\n\nTop gift suggestions:\n")
+	b.WriteString(FormatSuggestionsWithVotes(ctx.TopSuggestions))
+
+	return b.String()
+}
+
+// fitConversationToBudget keeps the most recent messages that fit in the token budget
+// Older messages are replaced by a short system note quoting the start of each, within a fraction of the budget.
+// The latest message is always kept, truncated if it alone exceeds the budget.
+func fitConversationToBudget(messages []MistralMessage, budget int) []MistralMessage {
+	if len(messages) == 0 {
+		return messages
+	}
+
+	summaryBudget := budget / 8
+	used := 0
+	start := len(messages)
+	for start > 0 {
+		cost := estimateMessageTokens(messages[start-1])
+		// Keep room for the summary whenever older messages will be dropped
+		reserve := 0
+		if start > 1 {
+			reserve = summaryBudget
+		}
+		if used+cost+reserve > budget && start < len(messages) {
+			break
+		}
+		used += cost
+		start--
+	}
+
+	kept := append([]MistralMessage{}, messages[start:]...)
+	if used > budget && len(kept) == 1 {
+		limit := budget - 4
+		if start > 0 {
+			limit -= summaryBudget
+		}
+		kept[0].Content = truncateToTokens(kept[0].Content, limit)
+	}
+	if start == 0 {
+		return kept
+	}
+
+	summary := summarizeOmittedMessages(messages[:start], summaryBudget)
+	if summary == "" {
+		return kept
+	}
+	return append([]MistralMessage{{Role: "system", Content: summary}}, kept...)
+}
+
+// summarizeOmittedMessages condenses older turns into a note quoting the start of the most recent ones
+func summarizeOmittedMessages(messages []MistralMessage, budget int) string {
+	header := fmt.Sprintf("%d earlier message(s) omitted, the latest began:", len(messages))
+	used := estimateTokens(header)
+	if used > budget {
+		return ""
+	}
+
+	lines := []string{}
+	for i := len(messages) - 1; i >= 0; i-- {
+		content := strings.Join(strings.Fields(messages[i].Content), " ")
+		if content == "" {
+			continue
+		}
+		line := fmt.Sprintf("- %s: %s", messages[i].Role, truncateToTokens(content, agentSummarySnippetLength/4))
+		if used+estimateTokens(line) > budget {
+			break
+		}
+		used += estimateTokens(line)
+		lines = append([]string{line}, lines...)
+	}
+
+	return header + "\n" + strings.Join(lines, "\n")
+}
+
+// BuildAgentMessages prepends a description of the event to the agent conversation and fits both in the token budget
+// The event description takes at most a third of the budget, the conversation the rest
+func BuildAgentMessages(eventID string, history []models.EventMessage) ([]MistralMessage, error) {
+	budget := config.GetConfig().AgentContextMaxTokens
+
+	ctx, err := loadAgentEventContext(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	systemPrompt := truncateToTokens(buildAgentSystemPrompt(*ctx), budget/3)
+	system := MistralMessage{Role: "system", Content: systemPrompt}
+
+	conversation := fitConversationToBudget(ConvertEventMessagesToMistralMessages(history), budget-estimateMessageTokens(system))
+	return append([]MistralMessage{system}, conversation...), nil
+}
+
+// loadAgentEventContext fetches the event, its guests and its best voted suggestions
+func loadAgentEventContext(eventID string) (*agentEventContext, error) {
+	event, participants, err := NewEventService().GetEventByID(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := &agentEventContext{Event: *event, Participants: participants}
+
+	err = db.DB.QueryRow(`SELECT COUNT(*) FROM event_invitations WHERE event_id = $1 AND status = 'pending'`, eventID).Scan(&ctx.PendingInvitations)
+	if err != nil {
+		return nil, fmt.Errorf("error counting invitations: %w", err)
+	}
+
+	ctx.TopSuggestions, err = getGiftSuggestionsByVotes(eventID, agentContextTopSuggestions)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx, nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"be-geoffray/models"
+)
+
+func TestFitConversationToBudget(t *testing.T) {
+	message := func(role string, length int) MistralMessage {
+		return MistralMessage{Role: role, Content: strings.Repeat("a", length)}
+	}
+
+	tests := []struct {
+		name        string
+		messages    []MistralMessage
+		budget      int
+		wantKept    int // Messages of the conversation kept, not counting the summary
+		wantSummary bool
+	}{
+		{"fits", []MistralMessage{message("user", 40), message("assistant", 40)}, 100, 2, false},
+		{"oldest dropped", []MistralMessage{message("user", 800), message("assistant", 400), message("user", 400)}, 300, 2, true},
+		{"latest alone too long", []MistralMessage{message("user", 40), message("user", 4000)}, 300, 1, true},
+		{"empty", nil, 100, 0, false},
+	}
+
+	for _, tt := range tests {
+		got := fitConversationToBudget(tt.messages, tt.budget)
+		hasSummary := len(got) > 0 && got[0].Role == "system"
+		kept := len(got)
+		if hasSummary {
+			kept--
+		}
+		if kept != tt.wantKept || hasSummary != tt.wantSummary {
+			t.Errorf("%s: kept %d (summary %v), want %d (summary %v)", tt.name, kept, hasSummary, tt.wantKept, tt.wantSummary)
+		}
+
+		total := 0
+		for _, m := range got {
+			total += estimateMessageTokens(m)
+		}
+		if total > tt.budget {
+			t.Errorf("%s: %d tokens, over the budget of %d", tt.name, total, tt.budget)
+		}
+		if len(tt.messages) > 0 && got[len(got)-1].Role != tt.messages[len(tt.messages)-1].Role {
+			t.Errorf("%s: latest message not kept", tt.name)
+		}
+	}
+}
+
+func TestAgentHistoryLimitKeepsWhatFitsInTheBudget(t *testing.T) {
+	const budget = 300
+	limit := agentHistoryLimit(budget)
+
+	// The shortest messages there are, far more of them than the limit
+	var messages []MistralMessage
+	for i := 0; i < limit*3; i++ {
+		messages = append(messages, MistralMessage{Role: "user", Content: "ok"})
+	}
+
+	full := fitConversationToBudget(messages, budget)
+	latest := fitConversationToBudget(messages[len(messages)-limit:], budget)
+	if len(full) != len(latest) {
+		t.Fatalf("kept %d messages from the latest %d, want the %d kept from all of them", len(latest), limit, len(full))
+	}
+	// Only the count of omitted messages in the summary header differs
+	for i := range full {
+		if i == 0 && full[0].Role == "system" {
+			fullLines := strings.SplitN(full[0].Content, "\n", 2)
+			latestLines := strings.SplitN(latest[0].Content, "\n", 2)
+			if len(fullLines) != len(latestLines) || fullLines[len(fullLines)-1] != latestLines[len(latestLines)-1] {
+				t.Errorf("summary %q, want the quotes of %q", latest[0].Content, full[0].Content)
+			}
+			continue
+		}
+		if full[i].Role != latest[i].Role || full[i].Content != latest[i].Content {
+			t.Errorf("message %d = %+v, want %+v", i, latest[i], full[i])
+		}
+	}
+}
+
+func TestBuildAgentSystemPrompt(t *testing.T) {
+	prompt := buildAgentSystemPrompt(agentEventContext{
+		Event: models.Event{
+			Title:         "Camille's 30th",
+			StartDate:     time.Date(2026, 6, 13, 19, 0, 0, 0, time.UTC),
+			Location:      "Lyon",
+			EventOccasion: "birthday",
+			GifteePersona: "the_foodie",
+		},
+		Participants:   []Participant{{FirstName: "Ada", Status: "accepted"}},
+		TopSuggestions: []models.GiftSuggestion{{NameEN: "Cooking class", UpvoteCount: 3}},
+	})
+
+	for _, want := range []string{"Camille's 30th", "Saturday 13 June 2026 19:00", "Lyon", "birthday", "the_foodie", "accepted: 1 (Ada)", "Cooking class"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("system prompt does not contain %q:\n%s", want, prompt)
+		}
+	}
+}
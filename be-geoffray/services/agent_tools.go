@@ -0,0 +1,342 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"be-geoffray/db"
+	"be-geoffray/models"
+
+	"github.com/google/uuid"
+)
+
+// maxAgentToolIterations caps the number of completions that may call tools for one agent reply
+const maxAgentToolIterations = 5
+
+// agentToolContext is what a tool knows about the call: the event of the discussion and the user who asked the agent
+type agentToolContext struct {
+	EventID string
+	UserID  string
+}
+
+// agentTool is an action the agent may take on behalf of the user who asked it
+type agentTool struct {
+	Schema FunctionCallSchema
+	Action EventAction // What the calling user must be allowed to do on the event
+	Run    func(ctx agentToolContext, args map[string]interface{}) (string, error)
+}
+
+// agentTools is the registry of the tools exposed to the agent, by name
+var agentTools = map[string]agentTool{}
+
+// agentToolOrder keeps the schemas sent to Mistral in a stable order
+var agentToolOrder []string
+
+func registerAgentTool(tool agentTool) {
+	agentTools[tool.Schema.Function.Name] = tool
+	agentToolOrder = append(agentToolOrder, tool.Schema.Function.Name)
+}
+
+func init() {
+	registerAgentTool(agentTool{
+		Schema: newFunctionSchema("list_gift_suggestions",
+			"List the gift suggestions of the event with their upvotes and downvotes, best voted first.",
+			map[string]interface{}{}),
+		Action: EventActionView,
+		Run:    runListGiftSuggestions,
+	})
+	registerAgentTool(agentTool{
+		Schema: newFunctionSchema("add_gift_suggestion",
+			"Add a gift suggestion to the event on behalf of the user who asked.",
+			map[string]interface{}{
+				"name":        stringProperty("Short name of the gift"),
+				"description": stringProperty("One or two sentences describing the gift"),
+				"language":    stringProperty("Language code of the name and description, e.g. \"en\" or \"fr\""),
+				"price_range": stringProperty("Approximate price in euros, e.g. \"€20-40\""),
+				"category": map[string]interface{}{
+					"type":        "string",
+					"description": "Gift category",
+					"enum":        GiftCategories,
+				},
+			}, "name", "price_range", "category"),
+		Action: EventActionParticipate,
+		Run:    runAddGiftSuggestion,
+	})
+	registerAgentTool(agentTool{
+		Schema: newFunctionSchema("summarize_rsvps",
+			"Summarize who accepted, declined or has not answered the invitation to the event.",
+			map[string]interface{}{}),
+		Action: EventActionView,
+		Run:    runSummarizeRSVPs,
+	})
+	registerAgentTool(agentTool{
+		Schema: newFunctionSchema("propose_date_poll",
+			"Create a poll where guests vote for the dates that work for them.",
+			map[string]interface{}{
+				"question": stringProperty("Question shown above the dates"),
+				"dates": map[string]interface{}{
+					"type":        "array",
+					"description": fmt.Sprintf("Between %d and %d candidate dates, as YYYY-MM-DD or RFC 3339 date-times", minDatePollOptions, maxDatePollOptions),
+					"items":       map[string]interface{}{"type": "string"},
+				},
+			}, "dates"),
+		Action: EventActionParticipate,
+		Run:    runProposeDatePoll,
+	})
+	registerAgentTool(agentTool{
+		Schema: newFunctionSchema("update_event_description",
+			"Replace the description of the event. Only the organizer may do this.",
+			map[string]interface{}{
+				"description": stringProperty("The new description of the event"),
+			}, "description"),
+		Action: EventActionManage,
+		Run:    runUpdateEventDescription,
+	})
+}
+
+// AgentToolSchemas returns the schemas of the agent tools
+func AgentToolSchemas() []FunctionCallSchema {
+	schemas := make([]FunctionCallSchema, 0, len(agentToolOrder))
+	for _, name := range agentToolOrder {
+		schemas = append(schemas, agentTools[name].Schema)
+	}
+	return schemas
+}
+
+// RunAgentWithTools asks the model for a reply to the discussion, executing the tools it calls as the given user
+// Tool results are fed back until the model answers with text or maxAgentToolIterations is reached
+func RunAgentWithTools(eventID, userID string, messages []MistralMessage) (string, error) {
+	client := GetLLMClient()
+	ctx := agentToolContext{EventID: eventID, UserID: userID}
+	llmCtx := WithLLMUsageScope(context.Background(), LLMUsageScope{UserID: userID, EventID: eventID, Purpose: LLMPurposeAgent})
+
+	relationship, err := ResolveEventRelationship(eventID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	tools := AgentToolSchemas()
+	for iteration := 0; iteration <= maxAgentToolIterations; iteration++ {
+		// The last completion gets no tools so that the model has to answer
+		if iteration == maxAgentToolIterations {
+			tools = nil
+		}
+
+		response, err := client.ChatWithTools(llmCtx, LLMRequest{Messages: messages, MaxTokens: 2000}, tools)
+		if err != nil {
+			return "", fmt.Errorf("error from LLM provider: %w", err)
+		}
+
+		reply := response.Message
+		if len(reply.ToolCalls) == 0 || tools == nil {
+			return reply.Content, nil
+		}
+
+		messages = append(messages, reply)
+		for _, call := range reply.ToolCalls {
+			messages = append(messages, MistralMessage{
+				Role:       "tool",
+				Name:       call.Function.Name,
+				ToolCallID: call.ID,
+				Content:    executeAgentToolCall(ctx, relationship, call),
+			})
+		}
+	}
+
+	return "", errors.New("agent did not produce a reply")
+}
+
+// executeAgentToolCall runs a tool call and returns the content of the tool message answering it
+// Failures are reported to the agent rather than aborting the reply, so it can explain them to the user
+func executeAgentToolCall(ctx agentToolContext, relationship EventRelationship, call MistralToolCall) string {
+	tool, ok := agentTools[call.Function.Name]
+	if !ok {
+		return agentToolError("unknown tool " + call.Function.Name)
+	}
+	if !relationship.Can(tool.Action) {
+		return agentToolError("the user who asked is not allowed to do this on the event")
+	}
+
+	args := map[string]interface{}{}
+	if strings.TrimSpace(call.Function.Arguments) != "" {
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+			return agentToolError("invalid arguments: " + err.Error())
+		}
+	}
+
+	result, err := tool.Run(ctx, args)
+	if err != nil {
+		log.Printf("Agent tool %s failed on event %s: %v", call.Function.Name, ctx.EventID, err)
+		return agentToolError(err.Error())
+	}
+	return result
+}
+
+func agentToolError(message string) string {
+	data, _ := json.Marshal(map[string]string{"error": message})
+	return string(data)
+}
+
+// stringArgument returns a trimmed string argument, or "" when it is missing or not a string
+func stringArgument(args map[string]interface{}, name string) string {
+	value, _ := args[name].(string)
+	return strings.TrimSpace(value)
+}
+
+func runListGiftSuggestions(ctx agentToolContext, _ map[string]interface{}) (string, error) {
+	suggestions, err := getGiftSuggestionsByVotes(ctx.EventID, 0)
+	if err != nil {
+		return "", err
+	}
+	return FormatSuggestionsWithVotes(suggestions), nil
+}
+
+// getGiftSuggestionsByVotes returns the names, prices and votes of the suggestions of an event, best voted first
+// A limit of 0 returns them all
+func getGiftSuggestionsByVotes(eventID string, limit int) ([]models.GiftSuggestion, error) {
+	query := `
+		SELECT gs.name_en, gs.name_fr, gs.price_range,
+			COUNT(*) FILTER (WHERE v.vote_type = 'upvote'),
+			COUNT(*) FILTER (WHERE v.vote_type = 'downvote')
+		FROM gift_suggestions gs
+		LEFT JOIN gift_suggestion_votes v ON v.suggestion_id = gs.id
+		WHERE gs.event_id = $1
+		GROUP BY gs.id
+		ORDER BY COUNT(*) FILTER (WHERE v.vote_type = 'upvote') - COUNT(*) FILTER (WHERE v.vote_type = 'downvote') DESC,
+			gs.created_at DESC`
+	args := []interface{}{eventID}
+	if limit > 0 {
+		query += ` LIMIT $2`
+		args = append(args, limit)
+	}
+
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching gift suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []models.GiftSuggestion{}
+	for rows.Next() {
+		var s models.GiftSuggestion
+		if err := rows.Scan(&s.NameEN, &s.NameFR, &s.PriceRange, &s.UpvoteCount, &s.DownvoteCount); err != nil {
+			return nil, fmt.Errorf("error scanning gift suggestion: %w", err)
+		}
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, rows.Err()
+}
+
+// newAgentGiftSuggestion validates the arguments of add_gift_suggestion like generated suggestions
+func newAgentGiftSuggestion(ctx agentToolContext, args map[string]interface{}) (models.GiftSuggestion, error) {
+	suggestion, problems := validateGiftSuggestion(MistralGiftSuggestion{
+		Name:        stringArgument(args, "name"),
+		Description: stringArgument(args, "description"),
+		PriceRange:  stringArgument(args, "price_range"),
+		Category:    stringArgument(args, "category"),
+	}, generationLanguage(stringArgument(args, "language")))
+	if len(problems) > 0 {
+		return models.GiftSuggestion{}, errors.New(strings.Join(problems, "; "))
+	}
+
+	suggestion.ID = uuid.NewString()
+	suggestion.EventID = ctx.EventID
+	suggestion.OwnerID = ctx.UserID
+	suggestion.CreationMode = "ai"
+	return suggestion, nil
+}
+
+func runAddGiftSuggestion(ctx agentToolContext, args map[string]interface{}) (string, error) {
+	suggestion, err := newAgentGiftSuggestion(ctx, args)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = db.DB.Exec(`
+		INSERT INTO gift_suggestions (
+			id, event_id, owner_id, name_en, name_fr, description_en, description_fr,
+			price_range, category, creation_mode, generated_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		suggestion.ID, suggestion.EventID, suggestion.OwnerID, suggestion.NameEN, suggestion.NameFR,
+		suggestion.DescriptionEN, suggestion.DescriptionFR, suggestion.PriceRange, suggestion.Category,
+		suggestion.CreationMode, suggestion.GeneratedAt, suggestion.CreatedAt, suggestion.UpdatedAt)
+	if err != nil {
+		return "", fmt.Errorf("error creating gift suggestion: %w", err)
+	}
+	if err := SaveGiftSuggestionTranslations(&suggestion); err != nil {
+		return "", err
+	}
+
+	EmitLifecycleEvent(LifecycleSuggestionCreated, suggestion.EventID, suggestion.OwnerID, suggestion)
+
+	return fmt.Sprintf("Added the gift suggestion %q at %s.", LocalizedGiftText(suggestion, suggestion.Language).Name, suggestion.PriceRange), nil
+}
+
+func runSummarizeRSVPs(ctx agentToolContext, _ map[string]interface{}) (string, error) {
+	_, participants, err := NewEventService().GetEventByID(ctx.EventID)
+	if err != nil {
+		return "", err
+	}
+
+	var pendingInvitations int
+	err = db.DB.QueryRow(`SELECT COUNT(*) FROM event_invitations WHERE event_id = $1 AND status = 'pending'`, ctx.EventID).Scan(&pendingInvitations)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("error counting invitations: %w", err)
+	}
+
+	return FormatRSVPSummary(participants, pendingInvitations), nil
+}
+
+func runProposeDatePoll(ctx agentToolContext, args map[string]interface{}) (string, error) {
+	rawDates, _ := args["dates"].([]interface{})
+	dates := make([]time.Time, 0, len(rawDates))
+	for _, raw := range rawDates {
+		value, _ := raw.(string)
+		date, err := parseAgentDate(value)
+		if err != nil {
+			return "", err
+		}
+		dates = append(dates, date)
+	}
+
+	poll, err := NewDatePollService().CreateDatePoll(ctx.EventID, ctx.UserID, stringArgument(args, "question"), dates)
+	if err != nil {
+		return "", err
+	}
+
+	labels := make([]string, 0, len(poll.Options))
+	for _, option := range poll.Options {
+		labels = append(labels, option.StartsAt.Format("Mon 2 Jan 2006 15:04"))
+	}
+	return fmt.Sprintf("Created the poll %q with the dates: %s.", poll.Question, strings.Join(labels, ", ")), nil
+}
+
+// parseAgentDate accepts the date formats the agent is told about
+func parseAgentDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date, nil
+	}
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+func runUpdateEventDescription(ctx agentToolContext, args map[string]interface{}) (string, error) {
+	description, ok := args["description"].(string)
+	if !ok {
+		return "", errors.New("description is required")
+	}
+
+	if _, err := NewEventService().UpdateEvent(ctx.EventID, ctx.UserID, map[string]interface{}{"description": description}); err != nil {
+		return "", err
+	}
+	return "Updated the event description.", nil
+}
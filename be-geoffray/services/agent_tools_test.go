@@ -0,0 +1,129 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecuteAgentToolCallChecksPermissions(t *testing.T) {
+	ctx := agentToolContext{EventID: "e1", UserID: "u1"}
+	tests := []struct {
+		tool         string
+		relationship EventRelationship
+		wantContains string
+	}{
+		{"update_event_description", EventRelationshipParticipant, "not allowed"},
+		{"add_gift_suggestion", EventRelationshipInvited, "not allowed"},
+		{"propose_date_poll", EventRelationshipInvited, "not allowed"},
+		{"list_gift_suggestions", EventRelationshipNone, "not allowed"},
+		{"delete_event", EventRelationshipOwner, "unknown tool"},
+	}
+
+	for _, tt := range tests {
+		var call MistralToolCall
+		call.Function.Name = tt.tool
+		call.Function.Arguments = `{"description":"x","name":"x"}`
+		if got := executeAgentToolCall(ctx, tt.relationship, call); !strings.Contains(got, tt.wantContains) {
+			t.Errorf("%s as %s = %s, want it to contain %q", tt.tool, tt.relationship, got, tt.wantContains)
+		}
+	}
+}
+
+func TestAgentToolSchemas(t *testing.T) {
+	schemas := AgentToolSchemas()
+	if len(schemas) != len(agentTools) {
+		t.Fatalf("got %d schemas, want %d", len(schemas), len(agentTools))
+	}
+	for _, s := range schemas {
+		if s.Type != "function" || s.Function.Parameters["type"] != "object" {
+			t.Errorf("schema %s is not a function taking an object", s.Function.Name)
+		}
+	}
+}
+
+func TestNewAgentGiftSuggestionValidatesArguments(t *testing.T) {
+	ctx := agentToolContext{EventID: "e1", UserID: "u1"}
+
+	suggestion, err := newAgentGiftSuggestion(ctx, map[string]interface{}{
+		"name":        "Livre de cuisine",
+		"description": "Des recettes de saison",
+		"language":    "fr",
+		"price_range": "20 - 40 €",
+		"category":    "books",
+	})
+	if err != nil {
+		t.Fatalf("newAgentGiftSuggestion() error = %v", err)
+	}
+	if suggestion.PriceRange != "€20-40" || suggestion.Category != "Books" {
+		t.Errorf("price and category = %q, %q, want €20-40, Books", suggestion.PriceRange, suggestion.Category)
+	}
+	if suggestion.Language != "fr" || suggestion.Translations["fr"].Name != "Livre de cuisine" {
+		t.Errorf("language and translations = %q, %v, want the French text", suggestion.Language, suggestion.Translations)
+	}
+	if suggestion.EventID != "e1" || suggestion.OwnerID != "u1" || suggestion.CreationMode != "ai" {
+		t.Errorf("suggestion = %+v, want it owned by u1 on e1 in ai mode", suggestion)
+	}
+
+	tests := []struct {
+		name         string
+		args         map[string]interface{}
+		wantContains string
+	}{
+		{"dollars", map[string]interface{}{"name": "Book", "price_range": "$20-$40", "category": "Books"}, "not a price in euros"},
+		{"unknown category", map[string]interface{}{"name": "Book", "price_range": "€20", "category": "Stuff"}, "category"},
+		{"no name", map[string]interface{}{"price_range": "€20", "category": "Books"}, "name is empty"},
+	}
+	for _, tt := range tests {
+		if _, err := newAgentGiftSuggestion(ctx, tt.args); err == nil || !strings.Contains(err.Error(), tt.wantContains) {
+			t.Errorf("%s: error = %v, want it to contain %q", tt.name, err, tt.wantContains)
+		}
+	}
+}
+
+func TestNormalizeDatePollOptions(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 6, d, 18, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name    string
+		options []time.Time
+		want    []time.Time
+		wantErr bool
+	}{
+		{"sorted", []time.Time{day(3), day(1)}, []time.Time{day(1), day(3)}, false},
+		{"duplicates dropped", []time.Time{day(1), day(1), day(2)}, []time.Time{day(1), day(2)}, false},
+		{"too few after dedup", []time.Time{day(1), day(1)}, nil, true},
+		{"too many", []time.Time{day(1), day(2), day(3), day(4), day(5), day(6), day(7), day(8), day(9), day(10), day(11)}, nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeDatePollOptions(tt.options)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !got[i].Equal(tt.want[i]) {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestFormatRSVPSummary(t *testing.T) {
+	participants := []Participant{
+		{FirstName: "Ada", LastName: "Lovelace", Status: "going"},
+		{FirstName: "Alan", LastName: "Turing", Status: "declined"},
+		{FirstName: "Grace", Status: "pending"},
+	}
+
+	got := FormatRSVPSummary(participants, 2)
+	for _, want := range []string{"accepted: 1 (Ada Lovelace)", "declined: 1 (Alan Turing)", "pending: 1 (Grace)", "by email: 2"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary %q does not contain %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,451 @@
+package services
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // Registers the GIF decoder used by image.Decode
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"be-geoffray/db"
+	"be-geoffray/models"
+	"be-geoffray/storage"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	// maxAttachmentsPerMessage caps the number of files attached to a single message
+	maxAttachmentsPerMessage = 10
+	// maxThumbnailSourcePixels skips thumbnails for huge images, whose decoding would use too much memory
+	maxThumbnailSourcePixels = 40_000_000
+	// unlinkedAttachmentTTL is how long an upload can wait for its message before being cleaned up
+	unlinkedAttachmentTTL = 24 * time.Hour
+)
+
+// allowedAttachmentTypes lists the sniffed content types accepted as attachments
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+// AttachmentService handles files attached to event messages
+type AttachmentService struct {
+	storage storage.Storage
+}
+
+// NewAttachmentService creates a new instance of AttachmentService
+func NewAttachmentService() *AttachmentService {
+	return &AttachmentService{storage: storage.GetStorage()}
+}
+
+// SniffAttachmentType detects the content type of a file from its content, ignoring what the client claims
+// It returns an error when the type is not allowed as an attachment
+func SniffAttachmentType(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	// DetectContentType may add parameters such as "; charset=utf-8"
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = strings.TrimSpace(contentType[:i])
+	}
+	if !allowedAttachmentTypes[contentType] {
+		return "", errors.New("unsupported file type")
+	}
+	return contentType, nil
+}
+
+// sanitizeFileName keeps the base name of an uploaded file, bounded to a reasonable length
+func sanitizeFileName(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	for utf8.RuneCountInString(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// Upload stores a new attachment for an event, not yet linked to a message
+func (s *AttachmentService) Upload(eventID, uploaderID, fileName string, r io.Reader, maxSize int64) (*models.Attachment, error) {
+	// Read one byte more than allowed to detect oversized files without trusting Content-Length
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading upload: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, errors.New("file too large")
+	}
+	if len(data) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	contentType, err := SniffAttachmentType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := models.Attachment{
+		ID:          uuid.NewString(),
+		EventID:     eventID,
+		UploaderID:  &uploaderID,
+		FileName:    sanitizeFileName(fileName),
+		ContentType: contentType,
+		SizeBytes:   int64(len(data)),
+	}
+	attachment.StorageKey = fmt.Sprintf("attachments/%s/%s", eventID, attachment.ID)
+
+	if err := s.storage.Put(attachment.StorageKey, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("error storing attachment: %w", err)
+	}
+
+	if strings.HasPrefix(contentType, "image/") {
+		s.addImageDetails(&attachment, data)
+	}
+
+	query := `
+		INSERT INTO message_attachments (
+			id, event_id, uploader_id, file_name, content_type, size_bytes, storage_key, thumbnail_key, width, height, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
+		RETURNING created_at
+	`
+	err = db.DB.QueryRow(query,
+		attachment.ID, attachment.EventID, attachment.UploaderID, attachment.FileName, attachment.ContentType,
+		attachment.SizeBytes, attachment.StorageKey, attachment.ThumbnailKey, attachment.Width, attachment.Height,
+	).Scan(&attachment.CreatedAt)
+	if err != nil {
+		s.deleteFiles(attachment.StorageKey, attachment.ThumbnailKey)
+		return nil, fmt.Errorf("error saving attachment: %w", err)
+	}
+
+	setAttachmentURLs(&attachment)
+	return &attachment, nil
+}
+
+// addImageDetails records the dimensions of an image and stores its thumbnail
+// Failures are logged: the attachment is still usable without a thumbnail
+func (s *AttachmentService) addImageDetails(attachment *models.Attachment, data []byte) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		// Formats without a standard library decoder (e.g. WebP) get no dimensions nor thumbnail
+		return
+	}
+	attachment.Width = &config.Width
+	attachment.Height = &config.Height
+
+	if config.Width*config.Height > maxThumbnailSourcePixels {
+		return
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		log.Printf("Error decoding image %s: %v", attachment.ID, err)
+		return
+	}
+
+	// JPEG thumbnails for photos, PNG to keep transparency for the other formats
+	var thumbnail bytes.Buffer
+	thumbnailKey := attachment.StorageKey + "_thumb"
+	scaled := ScaleImage(img, thumbnailMaxSize)
+	if format == "jpeg" {
+		thumbnailKey += ".jpg"
+		err = jpeg.Encode(&thumbnail, scaled, &jpeg.Options{Quality: 80})
+	} else {
+		thumbnailKey += ".png"
+		err = png.Encode(&thumbnail, scaled)
+	}
+	if err != nil {
+		log.Printf("Error encoding thumbnail of %s: %v", attachment.ID, err)
+		return
+	}
+
+	if err := s.storage.Put(thumbnailKey, &thumbnail); err != nil {
+		log.Printf("Error storing thumbnail of %s: %v", attachment.ID, err)
+		return
+	}
+	attachment.ThumbnailKey = &thumbnailKey
+}
+
+// GetAttachment returns an attachment of an event
+func (s *AttachmentService) GetAttachment(eventID, attachmentID string) (*models.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM message_attachments WHERE id = $1 AND event_id = $2`
+	attachment, err := scanAttachment(db.DB.QueryRow(query, attachmentID, eventID))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("attachment not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching attachment: %w", err)
+	}
+	return attachment, nil
+}
+
+// Open opens the content of an attachment, or of its thumbnail, and returns its content type
+func (s *AttachmentService) Open(attachment *models.Attachment, thumbnail bool) (io.ReadCloser, string, error) {
+	key, contentType := attachment.StorageKey, attachment.ContentType
+	if thumbnail {
+		if attachment.ThumbnailKey == nil {
+			return nil, "", errors.New("thumbnail not found")
+		}
+		key, contentType = *attachment.ThumbnailKey, "image/png"
+		if strings.HasSuffix(key, ".jpg") {
+			contentType = "image/jpeg"
+		}
+	}
+
+	reader, err := s.storage.Get(key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, "", errors.New("attachment not found")
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("error opening attachment: %w", err)
+	}
+	return reader, contentType, nil
+}
+
+// CheckAttachmentsAvailable verifies that attachments were uploaded by the user to the event and are not linked yet
+func (s *AttachmentService) CheckAttachmentsAvailable(eventID, uploaderID string, attachmentIDs []string) error {
+	if len(attachmentIDs) == 0 {
+		return nil
+	}
+	if len(attachmentIDs) > maxAttachmentsPerMessage {
+		return fmt.Errorf("too many attachments (maximum %d)", maxAttachmentsPerMessage)
+	}
+
+	var available int
+	err := db.DB.QueryRow(`
+		SELECT COUNT(DISTINCT id) FROM message_attachments
+		WHERE id::text = ANY($1) AND event_id = $2 AND uploader_id = $3 AND message_id IS NULL`,
+		pq.Array(attachmentIDs), eventID, uploaderID).Scan(&available)
+	if err != nil {
+		return fmt.Errorf("error checking attachments: %w", err)
+	}
+	if available != len(attachmentIDs) {
+		return errors.New("invalid attachments")
+	}
+	return nil
+}
+
+// linkMessageAttachments attaches uploaded files to a message within the transaction creating it
+// It fails if any of the attachments was linked in the meantime, so that the message is not created half-attached
+func linkMessageAttachments(tx *sql.Tx, eventID, messageID, uploaderID string, attachmentIDs []string) ([]models.Attachment, error) {
+	attachments := []models.Attachment{}
+	if len(attachmentIDs) == 0 {
+		return attachments, nil
+	}
+
+	rows, err := tx.Query(`
+		UPDATE message_attachments SET message_id = $1
+		WHERE id::text = ANY($2) AND event_id = $3 AND uploader_id = $4 AND message_id IS NULL
+		RETURNING `+attachmentColumns,
+		messageID, pq.Array(attachmentIDs), eventID, uploaderID)
+	if err != nil {
+		return nil, fmt.Errorf("error linking attachments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning attachment: %w", err)
+		}
+		attachments = append(attachments, *attachment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error linking attachments: %w", err)
+	}
+
+	if len(attachments) != len(attachmentIDs) {
+		return nil, errors.New("invalid attachments")
+	}
+	return attachments, nil
+}
+
+// DeleteMessageAttachments removes the attachments of a message and their files
+func (s *AttachmentService) DeleteMessageAttachments(messageID string) error {
+	rows, err := db.DB.Query(`DELETE FROM message_attachments WHERE message_id = $1 RETURNING storage_key, thumbnail_key`, messageID)
+	if err != nil {
+		return fmt.Errorf("error deleting attachments: %w", err)
+	}
+	s.deleteReturnedFiles(rows)
+	return nil
+}
+
+// GetEventAttachmentKeys returns the storage keys of all the files of an event
+// Rows are removed by the event's cascade, so files must be collected before the event is deleted
+func (s *AttachmentService) GetEventAttachmentKeys(eventID string) ([]string, error) {
+	rows, err := db.DB.Query(`SELECT storage_key, thumbnail_key FROM message_attachments WHERE event_id = $1`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var storageKey string
+		var thumbnailKey sql.NullString
+		if err := rows.Scan(&storageKey, &thumbnailKey); err != nil {
+			return nil, fmt.Errorf("error scanning attachment: %w", err)
+		}
+		keys = append(keys, storageKey)
+		if thumbnailKey.Valid {
+			keys = append(keys, thumbnailKey.String)
+		}
+	}
+	return keys, rows.Err()
+}
+
+// DeleteFiles removes stored files, logging failures
+func (s *AttachmentService) DeleteFiles(keys []string) {
+	for _, key := range keys {
+		if err := s.storage.Delete(key); err != nil {
+			log.Printf("Error deleting stored file %s: %v", key, err)
+		}
+	}
+}
+
+// StartCleanupWorker periodically removes uploads that were never attached to a message
+func (s *AttachmentService) StartCleanupWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			rows, err := db.DB.Query(`
+				DELETE FROM message_attachments
+				WHERE message_id IS NULL AND created_at < $1
+				RETURNING storage_key, thumbnail_key`, time.Now().Add(-unlinkedAttachmentTTL))
+			if err != nil {
+				log.Printf("Error cleaning up unlinked attachments: %v", err)
+				continue
+			}
+			s.deleteReturnedFiles(rows)
+		}
+	}()
+}
+
+// deleteReturnedFiles deletes the files of rows returning (storage_key, thumbnail_key)
+func (s *AttachmentService) deleteReturnedFiles(rows *sql.Rows) {
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var storageKey string
+		var thumbnailKey sql.NullString
+		if err := rows.Scan(&storageKey, &thumbnailKey); err != nil {
+			log.Printf("Error scanning deleted attachment: %v", err)
+			continue
+		}
+		keys = append(keys, storageKey)
+		if thumbnailKey.Valid {
+			keys = append(keys, thumbnailKey.String)
+		}
+	}
+	s.DeleteFiles(keys)
+}
+
+// deleteFiles removes the files of an attachment that could not be saved
+func (s *AttachmentService) deleteFiles(storageKey string, thumbnailKey *string) {
+	keys := []string{storageKey}
+	if thumbnailKey != nil {
+		keys = append(keys, *thumbnailKey)
+	}
+	s.DeleteFiles(keys)
+}
+
+// attachmentColumns lists the columns scanned by scanAttachment
+const attachmentColumns = `id, event_id, message_id, uploader_id, file_name, content_type, size_bytes,
+	storage_key, thumbnail_key, width, height, created_at`
+
+// scanAttachment scans a row selected with attachmentColumns
+func scanAttachment(row rowScanner) (*models.Attachment, error) {
+	var attachment models.Attachment
+	var messageID, uploaderID, thumbnailKey sql.NullString
+	var width, height sql.NullInt64
+
+	err := row.Scan(&attachment.ID, &attachment.EventID, &messageID, &uploaderID, &attachment.FileName,
+		&attachment.ContentType, &attachment.SizeBytes, &attachment.StorageKey, &thumbnailKey,
+		&width, &height, &attachment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if messageID.Valid {
+		attachment.MessageID = &messageID.String
+	}
+	if uploaderID.Valid {
+		attachment.UploaderID = &uploaderID.String
+	}
+	if thumbnailKey.Valid {
+		attachment.ThumbnailKey = &thumbnailKey.String
+	}
+	if width.Valid && height.Valid {
+		w, h := int(width.Int64), int(height.Int64)
+		attachment.Width, attachment.Height = &w, &h
+	}
+
+	setAttachmentURLs(&attachment)
+	return &attachment, nil
+}
+
+// setAttachmentURLs sets the URLs at which participants can download an attachment
+func setAttachmentURLs(attachment *models.Attachment) {
+	attachment.URL = fmt.Sprintf("/events/%s/attachments/%s", attachment.EventID, attachment.ID)
+	if attachment.ThumbnailKey != nil {
+		thumbnailURL := attachment.URL + "?thumbnail=true"
+		attachment.ThumbnailURL = &thumbnailURL
+	}
+}
+
+// attachMessageAttachments fills the attachments of the given messages
+func attachMessageAttachments(messages []models.EventMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	messageIDs := make([]string, len(messages))
+	for i := range messages {
+		messageIDs[i] = messages[i].ID
+		messages[i].Attachments = []models.Attachment{}
+	}
+
+	rows, err := db.DB.Query(`SELECT `+attachmentColumns+`
+		FROM message_attachments
+		WHERE message_id::text = ANY($1)
+		ORDER BY created_at`, pq.Array(messageIDs))
+	if err != nil {
+		return fmt.Errorf("error fetching attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := make(map[string][]models.Attachment)
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return fmt.Errorf("error scanning attachment: %w", err)
+		}
+		attachments[*attachment.MessageID] = append(attachments[*attachment.MessageID], *attachment)
+	}
+
+	for i := range messages {
+		if messageAttachments, ok := attachments[messages[i].ID]; ok {
+			messages[i].Attachments = messageAttachments
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestSniffAttachmentType(t *testing.T) {
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{"png", pngData.Bytes(), "image/png", false},
+		{"pdf", []byte("%PDF-1.4\n%âãÏÓ\n"), "application/pdf", false},
+		{"text", []byte("shopping list"), "text/plain", false},
+		{"html", []byte("<html><script>alert(1)</script></html>"), "", true},
+		{"zip", []byte("PK\x03\x04rest of the archive"), "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := SniffAttachmentType(tt.data)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: SniffAttachmentType() = %q, %v, want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestScaleImage(t *testing.T) {
+	tests := []struct {
+		width, height         int
+		wantWidth, wantHeight int
+	}{
+		{100, 50, 100, 50},
+		{1000, 500, 320, 160},
+		{500, 1000, 160, 320},
+		{4000, 1, 320, 1},
+	}
+
+	for _, tt := range tests {
+		scaled := ScaleImage(image.NewRGBA(image.Rect(0, 0, tt.width, tt.height)), thumbnailMaxSize)
+		if got := scaled.Bounds(); got.Dx() != tt.wantWidth || got.Dy() != tt.wantHeight {
+			t.Errorf("ScaleImage(%dx%d) = %dx%d, want %dx%d", tt.width, tt.height, got.Dx(), got.Dy(), tt.wantWidth, tt.wantHeight)
+		}
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := map[string]string{
+		"photo.jpg":            "photo.jpg",
+		"../../etc/passwd":     "passwd",
+		"C:\\Users\\me\\a.pdf": "a.pdf",
+		"  ":                   "file",
+		"dir/":                 "dir",
+	}
+
+	for name, want := range tests {
+		if got := sanitizeFileName(name); got != want {
+			t.Errorf("sanitizeFileName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
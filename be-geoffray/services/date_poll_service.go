@@ -0,0 +1,194 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"be-geoffray/db"
+	"be-geoffray/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	minDatePollOptions = 2
+	maxDatePollOptions = 10
+)
+
+// DatePollService handles the date polls of events
+type DatePollService struct{}
+
+// NewDatePollService creates a new instance of DatePollService
+func NewDatePollService() *DatePollService {
+	return &DatePollService{}
+}
+
+// normalizeDatePollOptions sorts candidate dates and drops duplicates
+func normalizeDatePollOptions(options []time.Time) ([]time.Time, error) {
+	seen := make(map[int64]bool)
+	normalized := []time.Time{}
+	for _, option := range options {
+		option = option.UTC().Truncate(time.Minute)
+		if seen[option.Unix()] {
+			continue
+		}
+		seen[option.Unix()] = true
+		normalized = append(normalized, option)
+	}
+
+	if len(normalized) < minDatePollOptions || len(normalized) > maxDatePollOptions {
+		return nil, fmt.Errorf("a date poll needs between %d and %d different dates", minDatePollOptions, maxDatePollOptions)
+	}
+
+	sort.Slice(normalized, func(i, j int) bool { return normalized[i].Before(normalized[j]) })
+	return normalized, nil
+}
+
+// CreateDatePoll creates a poll on the candidate dates of an event
+func (s *DatePollService) CreateDatePoll(eventID, userID, question string, options []time.Time) (*models.DatePoll, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		question = "Which dates work for you?"
+	}
+
+	dates, err := normalizeDatePollOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	poll := models.DatePoll{EventID: eventID, CreatedBy: &userID, Question: question, Options: []models.DatePollOption{}}
+	err = tx.QueryRow(`
+		INSERT INTO event_date_polls (event_id, created_by, question, created_at)
+		VALUES ($1, $2, $3, NOW())
+		RETURNING id, created_at`, eventID, userID, question).Scan(&poll.ID, &poll.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error creating date poll: %w", err)
+	}
+
+	for _, date := range dates {
+		option := models.DatePollOption{StartsAt: date}
+		err := tx.QueryRow(`
+			INSERT INTO event_date_poll_options (poll_id, starts_at) VALUES ($1, $2) RETURNING id`,
+			poll.ID, date).Scan(&option.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error creating date poll option: %w", err)
+		}
+		poll.Options = append(poll.Options, option)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	EmitLifecycleEvent(LifecyclePollCreated, eventID, userID, poll)
+
+	return &poll, nil
+}
+
+// GetEventDatePolls returns the polls of an event, most recent first, with the votes of each option
+func (s *DatePollService) GetEventDatePolls(eventID, currentUserID string) ([]models.DatePoll, error) {
+	rows, err := db.DB.Query(`
+		SELECT p.id, p.event_id, p.created_by, p.question, p.created_at,
+			o.id, o.starts_at,
+			COUNT(v.user_id),
+			COALESCE(BOOL_OR(v.user_id::text = $2), false)
+		FROM event_date_polls p
+		JOIN event_date_poll_options o ON o.poll_id = p.id
+		LEFT JOIN event_date_poll_votes v ON v.option_id = o.id
+		WHERE p.event_id = $1
+		GROUP BY p.id, o.id
+		ORDER BY p.created_at DESC, o.starts_at ASC`, eventID, currentUserID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching date polls: %w", err)
+	}
+	defer rows.Close()
+
+	polls := []models.DatePoll{}
+	for rows.Next() {
+		var poll models.DatePoll
+		var createdBy sql.NullString
+		var option models.DatePollOption
+		if err := rows.Scan(&poll.ID, &poll.EventID, &createdBy, &poll.Question, &poll.CreatedAt,
+			&option.ID, &option.StartsAt, &option.VoteCount, &option.VotedByMe); err != nil {
+			return nil, fmt.Errorf("error scanning date poll: %w", err)
+		}
+
+		// Rows of a poll are contiguous
+		if len(polls) == 0 || polls[len(polls)-1].ID != poll.ID {
+			if createdBy.Valid {
+				poll.CreatedBy = &createdBy.String
+			}
+			poll.Options = []models.DatePollOption{}
+			polls = append(polls, poll)
+		}
+		last := &polls[len(polls)-1]
+		last.Options = append(last.Options, option)
+	}
+
+	return polls, rows.Err()
+}
+
+// VoteDatePoll replaces the user's choices in a poll with the given options (none clears them)
+func (s *DatePollService) VoteDatePoll(eventID, pollID, userID string, optionIDs []string) error {
+	if _, err := uuid.Parse(pollID); err != nil {
+		return errors.New("poll not found")
+	}
+
+	var exists bool
+	err := db.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM event_date_polls WHERE id = $1 AND event_id = $2)`, pollID, eventID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("error fetching date poll: %w", err)
+	}
+	if !exists {
+		return errors.New("poll not found")
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		DELETE FROM event_date_poll_votes
+		WHERE user_id = $1 AND option_id IN (SELECT id FROM event_date_poll_options WHERE poll_id = $2)`, userID, pollID)
+	if err != nil {
+		return fmt.Errorf("error clearing votes: %w", err)
+	}
+
+	if len(optionIDs) > 0 {
+		result, err := tx.Exec(`
+			INSERT INTO event_date_poll_votes (option_id, user_id, created_at)
+			SELECT id, $1, NOW() FROM event_date_poll_options
+			WHERE poll_id = $2 AND id::text = ANY($3)`, userID, pollID, pq.Array(optionIDs))
+		if err != nil {
+			return fmt.Errorf("error saving votes: %w", err)
+		}
+		if inserted, _ := result.RowsAffected(); int(inserted) != len(optionIDs) {
+			return errors.New("invalid poll options")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	EmitLifecycleEvent(LifecyclePollVote, eventID, userID, map[string]interface{}{
+		"poll_id":    pollID,
+		"user_id":    userID,
+		"option_ids": optionIDs,
+	})
+
+	return nil
+}
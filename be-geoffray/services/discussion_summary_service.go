@@ -0,0 +1,350 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"be-geoffray/db"
+	"be-geoffray/models"
+	"be-geoffray/prompts"
+)
+
+// Limits of the discussion given to the model
+const (
+	maxSummaryMessages       = 300  // Unread messages loaded, the most recent ones
+	summaryMessagesMaxTokens = 6000 // Token budget of the messages in the prompt
+	summaryMessageMaxTokens  = 200  // Longer messages are truncated
+	summaryTopSuggestions    = 5
+)
+
+// summaryLanguages are the languages summaries may be written in, with their names for the prompt
+var summaryLanguages = map[string]string{"en": "English", "fr": "French"}
+
+// discussionSummarySchema is the JSON schema requested from the provider for discussion summaries
+var discussionSummarySchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"overview":        map[string]interface{}{"type": "string"},
+		"decisions":       map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		"open_questions":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		"top_suggestions": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		"commitments": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"participant": map[string]interface{}{"type": "string"},
+					"commitment":  map[string]interface{}{"type": "string"},
+				},
+				"required":             []string{"participant", "commitment"},
+				"additionalProperties": false,
+			},
+		},
+	},
+	"required":             []string{"overview", "decisions", "open_questions", "top_suggestions", "commitments"},
+	"additionalProperties": false,
+}
+
+// summaryMessage is a discussion message as given to the summary
+type summaryMessage struct {
+	ID        string
+	Author    string
+	Content   string
+	IsAgent   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// summaryPromptData is the data of the discussion summary prompt templates
+type summaryPromptData struct {
+	Title         string
+	Occasion      string
+	Persona       string
+	Date          string
+	Language      string
+	Suggestions   string
+	GifteeProfile *gifteePromptProfile // Only for callers allowed to see it
+	MessageCount  int
+	Omitted       int
+	Messages      []string // "- [date] Author: content", oldest first
+}
+
+// DiscussionSummaryService summarizes the unread messages of event discussions with the language model
+type DiscussionSummaryService struct {
+	llm     LLMClient
+	prompts *prompts.Registry // Prompt templates, the default ones when nil
+}
+
+// NewDiscussionSummaryService creates a new discussion summary service
+func NewDiscussionSummaryService() *DiscussionSummaryService {
+	return &DiscussionSummaryService{llm: GetLLMClient()}
+}
+
+// SummarizeUnreadMessages summarizes the messages of an event the user has not read yet
+// withGifteeProfile tells whether the user may see the giftee profile, which then informs the summary.
+// Summaries are cached per range of messages: the same messages, unedited, are only summarized once.
+func (s *DiscussionSummaryService) SummarizeUnreadMessages(eventID, userID, language string, withGifteeProfile bool) (*models.DiscussionSummary, error) {
+	if _, ok := summaryLanguages[language]; !ok {
+		language = "en"
+	}
+
+	messages, err := loadUnreadSummaryMessages(eventID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return &models.DiscussionSummary{
+			EventID:        eventID,
+			Language:       language,
+			Decisions:      []string{},
+			OpenQuestions:  []string{},
+			TopSuggestions: []string{},
+			Commitments:    []models.DiscussionCommitment{},
+			GeneratedAt:    time.Now(),
+		}, nil
+	}
+
+	key := discussionRangeKey(messages, language, withGifteeProfile)
+	if cached, err := loadCachedDiscussionSummary(eventID, key); err != nil {
+		fmt.Printf("Warning: failed to read cached discussion summary: %v\n", err)
+	} else if cached != nil {
+		return cached, nil
+	}
+
+	event, _, err := NewEventService().GetEventByID(eventID)
+	if err != nil {
+		return nil, err
+	}
+	suggestions, err := getGiftSuggestionsByVotes(eventID, summaryTopSuggestions)
+	if err != nil {
+		return nil, err
+	}
+	var giftee *models.GifteeProfile
+	if withGifteeProfile {
+		giftee = LoadGifteeProfileForGeneration(eventID)
+	}
+
+	summary, err := s.summarize(event, userID, language, messages, suggestions, giftee)
+	if err != nil {
+		return nil, err
+	}
+	storeDiscussionSummary(key, summary)
+	return summary, nil
+}
+
+// summarize asks the model for the summary of the given messages
+func (s *DiscussionSummaryService) summarize(event *models.Event, userID, language string, messages []summaryMessage,
+	suggestions []models.GiftSuggestion, giftee *models.GifteeProfile) (*models.DiscussionSummary, error) {
+	prompt, version, err := s.buildDiscussionSummaryPrompt(event, language, messages, suggestions, giftee)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build discussion summary prompt: %w", err)
+	}
+
+	ctx := WithLLMUsageScope(context.Background(), LLMUsageScope{UserID: userID, EventID: event.ID, Purpose: LLMPurposeSummary})
+	temperature := 0.2 // Summaries report facts
+	response, err := s.llm.Chat(ctx, LLMRequest{
+		Messages:       []MistralMessage{{Role: "user", Content: prompt}},
+		Temperature:    &temperature,
+		ResponseFormat: &LLMResponseFormat{Name: "discussion_summary", Schema: discussionSummarySchema},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize discussion: %w", err)
+	}
+
+	summary, err := parseDiscussionSummary(response.Message.Content)
+	if err != nil {
+		return nil, err
+	}
+	summary.EventID = event.ID
+	summary.FromMessageID = messages[0].ID
+	summary.ToMessageID = messages[len(messages)-1].ID
+	summary.MessageCount = len(messages)
+	summary.Language = language
+	summary.PromptVersion = version
+	summary.GeneratedAt = time.Now()
+	return summary, nil
+}
+
+// buildDiscussionSummaryPrompt renders the discussion summary prompt
+// The most recent messages that fit in the token budget are kept; it also returns the template version
+func (s *DiscussionSummaryService) buildDiscussionSummaryPrompt(event *models.Event, language string, messages []summaryMessage,
+	suggestions []models.GiftSuggestion, giftee *models.GifteeProfile) (string, string, error) {
+	registry := s.prompts
+	if registry == nil {
+		var err error
+		if registry, err = prompts.Default(); err != nil {
+			return "", "", err
+		}
+	}
+	selector := prompts.Selector{Persona: event.GifteePersona, Occasion: event.EventOccasion, Language: language}
+	variant, err := registry.Select(prompts.DiscussionSummary, selector, event.ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	data := summaryPromptData{
+		Title:         event.Title,
+		Occasion:      event.EventOccasion,
+		Persona:       event.GifteePersona,
+		Date:          event.StartDate.Format("2006-01-02"),
+		Language:      summaryLanguages[language],
+		Suggestions:   FormatSuggestionsWithVotes(suggestions),
+		GifteeProfile: newGifteePromptProfile(giftee),
+		MessageCount:  len(messages),
+	}
+
+	used := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		line := formatSummaryMessage(messages[i])
+		if used+estimateTokens(line) > summaryMessagesMaxTokens {
+			data.Omitted = i + 1
+			break
+		}
+		used += estimateTokens(line)
+		data.Messages = append([]string{line}, data.Messages...)
+	}
+
+	prompt, err := variant.Render(data)
+	if err != nil {
+		return "", "", err
+	}
+	return prompt, variant.Version, nil
+}
+
+// formatSummaryMessage renders a message as a line of the prompt
+func formatSummaryMessage(message summaryMessage) string {
+	author := message.Author
+	if message.IsAgent {
+		author = "Assistant"
+	} else if author == "" {
+		author = "Someone"
+	}
+	content := truncateToTokens(strings.Join(strings.Fields(message.Content), " "), summaryMessageMaxTokens)
+	return fmt.Sprintf("- [%s] %s: %s", message.CreatedAt.Format("2006-01-02 15:04"), author, content)
+}
+
+// discussionRangeKey identifies a range of messages, as last edited, summarized in a language for an audience
+func discussionRangeKey(messages []summaryMessage, language string, withGifteeProfile bool) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s|%t", language, withGifteeProfile)
+	for _, message := range messages {
+		fmt.Fprintf(hash, "|%s@%d", message.ID, message.UpdatedAt.UnixNano())
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// parseDiscussionSummary reads the summary in a reply, ignoring surrounding text and empty items
+func parseDiscussionSummary(content string) (*models.DiscussionSummary, error) {
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start == -1 || end < start {
+		return nil, errors.New("no JSON found in discussion summary")
+	}
+	var summary models.DiscussionSummary
+	if err := json.Unmarshal([]byte(content[start:end+1]), &summary); err != nil {
+		return nil, fmt.Errorf("invalid discussion summary: %w", err)
+	}
+
+	summary.Overview = strings.TrimSpace(summary.Overview)
+	summary.Decisions = cleanSummaryItems(summary.Decisions)
+	summary.OpenQuestions = cleanSummaryItems(summary.OpenQuestions)
+	summary.TopSuggestions = cleanSummaryItems(summary.TopSuggestions)
+	commitments := []models.DiscussionCommitment{}
+	for _, commitment := range summary.Commitments {
+		commitment.Participant = strings.TrimSpace(commitment.Participant)
+		commitment.Commitment = strings.TrimSpace(commitment.Commitment)
+		if commitment.Commitment != "" {
+			commitments = append(commitments, commitment)
+		}
+	}
+	summary.Commitments = commitments
+	if summary.Overview == "" && len(summary.Decisions)+len(summary.OpenQuestions)+len(summary.TopSuggestions)+len(commitments) == 0 {
+		return nil, errors.New("discussion summary is empty")
+	}
+	return &summary, nil
+}
+
+// cleanSummaryItems trims the items of a summary list and drops the empty ones
+func cleanSummaryItems(items []string) []string {
+	cleaned := []string{}
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			cleaned = append(cleaned, item)
+		}
+	}
+	return cleaned
+}
+
+// loadUnreadSummaryMessages returns the messages of an event after the user's read marker, oldest first
+// Deleted messages are skipped, and only the most recent maxSummaryMessages are kept
+func loadUnreadSummaryMessages(eventID, userID string) ([]summaryMessage, error) {
+	rows, err := db.DB.Query(`
+		SELECT m.id, TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')), m.content,
+			COALESCE(m.is_agent_message, false), m.created_at, m.updated_at
+		FROM event_messages m
+		JOIN users u ON u.id = m.user_id
+		LEFT JOIN event_read_markers rm ON rm.event_id = m.event_id AND rm.user_id = $2
+		WHERE m.event_id = $1 AND m.deleted_at IS NULL
+		AND (rm.last_read_at IS NULL OR m.created_at > rm.last_read_at)
+		ORDER BY m.created_at DESC, m.id DESC
+		LIMIT $3
+	`, eventID, userID, maxSummaryMessages)
+	if err != nil {
+		return nil, fmt.Errorf("error loading unread messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []summaryMessage
+	for rows.Next() {
+		var message summaryMessage
+		if err := rows.Scan(&message.ID, &message.Author, &message.Content, &message.IsAgent, &message.CreatedAt, &message.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning unread message: %w", err)
+		}
+		messages = append([]summaryMessage{message}, messages...)
+	}
+	return messages, rows.Err()
+}
+
+// loadCachedDiscussionSummary returns the summary of a message range, nil when it was never summarized
+func loadCachedDiscussionSummary(eventID, key string) (*models.DiscussionSummary, error) {
+	var data []byte
+	err := db.DB.QueryRow(`SELECT summary FROM event_discussion_summaries WHERE event_id = $1 AND range_key = $2`,
+		eventID, key).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var summary models.DiscussionSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, err
+	}
+	summary.Cached = true
+	return &summary, nil
+}
+
+// storeDiscussionSummary caches a summary for its message range
+// Caching is best-effort: failures only cost a new summary next time
+func storeDiscussionSummary(key string, summary *models.DiscussionSummary) {
+	data, err := json.Marshal(summary)
+	if err == nil {
+		_, err = db.DB.Exec(`
+			INSERT INTO event_discussion_summaries (
+				event_id, range_key, from_message_id, to_message_id, message_count, language, prompt_version, summary
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (event_id, range_key) DO NOTHING
+		`, summary.EventID, key, optionalString(summary.FromMessageID), optionalString(summary.ToMessageID),
+			summary.MessageCount, summary.Language, optionalString(summary.PromptVersion), data)
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to cache discussion summary: %v\n", err)
+	}
+}
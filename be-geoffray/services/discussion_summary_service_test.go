@@ -0,0 +1,107 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"be-geoffray/models"
+)
+
+func testSummaryMessages(count int) []summaryMessage {
+	start := time.Date(2026, 5, 1, 18, 0, 0, 0, time.UTC)
+	var messages []summaryMessage
+	for i := 0; i < count; i++ {
+		created := start.Add(time.Duration(i) * time.Minute)
+		messages = append(messages, summaryMessage{
+			ID:        fmt.Sprintf("m%d", i),
+			Author:    "Ana Silva",
+			Content:   fmt.Sprintf("Message number %d about the gift", i),
+			CreatedAt: created,
+			UpdatedAt: created,
+		})
+	}
+	return messages
+}
+
+func TestDiscussionRangeKey(t *testing.T) {
+	messages := testSummaryMessages(3)
+	key := discussionRangeKey(messages, "en", false)
+
+	if discussionRangeKey(testSummaryMessages(3), "en", false) != key {
+		t.Error("the same range got different keys")
+	}
+	edited := testSummaryMessages(3)
+	edited[1].UpdatedAt = edited[1].UpdatedAt.Add(time.Hour)
+	for name, other := range map[string]string{
+		"edited message":  discussionRangeKey(edited, "en", false),
+		"longer range":    discussionRangeKey(testSummaryMessages(4), "en", false),
+		"other language":  discussionRangeKey(messages, "fr", false),
+		"giftee audience": discussionRangeKey(messages, "en", true),
+	} {
+		if other == key {
+			t.Errorf("%s shares the key of the original range", name)
+		}
+	}
+}
+
+func TestParseDiscussionSummary(t *testing.T) {
+	summary, err := parseDiscussionSummary("```json\n" + `{"overview":" We picked a date. ","decisions":["Party on Saturday",""],
+		"open_questions":[],"top_suggestions":["Climbing shoes"],
+		"commitments":[{"participant":"Ana","commitment":"Book the room"},{"participant":"Leo","commitment":" "}]}` + "\n```")
+	if err != nil {
+		t.Fatalf("parseDiscussionSummary() error = %v", err)
+	}
+	if summary.Overview != "We picked a date." || len(summary.Decisions) != 1 || len(summary.Commitments) != 1 {
+		t.Errorf("summary = %+v", summary)
+	}
+	if summary.OpenQuestions == nil {
+		t.Error("empty lists must be encoded as [] rather than null")
+	}
+
+	for _, content := range []string{"Sorry", `{"overview":"","decisions":[]}`, `{"overview": 3}`} {
+		if _, err := parseDiscussionSummary(content); err == nil {
+			t.Errorf("parseDiscussionSummary(%q) succeeded", content)
+		}
+	}
+}
+
+func TestSummarizeKeepsRecentMessagesAndHidesGiftee(t *testing.T) {
+	reply := LLMResponse{Message: MistralMessage{Role: "assistant",
+		Content: `{"overview":"Lots of gift talk.","decisions":[],"open_questions":[],"top_suggestions":[],"commitments":[]}`}}
+	fake := NewFakeLLMClient(reply, reply)
+	service := &DiscussionSummaryService{llm: fake}
+	event := &models.Event{ID: "e1", Title: "Ana turns 30", GifteePersona: "friend", EventOccasion: "birthday"}
+	messages := testSummaryMessages(2000)
+	messages[1999].IsAgent = true
+
+	summary, err := service.summarize(event, "u1", "fr", messages, nil, nil)
+	if err != nil {
+		t.Fatalf("summarize() error = %v", err)
+	}
+	if summary.FromMessageID != "m0" || summary.ToMessageID != "m1999" || summary.MessageCount != 2000 || summary.Language != "fr" {
+		t.Errorf("summary range = %+v", summary)
+	}
+
+	prompt := fake.Requests()[0].Messages[0].Content
+	if estimateTokens(prompt) > summaryMessagesMaxTokens+1000 {
+		t.Errorf("prompt has %d tokens, over the budget", estimateTokens(prompt))
+	}
+	for _, want := range []string{"Write the summary in French", "older unread message(s) omitted", "Assistant: Message number 1999", "UNREAD MESSAGES (2000)"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt does not contain %q", want)
+		}
+	}
+	if strings.Contains(prompt, "Message number 0 ") || strings.Contains(prompt, "Giftee interests") {
+		t.Error("prompt has the oldest message or the giftee profile")
+	}
+
+	giftee := &models.GifteeProfile{Interests: []string{"climbing"}}
+	if _, err := service.summarize(event, "u1", "en", messages[:3], nil, giftee); err != nil {
+		t.Fatalf("summarize() error = %v", err)
+	}
+	if prompt := fake.Requests()[1].Messages[0].Content; !strings.Contains(prompt, "- Giftee interests: climbing") {
+		t.Errorf("prompt lacks the giftee profile:\n%s", prompt)
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+
+	"be-geoffray/db"
+
+	"github.com/google/uuid"
+)
+
+// EventRelationship is how a user relates to an event
+type EventRelationship string
+
+const (
+	// EventRelationshipOwner is the creator of the event
+	EventRelationshipOwner EventRelationship = "owner"
+	// EventRelationshipParticipant has accepted to take part in the event
+	EventRelationshipParticipant EventRelationship = "participant"
+	// EventRelationshipInvited was invited but has not accepted (or has declined)
+	EventRelationshipInvited EventRelationship = "invited"
+	// EventRelationshipNone has nothing to do with the event
+	EventRelationshipNone EventRelationship = "none"
+)
+
+// EventAction is a kind of operation on an event or on what belongs to it
+type EventAction string
+
+const (
+	// EventActionView reads the event, its discussion and its suggestions, and answers the invitation
+	EventActionView EventAction = "view"
+	// EventActionParticipate writes messages, reactions, suggestions and votes
+	EventActionParticipate EventAction = "participate"
+	// EventActionManage changes, deletes the event or manages its guests
+	EventActionManage EventAction = "manage"
+)
+
+// Can reports whether the relationship allows an action
+func (r EventRelationship) Can(action EventAction) bool {
+	switch r {
+	case EventRelationshipOwner:
+		return true
+	case EventRelationshipParticipant:
+		return action == EventActionView || action == EventActionParticipate
+	case EventRelationshipInvited:
+		return action == EventActionView
+	default:
+		return false
+	}
+}
+
+// eventRelationshipFrom derives the relationship from what is stored about the user and the event
+func eventRelationshipFrom(isCreator bool, participantStatus string, hasPendingInvitation bool) EventRelationship {
+	switch {
+	case isCreator:
+		return EventRelationshipOwner
+	case participantStatus == "accepted" || participantStatus == "going":
+		return EventRelationshipParticipant
+	case participantStatus != "" || hasPendingInvitation:
+		return EventRelationshipInvited
+	default:
+		return EventRelationshipNone
+	}
+}
+
+// ResolveEventRelationship returns how a user relates to an event
+// Unknown events, and malformed IDs, resolve to EventRelationshipNone
+func ResolveEventRelationship(eventID, userID string) (EventRelationship, error) {
+	if _, err := uuid.Parse(eventID); err != nil {
+		return EventRelationshipNone, nil
+	}
+	if _, err := uuid.Parse(userID); err != nil {
+		return EventRelationshipNone, nil
+	}
+
+	query := `
+		SELECT
+			e.creator_id = $2,
+			COALESCE(ep.status, ''),
+			EXISTS(
+				SELECT 1 FROM event_invitations i
+				JOIN users u ON LOWER(u.email) = LOWER(i.email)
+				WHERE i.event_id = e.id AND u.id = $2 AND i.status = 'pending'
+			)
+		FROM events e
+		LEFT JOIN event_participants ep ON ep.event_id = e.id AND ep.user_id = $2
+		WHERE e.id = $1
+	`
+
+	var isCreator, hasPendingInvitation bool
+	var participantStatus string
+	err := db.DB.QueryRow(query, eventID, userID).Scan(&isCreator, &participantStatus, &hasPendingInvitation)
+	if err == sql.ErrNoRows {
+		return EventRelationshipNone, nil
+	}
+	if err != nil {
+		log.Printf("Error resolving access of user %s to event %s: %v", userID, eventID, err)
+		return EventRelationshipNone, errors.New("failed to check event access")
+	}
+
+	return eventRelationshipFrom(isCreator, participantStatus, hasPendingInvitation), nil
+}
+
+// GetSuggestionEventID returns the event a gift suggestion belongs to
+func GetSuggestionEventID(suggestionID string) (string, error) {
+	if _, err := uuid.Parse(suggestionID); err != nil {
+		return "", errors.New("suggestion not found")
+	}
+
+	var eventID string
+	err := db.DB.QueryRow(`SELECT event_id FROM gift_suggestions WHERE id = $1`, suggestionID).Scan(&eventID)
+	if err == sql.ErrNoRows {
+		return "", errors.New("suggestion not found")
+	}
+	if err != nil {
+		log.Printf("Error fetching event of suggestion %s: %v", suggestionID, err)
+		return "", errors.New("failed to check event access")
+	}
+	return eventID, nil
+}
+
+// AuthorizeEventAction checks that a user may perform an action on an event
+// It returns "event not found" when the user has no relationship with the event, so that
+// its existence is not disclosed, and "insufficient event access" when the relationship is too weak.
+func AuthorizeEventAction(eventID, userID string, action EventAction) (EventRelationship, error) {
+	relationship, err := ResolveEventRelationship(eventID, userID)
+	if err != nil {
+		return relationship, err
+	}
+	if relationship == EventRelationshipNone {
+		return relationship, errors.New("event not found")
+	}
+	if !relationship.Can(action) {
+		return relationship, errors.New("insufficient event access")
+	}
+	return relationship, nil
+}
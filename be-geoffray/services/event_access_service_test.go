@@ -0,0 +1,53 @@
+package services
+
+import "testing"
+
+func TestEventRelationshipFrom(t *testing.T) {
+	tests := []struct {
+		isCreator            bool
+		participantStatus    string
+		hasPendingInvitation bool
+		want                 EventRelationship
+	}{
+		{true, "going", false, EventRelationshipOwner},
+		{true, "", false, EventRelationshipOwner},
+		{false, "going", false, EventRelationshipParticipant},
+		{false, "accepted", true, EventRelationshipParticipant},
+		{false, "pending", false, EventRelationshipInvited},
+		{false, "declined", false, EventRelationshipInvited},
+		{false, "", true, EventRelationshipInvited},
+		{false, "", false, EventRelationshipNone},
+	}
+
+	for _, tt := range tests {
+		if got := eventRelationshipFrom(tt.isCreator, tt.participantStatus, tt.hasPendingInvitation); got != tt.want {
+			t.Errorf("eventRelationshipFrom(%v, %q, %v) = %s, want %s", tt.isCreator, tt.participantStatus, tt.hasPendingInvitation, got, tt.want)
+		}
+	}
+}
+
+func TestEventRelationshipCan(t *testing.T) {
+	tests := []struct {
+		relationship EventRelationship
+		view         bool
+		participate  bool
+		manage       bool
+	}{
+		{EventRelationshipOwner, true, true, true},
+		{EventRelationshipParticipant, true, true, false},
+		{EventRelationshipInvited, true, false, false},
+		{EventRelationshipNone, false, false, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.relationship.Can(EventActionView); got != tt.view {
+			t.Errorf("%s.Can(view) = %v, want %v", tt.relationship, got, tt.view)
+		}
+		if got := tt.relationship.Can(EventActionParticipate); got != tt.participate {
+			t.Errorf("%s.Can(participate) = %v, want %v", tt.relationship, got, tt.participate)
+		}
+		if got := tt.relationship.Can(EventActionManage); got != tt.manage {
+			t.Errorf("%s.Can(manage) = %v, want %v", tt.relationship, got, tt.manage)
+		}
+	}
+}
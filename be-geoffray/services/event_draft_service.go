@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"be-geoffray/models"
+	"be-geoffray/prompts"
+)
+
+// EventDraftTones are the tones an event draft may be written in
+var EventDraftTones = []string{"funny", "formal", "heartfelt"}
+
+// EventDraftLanguages are the languages an event draft may be written in, with their names for the prompt
+var EventDraftLanguages = map[string]string{"en": "English", "fr": "French"}
+
+// Limits of a drafted text
+const (
+	maxEventDraftLength       = 2000
+	maxEventDraftRepairRounds = 1
+)
+
+// ErrInvalidEventDraftRequest is wrapped by the validation errors of a draft request
+var ErrInvalidEventDraftRequest = errors.New("invalid draft request")
+
+// EventDraftRequest is what the organizer asks for
+type EventDraftRequest struct {
+	Tone      string   `json:"tone"`      // One of EventDraftTones, heartfelt when empty
+	Languages []string `json:"languages"` // Codes of EventDraftLanguages, English and French when empty
+}
+
+// NormalizeEventDraftRequest validates a draft request and fills its defaults
+func NormalizeEventDraftRequest(request *EventDraftRequest) error {
+	request.Tone = strings.ToLower(strings.TrimSpace(request.Tone))
+	if request.Tone == "" {
+		request.Tone = "heartfelt"
+	}
+	if !containsString(EventDraftTones, request.Tone) {
+		return fmt.Errorf("%w: tone must be one of %s", ErrInvalidEventDraftRequest, strings.Join(EventDraftTones, ", "))
+	}
+
+	var languages []string
+	for _, language := range request.Languages {
+		language = strings.ToLower(strings.TrimSpace(language))
+		if _, ok := EventDraftLanguages[language]; !ok {
+			return fmt.Errorf("%w: unsupported language %q", ErrInvalidEventDraftRequest, language)
+		}
+		if !containsString(languages, language) {
+			languages = append(languages, language)
+		}
+	}
+	if len(languages) == 0 {
+		languages = []string{"en", "fr"}
+	}
+	request.Languages = languages
+	return nil
+}
+
+// eventDraftSchema is the JSON schema requested from the provider for event drafts
+var eventDraftSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"drafts": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"language":    map[string]interface{}{"type": "string"},
+					"description": map[string]interface{}{"type": "string"},
+					"invitation":  map[string]interface{}{"type": "string"},
+				},
+				"required":             []string{"language", "description", "invitation"},
+				"additionalProperties": false,
+			},
+		},
+	},
+	"required":             []string{"drafts"},
+	"additionalProperties": false,
+}
+
+// eventDraftPromptData is the data of the event draft prompt templates
+type eventDraftPromptData struct {
+	Title        string
+	Occasion     string
+	Persona      string
+	Date         string
+	Location     string
+	Description  string
+	SurpriseMode bool
+	Tone         string
+	Languages    []string // "English (en)"
+}
+
+// EventDraftService drafts event descriptions and invitation messages with the language model
+type EventDraftService struct {
+	llm     LLMClient
+	prompts *prompts.Registry // Prompt templates, the default ones when nil
+}
+
+// NewEventDraftService creates a new event draft service
+func NewEventDraftService() *EventDraftService {
+	return &EventDraftService{llm: GetLLMClient()}
+}
+
+// DraftEvent writes a description and an invitation message for the event in every requested language
+// The texts are only returned: nothing is saved until the organizer applies them
+func (s *EventDraftService) DraftEvent(event *models.Event, userID string, request EventDraftRequest) (*models.EventDraft, error) {
+	if err := NormalizeEventDraftRequest(&request); err != nil {
+		return nil, err
+	}
+
+	prompt, version, err := s.buildEventDraftPrompt(event, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build event draft prompt: %w", err)
+	}
+
+	ctx := WithLLMUsageScope(context.Background(), LLMUsageScope{UserID: userID, EventID: event.ID, Purpose: LLMPurposeDrafting})
+	messages := []MistralMessage{{Role: "user", Content: prompt}}
+	format := &LLMResponseFormat{Name: "event_drafts", Schema: eventDraftSchema}
+
+	texts := make(map[string]models.EventDraftText)
+	for repair := 0; repair <= maxEventDraftRepairRounds; repair++ {
+		response, err := s.llm.Chat(ctx, LLMRequest{Messages: messages, ResponseFormat: format})
+		if err != nil {
+			return nil, fmt.Errorf("failed to draft event texts: %w", err)
+		}
+
+		content := response.Message.Content
+		problems := collectEventDraftTexts(content, request.Languages, texts)
+		if len(problems) == 0 {
+			break
+		}
+		fmt.Printf("Event draft reply (repair %d) is incomplete: %s\n", repair, strings.Join(problems, "; "))
+
+		// Ask only for the languages still missing, with the previous reply as context
+		messages = append(messages,
+			MistralMessage{Role: "assistant", Content: content},
+			MistralMessage{Role: "user", Content: "Your previous reply is incomplete:\n- " + strings.Join(problems, "\n- ") +
+				"\n\nReply again with the same JSON format, with the drafts of these languages only: " +
+				strings.Join(missingDraftLanguages(request.Languages, texts), ", ")},
+		)
+	}
+
+	draft := &models.EventDraft{EventID: event.ID, Tone: request.Tone, PromptVersion: version}
+	for _, language := range request.Languages {
+		if text, ok := texts[language]; ok {
+			draft.Texts = append(draft.Texts, text)
+		}
+	}
+	if len(draft.Texts) == 0 {
+		return nil, errors.New("the language model returned no usable draft")
+	}
+	return draft, nil
+}
+
+// buildEventDraftPrompt renders the event draft prompt selected for the event
+// It also returns the template version
+func (s *EventDraftService) buildEventDraftPrompt(event *models.Event, request EventDraftRequest) (string, string, error) {
+	registry := s.prompts
+	if registry == nil {
+		var err error
+		if registry, err = prompts.Default(); err != nil {
+			return "", "", err
+		}
+	}
+
+	selector := prompts.Selector{Persona: event.GifteePersona, Occasion: event.EventOccasion}
+	if len(request.Languages) == 1 {
+		selector.Language = request.Languages[0]
+	}
+	variant, err := registry.Select(prompts.EventDraft, selector, event.ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	data := eventDraftPromptData{
+		Title:        event.Title,
+		Occasion:     event.EventOccasion,
+		Persona:      event.GifteePersona,
+		Date:         event.StartDate.Format("2006-01-02"),
+		Location:     event.Location,
+		Description:  event.Description,
+		SurpriseMode: event.SurpriseMode,
+		Tone:         request.Tone,
+	}
+	for _, language := range request.Languages {
+		data.Languages = append(data.Languages, fmt.Sprintf("%s (%s)", EventDraftLanguages[language], language))
+	}
+
+	prompt, err := variant.Render(data)
+	if err != nil {
+		return "", "", err
+	}
+	return prompt, variant.Version, nil
+}
+
+// collectEventDraftTexts adds the valid drafts of a reply to texts
+// It returns the problems of the reply, including the requested languages still missing
+func collectEventDraftTexts(content string, languages []string, texts map[string]models.EventDraftText) []string {
+	var reply struct {
+		Drafts []models.EventDraftText `json:"drafts"`
+	}
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start == -1 || end < start {
+		return []string{"the reply has no JSON object"}
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &reply); err != nil {
+		return []string{"the reply could not be read: " + err.Error()}
+	}
+
+	var problems []string
+	reported := make(map[string]bool)
+	for _, text := range reply.Drafts {
+		text.Language = strings.ToLower(strings.TrimSpace(text.Language))
+		text.Description = strings.TrimSpace(text.Description)
+		text.Invitation = strings.TrimSpace(text.Invitation)
+		if !containsString(languages, text.Language) {
+			continue
+		}
+		switch {
+		case text.Description == "" || text.Invitation == "":
+			problems = append(problems, fmt.Sprintf("the %s draft has an empty description or invitation", text.Language))
+			reported[text.Language] = true
+		case len(text.Description) > maxEventDraftLength || len(text.Invitation) > maxEventDraftLength:
+			problems = append(problems, fmt.Sprintf("the %s draft is longer than %d characters", text.Language, maxEventDraftLength))
+			reported[text.Language] = true
+		default:
+			texts[text.Language] = text
+		}
+	}
+
+	for _, language := range missingDraftLanguages(languages, texts) {
+		if !reported[language] {
+			problems = append(problems, fmt.Sprintf("the %s draft is missing", language))
+		}
+	}
+	return problems
+}
+
+// missingDraftLanguages returns the requested languages without a valid draft yet
+func missingDraftLanguages(languages []string, texts map[string]models.EventDraftText) []string {
+	var missing []string
+	for _, language := range languages {
+		if _, ok := texts[language]; !ok {
+			missing = append(missing, language)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"be-geoffray/models"
+)
+
+func TestNormalizeEventDraftRequest(t *testing.T) {
+	request := EventDraftRequest{Tone: " Funny ", Languages: []string{"FR", "en", "fr"}}
+	if err := NormalizeEventDraftRequest(&request); err != nil {
+		t.Fatalf("NormalizeEventDraftRequest() error = %v", err)
+	}
+	if request.Tone != "funny" || strings.Join(request.Languages, ",") != "fr,en" {
+		t.Errorf("normalized request = %+v", request)
+	}
+
+	request = EventDraftRequest{}
+	if err := NormalizeEventDraftRequest(&request); err != nil || request.Tone != "heartfelt" || len(request.Languages) != 2 {
+		t.Errorf("defaults = %+v, %v", request, err)
+	}
+
+	for _, invalid := range []EventDraftRequest{{Tone: "sarcastic"}, {Languages: []string{"de"}}} {
+		if err := NormalizeEventDraftRequest(&invalid); !errors.Is(err, ErrInvalidEventDraftRequest) {
+			t.Errorf("NormalizeEventDraftRequest(%+v) error = %v", invalid, err)
+		}
+	}
+}
+
+func TestDraftEventRepairsMissingLanguages(t *testing.T) {
+	fake := NewFakeLLMClient(
+		LLMResponse{Message: MistralMessage{Role: "assistant", Content: `{"drafts":[
+			{"language":"en","description":" Let's celebrate Ana! ","invitation":"Join us and pick a gift."},
+			{"language":"fr","description":"","invitation":"Rejoignez-nous"}]}`}},
+		LLMResponse{Message: MistralMessage{Role: "assistant", Content: "```json\n" +
+			`{"drafts":[{"language":"fr","description":"Fêtons Ana !","invitation":"Rejoignez-nous et choisissez un cadeau."}]}` + "\n```"}},
+	)
+	service := &EventDraftService{llm: fake}
+	event := &models.Event{
+		ID: "e1", Title: "Ana turns 30", StartDate: time.Date(2026, 5, 2, 18, 0, 0, 0, time.UTC),
+		GifteePersona: "friend", EventOccasion: "birthday", SurpriseMode: true,
+	}
+
+	draft, err := service.DraftEvent(event, "u1", EventDraftRequest{Tone: "funny", Languages: []string{"en", "fr"}})
+	if err != nil {
+		t.Fatalf("DraftEvent() error = %v", err)
+	}
+	if len(draft.Texts) != 2 || draft.Texts[0].Description != "Let's celebrate Ana!" || draft.Texts[1].Description != "Fêtons Ana !" {
+		t.Fatalf("draft texts = %+v", draft.Texts)
+	}
+	if draft.Tone != "funny" || draft.PromptVersion != "v1" {
+		t.Errorf("draft = %+v", draft)
+	}
+
+	requests := fake.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("model called %d times, want 2", len(requests))
+	}
+	prompt := requests[0].Messages[0].Content
+	for _, want := range []string{"in a funny tone", "- Date: 2026-05-02", "English (en), French (fr)", "keep the surprise secret"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt does not contain %q:\n%s", want, prompt)
+		}
+	}
+	repairPrompt := requests[1].Messages[len(requests[1].Messages)-1].Content
+	if !strings.Contains(repairPrompt, "the fr draft has an empty description") || !strings.HasSuffix(repairPrompt, "languages only: fr") {
+		t.Errorf("repair prompt = %s", repairPrompt)
+	}
+}
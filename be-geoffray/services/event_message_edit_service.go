@@ -0,0 +1,356 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"be-geoffray/db"
+	"be-geoffray/models"
+
+	"github.com/lib/pq"
+)
+
+// maxReactionEmojiRunes bounds a reaction to a single (possibly composed) emoji
+const maxReactionEmojiRunes = 8
+
+// getEditableMessage loads the author and state of a message, checking it belongs to the event
+func getEditableMessage(eventID, messageID string) (authorID string, content string, isAgent bool, deleted bool, err error) {
+	var deletedAt sql.NullTime
+	query := `SELECT user_id, content, is_agent_message, deleted_at FROM event_messages WHERE id = $1 AND event_id = $2`
+	err = db.DB.QueryRow(query, messageID, eventID).Scan(&authorID, &content, &isAgent, &deletedAt)
+	if err == sql.ErrNoRows {
+		return "", "", false, false, errors.New("message not found")
+	}
+	if err != nil {
+		return "", "", false, false, fmt.Errorf("error fetching message: %w", err)
+	}
+	return authorID, content, isAgent, deletedAt.Valid, nil
+}
+
+// UpdateEventMessage changes the content of a message, keeping its previous content in the edit history
+func UpdateEventMessage(eventID, messageID, userID, content string) (*models.EventMessage, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, errors.New("content is required")
+	}
+
+	authorID, previousContent, isAgent, deleted, err := getEditableMessage(eventID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if deleted {
+		return nil, errors.New("message has been deleted")
+	}
+	if isAgent || authorID != userID {
+		return nil, errors.New("only the author can edit this message")
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO event_message_edits (message_id, previous_content, edited_by, edited_at)
+		VALUES ($1, $2, $3, NOW())`, messageID, previousContent, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error saving edit history: %w", err)
+	}
+
+	// for_agent is left as is: the agent only answers new messages, so an @agent added by an edit
+	// would leave an unanswered agent turn in the history
+	_, err = tx.Exec(`
+		UPDATE event_messages
+		SET content = $1, edited_at = NOW()
+		WHERE id = $2`, content, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("error updating message: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	// Newly mentioned participants are notified, mentions removed by the edit are dropped
+	processMessageMentions(&models.EventMessage{ID: messageID, EventID: eventID, UserID: userID, Content: content})
+
+	message, err := getEventMessageByID(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	EmitLifecycleEvent(LifecycleMessageUpdated, eventID, userID, message)
+
+	return message, nil
+}
+
+// DeleteEventMessage tombstones a message: its content, history, reactions and attachments are removed
+// but the row is kept so that replies still point to it
+func DeleteEventMessage(eventID, messageID, userID string) error {
+	authorID, _, isAgent, deleted, err := getEditableMessage(eventID, messageID)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		return nil
+	}
+	if isAgent || authorID != userID {
+		return errors.New("only the author can delete this message")
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM event_message_edits WHERE message_id = $1`, messageID); err != nil {
+		return fmt.Errorf("error deleting edit history: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM event_message_reactions WHERE message_id = $1`, messageID); err != nil {
+		return fmt.Errorf("error deleting reactions: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM event_message_mentions WHERE message_id = $1`, messageID); err != nil {
+		return fmt.Errorf("error deleting mentions: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE event_messages SET content = '', deleted_at = NOW() WHERE id = $1`, messageID); err != nil {
+		return fmt.Errorf("error deleting message: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	// Files of a deleted message are removed too; a failure leaves them to be cleaned up by the event deletion
+	if err := NewAttachmentService().DeleteMessageAttachments(messageID); err != nil {
+		fmt.Printf("Error deleting attachments of message %s: %v\n", messageID, err)
+	}
+
+	EmitLifecycleEvent(LifecycleMessageDeleted, eventID, userID, map[string]string{"message_id": messageID})
+
+	return nil
+}
+
+// GetEventMessageEdits returns the previous versions of a message, oldest first
+func GetEventMessageEdits(eventID, messageID string) ([]models.EventMessageEdit, error) {
+	if _, _, _, _, err := getEditableMessage(eventID, messageID); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT id, message_id, previous_content, edited_by, edited_at
+		FROM event_message_edits
+		WHERE message_id = $1
+		ORDER BY edited_at ASC`, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching edit history: %w", err)
+	}
+	defer rows.Close()
+
+	edits := []models.EventMessageEdit{}
+	for rows.Next() {
+		var edit models.EventMessageEdit
+		var editedBy sql.NullString
+		if err := rows.Scan(&edit.ID, &edit.MessageID, &edit.PreviousContent, &editedBy, &edit.EditedAt); err != nil {
+			return nil, fmt.Errorf("error scanning edit history: %w", err)
+		}
+		if editedBy.Valid {
+			edit.EditedBy = &editedBy.String
+		}
+		edits = append(edits, edit)
+	}
+
+	return edits, rows.Err()
+}
+
+// IsValidReactionEmoji checks that a reaction is a single short emoji and not arbitrary text
+func IsValidReactionEmoji(emoji string) bool {
+	if emoji == "" || utf8.RuneCountInString(emoji) > maxReactionEmojiRunes {
+		return false
+	}
+	if isKeycapEmoji(emoji) {
+		return true
+	}
+	for _, r := range emoji {
+		if r < 0x80 || unicode.IsLetter(r) || unicode.IsSpace(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isKeycapEmoji reports whether emoji is a keycap such as 1️⃣, #️⃣ or *️⃣: an ASCII digit, # or *,
+// an optional variation selector U+FE0F and the combining keycap U+20E3
+func isKeycapEmoji(emoji string) bool {
+	if emoji == "" || !strings.ContainsRune("0123456789#*", rune(emoji[0])) {
+		return false
+	}
+	rest := emoji[1:]
+	return rest == "\uFE0F\u20E3" || rest == "\u20E3"
+}
+
+// AddMessageReaction adds the user's reaction with an emoji to a message (adding it twice is a no-op)
+func AddMessageReaction(eventID, messageID, userID, emoji string) error {
+	if !IsValidReactionEmoji(emoji) {
+		return errors.New("invalid emoji")
+	}
+
+	_, _, _, deleted, err := getEditableMessage(eventID, messageID)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		return errors.New("message has been deleted")
+	}
+
+	_, err = db.DB.Exec(`
+		INSERT INTO event_message_reactions (message_id, user_id, emoji, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (message_id, user_id, emoji) DO NOTHING`, messageID, userID, emoji)
+	if err != nil {
+		return fmt.Errorf("error adding reaction: %w", err)
+	}
+
+	emitReactionChange(eventID, messageID, userID)
+	return nil
+}
+
+// RemoveMessageReaction removes the user's reaction with an emoji from a message
+func RemoveMessageReaction(eventID, messageID, userID, emoji string) error {
+	if _, _, _, _, err := getEditableMessage(eventID, messageID); err != nil {
+		return err
+	}
+
+	result, err := db.DB.Exec(`
+		DELETE FROM event_message_reactions
+		WHERE message_id = $1 AND user_id = $2 AND emoji = $3`, messageID, userID, emoji)
+	if err != nil {
+		return fmt.Errorf("error removing reaction: %w", err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return errors.New("reaction not found")
+	}
+
+	emitReactionChange(eventID, messageID, userID)
+	return nil
+}
+
+// emitReactionChange notifies listeners of the new reaction totals of a message
+func emitReactionChange(eventID, messageID, userID string) {
+	reactions, err := getMessageReactions(`WHERE r.message_id = $1`, messageID)
+	if err != nil {
+		fmt.Printf("Error fetching reactions of message %s: %v\n", messageID, err)
+		return
+	}
+
+	EmitLifecycleEvent(LifecycleMessageReaction, eventID, userID, map[string]interface{}{
+		"message_id": messageID,
+		"reactions":  reactions[messageID],
+	})
+}
+
+// attachMessageReactions fills the aggregated reactions of the given messages
+func attachMessageReactions(currentUserID string, messages []models.EventMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	messageIDs := make([]string, len(messages))
+	for i := range messages {
+		messageIDs[i] = messages[i].ID
+	}
+
+	reactions, err := getMessageReactions(`WHERE r.message_id = ANY($1)`, pq.Array(messageIDs))
+	if err != nil {
+		return err
+	}
+
+	for i := range messages {
+		messageReactions := reactions[messages[i].ID]
+		if messageReactions == nil {
+			continue
+		}
+		for j := range messageReactions {
+			for _, reactorID := range messageReactions[j].UserIDs {
+				if reactorID == currentUserID {
+					messageReactions[j].ReactedByMe = true
+					break
+				}
+			}
+		}
+		messages[i].Reactions = messageReactions
+	}
+
+	return nil
+}
+
+// getMessageReactions aggregates reactions per message and emoji, in order of first use
+func getMessageReactions(filter string, arg interface{}) (map[string][]models.MessageReaction, error) {
+	query := `
+		SELECT r.message_id, r.emoji, COUNT(*), array_agg(r.user_id::text ORDER BY r.created_at)
+		FROM event_message_reactions r
+		` + filter + `
+		GROUP BY r.message_id, r.emoji
+		ORDER BY r.message_id, MIN(r.created_at)
+	`
+
+	rows, err := db.DB.Query(query, arg)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching reactions: %w", err)
+	}
+	defer rows.Close()
+
+	reactions := make(map[string][]models.MessageReaction)
+	for rows.Next() {
+		var messageID string
+		var reaction models.MessageReaction
+		var userIDs pq.StringArray
+		if err := rows.Scan(&messageID, &reaction.Emoji, &reaction.Count, &userIDs); err != nil {
+			return nil, fmt.Errorf("error scanning reaction: %w", err)
+		}
+		reaction.UserIDs = []string(userIDs)
+		reactions[messageID] = append(reactions[messageID], reaction)
+	}
+
+	return reactions, rows.Err()
+}
+
+// getEventMessageByID loads a single message with its author and reactions
+func getEventMessageByID(messageID string) (*models.EventMessage, error) {
+	query := `SELECT ` + eventMessageColumns + `
+		FROM event_messages m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.id = $1
+	`
+	message, err := scanEventMessage(db.DB.QueryRow(query, messageID))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("message not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching message: %w", err)
+	}
+
+	reactions, err := getMessageReactions(`WHERE r.message_id = $1`, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if reactions[messageID] != nil {
+		message.Reactions = reactions[messageID]
+	}
+
+	messages := []models.EventMessage{*message}
+	if err := attachMessageMentions(messages); err != nil {
+		return nil, err
+	}
+	if err := attachMessageAttachments(messages); err != nil {
+		return nil, err
+	}
+	message = &messages[0]
+
+	return message, nil
+}
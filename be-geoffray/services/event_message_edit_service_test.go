@@ -0,0 +1,35 @@
+package services
+
+import "testing"
+
+func TestIsValidReactionEmoji(t *testing.T) {
+	tests := []struct {
+		emoji string
+		want  bool
+	}{
+		{"👍", true},
+		{"❤️", true},
+		{"👋🏽", true},
+		{"👨‍👩‍👧", true},
+		{"", false},
+		{"ok", false},
+		{"é", false},
+		{"👍 ", false},
+		{"👍👍👍👍👍👍👍👍👍", false},
+		{"1️⃣", true},
+		{"#️⃣", true},
+		{"*️⃣", true},
+		{"1\u20E3", true},
+		{"1", false},
+		{"#", false},
+		{"1\uFE0F", false},
+		{"12️⃣", false},
+		{"a️⃣", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsValidReactionEmoji(tt.emoji); got != tt.want {
+			t.Errorf("IsValidReactionEmoji(%q) = %v, want %v", tt.emoji, got, tt.want)
+		}
+	}
+}
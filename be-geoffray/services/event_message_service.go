@@ -2,7 +2,6 @@ package services
 
 import (
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -10,95 +9,214 @@ import (
 
 	"github.com/google/uuid"
 
+	"be-geoffray/config"
 	"be-geoffray/db"
 	"be-geoffray/models"
 	"github.com/gin-gonic/gin"
 )
 
-// EventMessageService handles event message related operations
-func GetEventMessages(c *gin.Context, eventID string) ([]models.EventMessage, error) {
-	var messages []models.EventMessage
+const (
+	// defaultMessagePageSize is the number of messages returned when no limit is given
+	defaultMessagePageSize = 50
+	// maxMessagePageSize caps the limit a client can ask for
+	maxMessagePageSize = 100
+	// maxThreadDepth protects the recursive thread queries against malformed chains
+	maxThreadDepth = 50
+)
 
-	// Use explicit column names instead of u.* to avoid potential schema issues
-	query := `
-		SELECT 
-			m.id, 
-			m.event_id, 
-			m.user_id, 
-			m.content, 
-			m.parent_id, 
-			m.created_at, 
-			m.updated_at,
-			m.is_agent_message,
-			m.for_agent,
-			u.id as user_id, 
-			u.email, 
-			u.first_name, 
-			u.last_name, 
-			u.firebase_uid
+// eventMessageColumns lists the columns scanned by scanEventMessage
+const eventMessageColumns = `
+	m.id,
+	m.event_id,
+	m.user_id,
+	m.content,
+	m.parent_id,
+	m.created_at,
+	m.updated_at,
+	m.is_agent_message,
+	m.for_agent,
+	m.edited_at,
+	m.deleted_at,
+	(SELECT COUNT(*) FROM event_messages r WHERE r.parent_id = m.id AND r.deleted_at IS NULL) AS reply_count,
+	u.id as user_id,
+	u.email,
+	u.first_name,
+	u.last_name,
+	COALESCE(u.firebase_uid, '')
+`
+
+// scanEventMessage scans a row selected with eventMessageColumns
+func scanEventMessage(row rowScanner) (*models.EventMessage, error) {
+	var message models.EventMessage
+	var parentID sql.NullString
+	var editedAt, deletedAt sql.NullTime
+
+	if err := row.Scan(
+		&message.ID,
+		&message.EventID,
+		&message.UserID,
+		&message.Content,
+		&parentID,
+		&message.CreatedAt,
+		&message.UpdatedAt,
+		&message.IsAgentMessage,
+		&message.ForAgent,
+		&editedAt,
+		&deletedAt,
+		&message.ReplyCount,
+		&message.User.ID,
+		&message.User.Email,
+		&message.User.FirstName,
+		&message.User.LastName,
+		&message.User.FirebaseUID,
+	); err != nil {
+		return nil, err
+	}
+
+	if parentID.Valid {
+		message.ParentID = &parentID.String
+	}
+	if editedAt.Valid {
+		message.EditedAt = &editedAt.Time
+	}
+	if deletedAt.Valid {
+		message.DeletedAt = &deletedAt.Time
+	}
+	message.Reactions = []models.MessageReaction{}
+	message.Attachments = []models.Attachment{}
+
+	return &message, nil
+}
+
+// GetEventMessages retrieves one page of the timeline of an event, in chronological order
+// Without a cursor the latest messages are returned; before/after are message IDs used as cursors
+func GetEventMessages(c *gin.Context, eventID string, before string, after string, limit int) (*models.EventMessagePage, error) {
+	if limit <= 0 {
+		limit = defaultMessagePageSize
+	}
+	if limit > maxMessagePageSize {
+		limit = maxMessagePageSize
+	}
+	if before != "" && after != "" {
+		return nil, errors.New("only one of before and after can be used")
+	}
+
+	cursor := before
+	if after != "" {
+		cursor = after
+	}
+	if cursor != "" {
+		var exists bool
+		err := db.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM event_messages WHERE id = $1 AND event_id = $2)`, cursor, eventID).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, errors.New("cursor not found")
+		}
+	}
+
+	query := `SELECT ` + eventMessageColumns + `
 		FROM event_messages m
 		JOIN users u ON m.user_id = u.id
-		WHERE m.event_id = $1
-		ORDER BY m.created_at ASC
-	`
+		WHERE m.event_id = $1`
+	args := []interface{}{eventID}
+
+	// Messages are ordered by (created_at, id) so that cursors are stable for equal timestamps
+	descending := true
+	switch {
+	case before != "":
+		query += ` AND (m.created_at, m.id) < (SELECT created_at, id FROM event_messages WHERE id = $2)
+		ORDER BY m.created_at DESC, m.id DESC LIMIT $3`
+		args = append(args, before, limit+1)
+	case after != "":
+		descending = false
+		query += ` AND (m.created_at, m.id) > (SELECT created_at, id FROM event_messages WHERE id = $2)
+		ORDER BY m.created_at ASC, m.id ASC LIMIT $3`
+		args = append(args, after, limit+1)
+	default:
+		query += ` ORDER BY m.created_at DESC, m.id DESC LIMIT $2`
+		args = append(args, limit+1)
+	}
 
-	rows, err := db.DB.Query(query, eventID)
+	rows, err := db.DB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	messages := []models.EventMessage{}
 	for rows.Next() {
-		var message models.EventMessage
-		var parentID sql.NullString
-		var userID string // Temporary variable to avoid overwriting message.UserID
-
-		if err := rows.Scan(
-			&message.ID,
-			&message.EventID,
-			&message.UserID,
-			&message.Content,
-			&parentID,
-			&message.CreatedAt,
-			&message.UpdatedAt,
-			&message.IsAgentMessage,
-			&message.ForAgent,
-			&userID, // Scan into temporary variable
-			&message.User.Email,
-			&message.User.FirstName,
-			&message.User.LastName,
-			&message.User.FirebaseUID,
-		); err != nil {
+		message, err := scanEventMessage(rows)
+		if err != nil {
 			return nil, err
 		}
+		messages = append(messages, *message)
+	}
 
-		// Set the user ID explicitly
-		message.User.ID = userID
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		if parentID.Valid {
-			message.ParentID = &parentID.String
+	// One extra row was fetched to know whether more messages exist in the paging direction
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+	if descending {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
 		}
+	}
 
-		messages = append(messages, message)
+	if err := attachMessageMentions(messages); err != nil {
+		return nil, err
+	}
+	if err := attachMessageAttachments(messages); err != nil {
+		return nil, err
 	}
 
-	if err := rows.Err(); err != nil {
+	page := &models.EventMessagePage{Messages: messages}
+	if after != "" {
+		page.HasMoreAfter = hasMore
+		page.HasMoreBefore = true // The cursor message itself is older
+	} else {
+		page.HasMoreBefore = hasMore
+		page.HasMoreAfter = before != ""
+	}
+
+	if err := attachMessageReactions(c.GetString("user_id"), page.Messages); err != nil {
 		return nil, err
 	}
 
-	return messages, nil
+	return page, nil
 }
 
 func CreateEventMessage(c *gin.Context, message *models.EventMessage) error {
-	// Check if the message is for the agent
-	message.ForAgent = IsMessageForAgent(message.Content)
+	return CreateEventMessageWithAttachments(c, message, nil)
+}
+
+// CreateEventMessageWithAttachments creates a message and links files previously uploaded by its author
+func CreateEventMessageWithAttachments(c *gin.Context, message *models.EventMessage, attachmentIDs []string) error {
+	// Check if the message is for the agent (callers may also flag it explicitly, e.g. chat streams)
+	message.ForAgent = message.ForAgent || IsMessageForAgent(message.Content)
+
+	if err := NewAttachmentService().CheckAttachmentsAvailable(message.EventID, message.UserID, attachmentIDs); err != nil {
+		return err
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
 	query := `
 		INSERT INTO event_messages (id, event_id, user_id, content, parent_id, created_at, updated_at, is_agent_message, for_agent)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
-	_, err := db.DB.Exec(query,
+	_, err = tx.Exec(query,
 		message.ID,
 		message.EventID,
 		message.UserID,
@@ -109,14 +227,124 @@ func CreateEventMessage(c *gin.Context, message *models.EventMessage) error {
 		message.IsAgentMessage,
 		message.ForAgent,
 	)
+	if err != nil {
+		return err
+	}
+
+	message.Attachments, err = linkMessageAttachments(tx, message.EventID, message.ID, message.UserID, attachmentIDs)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	processMessageMentions(message)
+
+	EmitLifecycleEvent(LifecycleMessageCreated, message.EventID, message.UserID, message)
+
+	return nil
+}
+
+// GetEventMessageThread retrieves the root of the thread a message belongs to, with all its replies nested
+func GetEventMessageThread(c *gin.Context, eventID, messageID string) (*models.EventMessage, error) {
+	// Walk up the parents to find the root of the thread
+	var rootID string
+	rootQuery := `
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, 0 AS depth FROM event_messages WHERE id = $1 AND event_id = $2
+			UNION ALL
+			SELECT p.id, p.parent_id, a.depth + 1
+			FROM event_messages p
+			JOIN ancestors a ON p.id = a.parent_id
+			WHERE a.depth < $3
+		)
+		SELECT id FROM ancestors ORDER BY depth DESC LIMIT 1
+	`
+	err := db.DB.QueryRow(rootQuery, messageID, eventID, maxThreadDepth).Scan(&rootID)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("message not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Then collect every reply below the root
+	threadQuery := `
+		WITH RECURSIVE thread AS (
+			SELECT id, 0 AS depth FROM event_messages WHERE id = $1
+			UNION ALL
+			SELECT r.id, t.depth + 1
+			FROM event_messages r
+			JOIN thread t ON r.parent_id = t.id
+			WHERE t.depth < $2
+		)
+		SELECT ` + eventMessageColumns + `
+		FROM thread t
+		JOIN event_messages m ON m.id = t.id
+		JOIN users u ON m.user_id = u.id
+		ORDER BY m.created_at ASC, m.id ASC
+	`
+	rows, err := db.DB.Query(threadQuery, rootID, maxThreadDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []models.EventMessage{}
+	for rows.Next() {
+		message, err := scanEventMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, *message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := attachMessageReactions(c.GetString("user_id"), messages); err != nil {
+		return nil, err
+	}
+	if err := attachMessageMentions(messages); err != nil {
+		return nil, err
+	}
+	if err := attachMessageAttachments(messages); err != nil {
+		return nil, err
+	}
 
-	return err
+	return buildMessageThread(rootID, messages), nil
 }
 
-// GetEventMessageThread retrieves a message and all its replies recursively
-func GetEventMessageThread(eventID, messageID string) (*models.EventMessage, error) {
-	// Implementation for getting a message thread
-	return nil, errors.New("not implemented")
+// buildMessageThread nests a flat, chronological list of messages under the root message
+func buildMessageThread(rootID string, messages []models.EventMessage) *models.EventMessage {
+	children := make(map[string][]models.EventMessage)
+	var root *models.EventMessage
+	for i := range messages {
+		if messages[i].ID == rootID {
+			root = &messages[i]
+			continue
+		}
+		if messages[i].ParentID != nil {
+			children[*messages[i].ParentID] = append(children[*messages[i].ParentID], messages[i])
+		}
+	}
+	if root == nil {
+		return nil
+	}
+
+	var nest func(message models.EventMessage) models.EventMessage
+	nest = func(message models.EventMessage) models.EventMessage {
+		message.Replies = []models.EventMessage{}
+		for _, child := range children[message.ID] {
+			message.Replies = append(message.Replies, nest(child))
+		}
+		return message
+	}
+
+	thread := nest(*root)
+	return &thread
 }
 
 // IsMessageForAgent checks if a message is intended for the agent (contains @agent tag)
@@ -129,33 +357,38 @@ func RemoveAgentTag(content string) string {
 	return strings.ReplaceAll(content, "@agent", "")
 }
 
-// GetAgentMessages retrieves all messages for a specific event that are either for the agent or from the agent
+// GetAgentMessages retrieves the latest messages of an event that are either for the agent or from the agent,
+// as many as can fit in the agent context, oldest first
 func GetAgentMessages(c *gin.Context, eventID string) ([]models.EventMessage, error) {
 	var messages []models.EventMessage
 
 	query := `
-		SELECT 
-			m.id, 
-			m.event_id, 
-			m.user_id, 
-			m.content, 
-			m.parent_id, 
-			m.created_at, 
-			m.updated_at,
-			m.is_agent_message,
-			m.for_agent,
-			u.id as user_id, 
-			u.email, 
-			u.first_name, 
-			u.last_name, 
-			u.profile_picture
-		FROM event_messages m
-		JOIN users u ON m.user_id = u.id
-		WHERE m.event_id = $1 AND (m.for_agent = TRUE OR m.is_agent_message = TRUE)
-		ORDER BY m.created_at ASC
+		SELECT * FROM (
+			SELECT
+				m.id,
+				m.event_id,
+				m.user_id,
+				m.content,
+				m.parent_id,
+				m.created_at,
+				m.updated_at,
+				m.is_agent_message,
+				m.for_agent,
+				u.id AS author_id,
+				u.email,
+				u.first_name,
+				u.last_name,
+				COALESCE(u.firebase_uid, '')
+			FROM event_messages m
+			JOIN users u ON m.user_id = u.id
+			WHERE m.event_id = $1 AND (m.for_agent = TRUE OR m.is_agent_message = TRUE) AND m.deleted_at IS NULL
+			ORDER BY m.created_at DESC
+			LIMIT $2
+		) latest
+		ORDER BY created_at ASC
 	`
 
-	rows, err := db.DB.Query(query, eventID)
+	rows, err := db.DB.Query(query, eventID, agentHistoryLimit(config.GetConfig().AgentContextMaxTokens))
 	if err != nil {
 		return nil, err
 	}
@@ -268,6 +501,11 @@ func CreateAgentMessage(c *gin.Context, eventID string, content string, parentID
 		return nil, err
 	}
 
+	message.Reactions = []models.MessageReaction{}
+	message.Mentions = []models.MessageMention{}
+
+	EmitLifecycleEvent(LifecycleMessageCreated, message.EventID, "", message)
+
 	return &message, nil
 }
 
@@ -277,7 +515,7 @@ func GetOrCreateSystemUser(c *gin.Context) (*models.User, error) {
 	var systemUser models.User
 
 	query := `
-		SELECT id, email, first_name, last_name, firebase_uid
+		SELECT id, email, first_name, last_name, COALESCE(firebase_uid, '')
 		FROM users
 		WHERE email = 'agent@system.local'
 	`
@@ -349,6 +587,7 @@ func GetEventByID(eventID string) (*models.Event, error) {
 }
 
 // ProcessAgentMessageWithMistral sends a message to Mistral AI and processes the response
+// Tools called by the agent act on behalf of the author of the message, with their access to the event
 func ProcessAgentMessageWithMistral(c *gin.Context, eventID string, messageID string) error {
 	// Get all agent-related messages for context
 	messages, err := GetAgentMessages(c, eventID)
@@ -356,34 +595,23 @@ func ProcessAgentMessageWithMistral(c *gin.Context, eventID string, messageID st
 		return fmt.Errorf("error getting agent messages: %w", err)
 	}
 
-	// Note: Event data retrieval removed as it's no longer needed after Amadeus removal
-
-	// Convert event messages to Mistral format
-	mistralMessages := ConvertEventMessagesToMistralMessages(messages)
-
-	// Create Mistral service
-	mistralService := NewMistralService()
-
-	// Send the message (no function calling tools available)
-	rawResp, err := mistralService.SendChatWithTools(mistralMessages, []interface{}{})
-	fmt.Printf("[DEBUG] Raw Mistral API response: %s\n", string(rawResp))
+	var authorID string
+	err = db.DB.QueryRow(`SELECT user_id FROM event_messages WHERE id = $1 AND event_id = $2`, messageID, eventID).Scan(&authorID)
 	if err != nil {
-		return fmt.Errorf("error from Mistral API: %w", err)
+		return fmt.Errorf("error getting message author: %w", err)
 	}
 
-	// Parse normal response and save (no function calling)
-	var parsed struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
+	// Describe the event and fit the conversation in the token budget
+	mistralMessages, err := BuildAgentMessages(eventID, messages)
+	if err != nil {
+		return fmt.Errorf("error building agent context: %w", err)
 	}
-	if err := json.Unmarshal([]byte(rawResp), &parsed); err != nil || len(parsed.Choices) == 0 {
-		return fmt.Errorf("failed to parse Mistral response: %w", err)
+
+	aiResp, err := RunAgentWithTools(eventID, authorID, mistralMessages)
+	if err != nil {
+		return err
 	}
-	aiResp := parsed.Choices[0].Message.Content
-	fmt.Printf("[DEBUG] Saving normal AI message: '%s'\n", aiResp)
+
 	_, err = CreateAgentMessage(c, eventID, aiResp, &messageID)
 	if err != nil {
 		return fmt.Errorf("error saving agent response: %w", err)
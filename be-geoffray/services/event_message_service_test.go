@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+
+	"be-geoffray/models"
+)
+
+func TestBuildMessageThread(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+
+	messages := []models.EventMessage{
+		{ID: "root"},
+		{ID: "a", ParentID: ptr("root")},
+		{ID: "b", ParentID: ptr("root")},
+		{ID: "a1", ParentID: ptr("a")},
+	}
+
+	thread := buildMessageThread("root", messages)
+	if thread == nil {
+		t.Fatal("buildMessageThread() returned nil")
+	}
+	if len(thread.Replies) != 2 || thread.Replies[0].ID != "a" || thread.Replies[1].ID != "b" {
+		t.Fatalf("root replies = %+v, want [a b]", thread.Replies)
+	}
+	if len(thread.Replies[0].Replies) != 1 || thread.Replies[0].Replies[0].ID != "a1" {
+		t.Errorf("replies of a = %+v, want [a1]", thread.Replies[0].Replies)
+	}
+	if len(thread.Replies[1].Replies) != 0 {
+		t.Errorf("replies of b = %+v, want none", thread.Replies[1].Replies)
+	}
+
+	if buildMessageThread("missing", messages) != nil {
+		t.Error("buildMessageThread() should return nil when the root is missing")
+	}
+}
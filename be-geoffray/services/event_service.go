@@ -103,10 +103,11 @@ type Participant struct {
 }
 
 // GetEventByID retrieves an event by its ID along with its participants
-func (s *EventService) GetEventByID(eventID string, userID string) (*models.Event, []Participant, error) {
+// Callers are responsible for checking that the user may view the event (see AuthorizeEventAction)
+func (s *EventService) GetEventByID(eventID string) (*models.Event, []Participant, error) {
 	// Query to get the event by ID including persona and occasion fields
 	query := `
-		SELECT e.id, e.creator_id, e.title, e.description, e.start_date, e.end_date, e.banner, e.location, e.active, e.created_at, e.updated_at, e.giftee_persona, e.event_occasion
+		SELECT e.id, e.creator_id, e.title, e.description, e.start_date, e.end_date, e.banner, e.location, e.active, e.created_at, e.updated_at, e.giftee_persona, e.event_occasion, e.surprise_mode, e.language
 		FROM events e
 		WHERE e.id = $1
 	`
@@ -115,7 +116,7 @@ func (s *EventService) GetEventByID(eventID string, userID string) (*models.Even
 	err := db.DB.QueryRow(query, eventID).Scan(
 		&event.ID, &event.CreatorID, &event.Title, &event.Description,
 		&event.StartDate, &event.EndDate, &event.Banner, &event.Location, &event.Active,
-		&event.CreatedAt, &event.UpdatedAt, &event.GifteePersona, &event.EventOccasion,
+		&event.CreatedAt, &event.UpdatedAt, &event.GifteePersona, &event.EventOccasion, &event.SurpriseMode, &event.Language,
 	)
 
 	if err != nil {
@@ -135,27 +136,6 @@ func (s *EventService) GetEventByID(eventID string, userID string) (*models.Even
 		event.ParticipantsCount = 0
 	}
 
-	// Check if the user is the creator or a participant
-	hasAccess := false
-
-	// If user is the creator, they have access
-	if event.CreatorID == userID {
-		hasAccess = true
-	} else {
-		// Check if user is a participant
-		participantQuery := `SELECT 1 FROM event_participants WHERE event_id = $1 AND user_id = $2 LIMIT 1`
-		var exists int
-		err = db.DB.QueryRow(participantQuery, eventID, userID).Scan(&exists)
-		if err == nil {
-			hasAccess = true
-		}
-	}
-
-	// If user doesn't have access, return error
-	if !hasAccess {
-		return nil, nil, errors.New("event not found")
-	}
-
 	// Fetch participants for this event
 	participantsQuery := `
 		SELECT u.id, u.first_name, u.last_name, ep.status
@@ -188,7 +168,14 @@ func (s *EventService) GetEventByID(eventID string, userID string) (*models.Even
 func (s *EventService) GetUserEvents(userID string) ([]models.Event, error) {
 	// Query to get all events where the user is either the creator or a participant
 	query := `
-		SELECT DISTINCT e.id, e.creator_id, e.title, e.description, e.start_date, e.end_date, e.banner, e.location, e.active, e.created_at, e.updated_at, e.participants_count, e.giftee_persona, e.event_occasion
+		SELECT DISTINCT e.id, e.creator_id, e.title, e.description, e.start_date, e.end_date, e.banner, e.location, e.active, e.created_at, e.updated_at, e.participants_count, e.giftee_persona, e.event_occasion,
+			(
+				SELECT COUNT(*)
+				FROM event_messages m
+				LEFT JOIN event_read_markers rm ON rm.event_id = m.event_id AND rm.user_id = $1
+				WHERE m.event_id = e.id AND m.user_id <> $1 AND m.deleted_at IS NULL
+				AND (rm.last_read_at IS NULL OR m.created_at > rm.last_read_at)
+			) AS unread_count
 		FROM events e
 		LEFT JOIN event_participants ep ON e.id = ep.event_id
 		WHERE e.creator_id = $1 OR ep.user_id = $1
@@ -206,11 +193,12 @@ func (s *EventService) GetUserEvents(userID string) ([]models.Event, error) {
 	events := []models.Event{}
 	for rows.Next() {
 		var event models.Event
+		var unreadCount int
 		err := rows.Scan(
 			&event.ID, &event.CreatorID, &event.Title, &event.Description,
 			&event.StartDate, &event.EndDate, &event.Banner, &event.Location, &event.Active,
 			&event.CreatedAt, &event.UpdatedAt, &event.ParticipantsCount,
-			&event.GifteePersona, &event.EventOccasion,
+			&event.GifteePersona, &event.EventOccasion, &unreadCount,
 		)
 		if err != nil {
 			log.Println("Error scanning event:", err)
@@ -229,6 +217,8 @@ func (s *EventService) GetUserEvents(userID string) ([]models.Event, error) {
 			event.ParticipantsCount = 0
 		}
 
+		event.UnreadCount = &unreadCount
+
 		events = append(events, event)
 	}
 
@@ -324,6 +314,18 @@ func (s *EventService) UpdateEvent(eventID string, userID string, updates map[st
 		updateParams = append(updateParams, location)
 	}
 
+	if surpriseMode, ok := updates["surprise_mode"].(bool); ok {
+		paramCount++
+		updateQuery += `, surprise_mode = $` + strconv.Itoa(paramCount)
+		updateParams = append(updateParams, surpriseMode)
+	}
+
+	if language, ok := updates["language"].(string); ok {
+		paramCount++
+		updateQuery += `, language = $` + strconv.Itoa(paramCount)
+		updateParams = append(updateParams, language)
+	}
+
 	// Add the WHERE clause and event ID parameter
 	paramCount++
 	updateQuery += ` WHERE id = $` + strconv.Itoa(paramCount)
@@ -338,7 +340,7 @@ func (s *EventService) UpdateEvent(eventID string, userID string, updates map[st
 
 	// Fetch the updated event to return
 	query := `
-		SELECT id, creator_id, title, description, start_date, end_date, banner, location, active, created_at, updated_at, participants_count
+		SELECT id, creator_id, title, description, start_date, end_date, banner, location, active, created_at, updated_at, participants_count, surprise_mode, language
 		FROM events
 		WHERE id = $1
 	`
@@ -347,7 +349,7 @@ func (s *EventService) UpdateEvent(eventID string, userID string, updates map[st
 	err = db.DB.QueryRow(query, eventID).Scan(
 		&event.ID, &event.CreatorID, &event.Title, &event.Description,
 		&event.StartDate, &event.EndDate, &event.Banner, &event.Location, &event.Active,
-		&event.CreatedAt, &event.UpdatedAt, &event.ParticipantsCount,
+		&event.CreatedAt, &event.UpdatedAt, &event.ParticipantsCount, &event.SurpriseMode, &event.Language,
 	)
 
 	if err != nil {
@@ -355,6 +357,8 @@ func (s *EventService) UpdateEvent(eventID string, userID string, updates map[st
 		return nil, errors.New("failed to fetch updated event")
 	}
 
+	EmitLifecycleEvent(LifecycleEventUpdated, eventID, userID, event)
+
 	return &event, nil
 }
 
@@ -523,6 +527,14 @@ func (s *EventService) SyncParticipantCounts() error {
 
 // DeleteEvent deletes an event and all its associated data
 func (s *EventService) DeleteEvent(eventID string) error {
+	// Attachment rows are removed by the cascade, their files must be collected beforehand
+	attachmentService := NewAttachmentService()
+	attachmentKeys, err := attachmentService.GetEventAttachmentKeys(eventID)
+	if err != nil {
+		log.Printf("Error fetching attachments of event %s: %v", eventID, err)
+		return errors.New("failed to fetch event attachments")
+	}
+
 	// Start a transaction to ensure all deletes succeed or none do
 	tx, err := db.DB.Begin()
 	if err != nil {
@@ -581,6 +593,8 @@ func (s *EventService) DeleteEvent(eventID string) error {
 		return errors.New("failed to commit transaction")
 	}
 
+	attachmentService.DeleteFiles(attachmentKeys)
+
 	log.Printf("Successfully deleted event %s and all associated data", eventID)
 	return nil
 }
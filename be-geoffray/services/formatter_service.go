@@ -1,4 +1,54 @@
 package services
 
-// Note: Flight formatting functions have been removed
-// Add new formatting functions here as needed for other services
+import (
+	"fmt"
+	"strings"
+
+	"be-geoffray/models"
+)
+
+// FormatSuggestionsWithVotes renders gift suggestions and their votes as a list the agent can quote
+func FormatSuggestionsWithVotes(suggestions []models.GiftSuggestion) string {
+	if len(suggestions) == 0 {
+		return "There are no gift suggestions for this event yet."
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d gift suggestion(s):\n", len(suggestions))
+	for i, s := range suggestions {
+		name := s.NameEN
+		if name == "" {
+			name = s.NameFR
+		}
+		fmt.Fprintf(&b, "%d. %s", i+1, name)
+		if s.PriceRange != "" {
+			fmt.Fprintf(&b, " (%s)", s.PriceRange)
+		}
+		fmt.Fprintf(&b, " - %d up, %d down\n", s.UpvoteCount, s.DownvoteCount)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// FormatRSVPSummary renders how many guests answered each way, with the names of those who did
+func FormatRSVPSummary(participants []Participant, pendingInvitations int) string {
+	groups := map[string][]string{}
+	for _, p := range participants {
+		status := p.Status
+		if status == "going" {
+			status = "accepted"
+		}
+		groups[status] = append(groups[status], strings.TrimSpace(p.FirstName+" "+p.LastName))
+	}
+
+	var b strings.Builder
+	for _, status := range []string{"accepted", "declined", "pending"} {
+		names := groups[status]
+		fmt.Fprintf(&b, "%s: %d", status, len(names))
+		if len(names) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(names, ", "))
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "invitations not yet accepted by email: %d", pendingInvitations)
+	return b.String()
+}
@@ -0,0 +1,341 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"be-geoffray/db"
+	"be-geoffray/models"
+)
+
+// Limits of the preference profile included in prompts
+const (
+	maxPreferenceNames  = 10
+	maxPreferenceThemes = 8
+)
+
+// Weights of the preference score used to rank new candidates
+const (
+	preferenceCategoryCap     = 3.0 // Net votes of a category counted at most
+	preferencePriceBonus      = 1.0
+	preferenceThemePenalty    = 1.0
+	preferenceRejectedPenalty = 5.0
+)
+
+// VotedGiftSuggestion is a suggestion with the votes it received
+type VotedGiftSuggestion struct {
+	Suggestion models.GiftSuggestion
+	Upvotes    int
+	Downvotes  int
+}
+
+// GiftPreferenceProfile summarizes what the participants of an event liked and disliked in its suggestions
+// It is included in the generation prompt and ranks the new candidates
+type GiftPreferenceProfile struct {
+	Votes              int
+	LikedCategories    []string // Most upvoted first
+	DislikedCategories []string // Most downvoted first
+	PriceMin           float64  // Prices of the upvoted suggestions, 0 when unknown
+	PriceMax           float64
+	AvoidedNames       []string // Names of the downvoted suggestions, in the request language
+	AvoidedThemes      []string // Words of the downvoted suggestions that no upvoted one shares
+
+	// Rejected are the downvoted suggestions, never to be suggested again
+	Rejected []models.GiftSuggestion
+
+	categoryScores map[string]int
+	avoidedTokens  map[string]bool
+}
+
+// IsEmpty reports whether the votes gave no preference at all
+func (p *GiftPreferenceProfile) IsEmpty() bool {
+	return p == nil || (len(p.LikedCategories) == 0 && len(p.DislikedCategories) == 0 &&
+		p.PriceMax == 0 && len(p.AvoidedNames) == 0 && len(p.AvoidedThemes) == 0)
+}
+
+// LikedPriceRange returns the price range of the upvoted suggestions as "€min-max", empty when unknown
+func (p *GiftPreferenceProfile) LikedPriceRange() string {
+	if p == nil || p.PriceMax == 0 {
+		return ""
+	}
+	return formatGiftPrice(p.PriceMin, p.PriceMax)
+}
+
+// BuildGiftPreferenceProfile derives the preferences of a group from the votes on its suggestions
+// A suggestion counts as liked or disliked by its net votes
+func BuildGiftPreferenceProfile(voted []VotedGiftSuggestion, language string) *GiftPreferenceProfile {
+	profile := &GiftPreferenceProfile{
+		categoryScores: make(map[string]int),
+		avoidedTokens:  make(map[string]bool),
+	}
+
+	likedTokens := make(map[string]bool)
+	themeCounts := make(map[string]int)
+	for _, item := range voted {
+		profile.Votes += item.Upvotes + item.Downvotes
+		net := item.Upvotes - item.Downvotes
+		if net == 0 {
+			continue
+		}
+
+		suggestion := item.Suggestion
+		if category := normalizeSimilarityText(suggestion.Category); category != "" {
+			profile.categoryScores[category] += net
+		}
+		tokens := suggestionNameTokens(suggestion)
+
+		if net > 0 {
+			for _, token := range tokens {
+				likedTokens[token] = true
+			}
+			if low, high, err := giftPriceBounds(suggestion.PriceRange); err == nil {
+				if profile.PriceMax == 0 || low < profile.PriceMin {
+					profile.PriceMin = low
+				}
+				profile.PriceMax = math.Max(profile.PriceMax, high)
+			}
+			continue
+		}
+
+		profile.Rejected = append(profile.Rejected, suggestion)
+		if len(profile.AvoidedNames) < maxPreferenceNames {
+			profile.AvoidedNames = append(profile.AvoidedNames, newPromptSuggestion(suggestion, language).Name)
+		}
+		for _, token := range tokens {
+			themeCounts[token]++
+		}
+	}
+
+	// Categories keep their display name, the one of the first suggestion seen
+	names := make(map[string]string)
+	for _, item := range voted {
+		key := normalizeSimilarityText(item.Suggestion.Category)
+		if _, ok := names[key]; !ok {
+			names[key] = item.Suggestion.Category
+		}
+	}
+	var categories []string
+	for category := range profile.categoryScores {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		a, b := profile.categoryScores[categories[i]], profile.categoryScores[categories[j]]
+		if a != b {
+			return a > b
+		}
+		return categories[i] < categories[j]
+	})
+	for _, category := range categories {
+		if profile.categoryScores[category] > 0 {
+			profile.LikedCategories = append(profile.LikedCategories, names[category])
+		}
+	}
+	for i := len(categories) - 1; i >= 0; i-- {
+		if profile.categoryScores[categories[i]] < 0 {
+			profile.DislikedCategories = append(profile.DislikedCategories, names[categories[i]])
+		}
+	}
+
+	// Themes are the words of rejected gifts that no liked gift shares, most frequent first
+	var themes []string
+	for token := range themeCounts {
+		if !likedTokens[token] && len(token) > 3 {
+			themes = append(themes, token)
+		}
+	}
+	sort.Slice(themes, func(i, j int) bool {
+		if themeCounts[themes[i]] != themeCounts[themes[j]] {
+			return themeCounts[themes[i]] > themeCounts[themes[j]]
+		}
+		return themes[i] < themes[j]
+	})
+	if len(themes) > maxPreferenceThemes {
+		themes = themes[:maxPreferenceThemes]
+	}
+	profile.AvoidedThemes = themes
+	for _, theme := range themes {
+		profile.avoidedTokens[theme] = true
+	}
+
+	return profile
+}
+
+// suggestionNameTokens returns the distinct meaningful words of both names of a suggestion
+func suggestionNameTokens(suggestion models.GiftSuggestion) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, token := range similarityTokens(suggestion.NameEN + " " + suggestion.NameFR) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// Score rates how well a candidate fits the preferences, higher is better
+// Liked categories and prices raise it; disliked categories, themes and rejected gifts lower it
+func (p *GiftPreferenceProfile) Score(candidate models.GiftSuggestion) float64 {
+	if p == nil {
+		return 0
+	}
+
+	score := float64(p.categoryScores[normalizeSimilarityText(candidate.Category)])
+	score = math.Max(-preferenceCategoryCap, math.Min(preferenceCategoryCap, score))
+
+	if p.PriceMax > 0 {
+		if low, high, err := giftPriceBounds(candidate.PriceRange); err == nil && low <= p.PriceMax && high >= p.PriceMin {
+			score += preferencePriceBonus
+		}
+	}
+
+	for _, token := range suggestionNameTokens(candidate) {
+		if p.avoidedTokens[token] {
+			score -= preferenceThemePenalty
+		}
+	}
+
+	candidateNames := []string{normalizeSimilarityText(candidate.NameEN), normalizeSimilarityText(candidate.NameFR)}
+	for _, rejected := range p.Rejected {
+		for _, name := range []string{normalizeSimilarityText(rejected.NameEN), normalizeSimilarityText(rejected.NameFR)} {
+			for _, candidateName := range candidateNames {
+				if name != "" && candidateName != "" && trigramSimilarity(name, candidateName) >= 0.6 {
+					return score - preferenceRejectedPenalty
+				}
+			}
+		}
+	}
+	return score
+}
+
+// rankByPreferences sorts suggestions from the best to the worst fit, keeping the generation order on ties
+func rankByPreferences(suggestions []models.GiftSuggestion, profile *GiftPreferenceProfile) {
+	if profile.IsEmpty() {
+		return
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return profile.Score(suggestions[i]) > profile.Score(suggestions[j])
+	})
+}
+
+// LoadGiftPreferenceProfile builds the preference profile of an event from the votes on its current suggestions
+// and on the ones earlier regenerations deleted, so that repeated regenerations converge on what the group likes
+// Names are taken in the given language
+func LoadGiftPreferenceProfile(eventID, language string) (*GiftPreferenceProfile, error) {
+	voted, err := loadEventGiftVotes(eventID)
+	if err != nil {
+		return nil, err
+	}
+	return BuildGiftPreferenceProfile(voted, language), nil
+}
+
+// loadEventGiftVotes reads the votes on the current suggestions of an event, then the archived ones,
+// most recent first so that the capped lists of the profile keep the latest rounds; replaced in tests
+var loadEventGiftVotes = func(eventID string) ([]VotedGiftSuggestion, error) {
+	current, err := queryGiftVotes(eventID, `
+		SELECT gs.id, gs.name_en, gs.name_fr, COALESCE(gs.description_en, ''), COALESCE(gs.description_fr, ''),
+			COALESCE(gs.price_range, ''), COALESCE(gs.category, ''),
+			COUNT(v.id) FILTER (WHERE v.vote_type = 'upvote'),
+			COUNT(v.id) FILTER (WHERE v.vote_type = 'downvote')
+		FROM gift_suggestions gs
+		JOIN gift_suggestion_votes v ON v.suggestion_id = gs.id
+		WHERE gs.event_id = $1
+		GROUP BY gs.id
+		ORDER BY gs.created_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+	archived, err := queryGiftVotes(eventID, `
+		SELECT suggestion_id, name_en, name_fr, COALESCE(description_en, ''), COALESCE(description_fr, ''),
+			COALESCE(price_range, ''), COALESCE(category, ''), upvotes, downvotes
+		FROM gift_vote_history
+		WHERE event_id = $1
+		ORDER BY archived_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return append(current, archived...), nil
+}
+
+// queryGiftVotes reads voted suggestions of an event with a query selecting their texts and vote counts
+func queryGiftVotes(eventID, query string) ([]VotedGiftSuggestion, error) {
+	rows, err := db.DB.Query(query, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading gift votes: %w", err)
+	}
+	defer rows.Close()
+
+	var voted []VotedGiftSuggestion
+	for rows.Next() {
+		var item VotedGiftSuggestion
+		s := &item.Suggestion
+		if err := rows.Scan(&s.ID, &s.NameEN, &s.NameFR, &s.DescriptionEN, &s.DescriptionFR, &s.PriceRange, &s.Category,
+			&item.Upvotes, &item.Downvotes); err != nil {
+			return nil, fmt.Errorf("error scanning gift votes: %w", err)
+		}
+		s.EventID = eventID
+		voted = append(voted, item)
+	}
+	return voted, rows.Err()
+}
+
+// ArchiveGiftVotes keeps the votes on the current suggestions of an event before a regeneration deletes them
+// It runs in the transaction of the deletion, so that no vote is lost or counted twice
+func ArchiveGiftVotes(tx *sql.Tx, eventID string) error {
+	return archiveGiftVotes(tx, "gs.event_id = $1", eventID)
+}
+
+// ArchiveGiftSuggestionVotes keeps the votes on a suggestion before it is deleted on its own,
+// so that a suggestion deleted because the group voted it down is still avoided
+func ArchiveGiftSuggestionVotes(tx *sql.Tx, suggestionID string) error {
+	return archiveGiftVotes(tx, "gs.id = $1", suggestionID)
+}
+
+// archiveGiftVotes copies the vote counts of the suggestions matching the condition to the history
+func archiveGiftVotes(tx *sql.Tx, condition, arg string) error {
+	_, err := tx.Exec(`
+		INSERT INTO gift_vote_history (event_id, suggestion_id, name_en, name_fr, description_en, description_fr,
+			price_range, category, upvotes, downvotes)
+		SELECT gs.event_id, gs.id, gs.name_en, gs.name_fr, gs.description_en, gs.description_fr,
+			gs.price_range, gs.category,
+			COUNT(v.id) FILTER (WHERE v.vote_type = 'upvote'),
+			COUNT(v.id) FILTER (WHERE v.vote_type = 'downvote')
+		FROM gift_suggestions gs
+		JOIN gift_suggestion_votes v ON v.suggestion_id = gs.id
+		WHERE `+condition+`
+		GROUP BY gs.id
+	`, arg)
+	if err != nil {
+		return fmt.Errorf("error archiving gift votes: %w", err)
+	}
+	return nil
+}
+
+// withRejectedSuggestions adds the rejected suggestions missing from the suggestions to avoid
+func withRejectedSuggestions(existing []models.GiftSuggestion, profile *GiftPreferenceProfile) []models.GiftSuggestion {
+	if profile == nil {
+		return existing
+	}
+	known := make(map[string]bool, len(existing))
+	for _, suggestion := range existing {
+		known[suggestion.ID] = true
+	}
+	for _, rejected := range profile.Rejected {
+		if !known[rejected.ID] {
+			existing = append(existing, rejected)
+		}
+	}
+	return existing
+}
+
+// describePreferences is a short summary of the profile for logs
+func (p *GiftPreferenceProfile) describePreferences() string {
+	return fmt.Sprintf("%d votes, liked [%s], disliked [%s], price %q, %d rejected",
+		p.Votes, strings.Join(p.LikedCategories, ", "), strings.Join(p.DislikedCategories, ", "),
+		p.LikedPriceRange(), len(p.Rejected))
+}
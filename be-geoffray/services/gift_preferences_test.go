@@ -0,0 +1,213 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"be-geoffray/models"
+)
+
+func testVotedSuggestions() []VotedGiftSuggestion {
+	cookbook := testGiftSuggestion("Books", "Cookbook", "Livre de cuisine", "", "")
+	cookbook.PriceRange = "€20-30"
+	novel := testGiftSuggestion("Books", "Crime novel", "Roman policier", "", "")
+	novel.PriceRange = "€15"
+	candle := testGiftSuggestion("Home", "Scented candle", "Bougie parfumée", "", "")
+	candle.ID = "candle"
+	candle.PriceRange = "€10-20"
+	diffuser := testGiftSuggestion("Home", "Scented diffuser", "Diffuseur parfumé", "", "")
+	diffuser.PriceRange = "€25-35"
+	return []VotedGiftSuggestion{
+		{Suggestion: cookbook, Upvotes: 3},
+		{Suggestion: novel, Upvotes: 2, Downvotes: 1},
+		{Suggestion: candle, Downvotes: 2},
+		{Suggestion: diffuser, Upvotes: 1, Downvotes: 2},
+		{Suggestion: testGiftSuggestion("Toys", "Puzzle", "Puzzle", "", ""), Upvotes: 1, Downvotes: 1},
+	}
+}
+
+func TestBuildGiftPreferenceProfile(t *testing.T) {
+	profile := BuildGiftPreferenceProfile(testVotedSuggestions(), "fr")
+
+	if profile.Votes != 13 {
+		t.Errorf("Votes = %d, want 13", profile.Votes)
+	}
+	if strings.Join(profile.LikedCategories, ",") != "Books" || strings.Join(profile.DislikedCategories, ",") != "Home" {
+		t.Errorf("categories liked %v and disliked %v, want Books and Home", profile.LikedCategories, profile.DislikedCategories)
+	}
+	if got := profile.LikedPriceRange(); got != "€15-30" {
+		t.Errorf("LikedPriceRange() = %q, want €15-30", got)
+	}
+	if strings.Join(profile.AvoidedNames, ",") != "Bougie parfumée,Diffuseur parfumé" {
+		t.Errorf("AvoidedNames = %v, want the French names of the rejected gifts", profile.AvoidedNames)
+	}
+	if len(profile.AvoidedThemes) == 0 || profile.AvoidedThemes[0] != "scented" {
+		t.Errorf("AvoidedThemes = %v, want the shared word of the rejected gifts first", profile.AvoidedThemes)
+	}
+
+	if !BuildGiftPreferenceProfile(nil, "en").IsEmpty() {
+		t.Error("profile without votes is not empty")
+	}
+}
+
+func TestRankByPreferences(t *testing.T) {
+	profile := BuildGiftPreferenceProfile(testVotedSuggestions(), "en")
+
+	repeat := testGiftSuggestion("Home", "Scented candles", "Bougies parfumées", "", "")
+	repeat.PriceRange = "€10-20"
+	themed := testGiftSuggestion("Wellness", "Scented bath salts", "Sels de bain parfumés", "", "")
+	themed.PriceRange = "€50-60"
+	neutral := testGiftSuggestion("Games", "Board game", "Jeu de société", "", "")
+	neutral.PriceRange = "€40-50"
+	liked := testGiftSuggestion("Books", "Travel guide", "Guide de voyage", "", "")
+	liked.PriceRange = "€20-25"
+
+	suggestions := []models.GiftSuggestion{repeat, themed, neutral, liked}
+	rankByPreferences(suggestions, profile)
+
+	var order []string
+	for _, suggestion := range suggestions {
+		order = append(order, suggestion.NameEN)
+	}
+	if got := strings.Join(order, ", "); got != "Travel guide, Board game, Scented bath salts, Scented candles" {
+		t.Errorf("ranked %s", got)
+	}
+
+	existing := withRejectedSuggestions([]models.GiftSuggestion{{ID: "candle"}}, profile)
+	if len(existing) != 2 {
+		t.Errorf("withRejectedSuggestions() added %d suggestions, want only the deleted rejected one", len(existing)-1)
+	}
+}
+
+func TestGiftPromptIncludesPreferences(t *testing.T) {
+	service := &GiftSuggestionService{}
+	request := GiftSuggestionRequest{GifteePersona: "Dad", EventOccasion: "Birthday", Language: "en"}
+
+	prompt, version, err := service.buildGiftSuggestionPrompt(request, nil)
+	if err != nil {
+		t.Fatalf("buildGiftSuggestionPrompt() error = %v", err)
+	}
+	if version != "v4" || strings.Contains(prompt, "GROUP PREFERENCES") {
+		t.Errorf("prompt %s without votes has preferences:\n%s", version, prompt)
+	}
+
+	if !strings.Contains(prompt, "- Names and descriptions are written in English\n") {
+		t.Errorf("prompt does not ask for the request language:\n%s", prompt)
+	}
+
+	request.Preferences = BuildGiftPreferenceProfile(testVotedSuggestions(), "en")
+	prompt, _, err = service.buildGiftSuggestionPrompt(request, nil)
+	if err != nil {
+		t.Fatalf("buildGiftSuggestionPrompt() error = %v", err)
+	}
+	for _, want := range []string{
+		"- Favour these categories: Books\n",
+		"- Preferred price range: €15-30\n",
+		"- Avoid these categories: Home\n",
+		"do NOT suggest them or close variants: Scented candle, Scented diffuser\n",
+		"- Suggestions follow the group preferences\n",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt does not contain %q:\n%s", want, prompt)
+		}
+	}
+}
+
+func TestGiftPreferencesSurviveRegenerations(t *testing.T) {
+	// An event whose regenerations archive the votes of the deleted suggestions, like ArchiveGiftVotes
+	var current, archived []VotedGiftSuggestion
+	load := loadEventGiftVotes
+	t.Cleanup(func() { loadEventGiftVotes = load })
+	loadEventGiftVotes = func(eventID string) ([]VotedGiftSuggestion, error) {
+		return append(append([]VotedGiftSuggestion(nil), current...), archived...), nil
+	}
+	regenerate := func() *GiftPreferenceProfile {
+		t.Helper()
+		profile, err := LoadGiftPreferenceProfile("event-1", "en")
+		if err != nil {
+			t.Fatalf("LoadGiftPreferenceProfile() error = %v", err)
+		}
+		archived = append(append([]VotedGiftSuggestion(nil), current...), archived...)
+		current = nil
+		return profile
+	}
+	voted := func(id, category, name, price string, upvotes, downvotes int) VotedGiftSuggestion {
+		suggestion := testGiftSuggestion(category, name, name, "", "")
+		suggestion.ID = id
+		suggestion.PriceRange = price
+		return VotedGiftSuggestion{Suggestion: suggestion, Upvotes: upvotes, Downvotes: downvotes}
+	}
+
+	// First round: the group likes a board game and rejects a candle
+	current = []VotedGiftSuggestion{
+		voted("game", "Games", "Board game", "€20-40", 2, 0),
+		voted("candle", "Home", "Scented candle", "€10-20", 0, 2),
+	}
+	regenerate()
+
+	// Second round: a puzzle is liked and a mug rejected
+	current = []VotedGiftSuggestion{
+		voted("puzzle", "Games", "Jigsaw puzzle", "€30-60", 1, 0),
+		voted("mug", "Kitchen", "Coffee mug", "€10-15", 0, 1),
+	}
+	regenerate()
+
+	// The third round, without any vote yet, still knows about both earlier ones
+	profile := regenerate()
+	if profile.Votes != 6 {
+		t.Errorf("Votes = %d, want the 6 votes of both rounds", profile.Votes)
+	}
+	if strings.Join(profile.LikedCategories, ",") != "Games" {
+		t.Errorf("LikedCategories = %v, want Games", profile.LikedCategories)
+	}
+	if strings.Join(profile.DislikedCategories, ",") != "Home,Kitchen" {
+		t.Errorf("DislikedCategories = %v, want Home and Kitchen", profile.DislikedCategories)
+	}
+	if got := profile.LikedPriceRange(); got != "€20-60" {
+		t.Errorf("LikedPriceRange() = %q, want €20-60", got)
+	}
+	var rejected []string
+	for _, suggestion := range profile.Rejected {
+		rejected = append(rejected, suggestion.ID)
+	}
+	if strings.Join(rejected, ",") != "mug,candle" {
+		t.Errorf("Rejected = %v, want the latest round first", rejected)
+	}
+	if profile.Score(testGiftSuggestion("Home", "Scented candle", "Bougie parfumée", "", "")) >= 0 {
+		t.Error("the candle rejected two rounds ago should rank last")
+	}
+}
+
+func TestGiftPreferencesSurviveDeletedSuggestions(t *testing.T) {
+	// An event where deleting a suggestion archives its votes, like ArchiveGiftSuggestionVotes
+	candle := testGiftSuggestion("Home", "Scented candle", "Bougie parfumée", "", "")
+	candle.ID = "candle"
+	game := testGiftSuggestion("Games", "Board game", "Jeu de société", "", "")
+	game.ID = "game"
+	current := []VotedGiftSuggestion{{Suggestion: game, Upvotes: 2}, {Suggestion: candle, Downvotes: 3}}
+	var archived []VotedGiftSuggestion
+
+	load := loadEventGiftVotes
+	t.Cleanup(func() { loadEventGiftVotes = load })
+	loadEventGiftVotes = func(eventID string) ([]VotedGiftSuggestion, error) {
+		return append(append([]VotedGiftSuggestion(nil), current...), archived...), nil
+	}
+
+	// The group voted the candle down, so its owner deletes it
+	archived = append(archived, current[1])
+	current = current[:1]
+
+	profile, err := LoadGiftPreferenceProfile("event-1", "en")
+	if err != nil {
+		t.Fatalf("LoadGiftPreferenceProfile() error = %v", err)
+	}
+	if len(profile.Rejected) != 1 || profile.Rejected[0].ID != "candle" {
+		t.Errorf("Rejected = %v, want the deleted candle", profile.Rejected)
+	}
+	if strings.Join(profile.AvoidedNames, ",") != "Scented candle" {
+		t.Errorf("AvoidedNames = %v, want the deleted candle", profile.AvoidedNames)
+	}
+	if strings.Join(profile.DislikedCategories, ",") != "Home" {
+		t.Errorf("DislikedCategories = %v, want Home", profile.DislikedCategories)
+	}
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"be-geoffray/models"
+)
+
+// Weights of the name and description scores in the similarity of two suggestions
+const (
+	similarityNameWeight        = 0.6
+	similarityDescriptionWeight = 0.4
+	// similarityCategoryPenalty scales the score of suggestions from different categories
+	similarityCategoryPenalty = 0.6
+)
+
+// SimilarityThresholds tune when a candidate counts as a duplicate of an existing suggestion
+// Scores in [Distinct, Duplicate) are borderline
+type SimilarityThresholds struct {
+	Duplicate float64 // At or above: duplicate
+	Distinct  float64 // Below: distinct
+}
+
+// SimilarityMatch is the existing suggestion closest to a candidate, and why
+type SimilarityMatch struct {
+	Existing         models.GiftSuggestion
+	Score            float64
+	NameScore        float64
+	DescriptionScore float64
+	SameCategory     bool
+	ExactName        bool
+}
+
+// Reason explains the match for logs and API responses
+func (m SimilarityMatch) Reason() string {
+	if m.ExactName {
+		return fmt.Sprintf("same name as %q", m.Existing.NameEN)
+	}
+	category := "different category"
+	if m.SameCategory {
+		category = "same category"
+	}
+	return fmt.Sprintf("close to %q (score %.2f: name %.2f, description %.2f, %s)",
+		m.Existing.NameEN, m.Score, m.NameScore, m.DescriptionScore, category)
+}
+
+// GiftSimilarityEngine detects duplicate gift suggestions locally, comparing both languages
+// Names are compared with character trigrams, descriptions with TF-IDF cosine similarity
+type GiftSimilarityEngine struct {
+	Thresholds SimilarityThresholds
+}
+
+// NewGiftSimilarityEngine creates a similarity engine with the given thresholds
+func NewGiftSimilarityEngine(thresholds SimilarityThresholds) *GiftSimilarityEngine {
+	return &GiftSimilarityEngine{Thresholds: thresholds}
+}
+
+// IsDuplicate reports whether the match is at or above the duplicate threshold
+func (e *GiftSimilarityEngine) IsDuplicate(match *SimilarityMatch) bool {
+	return match != nil && match.Score >= e.Thresholds.Duplicate
+}
+
+// IsBorderline reports whether the match is between the distinct and duplicate thresholds
+func (e *GiftSimilarityEngine) IsBorderline(match *SimilarityMatch) bool {
+	return match != nil && match.Score >= e.Thresholds.Distinct && match.Score < e.Thresholds.Duplicate
+}
+
+// BestMatch returns the existing suggestion most similar to the candidate, nil if there is none
+func (e *GiftSimilarityEngine) BestMatch(candidate models.GiftSuggestion, existing []models.GiftSuggestion) *SimilarityMatch {
+	if len(existing) == 0 {
+		return nil
+	}
+
+	// Document frequencies are computed over the candidate and the existing suggestions
+	corpus := newTFIDFCorpus()
+	candidateDocs := suggestionDescriptions(candidate)
+	for _, doc := range candidateDocs {
+		corpus.add(doc)
+	}
+	existingDocs := make([][2][]string, len(existing))
+	for i, suggestion := range existing {
+		existingDocs[i] = suggestionDescriptions(suggestion)
+		for _, doc := range existingDocs[i] {
+			corpus.add(doc)
+		}
+	}
+
+	var best *SimilarityMatch
+	for i, suggestion := range existing {
+		match := SimilarityMatch{
+			Existing:     suggestion,
+			SameCategory: normalizeSimilarityText(candidate.Category) == normalizeSimilarityText(suggestion.Category),
+		}
+
+		// Languages are compared with each other, names also across languages (e.g. "Lego" in both)
+		candidateNames := []string{normalizeSimilarityText(candidate.NameEN), normalizeSimilarityText(candidate.NameFR)}
+		existingNames := []string{normalizeSimilarityText(suggestion.NameEN), normalizeSimilarityText(suggestion.NameFR)}
+		for _, a := range candidateNames {
+			for _, b := range existingNames {
+				if a == "" || b == "" {
+					continue
+				}
+				if a == b {
+					match.ExactName = true
+				}
+				match.NameScore = math.Max(match.NameScore, trigramSimilarity(a, b))
+			}
+		}
+		for lang := range candidateDocs {
+			match.DescriptionScore = math.Max(match.DescriptionScore, corpus.cosine(candidateDocs[lang], existingDocs[i][lang]))
+		}
+
+		match.Score = similarityNameWeight*match.NameScore + similarityDescriptionWeight*match.DescriptionScore
+		if !match.SameCategory {
+			match.Score *= similarityCategoryPenalty
+		}
+		if match.ExactName {
+			match.Score = 1
+		}
+
+		if best == nil || match.Score > best.Score {
+			best = &match
+		}
+	}
+	return best
+}
+
+// similarityAccents folds the accented letters of French and other Latin languages
+var similarityAccents = strings.NewReplacer(
+	"à", "a", "â", "a", "ä", "a", "á", "a", "ã", "a",
+	"ç", "c",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"î", "i", "ï", "i", "í", "i",
+	"ô", "o", "ö", "o", "ó", "o", "õ", "o",
+	"ù", "u", "û", "u", "ü", "u", "ú", "u",
+	"ÿ", "y", "ñ", "n", "œ", "oe", "æ", "ae",
+)
+
+// normalizeSimilarityText lowercases, folds accents and replaces punctuation with single spaces
+func normalizeSimilarityText(text string) string {
+	text = similarityAccents.Replace(strings.ToLower(text))
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// similarityStopWords are frequent English and French words that carry no meaning about the gift
+var similarityStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true, "for": true, "to": true, "in": true,
+	"with": true, "on": true, "your": true, "their": true, "this": true, "that": true, "is": true, "it": true,
+	"le": true, "la": true, "les": true, "un": true, "une": true, "des": true, "de": true, "du": true, "et": true,
+	"ou": true, "pour": true, "avec": true, "en": true, "au": true, "aux": true, "son": true, "sa": true, "ses": true,
+	"ce": true, "cet": true, "cette": true, "qui": true, "est": true, "d": true, "l": true,
+}
+
+// similarityTokens splits normalized text into meaningful words, with plural endings stripped
+func similarityTokens(text string) []string {
+	var tokens []string
+	for _, word := range strings.Fields(normalizeSimilarityText(text)) {
+		if similarityStopWords[word] {
+			continue
+		}
+		if len(word) > 3 && (strings.HasSuffix(word, "s") || strings.HasSuffix(word, "x")) {
+			word = word[:len(word)-1]
+		}
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+// suggestionDescriptions returns the tokens of the English and French descriptions of a suggestion
+func suggestionDescriptions(suggestion models.GiftSuggestion) [2][]string {
+	return [2][]string{similarityTokens(suggestion.DescriptionEN), similarityTokens(suggestion.DescriptionFR)}
+}
+
+// trigramSimilarity is the Jaccard index of the character trigrams of two normalized strings
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for trigram := range ta {
+		if tb[trigram] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// trigrams returns the set of character trigrams of a string, padded so that short words still have some
+func trigrams(text string) map[string]bool {
+	runes := []rune("  " + text + " ")
+	set := make(map[string]bool)
+	for i := 0; i+3 <= len(runes); i++ {
+		set[string(runes[i:i+3])] = true
+	}
+	return set
+}
+
+// tfidfCorpus holds the document frequencies used to weight the words of the compared descriptions
+type tfidfCorpus struct {
+	documents int
+	frequency map[string]int
+}
+
+func newTFIDFCorpus() *tfidfCorpus {
+	return &tfidfCorpus{frequency: make(map[string]int)}
+}
+
+// add counts a document in the document frequencies
+func (c *tfidfCorpus) add(tokens []string) {
+	if len(tokens) == 0 {
+		return
+	}
+	c.documents++
+	seen := make(map[string]bool)
+	for _, token := range tokens {
+		if !seen[token] {
+			seen[token] = true
+			c.frequency[token]++
+		}
+	}
+}
+
+// vector returns the TF-IDF weights of a document
+func (c *tfidfCorpus) vector(tokens []string) map[string]float64 {
+	vector := make(map[string]float64)
+	for _, token := range tokens {
+		vector[token]++
+	}
+	for token, count := range vector {
+		// Smoothed IDF, so that words present in every document still count a little
+		idf := math.Log(float64(1+c.documents)/float64(1+c.frequency[token])) + 1
+		vector[token] = count * idf
+	}
+	return vector
+}
+
+// cosine is the cosine similarity of the TF-IDF vectors of two documents
+func (c *tfidfCorpus) cosine(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	va, vb := c.vector(a), c.vector(b)
+	var dot, normA, normB float64
+	for token, weight := range va {
+		dot += weight * vb[token]
+		normA += weight * weight
+	}
+	for _, weight := range vb {
+		normB += weight * weight
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
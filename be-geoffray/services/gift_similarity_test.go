@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"be-geoffray/models"
+)
+
+func testGiftSuggestion(category, nameEN, nameFR, descriptionEN, descriptionFR string) models.GiftSuggestion {
+	return models.GiftSuggestion{
+		Category:      category,
+		NameEN:        nameEN,
+		NameFR:        nameFR,
+		DescriptionEN: descriptionEN,
+		DescriptionFR: descriptionFR,
+	}
+}
+
+var testExistingSuggestions = []models.GiftSuggestion{
+	testGiftSuggestion("Books", "Cookbook", "Livre de cuisine",
+		"A cookbook of Italian recipes for home cooks", "Un livre de recettes italiennes pour cuisiner à la maison"),
+	testGiftSuggestion("Experiences", "Hot air balloon ride", "Vol en montgolfière",
+		"A morning flight over the countryside", "Un vol matinal au-dessus de la campagne"),
+}
+
+func TestGiftSimilarityEngineBestMatch(t *testing.T) {
+	engine := NewGiftSimilarityEngine(SimilarityThresholds{Duplicate: 0.6, Distinct: 0.35})
+
+	tests := []struct {
+		name          string
+		candidate     models.GiftSuggestion
+		wantMatch     string
+		wantDuplicate bool
+	}{
+		{
+			name: "same French name with different accents and case",
+			candidate: testGiftSuggestion("Adventure", "Balloon flight", "VOL EN MONTGOLFIERE",
+				"Fly above the fields", "Survolez les champs"),
+			wantMatch:     "Hot air balloon ride",
+			wantDuplicate: true,
+		},
+		{
+			name: "reworded name and description in the same category",
+			candidate: testGiftSuggestion("Books", "Italian cookbook", "Livre de cuisine italienne",
+				"Italian recipes cookbook for cooks at home", "Recettes italiennes à cuisiner à la maison"),
+			wantMatch:     "Cookbook",
+			wantDuplicate: true,
+		},
+		{
+			name: "unrelated gift",
+			candidate: testGiftSuggestion("Tech", "Wireless headphones", "Casque sans fil",
+				"Noise cancelling headphones for travel", "Casque à réduction de bruit pour voyager"),
+			wantDuplicate: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := engine.BestMatch(tt.candidate, testExistingSuggestions)
+			if match == nil {
+				t.Fatal("BestMatch() = nil")
+			}
+			if engine.IsDuplicate(match) != tt.wantDuplicate {
+				t.Errorf("duplicate = %v, want %v (%s)", !tt.wantDuplicate, tt.wantDuplicate, match.Reason())
+			}
+			if tt.wantMatch != "" && match.Existing.NameEN != tt.wantMatch {
+				t.Errorf("matched %q, want %q", match.Existing.NameEN, tt.wantMatch)
+			}
+		})
+	}
+
+	if match := engine.BestMatch(testExistingSuggestions[0], nil); match != nil {
+		t.Errorf("BestMatch() without existing suggestions = %+v, want nil", match)
+	}
+}
+
+func TestNormalizeSimilarityText(t *testing.T) {
+	if got := normalizeSimilarityText("  Vol en Montgolfière — l'été! "); got != "vol en montgolfiere l ete" {
+		t.Errorf("normalizeSimilarityText() = %q", got)
+	}
+}
+
+func TestCheckSimilarityTieBreaker(t *testing.T) {
+	candidate := testGiftSuggestion("Books", "Pasta recipes", "Recettes de pâtes", "", "")
+	engine := NewGiftSimilarityEngine(SimilarityThresholds{Duplicate: 0.99, Distinct: 0.01})
+	if !engine.IsBorderline(engine.BestMatch(candidate, testExistingSuggestions)) {
+		t.Fatal("test candidate is not borderline")
+	}
+
+	tests := []struct {
+		name        string
+		tieBreak    bool
+		reply       string
+		wantSimilar bool
+		wantCalls   int
+	}{
+		{name: "local decision only", tieBreak: false, wantSimilar: false, wantCalls: 0},
+		{name: "model says similar", tieBreak: true, reply: "YES, both are cookbooks", wantSimilar: true, wantCalls: 1},
+		{name: "model says different", tieBreak: true, reply: "NO", wantSimilar: false, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := NewFakeLLMClient(LLMResponse{Message: MistralMessage{Role: "assistant", Content: tt.reply}})
+			service := &GiftSuggestionService{llm: fake, similarity: engine, llmTieBreak: tt.tieBreak}
+
+			similar, reason, err := service.CheckSimilarity(context.Background(), candidate, testExistingSuggestions, "en")
+			if err != nil {
+				t.Fatalf("CheckSimilarity() error = %v", err)
+			}
+			if similar != tt.wantSimilar {
+				t.Errorf("similar = %v, want %v (%s)", similar, tt.wantSimilar, reason)
+			}
+			if !strings.Contains(reason, "Cookbook") {
+				t.Errorf("reason %q does not name the matching suggestion", reason)
+			}
+			if got := len(fake.Requests()); got != tt.wantCalls {
+				t.Errorf("model called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestBuildSimilarityCheckPrompt(t *testing.T) {
+	service := &GiftSuggestionService{}
+	prompt, err := service.buildSimilarityCheckPrompt(testExistingSuggestions[1], testExistingSuggestions[:1], "fr")
+	if err != nil {
+		t.Fatalf("buildSimilarityCheckPrompt() error = %v", err)
+	}
+	for _, want := range []string{"- Name: Vol en montgolfière\n", "1. Category: Books\n   Name: Livre de cuisine\n", "Answer with ONLY 'YES'"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt does not contain %q:\n%s", want, prompt)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"be-geoffray/models"
+)
+
+// GiftCategories are the categories AI suggestions may use, matching the curated gifts
+var GiftCategories = []string{
+	"Art", "Beauty", "Books", "Collectibles", "Electronics", "Experiences", "Fashion", "Food & Drink",
+	"Games", "Garden", "Home", "Jewelry", "Kitchen", "Music", "Outdoor", "Sports", "Stationery", "Toys",
+	"Travel", "Wellness",
+}
+
+// giftSuggestionSchema is the JSON schema requested from the provider for gift suggestions
+var giftSuggestionSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"suggestions": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name":        map[string]interface{}{"type": "string"},
+					"description": map[string]interface{}{"type": "string"},
+					"price_range": map[string]interface{}{"type": "string"},
+					"category":    map[string]interface{}{"type": "string", "enum": GiftCategories},
+					"url":         map[string]interface{}{"type": "string"},
+				},
+				"required":             []string{"name", "description", "price_range", "category", "url"},
+				"additionalProperties": false,
+			},
+		},
+	},
+	"required":             []string{"suggestions"},
+	"additionalProperties": false,
+}
+
+// bilingualGiftPromptVersions are the gift suggestion prompts asking for English and French texts
+var bilingualGiftPromptVersions = []string{"v1", "v2", "v3"}
+
+// isBilingualGiftPrompt reports whether a gift suggestion prompt version asks for English and French texts
+func isBilingualGiftPrompt(version string) bool {
+	return containsString(bilingualGiftPromptVersions, version)
+}
+
+// bilingualGiftSuggestionSchema is the JSON schema of the replies to the bilingual prompts
+var bilingualGiftSuggestionSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"suggestions": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name_en":        map[string]interface{}{"type": "string"},
+					"name_fr":        map[string]interface{}{"type": "string"},
+					"description_en": map[string]interface{}{"type": "string"},
+					"description_fr": map[string]interface{}{"type": "string"},
+					"price_range":    map[string]interface{}{"type": "string"},
+					"category":       map[string]interface{}{"type": "string", "enum": GiftCategories},
+					"url":            map[string]interface{}{"type": "string"},
+				},
+				"required":             []string{"name_en", "name_fr", "description_en", "description_fr", "price_range", "category", "url"},
+				"additionalProperties": false,
+			},
+		},
+	},
+	"required":             []string{"suggestions"},
+	"additionalProperties": false,
+}
+
+// giftPricePattern matches "€15-30", "15 - 30 €", "€15 - €30", "EUR 50" or "50€"
+var giftPricePattern = regexp.MustCompile(`^(?:€|eur)?\s*(\d+(?:[.,]\d+)?)\s*(?:€|eur)?\s*(?:[-–]\s*(?:€|eur)?\s*(\d+(?:[.,]\d+)?)\s*(?:€|eur)?)?$`)
+
+// giftPriceBounds parses a price range, the high bound equals the low one for a single price
+func giftPriceBounds(price string) (float64, float64, error) {
+	matches := giftPricePattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(price)))
+	if matches == nil {
+		return 0, 0, fmt.Errorf("price_range %q is not a price in euros", price)
+	}
+
+	parse := func(value string) float64 {
+		number, _ := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+		return number
+	}
+	low := parse(matches[1])
+	if low <= 0 {
+		return 0, 0, fmt.Errorf("price_range %q must be positive", price)
+	}
+	if matches[2] == "" {
+		return low, low, nil
+	}
+	high := parse(matches[2])
+	if high < low {
+		return 0, 0, fmt.Errorf("price_range %q goes down", price)
+	}
+	return low, high, nil
+}
+
+// formatGiftPrice formats price bounds as "€min-max" (or "€price")
+func formatGiftPrice(low, high float64) string {
+	if low == high {
+		return "€" + strconv.FormatFloat(low, 'f', -1, 64)
+	}
+	return fmt.Sprintf("€%s-%s", strconv.FormatFloat(low, 'f', -1, 64), strconv.FormatFloat(high, 'f', -1, 64))
+}
+
+// normalizeGiftPrice parses a price range and returns it as "€min-max" (or "€price")
+func normalizeGiftPrice(price string) (string, error) {
+	low, high, err := giftPriceBounds(price)
+	if err != nil {
+		return "", err
+	}
+	return formatGiftPrice(low, high), nil
+}
+
+// normalizeGiftCategory returns the known category matching name regardless of case
+func normalizeGiftCategory(name string) (string, bool) {
+	for _, category := range GiftCategories {
+		if strings.EqualFold(strings.TrimSpace(name), category) {
+			return category, true
+		}
+	}
+	return "", false
+}
+
+// validateGiftSuggestion checks every field of a generated suggestion, written in language
+// Replies to the bilingual prompts carry English and French texts instead
+// It returns the normalized suggestion, or the problems to report back to the model
+func validateGiftSuggestion(item MistralGiftSuggestion, language string) (models.GiftSuggestion, []string) {
+	var problems []string
+
+	var translations map[string]models.GiftTranslation
+	if item.Name != "" || item.Description != "" || (item.NameEN == "" && item.NameFR == "") {
+		name := strings.TrimSpace(item.Name)
+		if name == "" {
+			problems = append(problems, "name is empty")
+		}
+		translations = map[string]models.GiftTranslation{
+			language: {Name: name, Description: strings.TrimSpace(item.Description), Source: GiftTranslationOriginal},
+		}
+	} else {
+		if strings.TrimSpace(item.NameEN) == "" {
+			problems = append(problems, "name_en is empty")
+		}
+		if strings.TrimSpace(item.NameFR) == "" {
+			problems = append(problems, "name_fr is empty")
+		}
+		translations = LegacyGiftTranslations(item.NameEN, item.DescriptionEN, item.NameFR, item.DescriptionFR)
+	}
+
+	price, err := normalizeGiftPrice(item.PriceRange)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	category, ok := normalizeGiftCategory(item.Category)
+	if !ok {
+		problems = append(problems, fmt.Sprintf("category %q is not one of %s", item.Category, strings.Join(GiftCategories, ", ")))
+	}
+
+	if strings.TrimSpace(item.URL) != "" {
+		problems = append(problems, "url must be empty")
+	}
+
+	if len(problems) > 0 {
+		return models.GiftSuggestion{}, problems
+	}
+
+	if _, ok := translations[language]; !ok {
+		language = DefaultGiftLanguage
+	}
+	now := time.Now()
+	suggestion := models.GiftSuggestion{
+		Language:     language,
+		Translations: translations,
+		PriceRange:   price,
+		Category:     category,
+		GeneratedAt:  now,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	SyncLegacyGiftFields(&suggestion)
+	return suggestion, nil
+}
+
+// invalidGiftSuggestion is a generated item rejected by the validation
+type invalidGiftSuggestion struct {
+	Raw      json.RawMessage
+	Problems []string
+}
+
+// parseGiftSuggestionItems extracts the items of the "suggestions" array of a reply
+// Markdown fences and surrounding text are ignored, unknown fields are skipped, and the complete
+// items of a truncated reply are kept; items that cannot be decoded are returned as invalid
+func parseGiftSuggestionItems(content string) ([]MistralGiftSuggestion, []invalidGiftSuggestion, error) {
+	start := strings.IndexAny(content, "{[")
+	if start == -1 {
+		return nil, nil, errors.New("no JSON found in response")
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(content[start:]))
+	var rawItems []json.RawMessage
+	truncated := false
+
+	// Bare arrays of suggestions are accepted too
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if token == json.Delim('[') {
+		rawItems, truncated = decodeRawArray(decoder)
+	} else {
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				truncated = true
+				break
+			}
+			if key != "suggestions" {
+				var skipped json.RawMessage
+				if err := decoder.Decode(&skipped); err != nil {
+					truncated = true
+					break
+				}
+				continue
+			}
+			if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+				return nil, nil, errors.New("suggestions is not an array")
+			}
+			rawItems, truncated = decodeRawArray(decoder)
+			break
+		}
+	}
+
+	if truncated {
+		fmt.Printf("Gift suggestion response was truncated, keeping %d complete items\n", len(rawItems))
+	}
+	if len(rawItems) == 0 {
+		if truncated {
+			return nil, nil, errors.New("response was truncated before the first suggestion")
+		}
+		return nil, nil, errors.New("response has no suggestions")
+	}
+
+	var items []MistralGiftSuggestion
+	var invalid []invalidGiftSuggestion
+	for _, raw := range rawItems {
+		var item MistralGiftSuggestion
+		if err := json.Unmarshal(raw, &item); err != nil {
+			invalid = append(invalid, invalidGiftSuggestion{Raw: raw, Problems: []string{"not a valid suggestion object: " + err.Error()}})
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, invalid, nil
+}
+
+// decodeRawArray decodes the remaining elements of an array, reporting whether the input ended early
+func decodeRawArray(decoder *json.Decoder) ([]json.RawMessage, bool) {
+	var items []json.RawMessage
+	for decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return items, true
+		}
+		items = append(items, raw)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return items, true
+	}
+	return items, false
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeGiftPrice(t *testing.T) {
+	tests := []struct {
+		price   string
+		want    string
+		wantErr bool
+	}{
+		{price: "€15-30", want: "€15-30"},
+		{price: "15 - 30 €", want: "€15-30"},
+		{price: "€15 – €30", want: "€15-30"},
+		{price: "EUR 49,90", want: "€49.9"},
+		{price: "50€", want: "€50"},
+		{price: "cheap", wantErr: true},
+		{price: "", wantErr: true},
+		{price: "€30-15", wantErr: true},
+		{price: "$20", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeGiftPrice(tt.price)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("normalizeGiftPrice(%q) error = %v, wantErr %v", tt.price, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("normalizeGiftPrice(%q) = %q, want %q", tt.price, got, tt.want)
+		}
+	}
+}
+
+const testValidGiftItem = `{"name_en":"Cookbook","name_fr":"Livre de cuisine","description_en":"Recipes","description_fr":"Recettes","price_range":"€20-30","category":"books","url":""}`
+
+func TestParseGiftSuggestionItems(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		wantItems   int
+		wantInvalid int
+		wantErr     bool
+	}{
+		{name: "plain object", content: `{"suggestions":[` + testValidGiftItem + `]}`, wantItems: 1},
+		{name: "markdown wrapped", content: "Here you go:\n```json\n{\"suggestions\":[" + testValidGiftItem + "]}\n```", wantItems: 1},
+		{name: "extra fields", content: `{"note":"x","suggestions":[{"rating":5,` + testValidGiftItem[1:] + `]}`, wantItems: 1},
+		{name: "bare array", content: `[` + testValidGiftItem + `,` + testValidGiftItem + `]`, wantItems: 2},
+		{name: "truncated", content: `{"suggestions":[` + testValidGiftItem + `,{"name_en":"Te`, wantItems: 1},
+		{name: "wrong types", content: `{"suggestions":[` + testValidGiftItem + `,{"name_en":42}]}`, wantItems: 1, wantInvalid: 1},
+		{name: "no json", content: "Sorry, I cannot help", wantErr: true},
+		{name: "no suggestions", content: `{"gifts":[]}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, invalid, err := parseGiftSuggestionItems(tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(items) != tt.wantItems || len(invalid) != tt.wantInvalid {
+				t.Errorf("got %d items and %d invalid, want %d and %d", len(items), len(invalid), tt.wantItems, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func TestValidateGiftSuggestion(t *testing.T) {
+	item := MistralGiftSuggestion{NameEN: " Cookbook ", NameFR: "Livre", PriceRange: "20-30€", Category: "KITCHEN"}
+	suggestion, problems := validateGiftSuggestion(item, "fr")
+	if len(problems) > 0 {
+		t.Fatalf("valid suggestion rejected: %v", problems)
+	}
+	if suggestion.NameEN != "Cookbook" || suggestion.PriceRange != "€20-30" || suggestion.Category != "Kitchen" {
+		t.Errorf("suggestion not normalized: %+v", suggestion)
+	}
+
+	_, problems = validateGiftSuggestion(MistralGiftSuggestion{NameEN: "Cookbook", PriceRange: "cheap", Category: "Stuff", URL: "https://example.com"}, "fr")
+	if len(problems) != 4 {
+		t.Errorf("got problems %v, want empty name_fr, price, category and url", problems)
+	}
+}
+
+func TestValidateGiftSuggestionInRequestLanguage(t *testing.T) {
+	item := MistralGiftSuggestion{Name: " Wanderschuhe ", Description: "Für die Berge", PriceRange: "€80-120", Category: "Outdoor"}
+	suggestion, problems := validateGiftSuggestion(item, "de")
+	if len(problems) > 0 {
+		t.Fatalf("valid suggestion rejected: %v", problems)
+	}
+	if suggestion.Language != "de" || suggestion.Translations["de"].Name != "Wanderschuhe" || suggestion.NameEN != "Wanderschuhe" {
+		t.Errorf("suggestion = %+v, want a German text mirrored in the bilingual fields", suggestion)
+	}
+
+	_, problems = validateGiftSuggestion(MistralGiftSuggestion{Description: "Für die Berge", PriceRange: "€80", Category: "Outdoor"}, "de")
+	if len(problems) != 1 || problems[0] != "name is empty" {
+		t.Errorf("got problems %v, want empty name", problems)
+	}
+}
+
+func TestGenerateSuggestionsAttemptRepairsInvalidItems(t *testing.T) {
+	var stored []giftGenerationResponse
+	store := storeGiftGenerationResponse
+	t.Cleanup(func() { storeGiftGenerationResponse = store })
+	storeGiftGenerationResponse = func(response giftGenerationResponse) { stored = append(stored, response) }
+
+	invalidItem := `{"name_en":"Headphones","name_fr":"","description_en":"","description_fr":"","price_range":"€50-80","category":"Electronics","url":""}`
+	fixedItem := `{"name_en":"Headphones","name_fr":"Casque audio","description_en":"","description_fr":"","price_range":"€50-80","category":"Electronics","url":""}`
+	fake := NewFakeLLMClient(
+		LLMResponse{Message: MistralMessage{Role: "assistant", Content: `{"suggestions":[` + testValidGiftItem + `,` + invalidItem + `]}`}},
+		LLMResponse{Message: MistralMessage{Role: "assistant", Content: `{"suggestions":[` + fixedItem + `]}`}},
+	)
+	service := &GiftSuggestionService{llm: fake}
+
+	suggestions, err := service.generateSuggestionsAttempt(context.Background(), GiftSuggestionRequest{EventID: "e1"}, nil)
+	if err != nil {
+		t.Fatalf("generateSuggestionsAttempt() error = %v", err)
+	}
+	if len(suggestions) != 2 || suggestions[1].NameFR != "Casque audio" {
+		t.Fatalf("got suggestions %+v, want the valid one and the repaired one", suggestions)
+	}
+	for _, suggestion := range suggestions {
+		if suggestion.PromptVersion == nil || *suggestion.PromptVersion != "v4" {
+			t.Errorf("suggestion %q does not record the prompt version", suggestion.NameEN)
+		}
+	}
+
+	requests := fake.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("model called %d times, want 2", len(requests))
+	}
+	if requests[0].ResponseFormat == nil || requests[0].ResponseFormat.Schema == nil {
+		t.Error("the JSON schema was not requested")
+	}
+	repairPrompt := requests[1].Messages[len(requests[1].Messages)-1].Content
+	if !strings.Contains(repairPrompt, "name_fr is empty") || !strings.Contains(repairPrompt, "ONLY 1 new") {
+		t.Errorf("repair prompt does not ask for the invalid and missing suggestions: %s", repairPrompt)
+	}
+
+	if len(stored) != 2 || stored[0].InvalidCount != 1 || stored[0].RawResponse == "" || stored[1].Repair != 1 {
+		t.Errorf("stored responses %+v, want both raw replies with their validation results", stored)
+	}
+}
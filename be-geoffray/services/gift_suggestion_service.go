@@ -1,16 +1,17 @@
 package services
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
 	"strings"
 	"time"
 
+	"be-geoffray/config"
+	"be-geoffray/db"
 	"be-geoffray/models"
+	"be-geoffray/prompts"
+
+	"github.com/lib/pq"
 )
 
 // GiftSuggestionRequest represents the data needed to generate gift suggestions
@@ -21,13 +22,22 @@ type GiftSuggestionRequest struct {
 	EventDate        string `json:"event_date"`
 	Location         string `json:"location,omitempty"`
 	Description      string `json:"description,omitempty"`
-	Language         string `json:"language"`              // "en" or "fr"
+	Language         string `json:"language"`              // Code of one of GiftLanguages, English when unknown
 	UserPrompt       string `json:"user_prompt,omitempty"` // Optional user-provided prompt
 	SingleSuggestion bool   `json:"single_suggestion"`     // Generate only one suggestion
+	// Preferences learnt from the votes on the event's suggestions, nil when unknown
+	Preferences *GiftPreferenceProfile `json:"-"`
+	// Giftee is what the organizers told about the recipient, nil when unknown
+	Giftee  *models.GifteeProfile `json:"-"`
+	EventID string                `json:"-"` // Event the AI usage is attributed to
+	UserID  string                `json:"-"` // User the AI usage is attributed to
 }
 
 // MistralGiftSuggestion represents a single gift suggestion from Mistral
+// Name and Description are in the request language; prompts up to v3 asked for English and French instead
 type MistralGiftSuggestion struct {
+	Name          string `json:"name,omitempty"`
+	Description   string `json:"description,omitempty"`
 	NameEN        string `json:"name_en"`
 	NameFR        string `json:"name_fr"`
 	DescriptionEN string `json:"description_en"`
@@ -37,118 +47,65 @@ type MistralGiftSuggestion struct {
 	URL           string `json:"url,omitempty"`
 }
 
-// MistralGiftResponse represents the response from Mistral API
-type MistralGiftResponse struct {
-	Suggestions []MistralGiftSuggestion `json:"suggestions"`
-}
-
-// MistralChatRequest for gift suggestion generation (simplified)
-type MistralGiftChatRequest struct {
-	Model       string           `json:"model"`
-	Messages    []MistralMessage `json:"messages"`
-	MaxTokens   int              `json:"max_tokens"`
-	Temperature float64          `json:"temperature"`
-	Stream      bool             `json:"stream"`
-}
-
-// MistralChatResponse for gift suggestions (kept for potential fallback)
-type MistralGiftChatResponse struct {
-	ID      string `json:"id"`
-	Object  string `json:"object"`
-	Created int64  `json:"created"`
-	Model   string `json:"model"`
-	Choices []struct {
-		Index   int `json:"index"`
-		Message struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
-		} `json:"message"`
-		FinishReason string `json:"finish_reason"`
-	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
-}
-
-// MistralConversationResponse for Conversations API
-type MistralConversationResponse struct {
-	Object         string `json:"object"`
-	ConversationID string `json:"conversation_id"`
-	Outputs        []struct {
-		Object      string `json:"object"`
-		Type        string `json:"type"`
-		CreatedAt   string `json:"created_at"`
-		CompletedAt string `json:"completed_at"`
-		ID          string `json:"id"`
-		AgentID     string `json:"agent_id"`
-		Model       string `json:"model"`
-		Role        string `json:"role"`
-		Content     string `json:"content"`
-	} `json:"outputs"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
-}
-
 // GiftSuggestionService handles gift suggestion generation
 type GiftSuggestionService struct {
-	mistralAPIKey  string
-	mistralAgentID string
-	mistralURL     string
-	amazonService  *AmazonService
+	llm           LLMClient
+	amazonService *AmazonService
+	similarity    *GiftSimilarityEngine
+	prompts       *prompts.Registry // Prompt templates, the default ones when nil
+	llmTieBreak   bool              // Ask the language model about borderline similarity scores
 }
 
 // NewGiftSuggestionService creates a new gift suggestion service
 func NewGiftSuggestionService() *GiftSuggestionService {
+	cfg := config.GetConfig()
 	return &GiftSuggestionService{
-		mistralAPIKey:  os.Getenv("MISTRAL_API_KEY"),
-		mistralAgentID: os.Getenv("MISTRAL_AGENT_ID"),
-		mistralURL:     "https://api.mistral.ai/v1/conversations",
-		amazonService:  NewAmazonService(),
+		llm:           GetLLMClient(),
+		amazonService: NewAmazonService(),
+		similarity: NewGiftSimilarityEngine(SimilarityThresholds{
+			Duplicate: cfg.GiftSimilarityDuplicateThreshold,
+			Distinct:  cfg.GiftSimilarityDistinctThreshold,
+		}),
+		llmTieBreak: cfg.GiftSimilarityLLMTieBreak,
 	}
 }
 
-// MistralConversationRequest matches the exact working format
-type MistralConversationRequest struct {
-	AgentID string           `json:"agent_id"`
-	Stream  bool             `json:"stream"`
-	Inputs  []MistralMessage `json:"inputs"`
-}
-
-// GenerateGiftSuggestions generates gift suggestions using Mistral AI Agent with similarity checking
+// GenerateGiftSuggestions generates gift suggestions using the configured language model with similarity checking
 func (g *GiftSuggestionService) GenerateGiftSuggestions(request GiftSuggestionRequest, existingSuggestions []models.GiftSuggestion) ([]models.GiftSuggestion, error) {
-	if g.mistralAPIKey == "" {
-		return nil, fmt.Errorf("MISTRAL_API_KEY not configured")
-	}
-
-	if g.mistralAgentID == "" {
-		return nil, fmt.Errorf("MISTRAL_AGENT_ID not configured")
-	}
-
 	const maxRetries = 3
+	request.Language = generationLanguage(request.Language)
 	var validSuggestions []models.GiftSuggestion
 	allExistingSuggestions := make([]models.GiftSuggestion, len(existingSuggestions))
 	copy(allExistingSuggestions, existingSuggestions)
+	// Gifts the group voted down must not come back, even after their suggestions were deleted
+	allExistingSuggestions = withRejectedSuggestions(allExistingSuggestions, request.Preferences)
+	// Neither should gifts the giftee already owns
+	allExistingSuggestions = withOwnedItems(allExistingSuggestions, request.Giftee)
+	if !request.Preferences.IsEmpty() {
+		fmt.Printf("Steering gift suggestions with the event preferences: %s\n", request.Preferences.describePreferences())
+	}
+
+	// Attribute the language model calls to the requester and the event, for usage accounting and quotas
+	scope := LLMUsageScope{UserID: request.UserID, EventID: request.EventID, Purpose: LLMPurposeGeneration}
+	generationCtx := WithLLMUsageScope(context.Background(), scope)
+	scope.Purpose = LLMPurposeSimilarity
+	similarityCtx := WithLLMUsageScope(context.Background(), scope)
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		fmt.Printf("Gift suggestion generation attempt %d/%d\n", attempt, maxRetries)
 
 		// Generate suggestions with current exclusion list
-		suggestions, err := g.generateSuggestionsAttempt(request, allExistingSuggestions)
+		suggestions, err := g.generateSuggestionsAttempt(generationCtx, request, allExistingSuggestions)
 		if err != nil {
 			return nil, err
 		}
 
 		// Validate each suggestion for similarity
 		for _, suggestion := range suggestions {
-			isSimilar, reason, err := g.CheckSimilarity(suggestion, allExistingSuggestions, request.Language)
+			isSimilar, reason, err := g.CheckSimilarity(similarityCtx, suggestion, allExistingSuggestions, request.Language)
 			if err != nil {
-				fmt.Printf("Warning: similarity check failed: %v\n", err)
-				// On similarity check error, accept the suggestion (fail open)
+				fmt.Printf("Warning: similarity tie-breaker failed: %v\n", err)
+				// Only borderline suggestions reach the tie-breaker, accept them when it fails
 				validSuggestions = append(validSuggestions, suggestion)
 				continue
 			}
@@ -185,344 +142,326 @@ func (g *GiftSuggestionService) GenerateGiftSuggestions(request GiftSuggestionRe
 		return nil, fmt.Errorf("failed to generate unique suggestions after %d attempts", maxRetries)
 	}
 
+	// Best fits first, callers needing a single suggestion take the first one
+	rankByPreferences(validSuggestions, request.Preferences)
+
 	// Enrich suggestions with Amazon affiliate data
 	g.enrichWithAmazonData(validSuggestions, request.Language)
 
+	// Clients reading name_en and name_fr get real texts, whatever the language of the event
+	scope.Purpose = LLMPurposeTranslation
+	translator := &GiftTranslationService{llm: g.llm, prompts: g.prompts}
+	if err := translator.FillLegacyLanguages(WithLLMUsageScope(context.Background(), scope), validSuggestions); err != nil {
+		// The bilingual fields fall back to the generated language until the gifts are translated on demand
+		fmt.Printf("Warning: failed to translate gift suggestions to English and French: %v\n", err)
+	}
+
 	return validSuggestions, nil
 }
 
+// maxGiftRepairRounds is the number of times invalid suggestions are sent back to the model for correction
+const maxGiftRepairRounds = 2
+
 // generateSuggestionsAttempt makes a single attempt to generate suggestions
-func (g *GiftSuggestionService) generateSuggestionsAttempt(request GiftSuggestionRequest, existingSuggestions []models.GiftSuggestion) ([]models.GiftSuggestion, error) {
+// The reply is requested as JSON matching giftSuggestionSchema and validated field by field;
+// the model is then asked to replace only the invalid suggestions
+func (g *GiftSuggestionService) generateSuggestionsAttempt(ctx context.Context, request GiftSuggestionRequest, existingSuggestions []models.GiftSuggestion) ([]models.GiftSuggestion, error) {
 	// Create the prompt for gift suggestions
-	prompt := g.buildGiftSuggestionPrompt(request, existingSuggestions)
-
-	// Prepare Mistral Conversations API request matching working curl format
-	mistralRequest := MistralConversationRequest{
-		AgentID: g.mistralAgentID,
-		Stream:  false,
-		Inputs: []MistralMessage{
-			{
-				Role:    "user",
-				Content: prompt,
-			},
+	prompt, promptVersion, err := g.buildGiftSuggestionPrompt(request, existingSuggestions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build gift suggestion prompt: %w", err)
+	}
+	language := generationLanguage(request.Language)
+	bilingual := isBilingualGiftPrompt(promptVersion)
+	messages := []MistralMessage{
+		{
+			Role:    "user",
+			Content: prompt,
 		},
 	}
-
-	// Convert to JSON
-	requestBody, err := json.Marshal(mistralRequest)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	format := &LLMResponseFormat{Name: "gift_suggestions", Schema: giftSuggestionSchema}
+	if bilingual {
+		format.Schema = bilingualGiftSuggestionSchema
 	}
 
-	// Use conversations endpoint directly (no agent ID in URL)
-	fmt.Printf("Making request to Mistral Conversations URL: %s\n", g.mistralURL)
-
-	// Make HTTP request to Mistral
-	req, err := http.NewRequest("POST", g.mistralURL, bytes.NewBuffer(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	// The prompt asks for 2-3 suggestions, 2 are enough
+	requested := 2
+	if request.SingleSuggestion {
+		requested = 1
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+g.mistralAPIKey)
+	var suggestions []models.GiftSuggestion
+	for repair := 0; repair <= maxGiftRepairRounds; repair++ {
+		response, err := g.llm.Chat(ctx, LLMRequest{Messages: messages, ResponseFormat: format})
+		if err != nil {
+			if len(suggestions) > 0 {
+				// Keep the suggestions that were already valid
+				fmt.Printf("Warning: gift suggestion repair failed: %v\n", err)
+				break
+			}
+			return nil, fmt.Errorf("failed to generate gift suggestions: %w", err)
+		}
 
-	client := &http.Client{Timeout: 120 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		content := response.Message.Content
+		valid, problems, missing := validateGiftSuggestionReply(content, requested-len(suggestions), language)
+		fmt.Printf("Gift suggestion reply (repair %d): %d valid, %d to replace\n", repair, len(valid), missing)
+
+		storeGiftGenerationResponse(giftGenerationResponse{
+			EventID:       request.EventID,
+			UserID:        request.UserID,
+			Repair:        repair,
+			PromptVersion: promptVersion,
+			RawResponse:   content,
+			ValidCount:    len(valid),
+			InvalidCount:  missing,
+			Errors:        problems,
+		})
+
+		for i := range valid {
+			valid[i].PromptVersion = &promptVersion
+		}
+		suggestions = append(suggestions, valid...)
+		if missing == 0 {
+			break
+		}
+
+		// Ask only for replacements of the invalid suggestions, with the previous reply as context
+		messages = append(messages,
+			MistralMessage{Role: "assistant", Content: content},
+			MistralMessage{Role: "user", Content: buildGiftRepairPrompt(problems, missing, language, bilingual)},
+		)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("mistral API error %d: %s", resp.StatusCode, string(body))
+	if len(suggestions) == 0 {
+		return nil, fmt.Errorf("no valid gift suggestions after %d repairs", maxGiftRepairRounds)
 	}
+	return suggestions, nil
+}
 
-	// Read response
-	responseBody, err := io.ReadAll(resp.Body)
+// validateGiftSuggestionReply parses a reply and validates its suggestions
+// It returns the valid ones, the problems of the others and how many must be regenerated
+func validateGiftSuggestionReply(content string, expected int, language string) ([]models.GiftSuggestion, []string, int) {
+	items, invalid, err := parseGiftSuggestionItems(content)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, []string{"the reply could not be read: " + err.Error()}, expected
 	}
 
-	// Log raw response for debugging
-	fmt.Printf("Mistral API Response Status: %d\n", resp.StatusCode)
-
-	// Parse Mistral Conversations API response
-	var mistralResponse MistralConversationResponse
-	if err := json.Unmarshal(responseBody, &mistralResponse); err != nil {
-		fmt.Printf("Failed to parse as MistralConversationResponse: %v\n", err)
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	var valid []models.GiftSuggestion
+	var problems []string
+	for _, item := range invalid {
+		problems = append(problems, strings.Join(item.Problems, "; "))
 	}
-
-	if len(mistralResponse.Outputs) == 0 {
-		return nil, fmt.Errorf("no response from Mistral")
+	for _, item := range items {
+		suggestion, itemProblems := validateGiftSuggestion(item, language)
+		if len(itemProblems) > 0 {
+			name := item.Name
+			if name == "" {
+				name = item.NameEN
+			}
+			problems = append(problems, fmt.Sprintf("%q: %s", name, strings.Join(itemProblems, "; ")))
+			continue
+		}
+		valid = append(valid, suggestion)
 	}
 
-	// Extract and parse the JSON suggestions from the response content
-	content := mistralResponse.Outputs[0].Content
-	fmt.Printf("Mistral API extracted content length: %d\n", len(content))
-
-	suggestions, err := g.parseGiftSuggestions(content)
-	if err != nil {
-		fmt.Printf("Error parsing gift suggestions. Content length: %d\n", len(content))
-		return nil, fmt.Errorf("failed to parse gift suggestions: %w", err)
+	// Extra suggestions are welcome, missing ones are regenerated like invalid ones
+	missing := len(problems)
+	if len(valid)+missing < expected {
+		missing = expected - len(valid)
 	}
-
-	return suggestions, nil
+	return valid, problems, missing
 }
 
-// buildGiftSuggestionPrompt creates the prompt for Mistral
-func (g *GiftSuggestionService) buildGiftSuggestionPrompt(request GiftSuggestionRequest, existingSuggestions []models.GiftSuggestion) string {
+// buildGiftRepairPrompt asks the model to replace the invalid suggestions of its previous reply
+// bilingual is set for the prompt versions asking for English and French texts
+func buildGiftRepairPrompt(problems []string, missing int, language string, bilingual bool) string {
 	var prompt strings.Builder
 
-	// Determine the number of suggestions to generate
-	numSuggestions := "2-3"
-	if request.SingleSuggestion {
-		numSuggestions = "1"
+	prompt.WriteString("Some suggestions of your previous reply are invalid:\n")
+	for _, problem := range problems {
+		prompt.WriteString(fmt.Sprintf("- %s\n", problem))
 	}
-
-	// Handle user-provided prompt
-	if request.UserPrompt != "" {
-		prompt.WriteString(fmt.Sprintf("Generate %s gift suggestion(s) based on this user request:\n", numSuggestions))
-		prompt.WriteString(fmt.Sprintf("User Request: %s\n\n", request.UserPrompt))
-		prompt.WriteString(fmt.Sprintf("Context - Persona: %s, Occasion: %s\n\n", request.GifteePersona, request.EventOccasion))
+	prompt.WriteString(fmt.Sprintf("\nReturn ONLY %d new suggestion(s) replacing them, in the same JSON format ({\"suggestions\": [...]}).\n", missing))
+	if bilingual {
+		prompt.WriteString("- name_en and name_fr must both be filled\n")
 	} else {
-		prompt.WriteString(fmt.Sprintf("Generate %s gift suggestions for %s for %s.\n\n", numSuggestions, request.GifteePersona, request.EventOccasion))
+		prompt.WriteString(fmt.Sprintf("- name must be filled, name and description written in %s\n", GiftLanguages[language]))
 	}
+	prompt.WriteString("- price_range must be a price in euros such as \"€15-30\"\n")
+	prompt.WriteString(fmt.Sprintf("- category must be one of: %s\n", strings.Join(GiftCategories, ", ")))
+	prompt.WriteString("- url must be an empty string\n")
 
-	prompt.WriteString("Event Details:\n")
-	prompt.WriteString(fmt.Sprintf("- Title: %s\n", request.EventTitle))
-	prompt.WriteString(fmt.Sprintf("- Date: %s\n", request.EventDate))
+	return prompt.String()
+}
 
-	if request.Location != "" {
-		prompt.WriteString(fmt.Sprintf("- Location: %s\n", request.Location))
-	}
+// giftGenerationResponse is a raw reply to a gift generation request, with its validation results
+type giftGenerationResponse struct {
+	EventID       string
+	UserID        string
+	Repair        int
+	PromptVersion string
+	RawResponse   string
+	ValidCount    int
+	InvalidCount  int
+	Errors        []string
+}
 
-	if request.Description != "" {
-		prompt.WriteString(fmt.Sprintf("- Description: %s\n", request.Description))
+// storeGiftGenerationResponse keeps the raw reply for debugging, replaced in tests
+var storeGiftGenerationResponse = func(response giftGenerationResponse) {
+	_, err := db.DB.Exec(`
+		INSERT INTO gift_generation_responses (
+			event_id, user_id, repair, prompt_version, raw_response, valid_count, invalid_count, validation_errors
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, optionalString(response.EventID), optionalString(response.UserID), response.Repair,
+		optionalString(response.PromptVersion), response.RawResponse,
+		response.ValidCount, response.InvalidCount, pq.Array(append([]string{}, response.Errors...)))
+	if err != nil {
+		fmt.Printf("Warning: failed to store gift generation response: %v\n", err)
 	}
+}
 
-	// Add existing suggestions to avoid
-	if len(existingSuggestions) > 0 {
-		prompt.WriteString("\n⚠️ AVOID THESE EXISTING SUGGESTIONS - Do not generate similar gifts:\n")
-		for i, existing := range existingSuggestions {
-			// Use appropriate language fields
-			name := existing.NameEN
-			desc := existing.DescriptionEN
-			if request.Language == "fr" {
-				name = existing.NameFR
-				desc = existing.DescriptionFR
-			}
-
-			prompt.WriteString(fmt.Sprintf("%d. %s (Category: %s)\n", i+1, name, existing.Category))
-			if desc != "" {
-				prompt.WriteString(fmt.Sprintf("   Description: %s\n", desc))
-			}
-		}
-		prompt.WriteString("\nYour new suggestions MUST be different in category OR name OR description from all the above.\n")
-	}
-
-	prompt.WriteString("\nReturn suggestions in this exact JSON format:\n")
-	prompt.WriteString(`{
-  "suggestions": [
-    {
-      "name_en": "English gift name",
-      "name_fr": "French gift name",
-      "description_en": "English description explaining why this gift is perfect",
-      "description_fr": "French description explaining why this gift is perfect",
-      "price_range": "€15-30",
-      "category": "Books",
-      "url": ""
-    }
-  ]
-}`)
-
-	prompt.WriteString("\n\nIMPORTANT RULES:\n")
-	prompt.WriteString("- Both English and French names/descriptions are provided\n")
-	prompt.WriteString("- Price ranges are realistic and in Euros\n")
-	prompt.WriteString("- Categories are specific (Books, Electronics, Fashion, Home, Sports, Kitchen, etc.)\n")
-	prompt.WriteString("- URL field: LEAVE EMPTY (just use empty string \"\") - DO NOT create fake URLs\n")
-	prompt.WriteString("- NEVER generate example URLs like https://example.com or https://amazon.fr/fake-product\n")
-	prompt.WriteString("- DO NOT invent product IDs or links that don't exist\n")
-	prompt.WriteString("- Focus on describing the gift well so users can search for it themselves\n")
-	if request.UserPrompt != "" {
-		prompt.WriteString("- The suggestion closely matches the user's specific request\n")
-	}
-	prompt.WriteString("- Suggestions are thoughtful and appropriate for the persona and occasion\n")
-
-	return prompt.String()
+// promptSuggestion is a suggestion as shown in prompt templates, in the request language
+type promptSuggestion struct {
+	Name        string
+	Description string
+	Category    string
 }
 
-// parseGiftSuggestions extracts gift suggestions from Mistral's response
-func (g *GiftSuggestionService) parseGiftSuggestions(content string) ([]models.GiftSuggestion, error) {
-	fmt.Printf("parseGiftSuggestions called with content length: %d\n", len(content))
+// newPromptSuggestion picks the fields of a suggestion in the given language
+func newPromptSuggestion(suggestion models.GiftSuggestion, language string) promptSuggestion {
+	text := LocalizedGiftText(suggestion, language)
+	return promptSuggestion{Name: text.Name, Description: text.Description, Category: suggestion.Category}
+}
 
-	// Handle empty content
-	if content == "" {
-		return nil, fmt.Errorf("empty content received from Mistral")
+// generationLanguage returns the supported language suggestions are generated in, English when unknown
+func generationLanguage(code string) string {
+	if language, ok := NormalizeGiftLanguage(code); ok {
+		return language
 	}
+	return DefaultGiftLanguage
+}
 
-	// Find JSON in the response (it might be wrapped in markdown or other text)
-	start := strings.Index(content, "{")
-	end := strings.LastIndex(content, "}") + 1
+// giftPromptData is the data of the gift suggestion prompt templates
+type giftPromptData struct {
+	GiftSuggestionRequest
+	NumSuggestions string
+	LanguageName   string // Name of the language suggestions are written in, e.g. "German"
+	Existing       []promptSuggestion
+	Categories     []string
+	GifteeProfile  *gifteePromptProfile // Nil when the organizers told nothing about the giftee
+}
 
-	if start == -1 || end == 0 {
-		fmt.Printf("No JSON found in content: %s\n", content)
-		return nil, fmt.Errorf("no JSON found in response")
+// promptRegistry returns the prompt templates of the service, the default ones if none were set
+func (g *GiftSuggestionService) promptRegistry() (*prompts.Registry, error) {
+	if g.prompts != nil {
+		return g.prompts, nil
 	}
+	return prompts.Default()
+}
 
-	jsonStr := content[start:end]
-	fmt.Printf("Extracted JSON string: %s\n", jsonStr)
-
-	// Parse the JSON response
-	var response MistralGiftResponse
-	if err := json.Unmarshal([]byte(jsonStr), &response); err != nil {
-		fmt.Printf("JSON unmarshal error: %v\n", err)
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+// buildGiftSuggestionPrompt renders the gift suggestion prompt selected for the request
+// It also returns the template version, recorded on the generated suggestions
+func (g *GiftSuggestionService) buildGiftSuggestionPrompt(request GiftSuggestionRequest, existingSuggestions []models.GiftSuggestion) (string, string, error) {
+	registry, err := g.promptRegistry()
+	if err != nil {
+		return "", "", err
+	}
+	selector := prompts.Selector{Persona: request.GifteePersona, Occasion: request.EventOccasion, Language: request.Language}
+	// Events keep the same version, so that all their suggestions can be compared by vote
+	variant, err := registry.Select(prompts.GiftSuggestions, selector, request.EventID)
+	if err != nil {
+		return "", "", err
 	}
 
-	// Convert to our model format
-	var suggestions []models.GiftSuggestion
-	for _, mistralSugg := range response.Suggestions {
-		suggestion := models.GiftSuggestion{
-			NameEN:        mistralSugg.NameEN,
-			NameFR:        mistralSugg.NameFR,
-			DescriptionEN: mistralSugg.DescriptionEN,
-			DescriptionFR: mistralSugg.DescriptionFR,
-			PriceRange:    mistralSugg.PriceRange,
-			Category:      mistralSugg.Category,
-			URL:           mistralSugg.URL,
-			GeneratedAt:   time.Now(),
-			CreatedAt:     time.Now(),
-			UpdatedAt:     time.Now(),
-		}
-		suggestions = append(suggestions, suggestion)
+	data := giftPromptData{
+		GiftSuggestionRequest: request,
+		NumSuggestions:        "2-3",
+		LanguageName:          GiftLanguages[generationLanguage(request.Language)],
+		Categories:            GiftCategories,
+		GifteeProfile:         newGifteePromptProfile(request.Giftee),
+	}
+	if request.SingleSuggestion {
+		data.NumSuggestions = "1"
+	}
+	for _, existing := range existingSuggestions {
+		data.Existing = append(data.Existing, newPromptSuggestion(existing, request.Language))
 	}
 
-	return suggestions, nil
+	prompt, err := variant.Render(data)
+	if err != nil {
+		return "", "", err
+	}
+	return prompt, variant.Version, nil
 }
 
 // CheckSimilarity checks if a new suggestion is too similar to existing ones
-// Uses Mistral AI to perform semantic similarity detection on category, name, and description
-func (g *GiftSuggestionService) CheckSimilarity(newSuggestion models.GiftSuggestion, existingSuggestions []models.GiftSuggestion, language string) (bool, string, error) {
-	if g.mistralAPIKey == "" {
-		// If Mistral is not configured, skip similarity check
-		return false, "", nil
-	}
-
-	if len(existingSuggestions) == 0 {
+// The local similarity engine decides; when enabled, the language model breaks the tie on borderline scores
+func (g *GiftSuggestionService) CheckSimilarity(ctx context.Context, newSuggestion models.GiftSuggestion, existingSuggestions []models.GiftSuggestion, language string) (bool, string, error) {
+	match := g.similarity.BestMatch(newSuggestion, existingSuggestions)
+	if match == nil {
 		// No existing suggestions to compare against
 		return false, "", nil
 	}
+	if g.similarity.IsDuplicate(match) {
+		return true, match.Reason(), nil
+	}
+	if !g.llmTieBreak || !g.similarity.IsBorderline(match) {
+		return false, match.Reason(), nil
+	}
 
-	// Build comparison prompt
-	prompt := g.buildSimilarityCheckPrompt(newSuggestion, existingSuggestions, language)
+	// Only the closest existing suggestion is submitted to the model
+	prompt, err := g.buildSimilarityCheckPrompt(newSuggestion, []models.GiftSuggestion{match.Existing}, language)
+	if err != nil {
+		return false, match.Reason(), fmt.Errorf("similarity check failed: %w", err)
+	}
 
-	// Use Mistral Chat API for faster responses
-	mistralRequest := MistralGiftChatRequest{
-		Model:       "mistral-large-latest",
+	temperature := 0.0 // Low temperature for consistent similarity checks
+	response, err := g.llm.Chat(ctx, LLMRequest{
 		MaxTokens:   500,
-		Temperature: 0.0, // Low temperature for consistent similarity checks
-		Stream:      false,
+		Temperature: &temperature,
 		Messages: []MistralMessage{
 			{
 				Role:    "user",
 				Content: prompt,
 			},
 		},
-	}
-
-	requestBody, err := json.Marshal(mistralRequest)
+	})
 	if err != nil {
-		return false, "", fmt.Errorf("failed to marshal similarity check request: %w", err)
+		return false, match.Reason(), fmt.Errorf("similarity check failed: %w", err)
 	}
 
-	// Make HTTP request to Mistral Chat API
-	chatURL := "https://api.mistral.ai/v1/chat/completions"
-	req, err := http.NewRequest("POST", chatURL, bytes.NewBuffer(requestBody))
-	if err != nil {
-		return false, "", fmt.Errorf("failed to create similarity check request: %w", err)
-	}
+	// Parse the AI's response
+	aiResponse := strings.TrimSpace(response.Message.Content)
+	fmt.Printf("Similarity tie-breaker response: %s\n", aiResponse)
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+g.mistralAPIKey)
+	// The answer starts with YES or NO, a reason may follow
+	isSimilar := strings.HasPrefix(strings.ToUpper(aiResponse), "YES")
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return false, "", fmt.Errorf("failed to make similarity check request: %w", err)
-	}
-	defer resp.Body.Close()
+	return isSimilar, match.Reason() + "; " + aiResponse, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return false, "", fmt.Errorf("mistral similarity check API error %d: %s", resp.StatusCode, string(body))
-	}
+// similarityPromptData is the data of the similarity check prompt templates
+type similarityPromptData struct {
+	New      promptSuggestion
+	Existing []promptSuggestion
+}
 
-	// Parse response
-	responseBody, err := io.ReadAll(resp.Body)
+// buildSimilarityCheckPrompt renders the prompt for checking similarity
+func (g *GiftSuggestionService) buildSimilarityCheckPrompt(newSuggestion models.GiftSuggestion, existingSuggestions []models.GiftSuggestion, language string) (string, error) {
+	registry, err := g.promptRegistry()
 	if err != nil {
-		return false, "", fmt.Errorf("failed to read similarity check response: %w", err)
+		return "", err
 	}
-
-	var chatResponse MistralGiftChatResponse
-	if err := json.Unmarshal(responseBody, &chatResponse); err != nil {
-		return false, "", fmt.Errorf("failed to parse similarity check response: %w", err)
+	variant, err := registry.Select(prompts.GiftSimilarity, prompts.Selector{Language: language}, "")
+	if err != nil {
+		return "", err
 	}
 
-	if len(chatResponse.Choices) == 0 {
-		return false, "", fmt.Errorf("no response from Mistral similarity check")
+	data := similarityPromptData{New: newPromptSuggestion(newSuggestion, language)}
+	for _, existing := range existingSuggestions {
+		data.Existing = append(data.Existing, newPromptSuggestion(existing, language))
 	}
-
-	// Parse the AI's response
-	aiResponse := strings.TrimSpace(chatResponse.Choices[0].Message.Content)
-	fmt.Printf("Similarity check response: %s\n", aiResponse)
-
-	// Check if AI says it's similar
-	isSimilar := strings.Contains(strings.ToUpper(aiResponse), "YES") ||
-		strings.Contains(strings.ToUpper(aiResponse), "SIMILAR")
-
-	return isSimilar, aiResponse, nil
-}
-
-// buildSimilarityCheckPrompt creates a prompt for checking similarity
-func (g *GiftSuggestionService) buildSimilarityCheckPrompt(newSuggestion models.GiftSuggestion, existingSuggestions []models.GiftSuggestion, language string) string {
-	var prompt strings.Builder
-
-	prompt.WriteString("You are a gift suggestion similarity checker. Analyze if the NEW suggestion is too similar to any EXISTING suggestions.\n\n")
-	prompt.WriteString("A suggestion is TOO SIMILAR if it matches in category AND (name OR description are semantically similar).\n\n")
-
-	// Get the appropriate name and description based on language
-	newName := newSuggestion.NameEN
-	newDesc := newSuggestion.DescriptionEN
-	if language == "fr" {
-		newName = newSuggestion.NameFR
-		newDesc = newSuggestion.DescriptionFR
-	}
-
-	prompt.WriteString("NEW SUGGESTION:\n")
-	prompt.WriteString(fmt.Sprintf("- Category: %s\n", newSuggestion.Category))
-	prompt.WriteString(fmt.Sprintf("- Name: %s\n", newName))
-	prompt.WriteString(fmt.Sprintf("- Description: %s\n\n", newDesc))
-
-	prompt.WriteString("EXISTING SUGGESTIONS:\n")
-	for i, existing := range existingSuggestions {
-		existingName := existing.NameEN
-		existingDesc := existing.DescriptionEN
-		if language == "fr" {
-			existingName = existing.NameFR
-			existingDesc = existing.DescriptionFR
-		}
-
-		prompt.WriteString(fmt.Sprintf("%d. Category: %s\n", i+1, existing.Category))
-		prompt.WriteString(fmt.Sprintf("   Name: %s\n", existingName))
-		prompt.WriteString(fmt.Sprintf("   Description: %s\n\n", existingDesc))
-	}
-
-	prompt.WriteString("Answer with ONLY 'YES' if the new suggestion is too similar to any existing suggestion, or 'NO' if it's sufficiently different.\n")
-	prompt.WriteString("You may add a brief reason after your YES/NO answer.\n")
-
-	return prompt.String()
+	return variant.Render(data)
 }
 
 // enrichWithAmazonData adds Amazon affiliate links to suggestions
@@ -538,10 +477,7 @@ func (g *GiftSuggestionService) enrichWithAmazonData(suggestions []models.GiftSu
 		// Still generate search URLs as fallback
 		region := MapLanguageToRegion(language)
 		for i := range suggestions {
-			name := suggestions[i].NameEN
-			if language == "fr" {
-				name = suggestions[i].NameFR
-			}
+			name := LocalizedGiftText(suggestions[i], language).Name
 			searchURL := g.amazonService.GenerateSearchURL(name, region)
 			suggestions[i].AmazonAffiliateURL = &searchURL
 			suggestions[i].AmazonRegion = &region
@@ -556,10 +492,7 @@ func (g *GiftSuggestionService) enrichWithAmazonData(suggestions []models.GiftSu
 
 	for i := range suggestions {
 		// Use appropriate language name for search
-		name := suggestions[i].NameEN
-		if language == "fr" {
-			name = suggestions[i].NameFR
-		}
+		name := LocalizedGiftText(suggestions[i], language).Name
 
 		// Try to enrich with Amazon data
 		affiliateURL, price, asin, err := g.amazonService.EnrichWithAmazonData(name, suggestions[i].Category, region)
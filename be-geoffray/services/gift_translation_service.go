@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"be-geoffray/models"
+	"be-geoffray/prompts"
+)
+
+// maxGiftTranslationBatch is the number of suggestions translated by a single language model call
+const maxGiftTranslationBatch = 20
+
+// giftTranslationSchema is the JSON schema requested from the provider for gift translations
+var giftTranslationSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"translations": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"index":       map[string]interface{}{"type": "integer"},
+					"name":        map[string]interface{}{"type": "string"},
+					"description": map[string]interface{}{"type": "string"},
+				},
+				"required":             []string{"index", "name", "description"},
+				"additionalProperties": false,
+			},
+		},
+	},
+	"required":             []string{"translations"},
+	"additionalProperties": false,
+}
+
+// giftTranslationItem is a gift to translate as shown in the prompt, numbered from 1
+type giftTranslationItem struct {
+	Index       int    `json:"index"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// giftTranslationPromptData is the data of the gift translation prompt templates
+type giftTranslationPromptData struct {
+	LanguageName string
+	Gifts        []giftTranslationItem
+}
+
+// storeGiftTranslations saves the translations of a suggestion, replaced in tests
+var storeGiftTranslations = SaveGiftSuggestionTranslations
+
+// GiftTranslationService translates gift suggestions on demand with the language model
+type GiftTranslationService struct {
+	llm     LLMClient
+	prompts *prompts.Registry // Prompt templates, the default ones when nil
+}
+
+// NewGiftTranslationService creates a new gift translation service
+func NewGiftTranslationService() *GiftTranslationService {
+	return &GiftTranslationService{llm: GetLLMClient()}
+}
+
+// TranslateGiftSuggestions translates the suggestions that have no text in language yet, from the language
+// they were written in, and stores the translations so that each gift is translated once
+// Suggestions are updated in place; those the model skipped keep their fallback text
+func (s *GiftTranslationService) TranslateGiftSuggestions(ctx context.Context, suggestions []models.GiftSuggestion, language string) error {
+	return s.translateMissing(ctx, suggestions, language, storeGiftTranslations)
+}
+
+// FillLegacyLanguages translates suggestions that are not stored yet into English and French, so that
+// name_en/name_fr/description_en/description_fr hold real texts for the clients reading them
+// The translations are stored along with the suggestions
+func (s *GiftTranslationService) FillLegacyLanguages(ctx context.Context, suggestions []models.GiftSuggestion) error {
+	for _, language := range []string{"en", "fr"} {
+		if err := s.translateMissing(ctx, suggestions, language, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// translateMissing translates the suggestions without a text in language, storing each translation with store when set
+func (s *GiftTranslationService) translateMissing(ctx context.Context, suggestions []models.GiftSuggestion, language string, store func(*models.GiftSuggestion) error) error {
+	var missing []int
+	for i, suggestion := range suggestions {
+		if !HasGiftTranslation(suggestion, language) && LocalizedGiftText(suggestion, suggestion.Language).Name != "" {
+			missing = append(missing, i)
+		}
+	}
+
+	for start := 0; start < len(missing); start += maxGiftTranslationBatch {
+		end := min(start+maxGiftTranslationBatch, len(missing))
+		if err := s.translateBatch(ctx, suggestions, missing[start:end], language, store); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// translateBatch translates the suggestions at the given indexes in one call
+func (s *GiftTranslationService) translateBatch(ctx context.Context, suggestions []models.GiftSuggestion, indexes []int, language string, store func(*models.GiftSuggestion) error) error {
+	prompt, err := s.buildGiftTranslationPrompt(suggestions, indexes, language)
+	if err != nil {
+		return fmt.Errorf("failed to build gift translation prompt: %w", err)
+	}
+
+	temperature := 0.2 // Translations should stay close to the original
+	response, err := s.llm.Chat(ctx, LLMRequest{
+		Messages:       []MistralMessage{{Role: "user", Content: prompt}},
+		Temperature:    &temperature,
+		ResponseFormat: &LLMResponseFormat{Name: "gift_translations", Schema: giftTranslationSchema},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to translate gift suggestions: %w", err)
+	}
+
+	items, err := parseGiftTranslations(response.Message.Content)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if item.Index < 1 || item.Index > len(indexes) {
+			continue
+		}
+		suggestion := &suggestions[indexes[item.Index-1]]
+		SetGiftTranslation(suggestion, language, models.GiftTranslation{
+			Name:        item.Name,
+			Description: item.Description,
+			Source:      GiftTranslationMachine,
+		})
+		if store == nil {
+			continue
+		}
+		if err := store(suggestion); err != nil {
+			fmt.Printf("Warning: failed to store the %s translation of suggestion %s: %v\n", language, suggestion.ID, err)
+		}
+	}
+	return nil
+}
+
+// buildGiftTranslationPrompt renders the gift translation prompt for the suggestions at the given indexes
+func (s *GiftTranslationService) buildGiftTranslationPrompt(suggestions []models.GiftSuggestion, indexes []int, language string) (string, error) {
+	registry := s.prompts
+	if registry == nil {
+		var err error
+		if registry, err = prompts.Default(); err != nil {
+			return "", err
+		}
+	}
+	variant, err := registry.Select(prompts.GiftTranslation, prompts.Selector{Language: language}, "")
+	if err != nil {
+		return "", err
+	}
+
+	data := giftTranslationPromptData{LanguageName: GiftLanguages[language]}
+	for i, index := range indexes {
+		text := LocalizedGiftText(suggestions[index], suggestions[index].Language)
+		data.Gifts = append(data.Gifts, giftTranslationItem{Index: i + 1, Name: text.Name, Description: text.Description})
+	}
+	return variant.Render(data)
+}
+
+// parseGiftTranslations reads the translations of a reply, dropping the ones without a name
+func parseGiftTranslations(content string) ([]giftTranslationItem, error) {
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start == -1 || end < start {
+		return nil, errors.New("no JSON found in gift translations")
+	}
+	var reply struct {
+		Translations []giftTranslationItem `json:"translations"`
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &reply); err != nil {
+		return nil, fmt.Errorf("invalid gift translations: %w", err)
+	}
+
+	var items []giftTranslationItem
+	for _, item := range reply.Translations {
+		item.Name = strings.TrimSpace(item.Name)
+		item.Description = strings.TrimSpace(item.Description)
+		if item.Name != "" {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
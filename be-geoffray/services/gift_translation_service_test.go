@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"be-geoffray/models"
+)
+
+func TestTranslateGiftSuggestionsOnlyMissingOnes(t *testing.T) {
+	var stored []string
+	store := storeGiftTranslations
+	t.Cleanup(func() { storeGiftTranslations = store })
+	storeGiftTranslations = func(suggestion *models.GiftSuggestion) error {
+		stored = append(stored, suggestion.ID)
+		return nil
+	}
+
+	suggestions := []models.GiftSuggestion{
+		{ID: "s1", Language: "en", Translations: map[string]models.GiftTranslation{"en": {Name: "Hiking boots", Description: "For the mountains"}}},
+		{ID: "s2", Language: "de", Translations: map[string]models.GiftTranslation{"de": {Name: "Buch"}, "es": {Name: "Libro"}}},
+		{ID: "s3", NameEN: "Board game", NameFR: "Jeu de société"},
+	}
+	fake := NewFakeLLMClient(LLMResponse{Message: MistralMessage{Role: "assistant", Content: `{"translations":[
+		{"index":1,"name":" Botas de montaña ","description":"Para la montaña"},
+		{"index":2,"name":"Juego de mesa","description":""},
+		{"index":7,"name":"Unknown","description":""}]}`}})
+	service := &GiftTranslationService{llm: fake}
+
+	if err := service.TranslateGiftSuggestions(context.Background(), suggestions, "es"); err != nil {
+		t.Fatalf("TranslateGiftSuggestions() error = %v", err)
+	}
+
+	requests := fake.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("model called %d times, want 1", len(requests))
+	}
+	prompt := requests[0].Messages[0].Content
+	if !strings.Contains(prompt, "into Spanish") || !strings.Contains(prompt, "1. Hiking boots") ||
+		!strings.Contains(prompt, "2. Board game") || strings.Contains(prompt, "Buch") {
+		t.Errorf("prompt does not list only the untranslated gifts:\n%s", prompt)
+	}
+
+	translated := suggestions[0].Translations["es"]
+	if translated.Name != "Botas de montaña" || translated.Source != GiftTranslationMachine {
+		t.Errorf("s1 translation = %+v", translated)
+	}
+	if suggestions[2].Translations["es"].Name != "Juego de mesa" || suggestions[2].Translations["fr"].Name != "Jeu de société" {
+		t.Errorf("s3 translations = %+v, want its bilingual texts kept", suggestions[2].Translations)
+	}
+	if strings.Join(stored, ",") != "s1,s3" {
+		t.Errorf("stored %v, want s1 and s3", stored)
+	}
+
+	// Nothing is left to translate
+	if err := service.TranslateGiftSuggestions(context.Background(), suggestions, "es"); err != nil || len(fake.Requests()) != 1 {
+		t.Errorf("second translation called the model again: %v", err)
+	}
+}
+
+func TestFillLegacyLanguagesGivesFrenchSuggestionsAnEnglishName(t *testing.T) {
+	store := storeGiftTranslations
+	t.Cleanup(func() { storeGiftTranslations = store })
+	storeGiftTranslations = func(suggestion *models.GiftSuggestion) error {
+		t.Errorf("suggestion %s stored before being inserted", suggestion.ID)
+		return nil
+	}
+
+	// Generated in the language of a French event: only the French text exists
+	suggestions := []models.GiftSuggestion{{
+		ID:           "s1",
+		Language:     "fr",
+		Translations: map[string]models.GiftTranslation{"fr": {Name: "Chaussures de randonnée", Description: "Pour la montagne", Source: GiftTranslationOriginal}},
+	}}
+	SyncLegacyGiftFields(&suggestions[0])
+	if suggestions[0].NameEN != "Chaussures de randonnée" {
+		t.Fatalf("name_en before translation = %q", suggestions[0].NameEN)
+	}
+
+	fake := NewFakeLLMClient(LLMResponse{Message: MistralMessage{Role: "assistant", Content: `{"translations":[
+		{"index":1,"name":"Hiking boots","description":"For the mountains"}]}`}})
+	service := &GiftTranslationService{llm: fake}
+
+	if err := service.FillLegacyLanguages(context.Background(), suggestions); err != nil {
+		t.Fatalf("FillLegacyLanguages() error = %v", err)
+	}
+
+	if requests := fake.Requests(); len(requests) != 1 || !strings.Contains(requests[0].Messages[0].Content, "into English") {
+		t.Fatalf("model calls = %d, want a single translation into English", len(requests))
+	}
+	suggestion := suggestions[0]
+	if suggestion.NameEN != "Hiking boots" || suggestion.DescriptionEN != "For the mountains" {
+		t.Errorf("english fields = %q, %q", suggestion.NameEN, suggestion.DescriptionEN)
+	}
+	if suggestion.NameFR != "Chaussures de randonnée" || suggestion.Language != "fr" {
+		t.Errorf("french field = %q, language = %q", suggestion.NameFR, suggestion.Language)
+	}
+	if suggestion.Translations["en"].Source != GiftTranslationMachine {
+		t.Errorf("english translation = %+v, want a machine translation", suggestion.Translations["en"])
+	}
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"be-geoffray/db"
+	"be-geoffray/models"
+
+	"github.com/lib/pq"
+)
+
+// GiftLanguages are the languages gift texts may be generated and translated in, with their names for prompts
+var GiftLanguages = map[string]string{
+	"en": "English",
+	"fr": "French",
+	"de": "German",
+	"es": "Spanish",
+	"it": "Italian",
+	"nl": "Dutch",
+	"pt": "Portuguese",
+}
+
+// DefaultGiftLanguage is the language gift texts fall back to when a translation is missing
+const DefaultGiftLanguage = "en"
+
+// Sources of gift translations
+const (
+	GiftTranslationOriginal = "original" // As written by a user or generated
+	GiftTranslationMachine  = "machine"  // Translated on demand by the language model
+)
+
+// ErrInvalidGiftTranslation is wrapped by the validation errors of gift texts
+var ErrInvalidGiftTranslation = errors.New("invalid gift translation")
+
+// NormalizeGiftLanguage returns the code of a supported language given as "FR" or "fr-CA"
+func NormalizeGiftLanguage(language string) (string, bool) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if i := strings.IndexAny(language, "-_"); i != -1 {
+		language = language[:i]
+	}
+	_, ok := GiftLanguages[language]
+	return language, ok
+}
+
+// LegacyGiftTranslations builds translations from the English and French fields of older clients
+// Languages without a name are skipped, so that a copy of the other language is not taken for a translation
+func LegacyGiftTranslations(nameEN, descriptionEN, nameFR, descriptionFR string) map[string]models.GiftTranslation {
+	translations := map[string]models.GiftTranslation{}
+	if name := strings.TrimSpace(nameEN); name != "" {
+		translations["en"] = models.GiftTranslation{Name: name, Description: strings.TrimSpace(descriptionEN), Source: GiftTranslationOriginal}
+	}
+	if name := strings.TrimSpace(nameFR); name != "" {
+		translations["fr"] = models.GiftTranslation{Name: name, Description: strings.TrimSpace(descriptionFR), Source: GiftTranslationOriginal}
+	}
+	return translations
+}
+
+// BuildGiftTranslations merges the texts written in the English and French fields with the translations
+// given for any language, the latter taking precedence; it also returns the language the gift was written in:
+// preferred when it has a text, otherwise English, French or the first language in alphabetical order
+// It returns no translations when no name was given at all
+func BuildGiftTranslations(nameEN, descriptionEN, nameFR, descriptionFR string, given map[string]models.GiftTranslation, preferred string) (map[string]models.GiftTranslation, string, error) {
+	translations := LegacyGiftTranslations(nameEN, descriptionEN, nameFR, descriptionFR)
+	for code, translation := range given {
+		language, ok := NormalizeGiftLanguage(code)
+		if !ok {
+			return nil, "", fmt.Errorf("%w: unsupported language %q", ErrInvalidGiftTranslation, code)
+		}
+		translation.Name = strings.TrimSpace(translation.Name)
+		translation.Description = strings.TrimSpace(translation.Description)
+		if translation.Name == "" {
+			return nil, "", fmt.Errorf("%w: the %s name is empty", ErrInvalidGiftTranslation, language)
+		}
+		translation.Source = GiftTranslationOriginal
+		translations[language] = translation
+	}
+	if len(translations) == 0 {
+		return nil, "", nil
+	}
+
+	preferred, _ = NormalizeGiftLanguage(preferred)
+	for _, language := range []string{preferred, DefaultGiftLanguage, "fr"} {
+		if _, ok := translations[language]; ok {
+			return translations, language, nil
+		}
+	}
+	languages := make([]string, 0, len(translations))
+	for language := range translations {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return translations, languages[0], nil
+}
+
+// giftTranslations returns the translations of a suggestion, read from the English and French fields if it has none
+func giftTranslations(suggestion models.GiftSuggestion) map[string]models.GiftTranslation {
+	if len(suggestion.Translations) > 0 {
+		return suggestion.Translations
+	}
+	return LegacyGiftTranslations(suggestion.NameEN, suggestion.DescriptionEN, suggestion.NameFR, suggestion.DescriptionFR)
+}
+
+// LocalizedGiftText returns the name and description of a suggestion in a language
+// Missing languages fall back to the one the suggestion was written in, then English, then French
+func LocalizedGiftText(suggestion models.GiftSuggestion, language string) models.GiftTranslation {
+	translations := giftTranslations(suggestion)
+	for _, candidate := range []string{language, suggestion.Language, DefaultGiftLanguage, "fr"} {
+		if translation, ok := translations[candidate]; ok && candidate != "" {
+			return translation
+		}
+	}
+
+	// Any other language, picked in a stable order
+	languages := make([]string, 0, len(translations))
+	for candidate := range translations {
+		languages = append(languages, candidate)
+	}
+	sort.Strings(languages)
+	if len(languages) > 0 {
+		return translations[languages[0]]
+	}
+	return models.GiftTranslation{}
+}
+
+// HasGiftTranslation reports whether a suggestion has a text of its own in a language
+func HasGiftTranslation(suggestion models.GiftSuggestion, language string) bool {
+	_, ok := giftTranslations(suggestion)[language]
+	return ok
+}
+
+// SetGiftTranslation records the text of a suggestion in a language and updates the English and French fields
+func SetGiftTranslation(suggestion *models.GiftSuggestion, language string, translation models.GiftTranslation) {
+	if len(suggestion.Translations) == 0 {
+		suggestion.Translations = giftTranslations(*suggestion)
+	}
+	suggestion.Translations[language] = translation
+	SyncLegacyGiftFields(suggestion)
+}
+
+// SyncLegacyGiftFields fills name_en/name_fr/description_en/description_fr from the translations,
+// so that clients reading the bilingual fields keep working; missing languages fall back like LocalizedGiftText
+func SyncLegacyGiftFields(suggestion *models.GiftSuggestion) {
+	if len(suggestion.Translations) == 0 {
+		return
+	}
+	en := LocalizedGiftText(*suggestion, "en")
+	fr := LocalizedGiftText(*suggestion, "fr")
+	suggestion.NameEN, suggestion.DescriptionEN = en.Name, en.Description
+	suggestion.NameFR, suggestion.DescriptionFR = fr.Name, fr.Description
+}
+
+// SaveGiftSuggestionTranslations stores the language and translations of a stored suggestion
+// The English and French columns are rewritten from the translations, for older clients
+func SaveGiftSuggestionTranslations(suggestion *models.GiftSuggestion) error {
+	if len(suggestion.Translations) == 0 {
+		suggestion.Translations = giftTranslations(*suggestion)
+	}
+	SyncLegacyGiftFields(suggestion)
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting translations transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE gift_suggestions
+		SET language = $2, name_en = $3, name_fr = $4, description_en = $5, description_fr = $6
+		WHERE id = $1
+	`, suggestion.ID, optionalString(suggestion.Language), suggestion.NameEN, suggestion.NameFR,
+		suggestion.DescriptionEN, suggestion.DescriptionFR)
+	if err != nil {
+		return fmt.Errorf("error updating suggestion language: %w", err)
+	}
+
+	for language, translation := range suggestion.Translations {
+		source := translation.Source
+		if source == "" {
+			source = GiftTranslationOriginal
+		}
+		_, err = tx.Exec(`
+			INSERT INTO gift_suggestion_translations (suggestion_id, language, name, description, source)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (suggestion_id, language)
+			DO UPDATE SET name = EXCLUDED.name, description = EXCLUDED.description, source = EXCLUDED.source, updated_at = CURRENT_TIMESTAMP
+		`, suggestion.ID, language, translation.Name, translation.Description, source)
+		if err != nil {
+			return fmt.Errorf("error storing %s translation: %w", language, err)
+		}
+	}
+
+	// Languages dropped from the suggestion, e.g. when a user rewrites it, must not outlive it
+	languages := make([]string, 0, len(suggestion.Translations))
+	for language := range suggestion.Translations {
+		languages = append(languages, language)
+	}
+	_, err = tx.Exec(`
+		DELETE FROM gift_suggestion_translations
+		WHERE suggestion_id = $1 AND NOT (language = ANY($2))
+	`, suggestion.ID, pq.Array(languages))
+	if err != nil {
+		return fmt.Errorf("error removing stale translations: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// LoadGiftSuggestionTranslations fills the language and translations of stored suggestions
+// Suggestions without translations keep their English and French fields
+func LoadGiftSuggestionTranslations(suggestions []models.GiftSuggestion) error {
+	if len(suggestions) == 0 {
+		return nil
+	}
+	ids := make([]string, len(suggestions))
+	for i, suggestion := range suggestions {
+		ids[i] = suggestion.ID
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT t.suggestion_id, t.language, t.name, COALESCE(t.description, ''), t.source, COALESCE(s.language, '')
+		FROM gift_suggestion_translations t
+		JOIN gift_suggestions s ON s.id = t.suggestion_id
+		WHERE t.suggestion_id = ANY($1)
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("error loading gift translations: %w", err)
+	}
+	defer rows.Close()
+
+	translations := map[string]map[string]models.GiftTranslation{}
+	primary := map[string]string{}
+	for rows.Next() {
+		var id, language, primaryLanguage string
+		var translation models.GiftTranslation
+		if err := rows.Scan(&id, &language, &translation.Name, &translation.Description, &translation.Source, &primaryLanguage); err != nil {
+			return fmt.Errorf("error scanning gift translation: %w", err)
+		}
+		if translations[id] == nil {
+			translations[id] = map[string]models.GiftTranslation{}
+		}
+		translations[id][language] = translation
+		primary[id] = primaryLanguage
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range suggestions {
+		if found, ok := translations[suggestions[i].ID]; ok {
+			suggestions[i].Translations = found
+			suggestions[i].Language = primary[suggestions[i].ID]
+			SyncLegacyGiftFields(&suggestions[i])
+		}
+	}
+	return nil
+}
+
+// loadStaticGiftTranslations reads the translations of a curated gift
+func loadStaticGiftTranslations(database *sql.DB, staticGiftID string) (map[string]models.GiftTranslation, error) {
+	rows, err := database.Query(`
+		SELECT language, name, COALESCE(description, '')
+		FROM static_gift_translations
+		WHERE static_gift_id = $1
+	`, staticGiftID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading static gift translations: %w", err)
+	}
+	defer rows.Close()
+
+	translations := map[string]models.GiftTranslation{}
+	for rows.Next() {
+		var language string
+		translation := models.GiftTranslation{Source: GiftTranslationOriginal}
+		if err := rows.Scan(&language, &translation.Name, &translation.Description); err != nil {
+			return nil, fmt.Errorf("error scanning static gift translation: %w", err)
+		}
+		translations[language] = translation
+	}
+	return translations, rows.Err()
+}
+
+// LocalizedOccasionName returns the name of an occasion in a language, falling back to English, then French
+func LocalizedOccasionName(occasion models.OccasionType, language string) string {
+	for _, candidate := range []string{language, DefaultGiftLanguage, "fr"} {
+		if name, ok := occasion.Names[candidate]; ok && candidate != "" {
+			return name
+		}
+	}
+
+	// Any other language, picked in a stable order
+	languages := make([]string, 0, len(occasion.Names))
+	for candidate := range occasion.Names {
+		languages = append(languages, candidate)
+	}
+	sort.Strings(languages)
+	if len(languages) > 0 {
+		return occasion.Names[languages[0]]
+	}
+	return ""
+}
+
+// LoadOccasionTypes reads the active occasions with their names in every language
+// name_en and name_fr are filled from the translations, for older clients
+func LoadOccasionTypes(database *sql.DB) ([]models.OccasionType, error) {
+	rows, err := database.Query(`
+		SELECT o.id, o.occasion_key, o.name_en, o.name_fr, o.color, o.order_index, o.active,
+			o.created_at, o.updated_at, COALESCE(t.language, ''), COALESCE(t.name, '')
+		FROM occasion_types o
+		LEFT JOIN occasion_type_translations t ON t.occasion_type_id = o.id
+		WHERE o.active = true
+		ORDER BY o.order_index ASC, o.id, t.language
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error loading occasion types: %w", err)
+	}
+	defer rows.Close()
+
+	var occasions []models.OccasionType
+	for rows.Next() {
+		var occasion models.OccasionType
+		var language, name string
+		if err := rows.Scan(&occasion.ID, &occasion.OccasionKey, &occasion.NameEN, &occasion.NameFR, &occasion.Color,
+			&occasion.OrderIndex, &occasion.Active, &occasion.CreatedAt, &occasion.UpdatedAt, &language, &name); err != nil {
+			return nil, fmt.Errorf("error scanning occasion type: %w", err)
+		}
+		if len(occasions) == 0 || occasions[len(occasions)-1].ID != occasion.ID {
+			occasion.Names = map[string]string{}
+			occasions = append(occasions, occasion)
+		}
+		if language != "" {
+			occasions[len(occasions)-1].Names[language] = name
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range occasions {
+		// Occasions without translations keep their English and French columns
+		if len(occasions[i].Names) == 0 {
+			occasions[i].Names = map[string]string{"en": occasions[i].NameEN, "fr": occasions[i].NameFR}
+		}
+		occasions[i].NameEN = LocalizedOccasionName(occasions[i], "en")
+		occasions[i].NameFR = LocalizedOccasionName(occasions[i], "fr")
+	}
+	return occasions, nil
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"be-geoffray/models"
+)
+
+func TestNormalizeGiftLanguage(t *testing.T) {
+	tests := []struct {
+		code   string
+		want   string
+		wantOK bool
+	}{
+		{"de", "de", true},
+		{" FR ", "fr", true},
+		{"es-MX", "es", true},
+		{"pt_BR", "pt", true},
+		{"xx", "xx", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := NormalizeGiftLanguage(tt.code); got != tt.want || ok != tt.wantOK {
+			t.Errorf("NormalizeGiftLanguage(%q) = %q, %v, want %q, %v", tt.code, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestBuildGiftTranslations(t *testing.T) {
+	translations, language, err := BuildGiftTranslations("", "", " Livre ", "Un roman", map[string]models.GiftTranslation{
+		"DE": {Name: " Buch ", Description: "Ein Roman"},
+	}, "de")
+	if err != nil {
+		t.Fatalf("BuildGiftTranslations() error = %v", err)
+	}
+	if language != "de" || len(translations) != 2 || translations["fr"].Name != "Livre" || translations["de"].Name != "Buch" {
+		t.Errorf("got %q and %+v, want the French and German texts written in German", language, translations)
+	}
+	if _, ok := translations["en"]; ok {
+		t.Error("the empty English name was taken for a translation")
+	}
+
+	// Without a text in the preferred language, English is the original, then French
+	if _, language, _ := BuildGiftTranslations("Book", "", "Livre", "", nil, "es"); language != "en" {
+		t.Errorf("language = %q, want en", language)
+	}
+
+	if translations, _, err := BuildGiftTranslations("", "", "", "", nil, "en"); err != nil || len(translations) != 0 {
+		t.Errorf("no names gave %+v, %v", translations, err)
+	}
+	for _, invalid := range []map[string]models.GiftTranslation{{"xx": {Name: "Book"}}, {"de": {Name: " "}}} {
+		if _, _, err := BuildGiftTranslations("Book", "", "", "", invalid, "en"); !errors.Is(err, ErrInvalidGiftTranslation) {
+			t.Errorf("BuildGiftTranslations(%+v) error = %v", invalid, err)
+		}
+	}
+}
+
+func TestLocalizedGiftTextFallsBack(t *testing.T) {
+	suggestion := models.GiftSuggestion{
+		Language: "de",
+		Translations: map[string]models.GiftTranslation{
+			"de": {Name: "Wanderschuhe"},
+			"es": {Name: "Botas de montaña", Source: GiftTranslationMachine},
+		},
+	}
+	if got := LocalizedGiftText(suggestion, "es").Name; got != "Botas de montaña" {
+		t.Errorf("es text = %q", got)
+	}
+	if got := LocalizedGiftText(suggestion, "fr").Name; got != "Wanderschuhe" {
+		t.Errorf("missing fr text = %q, want the original German one", got)
+	}
+
+	// The bilingual fields show the original until the gift is translated
+	SyncLegacyGiftFields(&suggestion)
+	if suggestion.NameEN != "Wanderschuhe" || suggestion.NameFR != "Wanderschuhe" {
+		t.Errorf("legacy fields = %q, %q", suggestion.NameEN, suggestion.NameFR)
+	}
+	SetGiftTranslation(&suggestion, "en", models.GiftTranslation{Name: "Hiking boots", Source: GiftTranslationMachine})
+	if suggestion.NameEN != "Hiking boots" || suggestion.NameFR != "Wanderschuhe" {
+		t.Errorf("legacy fields after translation = %q, %q", suggestion.NameEN, suggestion.NameFR)
+	}
+
+	// Suggestions written before translations are read from their bilingual fields
+	legacy := models.GiftSuggestion{NameEN: "Book", NameFR: "Livre"}
+	if got := LocalizedGiftText(legacy, "fr").Name; got != "Livre" || !HasGiftTranslation(legacy, "en") || HasGiftTranslation(legacy, "de") {
+		t.Errorf("legacy fr text = %q", got)
+	}
+}
+
+func TestLocalizedOccasionNameFallsBack(t *testing.T) {
+	occasion := models.OccasionType{Names: map[string]string{"en": "Birthday", "fr": "Anniversaire", "de": "Geburtstag"}}
+	tests := []struct {
+		language string
+		want     string
+	}{
+		{"de", "Geburtstag"},
+		{"fr", "Anniversaire"},
+		{"es", "Birthday"},
+		{"", "Birthday"},
+	}
+	for _, tt := range tests {
+		if got := LocalizedOccasionName(occasion, tt.language); got != tt.want {
+			t.Errorf("LocalizedOccasionName(%q) = %q, want %q", tt.language, got, tt.want)
+		}
+	}
+
+	if got := LocalizedOccasionName(models.OccasionType{Names: map[string]string{"es": "Cumpleaños"}}, "en"); got != "Cumpleaños" {
+		t.Errorf("only Spanish name = %q", got)
+	}
+}
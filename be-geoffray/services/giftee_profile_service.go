@@ -0,0 +1,248 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"be-geoffray/db"
+	"be-geoffray/models"
+
+	"github.com/lib/pq"
+)
+
+// GifteeAgeRanges are the accepted age ranges of a giftee
+var GifteeAgeRanges = []string{"0-2", "3-5", "6-12", "13-17", "18-25", "26-40", "41-60", "61+"}
+
+// GifteeRelationships are the accepted relationships of the organizers to the giftee
+var GifteeRelationships = []string{"partner", "parent", "child", "sibling", "grandparent", "family", "friend", "colleague", "other"}
+
+// GifteeClothingKinds are the kinds of clothing a size may be given for
+var GifteeClothingKinds = []string{"top", "bottom", "dress", "shoes", "ring", "hat"}
+
+// Limits of the free-text lists of a giftee profile
+const (
+	maxGifteeTags      = 20
+	maxGifteeTagLength = 50
+)
+
+// ErrInvalidGifteeProfile is wrapped by the validation errors of a giftee profile
+var ErrInvalidGifteeProfile = errors.New("invalid giftee profile")
+
+// NormalizeGifteeProfile validates a giftee profile and cleans its lists
+// Tags are trimmed and deduplicated regardless of case, empty clothing sizes are dropped
+func NormalizeGifteeProfile(profile *models.GifteeProfile) error {
+	profile.AgeRange = strings.TrimSpace(profile.AgeRange)
+	if profile.AgeRange != "" && !containsString(GifteeAgeRanges, profile.AgeRange) {
+		return fmt.Errorf("%w: age_range must be one of %s", ErrInvalidGifteeProfile, strings.Join(GifteeAgeRanges, ", "))
+	}
+	profile.Relationship = strings.ToLower(strings.TrimSpace(profile.Relationship))
+	if profile.Relationship != "" && !containsString(GifteeRelationships, profile.Relationship) {
+		return fmt.Errorf("%w: relationship must be one of %s", ErrInvalidGifteeProfile, strings.Join(GifteeRelationships, ", "))
+	}
+
+	var err error
+	if profile.Interests, err = normalizeGifteeTags("interests", profile.Interests); err != nil {
+		return err
+	}
+	if profile.Dislikes, err = normalizeGifteeTags("dislikes", profile.Dislikes); err != nil {
+		return err
+	}
+	if profile.OwnedItems, err = normalizeGifteeTags("owned_items", profile.OwnedItems); err != nil {
+		return err
+	}
+
+	sizes := make(map[string]string)
+	for kind, size := range profile.ClothingSizes {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		if !containsString(GifteeClothingKinds, kind) {
+			return fmt.Errorf("%w: clothing size kinds are %s", ErrInvalidGifteeProfile, strings.Join(GifteeClothingKinds, ", "))
+		}
+		size = strings.TrimSpace(size)
+		if len(size) > maxGifteeTagLength {
+			return fmt.Errorf("%w: %s size is too long", ErrInvalidGifteeProfile, kind)
+		}
+		if size != "" {
+			sizes[kind] = size
+		}
+	}
+	profile.ClothingSizes = sizes
+	return nil
+}
+
+// normalizeGifteeTags trims, deduplicates and bounds a list of tags
+func normalizeGifteeTags(field string, tags []string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(tag), " ")
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		if len(tag) > maxGifteeTagLength {
+			return nil, fmt.Errorf("%w: %s entries are limited to %d characters", ErrInvalidGifteeProfile, field, maxGifteeTagLength)
+		}
+		seen[strings.ToLower(tag)] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > maxGifteeTags {
+		return nil, fmt.Errorf("%w: %s are limited to %d entries", ErrInvalidGifteeProfile, field, maxGifteeTags)
+	}
+	return normalized, nil
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// CanViewGifteeProfile reports whether a user may read the giftee profile of an event
+// Organizers always may; the other guests only when the event is not a surprise
+func CanViewGifteeProfile(relationship EventRelationship, surpriseMode bool) bool {
+	if relationship.Can(EventActionManage) {
+		return true
+	}
+	return !surpriseMode && relationship.Can(EventActionView)
+}
+
+// gifteePromptProfile is a giftee profile as shown in prompt templates
+type gifteePromptProfile struct {
+	AgeRange      string
+	Relationship  string
+	Interests     []string
+	Dislikes      []string
+	OwnedItems    []string
+	ClothingSizes []string // "kind: size", sorted
+}
+
+// newGifteePromptProfile prepares a profile for the prompt, nil when it says nothing about the giftee
+func newGifteePromptProfile(profile *models.GifteeProfile) *gifteePromptProfile {
+	if profile == nil || (profile.AgeRange == "" && profile.Relationship == "" && len(profile.Interests) == 0 &&
+		len(profile.Dislikes) == 0 && len(profile.OwnedItems) == 0 && len(profile.ClothingSizes) == 0) {
+		return nil
+	}
+	prompt := &gifteePromptProfile{
+		AgeRange:     profile.AgeRange,
+		Relationship: profile.Relationship,
+		Interests:    profile.Interests,
+		Dislikes:     profile.Dislikes,
+		OwnedItems:   profile.OwnedItems,
+	}
+	for kind, size := range profile.ClothingSizes {
+		prompt.ClothingSizes = append(prompt.ClothingSizes, kind+": "+size)
+	}
+	sort.Strings(prompt.ClothingSizes)
+	return prompt
+}
+
+// withOwnedItems adds what the giftee already owns to the suggestions to avoid,
+// so that the similarity check rejects gifts they have
+func withOwnedItems(existing []models.GiftSuggestion, profile *models.GifteeProfile) []models.GiftSuggestion {
+	if profile == nil {
+		return existing
+	}
+	for _, item := range profile.OwnedItems {
+		existing = append(existing, models.GiftSuggestion{NameEN: item, NameFR: item})
+	}
+	return existing
+}
+
+// GifteeProfileService stores the giftee profiles of events
+type GifteeProfileService struct {
+	db *sql.DB
+}
+
+// NewGifteeProfileService creates a new giftee profile service
+func NewGifteeProfileService() *GifteeProfileService {
+	return &GifteeProfileService{db: db.DB}
+}
+
+// GetGifteeProfile returns the giftee profile of an event, nil if it has none
+func (s *GifteeProfileService) GetGifteeProfile(eventID string) (*models.GifteeProfile, error) {
+	profile := &models.GifteeProfile{EventID: eventID}
+	var ageRange, relationship, updatedBy sql.NullString
+	var sizes []byte
+	err := s.db.QueryRow(`
+		SELECT age_range, relationship, interests, dislikes, owned_items, clothing_sizes, updated_by, created_at, updated_at
+		FROM event_giftee_profiles
+		WHERE event_id = $1
+	`, eventID).Scan(&ageRange, &relationship, pq.Array(&profile.Interests), pq.Array(&profile.Dislikes),
+		pq.Array(&profile.OwnedItems), &sizes, &updatedBy, &profile.CreatedAt, &profile.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error loading giftee profile: %w", err)
+	}
+
+	profile.AgeRange = ageRange.String
+	profile.Relationship = relationship.String
+	if updatedBy.Valid {
+		profile.UpdatedBy = &updatedBy.String
+	}
+	if err := json.Unmarshal(sizes, &profile.ClothingSizes); err != nil {
+		return nil, fmt.Errorf("error decoding clothing sizes: %w", err)
+	}
+	return profile, nil
+}
+
+// SaveGifteeProfile validates and replaces the giftee profile of an event
+func (s *GifteeProfileService) SaveGifteeProfile(profile *models.GifteeProfile, userID string) (*models.GifteeProfile, error) {
+	if err := NormalizeGifteeProfile(profile); err != nil {
+		return nil, err
+	}
+	sizes, err := json.Marshal(profile.ClothingSizes)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO event_giftee_profiles (
+			event_id, age_range, relationship, interests, dislikes, owned_items, clothing_sizes, updated_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (event_id) DO UPDATE SET
+			age_range = EXCLUDED.age_range, relationship = EXCLUDED.relationship,
+			interests = EXCLUDED.interests, dislikes = EXCLUDED.dislikes, owned_items = EXCLUDED.owned_items,
+			clothing_sizes = EXCLUDED.clothing_sizes, updated_by = EXCLUDED.updated_by, updated_at = NOW()
+	`, profile.EventID, optionalString(profile.AgeRange), optionalString(profile.Relationship),
+		pq.Array(profile.Interests), pq.Array(profile.Dislikes), pq.Array(profile.OwnedItems), sizes, optionalString(userID))
+	if err != nil {
+		return nil, fmt.Errorf("error saving giftee profile: %w", err)
+	}
+
+	return s.GetGifteeProfile(profile.EventID)
+}
+
+// DeleteGifteeProfile removes the giftee profile of an event
+func (s *GifteeProfileService) DeleteGifteeProfile(eventID string) error {
+	if _, err := s.db.Exec(`DELETE FROM event_giftee_profiles WHERE event_id = $1`, eventID); err != nil {
+		return fmt.Errorf("error deleting giftee profile: %w", err)
+	}
+	return nil
+}
+
+// IsSurprise reports whether an event is in surprise mode
+func (s *GifteeProfileService) IsSurprise(eventID string) (bool, error) {
+	var surprise bool
+	err := s.db.QueryRow(`SELECT surprise_mode FROM events WHERE id = $1`, eventID).Scan(&surprise)
+	return surprise, err
+}
+
+// LoadGifteeProfileForGeneration returns the profile to give to gift generation, nil when missing or unreadable
+// Generation does not depend on the profile, so failures are only logged
+func LoadGifteeProfileForGeneration(eventID string) *models.GifteeProfile {
+	profile, err := NewGifteeProfileService().GetGifteeProfile(eventID)
+	if err != nil {
+		fmt.Printf("Error loading giftee profile for event %s: %v\n", eventID, err)
+		return nil
+	}
+	return profile
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"be-geoffray/models"
+)
+
+func TestNormalizeGifteeProfile(t *testing.T) {
+	profile := &models.GifteeProfile{
+		AgeRange:      " 26-40 ",
+		Relationship:  "Friend",
+		Interests:     []string{" rock  climbing ", "Cooking", "cooking", ""},
+		ClothingSizes: map[string]string{"Shoes": " 42 ", "hat": ""},
+	}
+	if err := NormalizeGifteeProfile(profile); err != nil {
+		t.Fatalf("NormalizeGifteeProfile() error = %v", err)
+	}
+	if profile.AgeRange != "26-40" || profile.Relationship != "friend" {
+		t.Errorf("age range %q and relationship %q", profile.AgeRange, profile.Relationship)
+	}
+	if got := strings.Join(profile.Interests, ", "); got != "rock climbing, Cooking" {
+		t.Errorf("interests = %s", got)
+	}
+	if profile.Dislikes == nil || len(profile.Dislikes) != 0 {
+		t.Errorf("dislikes = %#v, want an empty list", profile.Dislikes)
+	}
+	if len(profile.ClothingSizes) != 1 || profile.ClothingSizes["shoes"] != "42" {
+		t.Errorf("clothing sizes = %v", profile.ClothingSizes)
+	}
+
+	invalid := []*models.GifteeProfile{
+		{AgeRange: "30"},
+		{Relationship: "boss"},
+		{ClothingSizes: map[string]string{"gloves": "M"}},
+		{OwnedItems: []string{strings.Repeat("x", maxGifteeTagLength+1)}},
+	}
+	for _, profile := range invalid {
+		if err := NormalizeGifteeProfile(profile); !errors.Is(err, ErrInvalidGifteeProfile) {
+			t.Errorf("NormalizeGifteeProfile(%+v) error = %v", profile, err)
+		}
+	}
+}
+
+func TestCanViewGifteeProfile(t *testing.T) {
+	owner, guest := EventRelationshipOwner, EventRelationshipInvited
+
+	if !CanViewGifteeProfile(owner, true) || !CanViewGifteeProfile(guest, false) {
+		t.Error("organizers and guests of a regular event must see the profile")
+	}
+	if CanViewGifteeProfile(guest, true) {
+		t.Error("guests of a surprise event must not see the profile")
+	}
+	if CanViewGifteeProfile(EventRelationshipNone, false) {
+		t.Error("strangers must not see the profile")
+	}
+}
+
+func TestGiftPromptIncludesGifteeProfile(t *testing.T) {
+	service := &GiftSuggestionService{}
+	request := GiftSuggestionRequest{GifteePersona: "Mom", EventOccasion: "Birthday", Language: "en"}
+
+	prompt, _, err := service.buildGiftSuggestionPrompt(request, nil)
+	if err != nil {
+		t.Fatalf("buildGiftSuggestionPrompt() error = %v", err)
+	}
+	if strings.Contains(prompt, "GIFTEE PROFILE") {
+		t.Errorf("prompt without profile has one:\n%s", prompt)
+	}
+
+	request.Giftee = &models.GifteeProfile{
+		AgeRange:      "61+",
+		Interests:     []string{"gardening", "jazz"},
+		OwnedItems:    []string{"Kindle"},
+		ClothingSizes: map[string]string{"top": "M", "shoes": "38"},
+	}
+	prompt, _, err = service.buildGiftSuggestionPrompt(request, nil)
+	if err != nil {
+		t.Fatalf("buildGiftSuggestionPrompt() error = %v", err)
+	}
+	for _, want := range []string{
+		"- Age range: 61+\n",
+		"- Interests and hobbies: gardening, jazz\n",
+		"do NOT suggest these again: Kindle\n",
+		"- Clothing sizes: shoes: 38, top: M\n",
+		"- Suggestions fit the giftee profile\n",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt does not contain %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "Relationship to the givers") {
+		t.Error("prompt mentions the unknown relationship")
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LifecycleEventType identifies a kind of change made to an event or to its content
+type LifecycleEventType string
+
+const (
+	LifecycleEventUpdated      LifecycleEventType = "event.updated"
+	LifecycleParticipantRSVP   LifecycleEventType = "participant.rsvp"
+	LifecycleSuggestionCreated LifecycleEventType = "suggestion.created"
+	LifecycleSuggestionUpdated LifecycleEventType = "suggestion.updated"
+	LifecycleSuggestionDeleted LifecycleEventType = "suggestion.deleted"
+	LifecycleVoteCast          LifecycleEventType = "vote.cast"
+	LifecycleMessageCreated    LifecycleEventType = "message.created"
+	LifecycleMessageUpdated    LifecycleEventType = "message.updated"
+	LifecycleMessageDeleted    LifecycleEventType = "message.deleted"
+	LifecycleMessageReaction   LifecycleEventType = "message.reaction"
+	LifecycleMessageRead       LifecycleEventType = "message.read"
+	LifecyclePollCreated       LifecycleEventType = "poll.created"
+	LifecyclePollVote          LifecycleEventType = "poll.vote"
+)
+
+// LifecycleEventTypes lists every lifecycle event type that can be subscribed to
+var LifecycleEventTypes = []LifecycleEventType{
+	LifecycleEventUpdated,
+	LifecycleParticipantRSVP,
+	LifecycleSuggestionCreated,
+	LifecycleSuggestionUpdated,
+	LifecycleSuggestionDeleted,
+	LifecycleVoteCast,
+	LifecycleMessageCreated,
+	LifecycleMessageUpdated,
+	LifecycleMessageDeleted,
+	LifecycleMessageReaction,
+	LifecycleMessageRead,
+	LifecyclePollCreated,
+	LifecyclePollVote,
+}
+
+// IsKnownLifecycleEventType reports whether the given string is a known lifecycle event type
+func IsKnownLifecycleEventType(eventType string) bool {
+	for _, t := range LifecycleEventTypes {
+		if string(t) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// LifecycleEvent describes a change that happened on an event
+type LifecycleEvent struct {
+	ID         string             `json:"id"`
+	Type       LifecycleEventType `json:"type"`
+	EventID    string             `json:"event_id"`
+	ActorID    string             `json:"actor_id,omitempty"` // Empty when the change was made by the system
+	OccurredAt time.Time          `json:"occurred_at"`
+	Data       interface{}        `json:"data"`
+}
+
+// LifecycleListener is called for every emitted lifecycle event
+type LifecycleListener func(event LifecycleEvent)
+
+var (
+	lifecycleListenersMu sync.RWMutex
+	lifecycleListeners   []LifecycleListener
+)
+
+// RegisterLifecycleListener subscribes a listener to all lifecycle events
+// Listeners are registered once at startup (see cmd/main.go)
+func RegisterLifecycleListener(listener LifecycleListener) {
+	lifecycleListenersMu.Lock()
+	defer lifecycleListenersMu.Unlock()
+	lifecycleListeners = append(lifecycleListeners, listener)
+}
+
+// EmitLifecycleEvent notifies all registered listeners of a change on an event
+// Listeners run in their own goroutine so that the request that made the change is never blocked
+func EmitLifecycleEvent(eventType LifecycleEventType, eventID string, actorID string, data interface{}) {
+	event := LifecycleEvent{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		EventID:    eventID,
+		ActorID:    actorID,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+
+	lifecycleListenersMu.RLock()
+	listeners := make([]LifecycleListener, len(lifecycleListeners))
+	copy(listeners, lifecycleListeners)
+	lifecycleListenersMu.RUnlock()
+
+	for _, listener := range listeners {
+		go func(listener LifecycleListener) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Lifecycle listener panicked on %s for event %s: %v", event.Type, event.EventID, r)
+				}
+			}()
+			listener(event)
+		}(listener)
+	}
+}
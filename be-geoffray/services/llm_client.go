@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"be-geoffray/config"
+)
+
+// LLMRequest is a chat completion request, independent of the provider
+type LLMRequest struct {
+	Messages       []MistralMessage
+	MaxTokens      int                // 0 leaves the provider default
+	Temperature    *float64           // Nil leaves the provider default
+	ResponseFormat *LLMResponseFormat // Nil lets the model answer with free text
+}
+
+// LLMResponseFormat asks the provider for a JSON reply, matching Schema when set
+type LLMResponseFormat struct {
+	Name   string
+	Schema map[string]interface{}
+}
+
+// LLMUsage is the number of tokens a completion consumed
+type LLMUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// LLMResponse is the assistant message of a completion, with the tools it calls if any
+type LLMResponse struct {
+	Message MistralMessage
+	Usage   LLMUsage
+	Model   string // Model that produced the reply, when the provider reports it
+}
+
+// LLMClient talks to a language model provider
+type LLMClient interface {
+	// Chat returns the reply to a conversation
+	Chat(ctx context.Context, request LLMRequest) (*LLMResponse, error)
+	// ChatWithTools returns the reply to a conversation, which may call the given tools instead of answering
+	ChatWithTools(ctx context.Context, request LLMRequest, tools []FunctionCallSchema) (*LLMResponse, error)
+	// Stream calls onDelta for every chunk of the reply and returns the whole reply once complete
+	// Cancelling ctx aborts the upstream request
+	Stream(ctx context.Context, request LLMRequest, onDelta func(delta string) error) (*LLMResponse, error)
+}
+
+var (
+	llmClientOnce sync.Once
+	llmClient     LLMClient
+)
+
+// GetLLMClient returns the language model client configured for the application
+// Its calls are subject to the AI quotas and recorded in the usage log
+func GetLLMClient() LLMClient {
+	llmClientOnce.Do(func() {
+		cfg := config.GetConfig()
+		client, err := NewLLMClientFromConfig(cfg)
+		if err != nil {
+			log.Printf("Warning: %v, AI features are disabled", err)
+			client = unavailableLLMClient{err: err}
+		}
+		modelFor := cfg.ModelForPurpose
+		if completions, ok := client.(*ChatCompletionsClient); ok {
+			modelFor = completions.ModelFor
+		}
+		llmClient = newMeteredLLMClient(client, cfg.LLMProvider, modelFor)
+	})
+	return llmClient
+}
+
+// SetLLMClient replaces the language model client, for tests and offline runs
+// The client is used as is, without quotas nor usage accounting
+func SetLLMClient(client LLMClient) {
+	llmClientOnce.Do(func() {})
+	llmClient = client
+}
+
+// NewLLMClientFromConfig builds the client of the configured provider
+func NewLLMClientFromConfig(cfg *config.AppConfig) (LLMClient, error) {
+	switch cfg.LLMProvider {
+	case "mistral":
+		if cfg.LLMAPIKey == "" {
+			return nil, fmt.Errorf("LLM_API_KEY (or MISTRAL_API_KEY) not configured")
+		}
+		client := NewMistralClient(cfg.LLMBaseURL, cfg.LLMAPIKey, cfg.LLMModel, cfg.LLMAgentID)
+		client.purposeModels = cfg.LLMPurposeModels
+		return client, nil
+	case "openai":
+		client := NewOpenAICompatibleClient(cfg.LLMBaseURL, cfg.LLMAPIKey, cfg.LLMModel)
+		client.purposeModels = cfg.LLMPurposeModels
+		return client, nil
+	case "fake":
+		if cfg.LLMFakeResponses == "" {
+			return NewFakeLLMClient(), nil
+		}
+		return LoadFakeLLMClient(cfg.LLMFakeResponses)
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", cfg.LLMProvider)
+	}
+}
+
+// unavailableLLMClient fails every request with the configuration error
+type unavailableLLMClient struct {
+	err error
+}
+
+func (u unavailableLLMClient) Chat(context.Context, LLMRequest) (*LLMResponse, error) {
+	return nil, u.err
+}
+
+func (u unavailableLLMClient) ChatWithTools(context.Context, LLMRequest, []FunctionCallSchema) (*LLMResponse, error) {
+	return nil, u.err
+}
+
+func (u unavailableLLMClient) Stream(context.Context, LLMRequest, func(string) error) (*LLMResponse, error) {
+	return nil, u.err
+}
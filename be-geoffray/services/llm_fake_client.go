@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// FakeLLMClient replays scripted replies, in order, so that AI features run offline and deterministically
+// Once the script is exhausted it echoes the last user message
+type FakeLLMClient struct {
+	mu        sync.Mutex
+	responses []LLMResponse
+	requests  []LLMRequest
+}
+
+// NewFakeLLMClient creates a fake client replying with the given responses
+func NewFakeLLMClient(responses ...LLMResponse) *FakeLLMClient {
+	return &FakeLLMClient{responses: responses}
+}
+
+// LoadFakeLLMClient creates a fake client from a JSON file holding an array of assistant messages
+// e.g. [{"content": "Hello"}, {"tool_calls": [{"id": "1", "function": {"name": "summarize_rsvps", "arguments": "{}"}}]}]
+func LoadFakeLLMClient(path string) (*FakeLLMClient, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading fake LLM responses: %w", err)
+	}
+
+	var messages []MistralMessage
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("error parsing fake LLM responses: %w", err)
+	}
+
+	responses := make([]LLMResponse, 0, len(messages))
+	for _, message := range messages {
+		message.Role = "assistant"
+		responses = append(responses, LLMResponse{Message: message})
+	}
+	return NewFakeLLMClient(responses...), nil
+}
+
+// Requests returns the requests received so far
+func (f *FakeLLMClient) Requests() []LLMRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]LLMRequest{}, f.requests...)
+}
+
+// next records the request and returns the scripted reply to it
+func (f *FakeLLMClient) next(request LLMRequest) *LLMResponse {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, request)
+
+	var response LLMResponse
+	if len(f.responses) > 0 {
+		response = f.responses[0]
+		f.responses = f.responses[1:]
+	} else {
+		lastUserMessage := ""
+		for _, message := range request.Messages {
+			if message.Role == "user" {
+				lastUserMessage = message.Content
+			}
+		}
+		response = LLMResponse{Message: MistralMessage{Role: "assistant", Content: "You said: " + lastUserMessage}}
+	}
+
+	if response.Usage.TotalTokens == 0 {
+		for _, message := range request.Messages {
+			response.Usage.PromptTokens += estimateMessageTokens(message)
+		}
+		response.Usage.CompletionTokens = estimateTokens(response.Message.Content)
+		response.Usage.TotalTokens = response.Usage.PromptTokens + response.Usage.CompletionTokens
+	}
+	return &response
+}
+
+// Chat returns the next scripted reply, without its tool calls
+func (f *FakeLLMClient) Chat(_ context.Context, request LLMRequest) (*LLMResponse, error) {
+	response := f.next(request)
+	response.Message.ToolCalls = nil
+	return response, nil
+}
+
+// ChatWithTools returns the next scripted reply
+func (f *FakeLLMClient) ChatWithTools(_ context.Context, request LLMRequest, _ []FunctionCallSchema) (*LLMResponse, error) {
+	return f.next(request), nil
+}
+
+// Stream sends the next scripted reply word by word
+func (f *FakeLLMClient) Stream(ctx context.Context, request LLMRequest, onDelta func(delta string) error) (*LLMResponse, error) {
+	response := f.next(request)
+	response.Message.ToolCalls = nil
+
+	words := strings.SplitAfter(response.Message.Content, " ")
+	for _, word := range words {
+		if err := ctx.Err(); err != nil {
+			return response, err
+		}
+		if word == "" {
+			continue
+		}
+		if err := onDelta(word); err != nil {
+			return response, err
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"be-geoffray/config"
+)
+
+func TestFakeLLMClientReplaysScript(t *testing.T) {
+	var call MistralToolCall
+	call.Function.Name = "summarize_rsvps"
+	fake := NewFakeLLMClient(
+		LLMResponse{Message: MistralMessage{Role: "assistant", ToolCalls: []MistralToolCall{call}}},
+		LLMResponse{Message: MistralMessage{Role: "assistant", Content: "Two guests are coming"}},
+	)
+	request := LLMRequest{Messages: []MistralMessage{{Role: "user", Content: "Who is coming?"}}}
+
+	first, _ := fake.ChatWithTools(context.Background(), request, AgentToolSchemas())
+	if len(first.Message.ToolCalls) != 1 || first.Message.ToolCalls[0].Function.Name != "summarize_rsvps" {
+		t.Errorf("first reply = %+v, want a summarize_rsvps call", first.Message)
+	}
+
+	var deltas []string
+	second, err := fake.Stream(context.Background(), request, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	if strings.Join(deltas, "") != "Two guests are coming" || len(deltas) != 4 {
+		t.Errorf("deltas = %q, want the reply word by word", deltas)
+	}
+	if second.Usage.TotalTokens == 0 {
+		t.Error("usage was not estimated")
+	}
+
+	// Exhausted scripts echo the last user message
+	third, _ := fake.Chat(context.Background(), request)
+	if third.Message.Content != "You said: Who is coming?" {
+		t.Errorf("third reply = %q, want the echo", third.Message.Content)
+	}
+	if got := len(fake.Requests()); got != 3 {
+		t.Errorf("recorded %d requests, want 3", got)
+	}
+}
+
+func TestNewLLMClientFromConfig(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "responses.json")
+	if err := os.WriteFile(script, []byte(`[{"content": "Hello"}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     config.AppConfig
+		wantErr bool
+	}{
+		{name: "mistral", cfg: config.AppConfig{LLMProvider: "mistral", LLMAPIKey: "key"}},
+		{name: "mistral without key", cfg: config.AppConfig{LLMProvider: "mistral"}, wantErr: true},
+		{name: "openai", cfg: config.AppConfig{LLMProvider: "openai", LLMBaseURL: "http://localhost:8080/v1/"}},
+		{name: "fake", cfg: config.AppConfig{LLMProvider: "fake"}},
+		{name: "scripted fake", cfg: config.AppConfig{LLMProvider: "fake", LLMFakeResponses: script}},
+		{name: "missing script", cfg: config.AppConfig{LLMProvider: "fake", LLMFakeResponses: script + ".missing"}, wantErr: true},
+		{name: "unknown", cfg: config.AppConfig{LLMProvider: "gpt"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLLMClientFromConfig(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
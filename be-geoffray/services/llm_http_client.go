@@ -0,0 +1,276 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ChatCompletionsClient talks to providers exposing the chat completions API: Mistral and OpenAI-compatible servers
+type ChatCompletionsClient struct {
+	url           string
+	agentURL      string // Mistral only: endpoint of the agent completions
+	apiKey        string
+	model         string
+	purposeModels map[string]string // Models replacing model for some purposes
+	agentID       string            // Mistral only: the agent replaces the model for the event chat
+	httpClient    *http.Client
+}
+
+// NewMistralClient creates a client of the Mistral API
+// When agentID is set, the event chat (LLMPurposeAgent) goes through the agent and its instructions;
+// every other call uses the bare model
+func NewMistralClient(baseURL, apiKey, model, agentID string) *ChatCompletionsClient {
+	return &ChatCompletionsClient{
+		url:        strings.TrimSuffix(baseURL, "/") + "/v1/chat/completions",
+		agentURL:   strings.TrimSuffix(baseURL, "/") + "/v1/agents/completions",
+		apiKey:     apiKey,
+		model:      model,
+		agentID:    agentID,
+		httpClient: &http.Client{},
+	}
+}
+
+// NewOpenAICompatibleClient creates a client of an OpenAI-compatible API, such as llama.cpp or Ollama
+// baseURL includes the version prefix, e.g. "http://localhost:11434/v1/"
+func NewOpenAICompatibleClient(baseURL, apiKey, model string) *ChatCompletionsClient {
+	return &ChatCompletionsClient{
+		url:        strings.TrimSuffix(baseURL, "/") + "/chat/completions",
+		apiKey:     apiKey,
+		model:      model,
+		httpClient: &http.Client{},
+	}
+}
+
+// ModelFor returns the model answering the calls of a purpose, "agent:<id>" for the agent
+func (c *ChatCompletionsClient) ModelFor(purpose string) string {
+	if c.usesAgent(purpose) {
+		return "agent:" + c.agentID
+	}
+	if model := c.purposeModels[purpose]; model != "" {
+		return model
+	}
+	return c.model
+}
+
+// usesAgent reports whether the calls of a purpose go through the agent
+func (c *ChatCompletionsClient) usesAgent(purpose string) bool {
+	return c.agentID != "" && purpose == LLMPurposeAgent
+}
+
+// chatCompletionsRequest is the body shared by the providers
+type chatCompletionsRequest struct {
+	Model       string               `json:"model,omitempty"`
+	AgentID     string               `json:"agent_id,omitempty"`
+	Messages    []MistralMessage     `json:"messages"`
+	MaxTokens   int                  `json:"max_tokens,omitempty"`
+	Temperature *float64             `json:"temperature,omitempty"`
+	Stream      bool                 `json:"stream"`
+	Tools       []FunctionCallSchema `json:"tools,omitempty"`
+	// ResponseFormat is {"type": "json_object"} or {"type": "json_schema", "json_schema": {...}}
+	ResponseFormat map[string]interface{} `json:"response_format,omitempty"`
+}
+
+// Chat returns the reply to a conversation
+func (c *ChatCompletionsClient) Chat(ctx context.Context, request LLMRequest) (*LLMResponse, error) {
+	return c.ChatWithTools(ctx, request, nil)
+}
+
+// ChatWithTools returns the reply to a conversation, which may call the given tools instead of answering
+func (c *ChatCompletionsClient) ChatWithTools(ctx context.Context, request LLMRequest, tools []FunctionCallSchema) (*LLMResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 120*time.Second)
+	defer cancel()
+
+	resp, err := c.send(ctx, request, tools, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+	return parseChatCompletion(body)
+}
+
+// Stream calls onDelta for every chunk of the reply and returns the whole reply once complete
+func (c *ChatCompletionsClient) Stream(ctx context.Context, request LLMRequest, onDelta func(delta string) error) (*LLMResponse, error) {
+	resp, err := c.send(ctx, request, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	reply, err := readChatStream(resp.Body, onDelta)
+	response := &LLMResponse{Message: MistralMessage{Role: "assistant", Content: reply}}
+	// Streams do not report usage, estimate it
+	for _, message := range request.Messages {
+		response.Usage.PromptTokens += estimateMessageTokens(message)
+	}
+	response.Usage.CompletionTokens = estimateTokens(reply)
+	response.Usage.TotalTokens = response.Usage.PromptTokens + response.Usage.CompletionTokens
+	return response, err
+}
+
+// send posts a completion request and checks the status of the response
+func (c *ChatCompletionsClient) send(ctx context.Context, request LLMRequest, tools []FunctionCallSchema, stream bool) (*http.Response, error) {
+	body := chatCompletionsRequest{
+		Messages:    request.Messages,
+		MaxTokens:   request.MaxTokens,
+		Temperature: request.Temperature,
+		Stream:      stream,
+		Tools:       tools,
+	}
+	if format := request.ResponseFormat; format != nil {
+		body.ResponseFormat = map[string]interface{}{"type": "json_object"}
+		if format.Schema != nil {
+			body.ResponseFormat = map[string]interface{}{
+				"type": "json_schema",
+				"json_schema": map[string]interface{}{
+					"name":   format.Name,
+					"schema": format.Schema,
+					"strict": true,
+				},
+			}
+		}
+	}
+	url := c.url
+	if purpose := llmUsageScopeFrom(ctx).Purpose; c.usesAgent(purpose) {
+		url = c.agentURL
+		body.AgentID = c.agentID
+		// Agents are configured with their own sampling parameters
+		body.Temperature = nil
+	} else {
+		body.Model = c.ModelFor(purpose)
+	}
+
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("API returned error: %d %s", resp.StatusCode, string(bodyBytes))
+	}
+	return resp, nil
+}
+
+// parseChatCompletion extracts the assistant message, the tools it calls and the usage from a completion response
+// Tool arguments are accepted both as a JSON string (Mistral, OpenAI) and as an object (some local servers)
+func parseChatCompletion(responseData []byte) (*LLMResponse, error) {
+	var parsed struct {
+		Choices []struct {
+			Message struct {
+				Content   string `json:"content"`
+				ToolCalls []struct {
+					ID       string `json:"id"`
+					Type     string `json:"type"`
+					Function struct {
+						Name      string          `json:"name"`
+						Arguments json.RawMessage `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+		} `json:"choices"`
+		Model string   `json:"model"`
+		Usage LLMUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(responseData, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse completion: %w", err)
+	}
+	if len(parsed.Choices) == 0 {
+		return nil, fmt.Errorf("failed to parse completion: no choices")
+	}
+
+	message := parsed.Choices[0].Message
+	response := &LLMResponse{
+		Message: MistralMessage{Role: "assistant", Content: message.Content},
+		Usage:   parsed.Usage,
+		Model:   parsed.Model,
+	}
+	for _, tc := range message.ToolCalls {
+		call := MistralToolCall{ID: tc.ID, Type: tc.Type}
+		call.Function.Name = tc.Function.Name
+		var arguments string
+		if err := json.Unmarshal(tc.Function.Arguments, &arguments); err == nil {
+			call.Function.Arguments = arguments
+		} else {
+			call.Function.Arguments = string(tc.Function.Arguments)
+		}
+		response.Message.ToolCalls = append(response.Message.ToolCalls, call)
+	}
+	return response, nil
+}
+
+// readChatStream parses the provider's SSE chunks ("data: {...}" lines terminated by "data: [DONE]")
+func readChatStream(body io.Reader, onDelta func(delta string) error) (string, error) {
+	var reply strings.Builder
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			// Blank separators, comments and other SSE fields carry no content
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return reply.String(), nil
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return reply.String(), fmt.Errorf("error unmarshaling stream chunk: %v", err)
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			reply.WriteString(choice.Delta.Content)
+			if err := onDelta(choice.Delta.Content); err != nil {
+				return reply.String(), err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return reply.String(), fmt.Errorf("error reading stream: %w", err)
+	}
+
+	return reply.String(), fmt.Errorf("stream ended before completion")
+}
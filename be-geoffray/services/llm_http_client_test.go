@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseChatCompletion(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		wantContent   string
+		wantToolCalls []string
+		wantArguments string
+		wantTokens    int
+		wantErr       bool
+	}{
+		{
+			name:        "text reply",
+			body:        `{"choices":[{"message":{"role":"assistant","content":"Bonjour"}}],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`,
+			wantContent: "Bonjour",
+			wantTokens:  7,
+		},
+		{
+			name: "tool calls",
+			body: `{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[` +
+				`{"id":"a","function":{"name":"summarize_rsvps","arguments":"{}"}},` +
+				`{"id":"b","function":{"name":"list_gift_suggestions","arguments":"{}"}}]}}]}`,
+			wantToolCalls: []string{"summarize_rsvps", "list_gift_suggestions"},
+			wantArguments: "{}",
+		},
+		{
+			name: "object arguments",
+			body: `{"choices":[{"message":{"role":"assistant","tool_calls":[` +
+				`{"id":"a","function":{"name":"add_gift_suggestion","arguments":{"name":"Book"}}}]}}]}`,
+			wantToolCalls: []string{"add_gift_suggestion"},
+			wantArguments: `{"name":"Book"}`,
+		},
+		{name: "no choices", body: `{"choices":[]}`, wantErr: true},
+		{name: "invalid json", body: `{`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChatCompletion([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Message.Role != "assistant" || got.Message.Content != tt.wantContent {
+				t.Errorf("message = %q/%q, want assistant/%q", got.Message.Role, got.Message.Content, tt.wantContent)
+			}
+			if got.Usage.TotalTokens != tt.wantTokens {
+				t.Errorf("total tokens = %d, want %d", got.Usage.TotalTokens, tt.wantTokens)
+			}
+			if len(got.Message.ToolCalls) != len(tt.wantToolCalls) {
+				t.Fatalf("got %d tool calls, want %d", len(got.Message.ToolCalls), len(tt.wantToolCalls))
+			}
+			for i, name := range tt.wantToolCalls {
+				if got.Message.ToolCalls[i].Function.Name != name {
+					t.Errorf("tool call %d = %s, want %s", i, got.Message.ToolCalls[i].Function.Name, name)
+				}
+				if got.Message.ToolCalls[i].Function.Arguments != tt.wantArguments {
+					t.Errorf("tool call %d arguments = %s, want %s", i, got.Message.ToolCalls[i].Function.Arguments, tt.wantArguments)
+				}
+			}
+		})
+	}
+}
+
+func TestReadChatStream(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantReply  string
+		wantDeltas int
+		wantErr    bool
+	}{
+		{
+			name: "complete stream",
+			body: "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"Bon\"}}]}\n\n" +
+				": keep-alive\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"jour\"}}]}\n\n" +
+				"data: [DONE]\n\n",
+			wantReply:  "Bonjour",
+			wantDeltas: 2,
+		},
+		{
+			name:       "stream cut before done",
+			body:       "data: {\"choices\":[{\"delta\":{\"content\":\"Bon\"}}]}\n\n",
+			wantReply:  "Bon",
+			wantDeltas: 1,
+			wantErr:    true,
+		},
+		{
+			name:    "invalid chunk",
+			body:    "data: {not json}\n\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deltas := 0
+			reply, err := readChatStream(strings.NewReader(tt.body), func(string) error {
+				deltas++
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readChatStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if reply != tt.wantReply {
+				t.Errorf("readChatStream() reply = %q, want %q", reply, tt.wantReply)
+			}
+			if deltas != tt.wantDeltas {
+				t.Errorf("readChatStream() deltas = %d, want %d", deltas, tt.wantDeltas)
+			}
+		})
+	}
+}
+
+func TestChatCompletionsClientRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		newClient func(url string) *ChatCompletionsClient
+		wantPath  string
+		wantModel string
+		wantAgent string
+		wantAuth  string
+	}{
+		{
+			name:      "mistral model",
+			newClient: func(url string) *ChatCompletionsClient { return NewMistralClient(url+"/", "key", "mistral-small", "") },
+			wantPath:  "/v1/chat/completions",
+			wantModel: "mistral-small",
+			wantAuth:  "Bearer key",
+		},
+		{
+			name: "mistral agent outside the event chat",
+			newClient: func(url string) *ChatCompletionsClient {
+				return NewMistralClient(url, "key", "mistral-small", "agent-1")
+			},
+			wantPath:  "/v1/chat/completions",
+			wantModel: "mistral-small",
+			wantAuth:  "Bearer key",
+		},
+		{
+			name:      "openai compatible without key",
+			newClient: func(url string) *ChatCompletionsClient { return NewOpenAICompatibleClient(url+"/v1/", "", "llama3") },
+			wantPath:  "/v1/chat/completions",
+			wantModel: "llama3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body chatCompletionsRequest
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("invalid request body: %v", err)
+				}
+				if r.URL.Path != tt.wantPath {
+					t.Errorf("path = %s, want %s", r.URL.Path, tt.wantPath)
+				}
+				if body.Model != tt.wantModel || body.AgentID != tt.wantAgent {
+					t.Errorf("model/agent = %q/%q, want %q/%q", body.Model, body.AgentID, tt.wantModel, tt.wantAgent)
+				}
+				if got := r.Header.Get("Authorization"); got != tt.wantAuth {
+					t.Errorf("authorization = %q, want %q", got, tt.wantAuth)
+				}
+				w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+			}))
+			defer server.Close()
+
+			response, err := tt.newClient(server.URL).Chat(context.Background(), LLMRequest{
+				Messages: []MistralMessage{{Role: "user", Content: "hi"}},
+			})
+			if err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+			if response.Message.Content != "ok" {
+				t.Errorf("content = %q, want ok", response.Message.Content)
+			}
+		})
+	}
+}
+
+func TestMistralClientUsesAgentOnlyForTheEventChat(t *testing.T) {
+	type received struct {
+		path string
+		body chatCompletionsRequest
+	}
+	var requests []received
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body chatCompletionsRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		requests = append(requests, received{path: r.URL.Path, body: body})
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewMistralClient(server.URL, "key", "mistral-large-latest", "agent-1")
+	client.purposeModels = map[string]string{LLMPurposeSimilarity: "mistral-small-latest"}
+
+	temperature := 0.2
+	tests := []struct {
+		purpose         string
+		wantPath        string
+		wantModel       string
+		wantAgent       string
+		wantTemperature bool
+	}{
+		{LLMPurposeAgent, "/v1/agents/completions", "", "agent-1", false},
+		{LLMPurposeSimilarity, "/v1/chat/completions", "mistral-small-latest", "", true},
+		{LLMPurposeGeneration, "/v1/chat/completions", "mistral-large-latest", "", true},
+		{LLMPurposeTranslation, "/v1/chat/completions", "mistral-large-latest", "", true},
+		{"", "/v1/chat/completions", "mistral-large-latest", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run("purpose "+tt.purpose, func(t *testing.T) {
+			ctx := WithLLMUsageScope(context.Background(), LLMUsageScope{Purpose: tt.purpose})
+			_, err := client.Chat(ctx, LLMRequest{
+				Messages:    []MistralMessage{{Role: "user", Content: "hi"}},
+				Temperature: &temperature,
+			})
+			if err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+
+			got := requests[len(requests)-1]
+			if got.path != tt.wantPath {
+				t.Errorf("path = %s, want %s", got.path, tt.wantPath)
+			}
+			if got.body.Model != tt.wantModel || got.body.AgentID != tt.wantAgent {
+				t.Errorf("model/agent = %q/%q, want %q/%q", got.body.Model, got.body.AgentID, tt.wantModel, tt.wantAgent)
+			}
+			if (got.body.Temperature != nil) != tt.wantTemperature {
+				t.Errorf("temperature = %v, want set: %v", got.body.Temperature, tt.wantTemperature)
+			}
+			wantModel := tt.wantModel
+			if tt.wantAgent != "" {
+				wantModel = "agent:" + tt.wantAgent
+			}
+			if recorded := client.ModelFor(tt.purpose); recorded != wantModel {
+				t.Errorf("ModelFor(%q) = %q, want %q", tt.purpose, recorded, wantModel)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"be-geoffray/config"
+	"be-geoffray/db"
+	"be-geoffray/models"
+)
+
+// Purposes of language model calls, recorded with their usage
+const (
+	LLMPurposeGeneration  = "generation"
+	LLMPurposeSimilarity  = "similarity"
+	LLMPurposeAgent       = "agent"
+	LLMPurposeDrafting    = "drafting"
+	LLMPurposeSummary     = "summary"
+	LLMPurposeTranslation = "translation"
+	LLMPurposeOther       = "other"
+)
+
+// Outcomes of language model calls
+const (
+	LLMOutcomeSuccess       = "success"
+	LLMOutcomeError         = "error"
+	LLMOutcomeQuotaExceeded = "quota_exceeded"
+)
+
+// AIQuotaExceededCode is the error code returned to clients when a quota refuses an AI request
+const AIQuotaExceededCode = "ai_quota_exceeded"
+
+// LLMUsageScope attributes language model calls to a user, an event and a purpose
+type LLMUsageScope struct {
+	UserID  string
+	EventID string
+	Purpose string
+}
+
+type llmUsageScopeKey struct{}
+
+// WithLLMUsageScope returns a context whose language model calls are attributed to the scope
+func WithLLMUsageScope(ctx context.Context, scope LLMUsageScope) context.Context {
+	return context.WithValue(ctx, llmUsageScopeKey{}, scope)
+}
+
+// llmUsageScopeFrom returns the scope of the context, empty if none was set
+func llmUsageScopeFrom(ctx context.Context) LLMUsageScope {
+	scope, _ := ctx.Value(llmUsageScopeKey{}).(LLMUsageScope)
+	return scope
+}
+
+// AIQuotaError reports that a user or an event has used up its AI tokens for the period
+type AIQuotaError struct {
+	Subject  string // "user" or "event"
+	Period   string // "day" or "month"
+	Limit    int
+	ResetsAt time.Time
+}
+
+func (e *AIQuotaError) Error() string {
+	return fmt.Sprintf("%s %s AI quota of %d tokens exceeded", e.Subject, e.Period, e.Limit)
+}
+
+// IsAIQuotaExceeded reports whether err was caused by an exhausted AI quota
+func IsAIQuotaExceeded(err error) bool {
+	var quotaErr *AIQuotaError
+	return errors.As(err, &quotaErr)
+}
+
+// AIQuotaLimits are the token limits per period, 0 means unlimited
+type AIQuotaLimits struct {
+	UserDaily    int
+	UserMonthly  int
+	EventDaily   int
+	EventMonthly int
+}
+
+// aiQuotaUsage is the number of tokens already consumed by a user and an event
+type aiQuotaUsage struct {
+	UserDaily    int
+	UserMonthly  int
+	EventDaily   int
+	EventMonthly int
+}
+
+// checkAIQuotaLimits returns an AIQuotaError for the first limit the usage has reached
+func checkAIQuotaLimits(limits AIQuotaLimits, usage aiQuotaUsage, now time.Time) error {
+	dayStart, monthStart := aiQuotaPeriodStarts(now)
+	checks := []struct {
+		subject, period string
+		limit, used     int
+		resetsAt        time.Time
+	}{
+		{"user", "day", limits.UserDaily, usage.UserDaily, dayStart.AddDate(0, 0, 1)},
+		{"user", "month", limits.UserMonthly, usage.UserMonthly, monthStart.AddDate(0, 1, 0)},
+		{"event", "day", limits.EventDaily, usage.EventDaily, dayStart.AddDate(0, 0, 1)},
+		{"event", "month", limits.EventMonthly, usage.EventMonthly, monthStart.AddDate(0, 1, 0)},
+	}
+	for _, check := range checks {
+		if check.limit > 0 && check.used >= check.limit {
+			return &AIQuotaError{Subject: check.subject, Period: check.period, Limit: check.limit, ResetsAt: check.resetsAt}
+		}
+	}
+	return nil
+}
+
+// aiQuotaPeriodStarts returns the start of the current UTC day and month
+func aiQuotaPeriodStarts(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return dayStart, monthStart
+}
+
+// LLMUsageService records language model usage and enforces AI quotas
+type LLMUsageService struct {
+	limits AIQuotaLimits
+}
+
+// NewLLMUsageService creates a new instance of LLMUsageService with the configured quotas
+func NewLLMUsageService() *LLMUsageService {
+	cfg := config.GetConfig()
+	return &LLMUsageService{
+		limits: AIQuotaLimits{
+			UserDaily:    cfg.AIQuotaUserDailyTokens,
+			UserMonthly:  cfg.AIQuotaUserMonthlyTokens,
+			EventDaily:   cfg.AIQuotaEventDailyTokens,
+			EventMonthly: cfg.AIQuotaEventMonthlyTokens,
+		},
+	}
+}
+
+// CheckQuota returns an AIQuotaError when the user or the event of the scope has no tokens left
+func (s *LLMUsageService) CheckQuota(scope LLMUsageScope) error {
+	var usage aiQuotaUsage
+	now := time.Now()
+	dayStart, monthStart := aiQuotaPeriodStarts(now)
+
+	if scope.UserID != "" && (s.limits.UserDaily > 0 || s.limits.UserMonthly > 0) {
+		if err := s.sumTokens("user_id", scope.UserID, dayStart, monthStart, &usage.UserDaily, &usage.UserMonthly); err != nil {
+			return err
+		}
+	}
+	if scope.EventID != "" && (s.limits.EventDaily > 0 || s.limits.EventMonthly > 0) {
+		if err := s.sumTokens("event_id", scope.EventID, dayStart, monthStart, &usage.EventDaily, &usage.EventMonthly); err != nil {
+			return err
+		}
+	}
+
+	return checkAIQuotaLimits(s.limits, usage, now)
+}
+
+// sumTokens loads the tokens consumed since the start of the day and of the month by a user or an event
+func (s *LLMUsageService) sumTokens(column, id string, dayStart, monthStart time.Time, daily, monthly *int) error {
+	query := fmt.Sprintf(`
+		SELECT COALESCE(SUM(total_tokens) FILTER (WHERE created_at >= $2), 0), COALESCE(SUM(total_tokens), 0)
+		FROM llm_usage
+		WHERE %s = $1 AND created_at >= $3
+	`, column)
+	if err := db.DB.QueryRow(query, id, dayStart, monthStart).Scan(daily, monthly); err != nil {
+		return fmt.Errorf("error loading AI usage: %w", err)
+	}
+	return nil
+}
+
+// Record stores the usage of a language model call
+func (s *LLMUsageService) Record(usage models.LLMUsage) error {
+	_, err := db.DB.Exec(`
+		INSERT INTO llm_usage (
+			user_id, event_id, purpose, provider, model, prompt_tokens, completion_tokens, total_tokens,
+			latency_ms, outcome, error
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, usage.UserID, usage.EventID, usage.Purpose, usage.Provider, usage.Model, usage.PromptTokens,
+		usage.CompletionTokens, usage.TotalTokens, usage.LatencyMs, usage.Outcome, usage.Error)
+	if err != nil {
+		return fmt.Errorf("error recording AI usage: %w", err)
+	}
+	return nil
+}
+
+// llmUsageGroupings maps the supported groupings of the usage summary to their SQL expression
+var llmUsageGroupings = map[string]string{
+	"user":     "COALESCE(user_id::text, '')",
+	"event":    "COALESCE(event_id::text, '')",
+	"purpose":  "purpose",
+	"model":    "model",
+	"provider": "provider",
+	"day":      "to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD')",
+}
+
+// GetUsageSummary aggregates the usage recorded in [from, to) by user, event, purpose, model, provider or day
+// Groups are sorted by decreasing token spend
+func (s *LLMUsageService) GetUsageSummary(from, to time.Time, groupBy string) ([]models.LLMUsageSummary, error) {
+	expression, ok := llmUsageGroupings[groupBy]
+	if !ok {
+		return nil, errors.New("invalid grouping")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %[1]s AS key,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE outcome = 'error'),
+			COUNT(*) FILTER (WHERE outcome = 'quota_exceeded'),
+			COALESCE(SUM(prompt_tokens), 0),
+			COALESCE(SUM(completion_tokens), 0),
+			COALESCE(SUM(total_tokens), 0),
+			COALESCE(AVG(latency_ms) FILTER (WHERE outcome <> 'quota_exceeded'), 0)
+		FROM llm_usage
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY %[1]s
+		ORDER BY SUM(total_tokens) DESC, key
+	`, expression)
+
+	rows, err := db.DB.Query(query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error loading AI usage: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := []models.LLMUsageSummary{}
+	for rows.Next() {
+		var summary models.LLMUsageSummary
+		if err := rows.Scan(&summary.Key, &summary.Calls, &summary.FailedCalls, &summary.RejectedCalls,
+			&summary.PromptTokens, &summary.CompletionTokens, &summary.TotalTokens, &summary.AvgLatencyMs); err != nil {
+			return nil, fmt.Errorf("error scanning AI usage: %w", err)
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, rows.Err()
+}
+
+// meteredLLMClient enforces quotas before every call of the wrapped client and records the usage of the call
+// Calls are attributed with the scope set on their context by WithLLMUsageScope
+type meteredLLMClient struct {
+	next       LLMClient
+	provider   string
+	modelFor   func(purpose string) string // Model recorded when the provider does not report it
+	checkQuota func(scope LLMUsageScope) error
+	record     func(usage models.LLMUsage) error
+}
+
+// newMeteredLLMClient wraps a client so that its calls are accounted in the database
+func newMeteredLLMClient(next LLMClient, provider string, modelFor func(purpose string) string) *meteredLLMClient {
+	usageService := NewLLMUsageService()
+	return &meteredLLMClient{
+		next:       next,
+		provider:   provider,
+		modelFor:   modelFor,
+		checkQuota: usageService.CheckQuota,
+		record:     usageService.Record,
+	}
+}
+
+// Chat returns the reply to a conversation
+func (m *meteredLLMClient) Chat(ctx context.Context, request LLMRequest) (*LLMResponse, error) {
+	return m.meter(ctx, func() (*LLMResponse, error) {
+		return m.next.Chat(ctx, request)
+	})
+}
+
+// ChatWithTools returns the reply to a conversation, which may call the given tools instead of answering
+func (m *meteredLLMClient) ChatWithTools(ctx context.Context, request LLMRequest, tools []FunctionCallSchema) (*LLMResponse, error) {
+	return m.meter(ctx, func() (*LLMResponse, error) {
+		return m.next.ChatWithTools(ctx, request, tools)
+	})
+}
+
+// Stream calls onDelta for every chunk of the reply and returns the whole reply once complete
+func (m *meteredLLMClient) Stream(ctx context.Context, request LLMRequest, onDelta func(delta string) error) (*LLMResponse, error) {
+	return m.meter(ctx, func() (*LLMResponse, error) {
+		return m.next.Stream(ctx, request, onDelta)
+	})
+}
+
+// meter checks the quotas of the scope, runs the call and records its usage
+func (m *meteredLLMClient) meter(ctx context.Context, call func() (*LLMResponse, error)) (*LLMResponse, error) {
+	scope := llmUsageScopeFrom(ctx)
+	usage := models.LLMUsage{
+		UserID:   optionalString(scope.UserID),
+		EventID:  optionalString(scope.EventID),
+		Purpose:  scope.Purpose,
+		Provider: m.provider,
+	}
+	if usage.Purpose == "" {
+		usage.Purpose = LLMPurposeOther
+	}
+	usage.Model = m.modelFor(scope.Purpose)
+
+	if err := m.checkQuota(scope); err != nil {
+		if IsAIQuotaExceeded(err) {
+			usage.Outcome = LLMOutcomeQuotaExceeded
+			m.recordUsage(usage)
+		}
+		return nil, err
+	}
+
+	start := time.Now()
+	response, err := call()
+	usage.LatencyMs = int(time.Since(start).Milliseconds())
+
+	if response != nil {
+		usage.PromptTokens = response.Usage.PromptTokens
+		usage.CompletionTokens = response.Usage.CompletionTokens
+		usage.TotalTokens = response.Usage.TotalTokens
+		if response.Model != "" {
+			usage.Model = response.Model
+		}
+	}
+	if err != nil {
+		usage.Outcome = LLMOutcomeError
+		message := err.Error()
+		usage.Error = &message
+	} else {
+		usage.Outcome = LLMOutcomeSuccess
+	}
+	m.recordUsage(usage)
+
+	return response, err
+}
+
+// recordUsage stores the usage, failures are logged since they must not fail the call
+func (m *meteredLLMClient) recordUsage(usage models.LLMUsage) {
+	if err := m.record(usage); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// optionalString returns nil for empty strings, for nullable columns
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"be-geoffray/models"
+)
+
+func TestCheckAIQuotaLimits(t *testing.T) {
+	now := time.Date(2026, 3, 31, 22, 0, 0, 0, time.UTC)
+	limits := AIQuotaLimits{UserDaily: 1000, UserMonthly: 10000, EventMonthly: 5000}
+
+	tests := []struct {
+		name         string
+		usage        aiQuotaUsage
+		wantSubject  string
+		wantPeriod   string
+		wantResetsAt time.Time
+	}{
+		{name: "within limits", usage: aiQuotaUsage{UserDaily: 999, UserMonthly: 9000, EventDaily: 100000, EventMonthly: 4999}},
+		{
+			name:         "user daily",
+			usage:        aiQuotaUsage{UserDaily: 1000, UserMonthly: 1000},
+			wantSubject:  "user",
+			wantPeriod:   "day",
+			wantResetsAt: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "event monthly",
+			usage:        aiQuotaUsage{EventMonthly: 6000},
+			wantSubject:  "event",
+			wantPeriod:   "month",
+			wantResetsAt: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAIQuotaLimits(limits, tt.usage, now)
+			if tt.wantSubject == "" {
+				if err != nil {
+					t.Fatalf("error = %v, want none", err)
+				}
+				return
+			}
+			var quotaErr *AIQuotaError
+			if !errors.As(err, &quotaErr) {
+				t.Fatalf("error = %v, want an AIQuotaError", err)
+			}
+			if quotaErr.Subject != tt.wantSubject || quotaErr.Period != tt.wantPeriod || !quotaErr.ResetsAt.Equal(tt.wantResetsAt) {
+				t.Errorf("quota = %s/%s resetting at %v, want %s/%s at %v",
+					quotaErr.Subject, quotaErr.Period, quotaErr.ResetsAt, tt.wantSubject, tt.wantPeriod, tt.wantResetsAt)
+			}
+		})
+	}
+}
+
+// newTestMeteredClient wraps a fake client with stubbed quotas, collecting the recorded usage
+func newTestMeteredClient(fake *FakeLLMClient, quotaErr error) (*meteredLLMClient, *[]models.LLMUsage) {
+	var recorded []models.LLMUsage
+	client := &meteredLLMClient{
+		next:       fake,
+		provider:   "fake",
+		modelFor:   func(string) string { return "fake-model" },
+		checkQuota: func(LLMUsageScope) error { return quotaErr },
+		record: func(usage models.LLMUsage) error {
+			recorded = append(recorded, usage)
+			return nil
+		},
+	}
+	return client, &recorded
+}
+
+func TestMeteredLLMClientRecordsUsage(t *testing.T) {
+	fake := NewFakeLLMClient(LLMResponse{
+		Message: MistralMessage{Role: "assistant", Content: "Hello"},
+		Usage:   LLMUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	})
+	client, recorded := newTestMeteredClient(fake, nil)
+
+	ctx := WithLLMUsageScope(context.Background(), LLMUsageScope{UserID: "u1", EventID: "e1", Purpose: LLMPurposeAgent})
+	if _, err := client.Chat(ctx, LLMRequest{Messages: []MistralMessage{{Role: "user", Content: "Hi"}}}); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if len(*recorded) != 1 {
+		t.Fatalf("recorded %d usages, want 1", len(*recorded))
+	}
+	usage := (*recorded)[0]
+	if usage.UserID == nil || *usage.UserID != "u1" || usage.EventID == nil || *usage.EventID != "e1" {
+		t.Errorf("usage attributed to %v/%v, want u1/e1", usage.UserID, usage.EventID)
+	}
+	if usage.Purpose != LLMPurposeAgent || usage.Outcome != LLMOutcomeSuccess || usage.TotalTokens != 15 || usage.Model != "fake-model" {
+		t.Errorf("usage = %+v, want a successful agent call of 15 tokens", usage)
+	}
+}
+
+func TestMeteredLLMClientEnforcesQuota(t *testing.T) {
+	fake := NewFakeLLMClient()
+	client, recorded := newTestMeteredClient(fake, &AIQuotaError{Subject: "user", Period: "day", Limit: 100})
+
+	_, err := client.Stream(context.Background(), LLMRequest{}, func(string) error { return nil })
+	if !IsAIQuotaExceeded(err) {
+		t.Fatalf("error = %v, want a quota error", err)
+	}
+	if len(fake.Requests()) != 0 {
+		t.Error("the provider was called despite the exhausted quota")
+	}
+	if len(*recorded) != 1 || (*recorded)[0].Outcome != LLMOutcomeQuotaExceeded || (*recorded)[0].Purpose != LLMPurposeOther {
+		t.Errorf("recorded %+v, want one unattributed quota_exceeded call", *recorded)
+	}
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"be-geoffray/db"
+	"be-geoffray/models"
+
+	"github.com/lib/pq"
+)
+
+// mentionIDTokenPattern matches the "<@user-id>" tokens inserted by clients when picking a participant
+var mentionIDTokenPattern = regexp.MustCompile(`<@([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})>`)
+
+// MentionCandidate is a participant that can be mentioned in an event discussion
+type MentionCandidate struct {
+	UserID    string
+	FirstName string
+	LastName  string
+}
+
+// DisplayName returns the name shown for the participant
+func (m MentionCandidate) DisplayName() string {
+	return strings.TrimSpace(m.FirstName + " " + m.LastName)
+}
+
+// ParseMentions finds the participants mentioned in a message
+// A mention is either an ID token ("<@user-id>") or "@" followed by a display name. The full name
+// is tried first, then the first name alone; names shared by several participants are ignored
+// since they cannot be resolved. Anyone who is not in candidates cannot be mentioned.
+func ParseMentions(content string, candidates []MentionCandidate) []models.MessageMention {
+	byID := make(map[string]MentionCandidate, len(candidates))
+	for _, candidate := range candidates {
+		byID[strings.ToLower(candidate.UserID)] = candidate
+	}
+
+	mentions := []models.MessageMention{}
+	seen := make(map[string]bool)
+	add := func(candidate MentionCandidate, token string) {
+		if seen[candidate.UserID] {
+			return
+		}
+		seen[candidate.UserID] = true
+		mentions = append(mentions, models.MessageMention{
+			UserID:      candidate.UserID,
+			DisplayName: candidate.DisplayName(),
+			Token:       token,
+		})
+	}
+
+	// ID tokens are unambiguous and take precedence
+	for _, match := range mentionIDTokenPattern.FindAllStringSubmatch(content, -1) {
+		if candidate, ok := byID[strings.ToLower(match[1])]; ok {
+			add(candidate, match[0])
+		}
+	}
+
+	// Longer names first, so that "@Marie Claire" wins over "@Marie"
+	fullNames := make([]MentionCandidate, len(candidates))
+	copy(fullNames, candidates)
+	sort.SliceStable(fullNames, func(i, j int) bool {
+		return len(fullNames[i].DisplayName()) > len(fullNames[j].DisplayName())
+	})
+
+	for i := 0; i < len(content); i++ {
+		if content[i] != '@' || !isMentionStart(content, i) {
+			continue
+		}
+		rest := content[i+1:]
+
+		if matched, length := matchMentionName(rest, fullNames, MentionCandidate.DisplayName); matched != nil {
+			add(*matched, "@"+rest[:length])
+			i += length
+			continue
+		}
+		if matched, length := matchMentionName(rest, fullNames, func(m MentionCandidate) string { return strings.TrimSpace(m.FirstName) }); matched != nil {
+			add(*matched, "@"+rest[:length])
+			i += length
+		}
+	}
+
+	return mentions
+}
+
+// isMentionStart reports whether the "@" at index i starts a mention (and is not part of an email or an ID token)
+func isMentionStart(content string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	previous, _ := utf8.DecodeLastRuneInString(content[:i])
+	return !(unicode.IsLetter(previous) || unicode.IsDigit(previous) || previous == '.' || previous == '_' || previous == '<')
+}
+
+// matchMentionName returns the single candidate whose name (as returned by name) starts rest,
+// along with the byte length of the match. It returns nil when no or several candidates match.
+func matchMentionName(rest string, candidates []MentionCandidate, name func(MentionCandidate) string) (*MentionCandidate, int) {
+	var matched *MentionCandidate
+	matchedLength := 0
+	ambiguous := false
+
+	for i := range candidates {
+		candidateName := name(candidates[i])
+		if candidateName == "" || len(rest) < len(candidateName) || !strings.EqualFold(rest[:len(candidateName)], candidateName) {
+			continue
+		}
+		// The name must end on a word boundary
+		if next, _ := utf8.DecodeRuneInString(rest[len(candidateName):]); len(rest) > len(candidateName) && (unicode.IsLetter(next) || unicode.IsDigit(next)) {
+			continue
+		}
+
+		switch {
+		case matched == nil || len(candidateName) > matchedLength:
+			matched, matchedLength, ambiguous = &candidates[i], len(candidateName), false
+		case len(candidateName) == matchedLength && candidates[i].UserID != matched.UserID:
+			ambiguous = true
+		}
+	}
+
+	if matched == nil || ambiguous {
+		return nil, 0
+	}
+	return matched, matchedLength
+}
+
+// mentionCandidateRow is a user linked to an event, with what decides their relationship to it
+type mentionCandidateRow struct {
+	MentionCandidate
+	isCreator         bool
+	participantStatus string
+}
+
+// getMentionCandidates returns the creator and participants of an event, who are the only users that can be mentioned
+func getMentionCandidates(eventID string) ([]MentionCandidate, error) {
+	rows, err := db.DB.Query(`
+		SELECT u.id, COALESCE(u.first_name, ''), COALESCE(u.last_name, ''), u.id = e.creator_id, COALESCE(ep.status, '')
+		FROM events e
+		JOIN users u ON u.id = e.creator_id
+			OR u.id IN (SELECT user_id FROM event_participants WHERE event_id = e.id)
+		LEFT JOIN event_participants ep ON ep.event_id = e.id AND ep.user_id = u.id
+		WHERE e.id = $1`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching participants: %w", err)
+	}
+	defer rows.Close()
+
+	var users []mentionCandidateRow
+	for rows.Next() {
+		var user mentionCandidateRow
+		if err := rows.Scan(&user.UserID, &user.FirstName, &user.LastName, &user.isCreator, &user.participantStatus); err != nil {
+			return nil, fmt.Errorf("error scanning participant: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return mentionableCandidates(users), nil
+}
+
+// mentionableCandidates keeps the users who may take part in the discussion, with the rule of the event access policy:
+// pending and declined guests cannot be mentioned
+func mentionableCandidates(users []mentionCandidateRow) []MentionCandidate {
+	var candidates []MentionCandidate
+	for _, user := range users {
+		if eventRelationshipFrom(user.isCreator, user.participantStatus, false).Can(EventActionParticipate) {
+			candidates = append(candidates, user.MentionCandidate)
+		}
+	}
+	return candidates
+}
+
+// processMessageMentions resolves the mentions of a message, stores them and notifies the newly mentioned participants
+// Failures are logged and never fail the message itself
+func processMessageMentions(message *models.EventMessage) {
+	message.Mentions = []models.MessageMention{}
+
+	candidates, err := getMentionCandidates(message.EventID)
+	if err != nil {
+		log.Printf("Error resolving mentions of message %s: %v", message.ID, err)
+		return
+	}
+
+	mentions := ParseMentions(message.Content, candidates)
+	mentionedIDs := make([]string, len(mentions))
+	for i, mention := range mentions {
+		mentionedIDs[i] = mention.UserID
+	}
+
+	// Drop mentions removed by an edit
+	_, err = db.DB.Exec(`
+		DELETE FROM event_message_mentions
+		WHERE message_id = $1 AND NOT (user_id::text = ANY($2))`, message.ID, pq.Array(mentionedIDs))
+	if err != nil {
+		log.Printf("Error updating mentions of message %s: %v", message.ID, err)
+		return
+	}
+
+	notificationService := NewNotificationService()
+	for _, mention := range mentions {
+		_, err := db.DB.Exec(`
+			INSERT INTO event_message_mentions (message_id, user_id, token, created_at)
+			VALUES ($1, $2, $3, NOW())
+			ON CONFLICT (message_id, user_id) DO UPDATE SET token = EXCLUDED.token`,
+			message.ID, mention.UserID, mention.Token)
+		if err != nil {
+			log.Printf("Error storing mention of %s in message %s: %v", mention.UserID, message.ID, err)
+			continue
+		}
+		message.Mentions = append(message.Mentions, mention)
+
+		// Never notify the author, nor people already notified for this message before an edit
+		if mention.UserID == message.UserID || alreadyNotifiedOfMention(message.ID, mention.UserID) {
+			continue
+		}
+		err = notificationService.CreateNotification(mention.UserID, "mention", &message.EventID, &message.ID, &message.UserID, map[string]string{
+			"content": message.Content,
+		})
+		if err != nil {
+			log.Printf("Error notifying %s of mention in message %s: %v", mention.UserID, message.ID, err)
+		}
+	}
+}
+
+// alreadyNotifiedOfMention reports whether the user was already notified of a mention in the message
+func alreadyNotifiedOfMention(messageID, userID string) bool {
+	var alreadyNotified bool
+	err := db.DB.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM notifications WHERE message_id = $1 AND user_id = $2 AND type = 'mention')`,
+		messageID, userID).Scan(&alreadyNotified)
+	if err != nil {
+		// Err on the side of not sending duplicate notifications
+		return true
+	}
+	return alreadyNotified
+}
+
+// attachMessageMentions fills the mentions of the given messages
+func attachMessageMentions(messages []models.EventMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	messageIDs := make([]string, len(messages))
+	for i := range messages {
+		messageIDs[i] = messages[i].ID
+		messages[i].Mentions = []models.MessageMention{}
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT mm.message_id, mm.user_id, TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')), mm.token
+		FROM event_message_mentions mm
+		JOIN users u ON u.id = mm.user_id
+		WHERE mm.message_id = ANY($1)
+		ORDER BY mm.created_at`, pq.Array(messageIDs))
+	if err != nil {
+		return fmt.Errorf("error fetching mentions: %w", err)
+	}
+	defer rows.Close()
+
+	mentions := make(map[string][]models.MessageMention)
+	for rows.Next() {
+		var messageID string
+		var mention models.MessageMention
+		if err := rows.Scan(&messageID, &mention.UserID, &mention.DisplayName, &mention.Token); err != nil {
+			return fmt.Errorf("error scanning mention: %w", err)
+		}
+		mentions[messageID] = append(mentions[messageID], mention)
+	}
+
+	for i := range messages {
+		if messageMentions, ok := mentions[messages[i].ID]; ok {
+			messages[i].Mentions = messageMentions
+		}
+	}
+
+	return rows.Err()
+}
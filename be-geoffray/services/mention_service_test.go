@@ -0,0 +1,106 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	candidates := []MentionCandidate{
+		{UserID: "11111111-1111-1111-1111-111111111111", FirstName: "Marie", LastName: "Dupont"},
+		{UserID: "22222222-2222-2222-2222-222222222222", FirstName: "Marie", LastName: "Martin"},
+		{UserID: "33333333-3333-3333-3333-333333333333", FirstName: "Lucas", LastName: "Bernard"},
+	}
+
+	tests := []struct {
+		name       string
+		content    string
+		wantIDs    []string
+		wantTokens []string
+	}{
+		{
+			name:       "unique first name",
+			content:    "Thanks @lucas!",
+			wantIDs:    []string{"33333333-3333-3333-3333-333333333333"},
+			wantTokens: []string{"@lucas"},
+		},
+		{
+			name:       "full name disambiguates a shared first name",
+			content:    "@Marie Martin what do you think?",
+			wantIDs:    []string{"22222222-2222-2222-2222-222222222222"},
+			wantTokens: []string{"@Marie Martin"},
+		},
+		{
+			name:    "ambiguous first name is not resolved",
+			content: "@Marie what do you think?",
+			wantIDs: []string{},
+		},
+		{
+			name:       "ID token",
+			content:    "Ask <@11111111-1111-1111-1111-111111111111> about it",
+			wantIDs:    []string{"11111111-1111-1111-1111-111111111111"},
+			wantTokens: []string{"<@11111111-1111-1111-1111-111111111111>"},
+		},
+		{
+			name:    "non-participant ID token",
+			content: "Ask <@44444444-4444-4444-4444-444444444444>",
+			wantIDs: []string{},
+		},
+		{
+			name:    "email and partial words are not mentions",
+			content: "Write to lucas@example.com or @Lucasfilm",
+			wantIDs: []string{},
+		},
+		{
+			name:       "duplicate mentions are kept once",
+			content:    "@Lucas @Lucas Bernard",
+			wantIDs:    []string{"33333333-3333-3333-3333-333333333333"},
+			wantTokens: []string{"@Lucas"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mentions := ParseMentions(tt.content, candidates)
+
+			ids := []string{}
+			tokens := []string{}
+			for _, mention := range mentions {
+				ids = append(ids, mention.UserID)
+				tokens = append(tokens, mention.Token)
+			}
+
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("ParseMentions() ids = %v, want %v", ids, tt.wantIDs)
+			}
+			if tt.wantTokens != nil && !reflect.DeepEqual(tokens, tt.wantTokens) {
+				t.Errorf("ParseMentions() tokens = %v, want %v", tokens, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestMentionableCandidatesAreParticipants(t *testing.T) {
+	user := func(id, firstName string, isCreator bool, status string) mentionCandidateRow {
+		return mentionCandidateRow{MentionCandidate: MentionCandidate{UserID: id, FirstName: firstName}, isCreator: isCreator, participantStatus: status}
+	}
+	candidates := mentionableCandidates([]mentionCandidateRow{
+		user("creator", "Alice", true, ""),
+		user("accepted", "Bob", false, "accepted"),
+		user("going", "Chloe", false, "going"),
+		user("pending", "David", false, "pending"),
+		user("declined", "Emma", false, "declined"),
+	})
+
+	var ids []string
+	for _, candidate := range candidates {
+		ids = append(ids, candidate.UserID)
+	}
+	if want := []string{"creator", "accepted", "going"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("mentionable users = %v, want %v", ids, want)
+	}
+
+	if mentions := ParseMentions("@David, can you bring the cake?", candidates); len(mentions) != 0 {
+		t.Errorf("a pending invitee was mentioned: %v", mentions)
+	}
+}
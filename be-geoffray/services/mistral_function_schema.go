@@ -2,6 +2,38 @@ package services
 
 // FunctionCallSchema describes a function that the AI can call
 // This schema is sent to Mistral as part of the chat context
+type FunctionCallSchema struct {
+	Type     string             `json:"type"` // Always "function"
+	Function FunctionDefinition `json:"function"`
+}
 
-// Note: Flight-related function schemas have been removed
-// Add new function schemas here as needed for other integrations
+// FunctionDefinition is the name, purpose and JSON schema of the arguments of a function
+type FunctionDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// newFunctionSchema builds the schema of a function taking an object of the given properties
+func newFunctionSchema(name, description string, properties map[string]interface{}, required ...string) FunctionCallSchema {
+	if required == nil {
+		required = []string{}
+	}
+	return FunctionCallSchema{
+		Type: "function",
+		Function: FunctionDefinition{
+			Name:        name,
+			Description: description,
+			Parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": properties,
+				"required":   required,
+			},
+		},
+	}
+}
+
+// stringProperty is the JSON schema of a string argument
+func stringProperty(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
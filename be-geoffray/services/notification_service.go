@@ -0,0 +1,134 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"be-geoffray/db"
+	"be-geoffray/models"
+)
+
+// NotificationService handles in-app notifications
+type NotificationService struct{}
+
+// NewNotificationService creates a new instance of NotificationService
+func NewNotificationService() *NotificationService {
+	return &NotificationService{}
+}
+
+// CreateNotification stores a notification for a user
+func (s *NotificationService) CreateNotification(userID string, notificationType string, eventID *string, messageID *string, actorID *string, data interface{}) error {
+	var payload []byte
+	if data != nil {
+		var err error
+		if payload, err = json.Marshal(data); err != nil {
+			return err
+		}
+	}
+
+	_, err := db.DB.Exec(`
+		INSERT INTO notifications (user_id, type, event_id, message_id, actor_id, data, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())`,
+		userID, notificationType, eventID, messageID, actorID, payload)
+	if err != nil {
+		log.Println("Error creating notification:", err)
+		return errors.New("failed to create notification")
+	}
+
+	return nil
+}
+
+// GetNotifications returns the latest notifications of a user
+func (s *NotificationService) GetNotifications(userID string, unreadOnly bool, limit int) ([]models.Notification, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	query := `
+		SELECT id, user_id, type, event_id, message_id, actor_id, data, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1 AND ($2 = false OR read_at IS NULL)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	rows, err := db.DB.Query(query, userID, unreadOnly, limit)
+	if err != nil {
+		log.Println("Error fetching notifications:", err)
+		return nil, errors.New("failed to fetch notifications")
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var notification models.Notification
+		var eventID, messageID, actorID sql.NullString
+		var data []byte
+		var readAt sql.NullTime
+
+		if err := rows.Scan(&notification.ID, &notification.UserID, &notification.Type,
+			&eventID, &messageID, &actorID, &data, &readAt, &notification.CreatedAt); err != nil {
+			log.Println("Error scanning notification:", err)
+			return nil, errors.New("failed to fetch notifications")
+		}
+
+		if eventID.Valid {
+			notification.EventID = &eventID.String
+		}
+		if messageID.Valid {
+			notification.MessageID = &messageID.String
+		}
+		if actorID.Valid {
+			notification.ActorID = &actorID.String
+		}
+		if data != nil {
+			notification.Data = json.RawMessage(data)
+		}
+		if readAt.Valid {
+			notification.ReadAt = &readAt.Time
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+// GetUnreadCount returns the number of unread notifications of a user
+func (s *NotificationService) GetUnreadCount(userID string) (int, error) {
+	var count int
+	err := db.DB.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID).Scan(&count)
+	if err != nil {
+		log.Println("Error counting notifications:", err)
+		return 0, errors.New("failed to count notifications")
+	}
+	return count, nil
+}
+
+// MarkAsRead marks one notification of the user as read
+func (s *NotificationService) MarkAsRead(userID string, notificationID string) error {
+	result, err := db.DB.Exec(`
+		UPDATE notifications SET read_at = COALESCE(read_at, NOW())
+		WHERE id = $1 AND user_id = $2`, notificationID, userID)
+	if err != nil {
+		log.Println("Error marking notification as read:", err)
+		return errors.New("failed to update notification")
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return errors.New("notification not found")
+	}
+	return nil
+}
+
+// MarkAllAsRead marks every notification of the user as read
+func (s *NotificationService) MarkAllAsRead(userID string) error {
+	_, err := db.DB.Exec(`UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL`, userID)
+	if err != nil {
+		log.Println("Error marking notifications as read:", err)
+		return errors.New("failed to update notifications")
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"log"
 
 	"be-geoffray/db"
@@ -56,6 +57,11 @@ func (s *ParticipantService) UpdateParticipantStatus(eventID string, userID inte
 		// Don't fail the request, just log the warning
 	}
 
+	EmitLifecycleEvent(LifecycleParticipantRSVP, eventID, fmt.Sprint(userID), map[string]interface{}{
+		"user_id": fmt.Sprint(userID),
+		"status":  status,
+	})
+
 	return nil
 }
 
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"be-geoffray/db"
+	"be-geoffray/models"
+)
+
+// GetPromptVersionStats aggregates the votes on the AI suggestions generated between from and to, per prompt version
+func GetPromptVersionStats(from, to time.Time) ([]models.PromptVersionStats, error) {
+	rows, err := db.DB.Query(`
+		SELECT COALESCE(gs.prompt_version, '') AS version,
+			COUNT(DISTINCT gs.id),
+			COUNT(DISTINCT gs.event_id),
+			COUNT(v.suggestion_id) FILTER (WHERE v.vote_type = 'upvote'),
+			COUNT(v.suggestion_id) FILTER (WHERE v.vote_type = 'downvote')
+		FROM gift_suggestions gs
+		LEFT JOIN gift_suggestion_votes v ON v.suggestion_id = gs.id
+		WHERE gs.creation_mode = 'ai' AND gs.generated_at >= $1 AND gs.generated_at < $2
+		GROUP BY version
+		ORDER BY version
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error loading prompt version stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []models.PromptVersionStats{}
+	for rows.Next() {
+		var stat models.PromptVersionStats
+		if err := rows.Scan(&stat.Version, &stat.Suggestions, &stat.Events, &stat.Upvotes, &stat.Downvotes); err != nil {
+			return nil, fmt.Errorf("error scanning prompt version stats: %w", err)
+		}
+		if votes := stat.Upvotes + stat.Downvotes; votes > 0 {
+			stat.UpvoteRatio = float64(stat.Upvotes) / float64(votes)
+			stat.VotesPerGift = float64(votes) / float64(stat.Suggestions)
+		}
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
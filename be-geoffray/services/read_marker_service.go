@@ -0,0 +1,115 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"be-geoffray/db"
+	"be-geoffray/models"
+)
+
+// MarkEventMessagesRead moves the read marker of a user in an event discussion up to a message
+// When messageID is empty, the marker moves to the latest message. Markers never move backwards.
+func MarkEventMessagesRead(eventID, userID, messageID string) error {
+	var targetID string
+	var createdAt time.Time
+
+	var err error
+	if messageID == "" {
+		err = db.DB.QueryRow(`
+			SELECT id, created_at FROM event_messages
+			WHERE event_id = $1
+			ORDER BY created_at DESC, id DESC
+			LIMIT 1`, eventID).Scan(&targetID, &createdAt)
+		if err == sql.ErrNoRows {
+			// Nothing to read yet
+			return nil
+		}
+	} else {
+		err = db.DB.QueryRow(`
+			SELECT id, created_at FROM event_messages
+			WHERE id = $1 AND event_id = $2`, messageID, eventID).Scan(&targetID, &createdAt)
+		if err == sql.ErrNoRows {
+			return errors.New("message not found")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("error fetching message: %w", err)
+	}
+
+	result, err := db.DB.Exec(`
+		INSERT INTO event_read_markers (event_id, user_id, last_read_message_id, last_read_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (event_id, user_id) DO UPDATE
+		SET last_read_message_id = EXCLUDED.last_read_message_id,
+			last_read_at = EXCLUDED.last_read_at,
+			updated_at = NOW()
+		WHERE event_read_markers.last_read_at < EXCLUDED.last_read_at`,
+		eventID, userID, targetID, createdAt)
+	if err != nil {
+		return fmt.Errorf("error updating read marker: %w", err)
+	}
+
+	// Let other participants update their "seen by" indicators
+	if moved, _ := result.RowsAffected(); moved > 0 {
+		EmitLifecycleEvent(LifecycleMessageRead, eventID, userID, map[string]interface{}{
+			"user_id":    userID,
+			"message_id": targetID,
+			"read_at":    createdAt,
+		})
+	}
+
+	return nil
+}
+
+// GetUnreadMessageCount returns the number of messages from others that a user has not read in an event
+func GetUnreadMessageCount(eventID, userID string) (int, error) {
+	var count int
+	err := db.DB.QueryRow(`
+		SELECT COUNT(*)
+		FROM event_messages m
+		LEFT JOIN event_read_markers rm ON rm.event_id = m.event_id AND rm.user_id = $2
+		WHERE m.event_id = $1 AND m.user_id <> $2 AND m.deleted_at IS NULL
+		AND (rm.last_read_at IS NULL OR m.created_at > rm.last_read_at)`, eventID, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting unread messages: %w", err)
+	}
+	return count, nil
+}
+
+// GetMessageSeenBy returns the participants whose read marker is at or after a message, excluding its author
+func GetMessageSeenBy(eventID, messageID string) ([]models.MessageSeenBy, error) {
+	var authorID string
+	var createdAt time.Time
+	err := db.DB.QueryRow(`SELECT user_id, created_at FROM event_messages WHERE id = $1 AND event_id = $2`, messageID, eventID).Scan(&authorID, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("message not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching message: %w", err)
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT u.id, COALESCE(u.first_name, ''), COALESCE(u.last_name, ''), rm.updated_at
+		FROM event_read_markers rm
+		JOIN users u ON u.id = rm.user_id
+		WHERE rm.event_id = $1 AND rm.last_read_at >= $2 AND rm.user_id <> $3
+		ORDER BY rm.updated_at ASC`, eventID, createdAt, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching read markers: %w", err)
+	}
+	defer rows.Close()
+
+	seenBy := []models.MessageSeenBy{}
+	for rows.Next() {
+		var seen models.MessageSeenBy
+		if err := rows.Scan(&seen.UserID, &seen.FirstName, &seen.LastName, &seen.ReadAt); err != nil {
+			return nil, fmt.Errorf("error scanning read marker: %w", err)
+		}
+		seenBy = append(seenBy, seen)
+	}
+
+	return seenBy, rows.Err()
+}
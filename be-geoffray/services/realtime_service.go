@@ -0,0 +1,254 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"be-geoffray/db"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// realtimeNotifyChannel is the Postgres channel used to fan out frames across replicas
+	realtimeNotifyChannel = "event_realtime"
+	// realtimeMaxNotifyPayload keeps NOTIFY payloads under the 8000 bytes Postgres limit
+	realtimeMaxNotifyPayload = 7900
+	// realtimeSubscriberBuffer is the number of frames buffered per WebSocket connection
+	realtimeSubscriberBuffer = 32
+)
+
+// RealtimeFrameResync tells clients that frames may have been missed and that they should refetch
+const RealtimeFrameResync = "resync"
+
+// RealtimeFrame is a typed JSON frame pushed to the WebSocket clients of an event
+type RealtimeFrame struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	EventID    string          `json:"event_id"`
+	ActorID    string          `json:"actor_id,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	// Truncated is set when the data was too large to be relayed, clients should refetch the resource
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// RealtimeBroker publishes frames to the subscribers of an event
+type RealtimeBroker interface {
+	// Publish sends a frame to every subscriber of the frame's event
+	Publish(frame RealtimeFrame) error
+	// Subscribe returns a channel of frames for an event and a function to unsubscribe
+	Subscribe(eventID string) (<-chan RealtimeFrame, func())
+}
+
+// NewRealtimeBroker creates the broker selected by the REALTIME_BROKER setting
+func NewRealtimeBroker(kind string) (RealtimeBroker, error) {
+	switch kind {
+	case "", "memory":
+		return NewRealtimeHub(), nil
+	case "postgres":
+		return NewPostgresRealtimeBroker(db.ConnectionString())
+	default:
+		return nil, errors.New("unknown realtime broker: " + kind)
+	}
+}
+
+// RealtimeHub is an in-process pub/sub hub, suitable for a single instance
+type RealtimeHub struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan RealtimeFrame]struct{}
+}
+
+// NewRealtimeHub creates a new in-process hub
+func NewRealtimeHub() *RealtimeHub {
+	return &RealtimeHub{
+		subscribers: make(map[string]map[chan RealtimeFrame]struct{}),
+	}
+}
+
+// Publish delivers a frame to the local subscribers of the event
+// Slow subscribers whose buffer is full miss the frame rather than blocking the publisher
+func (h *RealtimeHub) Publish(frame RealtimeFrame) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.subscribers[frame.EventID] {
+		select {
+		case ch <- frame:
+		default:
+			log.Printf("Dropping realtime frame %s for a slow subscriber of event %s", frame.Type, frame.EventID)
+		}
+	}
+	return nil
+}
+
+// Subscribe registers a new subscriber for an event
+func (h *RealtimeHub) Subscribe(eventID string) (<-chan RealtimeFrame, func()) {
+	ch := make(chan RealtimeFrame, realtimeSubscriberBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[eventID] == nil {
+		h.subscribers[eventID] = make(map[chan RealtimeFrame]struct{})
+	}
+	h.subscribers[eventID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[eventID], ch)
+			if len(h.subscribers[eventID]) == 0 {
+				delete(h.subscribers, eventID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// broadcast delivers a frame to every local subscriber, whatever their event
+func (h *RealtimeHub) broadcast(frameType string) {
+	h.mu.RLock()
+	eventIDs := make([]string, 0, len(h.subscribers))
+	for eventID := range h.subscribers {
+		eventIDs = append(eventIDs, eventID)
+	}
+	h.mu.RUnlock()
+
+	for _, eventID := range eventIDs {
+		h.Publish(RealtimeFrame{Type: frameType, EventID: eventID, OccurredAt: time.Now().UTC()})
+	}
+}
+
+// PostgresRealtimeBroker relays frames through Postgres LISTEN/NOTIFY so that
+// every replica can push them to its own WebSocket connections
+type PostgresRealtimeBroker struct {
+	hub      *RealtimeHub
+	listener *pq.Listener
+}
+
+// NewPostgresRealtimeBroker creates a broker listening on the realtime channel
+func NewPostgresRealtimeBroker(connString string) (*PostgresRealtimeBroker, error) {
+	broker := &PostgresRealtimeBroker{hub: NewRealtimeHub()}
+
+	broker.listener = pq.NewListener(connString, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Realtime listener error: %v", err)
+		}
+		// Frames sent while the connection was down are lost, ask clients to refetch
+		if event == pq.ListenerEventReconnected {
+			broker.hub.broadcast(RealtimeFrameResync)
+		}
+	})
+
+	if err := broker.listener.Listen(realtimeNotifyChannel); err != nil {
+		broker.listener.Close()
+		return nil, err
+	}
+
+	go broker.listen()
+
+	return broker, nil
+}
+
+// listen dispatches notifications to the local subscribers
+func (b *PostgresRealtimeBroker) listen() {
+	for {
+		select {
+		case notification := <-b.listener.Notify:
+			if notification == nil {
+				continue
+			}
+			var frame RealtimeFrame
+			if err := json.Unmarshal([]byte(notification.Extra), &frame); err != nil {
+				log.Printf("Error decoding realtime notification: %v", err)
+				continue
+			}
+			b.hub.Publish(frame)
+		case <-time.After(90 * time.Second):
+			// Check the connection is still alive when the channel is quiet
+			go b.listener.Ping()
+		}
+	}
+}
+
+// Publish sends the frame through NOTIFY, local subscribers receive it back through the listener
+func (b *PostgresRealtimeBroker) Publish(frame RealtimeFrame) error {
+	payload, err := json.Marshal(frame)
+	if err != nil {
+		return err
+	}
+
+	if len(payload) > realtimeMaxNotifyPayload {
+		frame.Data = nil
+		frame.Truncated = true
+		if payload, err = json.Marshal(frame); err != nil {
+			return err
+		}
+	}
+
+	_, err = db.DB.Exec(`SELECT pg_notify($1, $2)`, realtimeNotifyChannel, string(payload))
+	return err
+}
+
+// Subscribe registers a new subscriber for an event
+func (b *PostgresRealtimeBroker) Subscribe(eventID string) (<-chan RealtimeFrame, func()) {
+	return b.hub.Subscribe(eventID)
+}
+
+// RealtimeService bridges lifecycle events to the realtime broker
+type RealtimeService struct {
+	broker RealtimeBroker
+}
+
+var realtimeService *RealtimeService
+
+// InitRealtimeService sets up the realtime broker and subscribes it to lifecycle events
+func InitRealtimeService(kind string) error {
+	broker, err := NewRealtimeBroker(kind)
+	if err != nil {
+		return err
+	}
+
+	realtimeService = &RealtimeService{broker: broker}
+	RegisterLifecycleListener(realtimeService.HandleLifecycleEvent)
+	return nil
+}
+
+// GetRealtimeService returns the realtime service set up on startup
+func GetRealtimeService() *RealtimeService {
+	return realtimeService
+}
+
+// HandleLifecycleEvent publishes a lifecycle event as a realtime frame
+func (s *RealtimeService) HandleLifecycleEvent(event LifecycleEvent) {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		log.Printf("Error marshaling realtime frame for %s: %v", event.Type, err)
+		return
+	}
+
+	frame := RealtimeFrame{
+		ID:         event.ID,
+		Type:       string(event.Type),
+		EventID:    event.EventID,
+		ActorID:    event.ActorID,
+		OccurredAt: event.OccurredAt,
+		Data:       data,
+	}
+
+	if err := s.broker.Publish(frame); err != nil {
+		log.Printf("Error publishing realtime frame %s for event %s: %v", frame.Type, frame.EventID, err)
+	}
+}
+
+// Subscribe returns the frames of an event and a function to unsubscribe
+func (s *RealtimeService) Subscribe(eventID string) (<-chan RealtimeFrame, func()) {
+	return s.broker.Subscribe(eventID)
+}
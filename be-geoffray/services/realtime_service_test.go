@@ -0,0 +1,38 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestRealtimeHubPublish(t *testing.T) {
+	hub := NewRealtimeHub()
+
+	frames, unsubscribe := hub.Subscribe("event-1")
+	other, unsubscribeOther := hub.Subscribe("event-2")
+	defer unsubscribeOther()
+
+	hub.Publish(RealtimeFrame{Type: string(LifecycleMessageCreated), EventID: "event-1"})
+
+	select {
+	case frame := <-frames:
+		if frame.Type != string(LifecycleMessageCreated) {
+			t.Errorf("received frame type = %v, want %v", frame.Type, LifecycleMessageCreated)
+		}
+	default:
+		t.Fatal("subscriber of event-1 did not receive the frame")
+	}
+
+	select {
+	case frame := <-other:
+		t.Errorf("subscriber of event-2 received a frame for %v", frame.EventID)
+	default:
+	}
+
+	unsubscribe()
+	if _, ok := <-frames; ok {
+		t.Error("channel should be closed after unsubscribing")
+	}
+
+	// Publishing after unsubscribing must not panic
+	hub.Publish(RealtimeFrame{Type: string(LifecycleMessageCreated), EventID: "event-1"})
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+
+	"be-geoffray/db"
+	"be-geoffray/models"
+)
+
+// Kinds of gift signals learnt from a user's history
+const (
+	GiftSignalUpvote   = "upvote"
+	GiftSignalDownvote = "downvote"
+	GiftSignalAdded    = "added" // Suggestion the user added or asked the AI for
+)
+
+// Tuning of the recommendations
+const (
+	// minDefaultChoices is how often a persona or occasion must have been chosen to become a default
+	minDefaultChoices       = 2
+	recommendationPersona   = 1.0
+	recommendationOccasion  = 0.5
+	recommendationPriceFit  = 0.5
+	recommendationTasteCap  = 3.0
+	maxRecommendationsLimit = 20
+)
+
+// UserGiftSignal is one sign of a user's taste: a vote on, or the addition of, a suggestion
+type UserGiftSignal struct {
+	Kind       string
+	Category   string
+	PriceRange string
+}
+
+// UserEventChoice is a persona and occasion the user picked for an event
+type UserEventChoice struct {
+	Persona  string
+	Occasion string
+}
+
+// categoryTaste counts the signals of a user on one category
+type categoryTaste struct {
+	Name      string
+	Upvotes   int
+	Downvotes int
+	Added     int
+}
+
+// score is positive for the categories the user likes
+func (t *categoryTaste) score() float64 {
+	return float64(t.Upvotes + t.Added - t.Downvotes)
+}
+
+// UserTasteProfile is what a user's gift history says about their tastes and habits
+type UserTasteProfile struct {
+	categories map[string]*categoryTaste
+
+	// Typical price of the liked gifts, 0 when unknown
+	PriceMin float64
+	PriceMax float64
+
+	// Most chosen persona and occasion, with how often they were chosen
+	Persona       string
+	PersonaCount  int
+	Occasion      string
+	OccasionCount int
+}
+
+// BuildUserTasteProfile learns a user's tastes from their signals and event choices
+func BuildUserTasteProfile(signals []UserGiftSignal, choices []UserEventChoice) *UserTasteProfile {
+	profile := &UserTasteProfile{categories: make(map[string]*categoryTaste)}
+
+	var prices []float64
+	for _, signal := range signals {
+		key := normalizeSimilarityText(signal.Category)
+		if key == "" {
+			continue
+		}
+		taste, ok := profile.categories[key]
+		if !ok {
+			taste = &categoryTaste{Name: signal.Category}
+			profile.categories[key] = taste
+		}
+		switch signal.Kind {
+		case GiftSignalUpvote:
+			taste.Upvotes++
+		case GiftSignalDownvote:
+			taste.Downvotes++
+			continue
+		case GiftSignalAdded:
+			taste.Added++
+		}
+		if low, high, err := giftPriceBounds(signal.PriceRange); err == nil {
+			prices = append(prices, (low+high)/2)
+		}
+	}
+
+	// The typical price range is the middle half of the liked prices, robust to a few expensive gifts
+	if len(prices) > 0 {
+		sort.Float64s(prices)
+		profile.PriceMin = prices[len(prices)/4]
+		profile.PriceMax = prices[len(prices)-1-len(prices)/4]
+	}
+
+	personas := make(map[string]int)
+	occasions := make(map[string]int)
+	for _, choice := range choices {
+		if choice.Persona != "" {
+			personas[choice.Persona]++
+		}
+		if choice.Occasion != "" {
+			occasions[choice.Occasion]++
+		}
+	}
+	profile.Persona, profile.PersonaCount = mostFrequent(personas)
+	profile.Occasion, profile.OccasionCount = mostFrequent(occasions)
+
+	return profile
+}
+
+// mostFrequent returns the most counted key, the first in alphabetical order on ties
+func mostFrequent(counts map[string]int) (string, int) {
+	best, bestCount := "", 0
+	for key, count := range counts {
+		if count > bestCount || (count == bestCount && key < best) {
+			best, bestCount = key, count
+		}
+	}
+	return best, bestCount
+}
+
+// EventDefaults proposes the persona and occasion the user picks most, nil without enough history
+func (p *UserTasteProfile) EventDefaults() *models.EventDefaults {
+	if p.PersonaCount < minDefaultChoices && p.OccasionCount < minDefaultChoices {
+		return nil
+	}
+	defaults := &models.EventDefaults{
+		ReasonEN: "Based on the events you created before",
+		ReasonFR: "D'après les événements que vous avez déjà créés",
+	}
+	if p.PersonaCount >= minDefaultChoices {
+		defaults.GifteePersona = p.Persona
+	}
+	if p.OccasionCount >= minDefaultChoices {
+		defaults.EventOccasion = p.Occasion
+	}
+	return defaults
+}
+
+// Recommend ranks catalog gifts for the user and explains each choice
+// Only gifts with a positive score are kept, at most limit of them
+func (p *UserTasteProfile) Recommend(catalog []models.GiftRecommendation, limit int) []models.GiftRecommendation {
+	recommendations := []models.GiftRecommendation{}
+	seen := make(map[string]bool)
+	for _, gift := range catalog {
+		name := normalizeSimilarityText(gift.NameEN)
+		if seen[name] {
+			continue
+		}
+
+		taste := p.categories[normalizeSimilarityText(gift.Category)]
+		score := 0.0
+		if taste != nil {
+			score = math.Max(-recommendationTasteCap, math.Min(recommendationTasteCap, taste.score()))
+		}
+		personaMatch := p.Persona != "" && gift.PersonaKey == p.Persona
+		if personaMatch {
+			score += recommendationPersona
+		}
+		if p.Occasion != "" && gift.OccasionKey == p.Occasion {
+			score += recommendationOccasion
+		}
+		if p.PriceMax > 0 {
+			if low, high, err := giftPriceBounds(gift.PriceRange); err == nil && low <= p.PriceMax && high >= p.PriceMin {
+				score += recommendationPriceFit
+			}
+		}
+		if score <= 0 {
+			continue
+		}
+
+		gift.Score = score
+		gift.ReasonEN, gift.ReasonFR = p.reason(taste, personaMatch)
+		seen[name] = true
+		recommendations = append(recommendations, gift)
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Score > recommendations[j].Score
+	})
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+	return recommendations
+}
+
+// reason explains a recommendation by its strongest signal
+func (p *UserTasteProfile) reason(taste *categoryTaste, personaMatch bool) (string, string) {
+	switch {
+	case taste != nil && taste.score() > 0 && taste.Upvotes >= taste.Added:
+		return fmt.Sprintf("Because you upvoted %d %s gift%s", taste.Upvotes, taste.Name, plural(taste.Upvotes)),
+			fmt.Sprintf("Parce que vous avez aimé %d cadeau%s %s", taste.Upvotes, pluralX(taste.Upvotes), taste.Name)
+	case taste != nil && taste.score() > 0:
+		return fmt.Sprintf("Because you added %d %s gift%s", taste.Added, taste.Name, plural(taste.Added)),
+			fmt.Sprintf("Parce que vous avez ajouté %d cadeau%s %s", taste.Added, pluralX(taste.Added), taste.Name)
+	case personaMatch:
+		return fmt.Sprintf("Because you often look for gifts for the %s persona", p.Persona),
+			fmt.Sprintf("Parce que vous cherchez souvent des cadeaux pour le profil %s", p.Persona)
+	default:
+		return "Matches your usual budget and occasions", "Correspond à votre budget et vos occasions habituels"
+	}
+}
+
+// plural returns the English plural suffix for count
+func plural(count int) string {
+	if count > 1 {
+		return "s"
+	}
+	return ""
+}
+
+// pluralX returns the plural suffix of "cadeau" for count
+func pluralX(count int) string {
+	if count > 1 {
+		return "x"
+	}
+	return ""
+}
+
+// RecommendationService learns each user's tastes from their gift history
+type RecommendationService struct {
+	db *sql.DB
+}
+
+// NewRecommendationService creates a new recommendation service
+func NewRecommendationService() *RecommendationService {
+	return &RecommendationService{db: db.DB}
+}
+
+// IsEnabled reports whether the user accepts personalized recommendations
+func (s *RecommendationService) IsEnabled(userID string) (bool, error) {
+	var enabled bool
+	err := s.db.QueryRow(`SELECT personalized_recommendations FROM users WHERE id = $1`, userID).Scan(&enabled)
+	return enabled, err
+}
+
+// GetRecommendations returns the recommendations of a user, nothing when they opted out
+func (s *RecommendationService) GetRecommendations(userID string, limit int) (*models.UserRecommendations, error) {
+	enabled, err := s.IsEnabled(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading recommendation setting: %w", err)
+	}
+	if !enabled {
+		return &models.UserRecommendations{Suggestions: []models.GiftRecommendation{}}, nil
+	}
+	if limit <= 0 || limit > maxRecommendationsLimit {
+		limit = maxRecommendationsLimit
+	}
+
+	profile, err := s.loadTasteProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := s.loadCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UserRecommendations{
+		Enabled:     true,
+		Defaults:    profile.EventDefaults(),
+		Suggestions: profile.Recommend(catalog, limit),
+	}, nil
+}
+
+// loadTasteProfile reads the votes, suggestions and event choices of a user
+func (s *RecommendationService) loadTasteProfile(userID string) (*UserTasteProfile, error) {
+	// Suggestions generated automatically for the user's events say nothing about their taste,
+	// only manual ones and those asked to the AI with a prompt count as added
+	rows, err := s.db.Query(`
+		SELECT v.vote_type, COALESCE(gs.category, ''), COALESCE(gs.price_range, '')
+		FROM gift_suggestion_votes v
+		JOIN gift_suggestions gs ON gs.id = v.suggestion_id
+		WHERE v.user_id = $1
+		UNION ALL
+		SELECT 'added', COALESCE(category, ''), COALESCE(price_range, '')
+		FROM gift_suggestions
+		WHERE owner_id = $1 AND (creation_mode = 'manual' OR prompt IS NOT NULL)
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading gift history: %w", err)
+	}
+	defer rows.Close()
+
+	var signals []UserGiftSignal
+	for rows.Next() {
+		var signal UserGiftSignal
+		if err := rows.Scan(&signal.Kind, &signal.Category, &signal.PriceRange); err != nil {
+			return nil, fmt.Errorf("error scanning gift history: %w", err)
+		}
+		signals = append(signals, signal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	choiceRows, err := s.db.Query(`
+		SELECT COALESCE(giftee_persona, ''), COALESCE(event_occasion, '')
+		FROM events
+		WHERE creator_id = $1
+		UNION ALL
+		SELECT COALESCE(p.persona_key, ''), COALESCE(o.occasion_key, '')
+		FROM gift_selections s
+		LEFT JOIN giftee_personas p ON p.id = s.persona_id
+		LEFT JOIN occasion_types o ON o.id = s.occasion_id
+		WHERE s.user_id = $1 AND s.event_id IS NULL
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading event choices: %w", err)
+	}
+	defer choiceRows.Close()
+
+	var choices []UserEventChoice
+	for choiceRows.Next() {
+		var choice UserEventChoice
+		if err := choiceRows.Scan(&choice.Persona, &choice.Occasion); err != nil {
+			return nil, fmt.Errorf("error scanning event choices: %w", err)
+		}
+		choices = append(choices, choice)
+	}
+	if err := choiceRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return BuildUserTasteProfile(signals, choices), nil
+}
+
+// loadCatalog reads the curated gifts recommendations are picked from
+func (s *RecommendationService) loadCatalog() ([]models.GiftRecommendation, error) {
+	rows, err := s.db.Query(`
+		SELECT name_en, name_fr, COALESCE(description_en, ''), COALESCE(description_fr, ''),
+			COALESCE(price_range, ''), COALESCE(category, ''), COALESCE(amazon_affiliate_url, ''),
+			persona_key, occasion_key
+		FROM static_gifts
+		ORDER BY persona_key, occasion_key
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error loading gift catalog: %w", err)
+	}
+	defer rows.Close()
+
+	var catalog []models.GiftRecommendation
+	for rows.Next() {
+		var gift models.GiftRecommendation
+		if err := rows.Scan(&gift.NameEN, &gift.NameFR, &gift.DescriptionEN, &gift.DescriptionFR,
+			&gift.PriceRange, &gift.Category, &gift.URL, &gift.PersonaKey, &gift.OccasionKey); err != nil {
+			return nil, fmt.Errorf("error scanning gift catalog: %w", err)
+		}
+		catalog = append(catalog, gift)
+	}
+	return catalog, rows.Err()
+}
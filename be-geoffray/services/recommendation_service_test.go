@@ -0,0 +1,79 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"be-geoffray/models"
+)
+
+func testTasteProfile() *UserTasteProfile {
+	return BuildUserTasteProfile(
+		[]UserGiftSignal{
+			{Kind: GiftSignalUpvote, Category: "Kitchen", PriceRange: "€20-30"},
+			{Kind: GiftSignalUpvote, Category: "kitchen", PriceRange: "€30-40"},
+			{Kind: GiftSignalUpvote, Category: "Kitchen", PriceRange: "€20"},
+			{Kind: GiftSignalAdded, Category: "Books", PriceRange: "€15"},
+			{Kind: GiftSignalUpvote, Category: "Electronics", PriceRange: "€300"},
+			{Kind: GiftSignalDownvote, Category: "Electronics", PriceRange: "€250"},
+			{Kind: GiftSignalDownvote, Category: "Electronics"},
+			{Kind: GiftSignalDownvote, Category: "Toys"},
+		},
+		[]UserEventChoice{
+			{Persona: "gourmet", Occasion: "birthday"},
+			{Persona: "gourmet", Occasion: "christmas"},
+			{Persona: "geek", Occasion: "wedding"},
+		},
+	)
+}
+
+func TestBuildUserTasteProfile(t *testing.T) {
+	profile := testTasteProfile()
+
+	kitchen := profile.categories["kitchen"]
+	if kitchen == nil || kitchen.Upvotes != 3 || kitchen.Name != "Kitchen" {
+		t.Fatalf("kitchen taste = %+v, want 3 upvotes whatever the case", kitchen)
+	}
+	// Liked prices are 15, 20, 25, 35 and 300: the expensive outlier is left out
+	if profile.PriceMin != 20 || profile.PriceMax != 35 {
+		t.Errorf("typical price €%v-%v, want €20-35", profile.PriceMin, profile.PriceMax)
+	}
+
+	defaults := profile.EventDefaults()
+	if defaults == nil || defaults.GifteePersona != "gourmet" || defaults.EventOccasion != "" {
+		t.Errorf("EventDefaults() = %+v, want the gourmet persona and no occasion", defaults)
+	}
+	if BuildUserTasteProfile(nil, nil).EventDefaults() != nil {
+		t.Error("EventDefaults() without history is not nil")
+	}
+}
+
+func TestUserTasteProfileRecommend(t *testing.T) {
+	catalog := []models.GiftRecommendation{
+		{NameEN: "Robot kit", Category: "Toys", PersonaKey: "geek", OccasionKey: "christmas"},
+		{NameEN: "Cheese board", Category: "Kitchen", PersonaKey: "gourmet", OccasionKey: "wedding", PriceRange: "€25-35"},
+		{NameEN: "Cheese Board", Category: "Kitchen", PersonaKey: "parent", OccasionKey: "birthday"},
+		{NameEN: "Cookbook", Category: "Books", PersonaKey: "artist", OccasionKey: "birthday"},
+		{NameEN: "Drone", Category: "Electronics", PersonaKey: "geek", OccasionKey: "birthday", PriceRange: "€300"},
+		{NameEN: "Whisky stones", Category: "Drinks", PersonaKey: "gourmet", OccasionKey: "retirement"},
+	}
+
+	recommendations := testTasteProfile().Recommend(catalog, 3)
+
+	var names []string
+	for _, recommendation := range recommendations {
+		names = append(names, recommendation.NameEN)
+	}
+	if got := strings.Join(names, ", "); got != "Cheese board, Cookbook, Whisky stones" {
+		t.Fatalf("recommended %s", got)
+	}
+	if recommendations[0].ReasonEN != "Because you upvoted 3 Kitchen gifts" || recommendations[0].ReasonFR != "Parce que vous avez aimé 3 cadeaux Kitchen" {
+		t.Errorf("reason = %q / %q", recommendations[0].ReasonEN, recommendations[0].ReasonFR)
+	}
+	if recommendations[1].ReasonEN != "Because you added 1 Books gift" {
+		t.Errorf("reason = %q", recommendations[1].ReasonEN)
+	}
+	if !strings.Contains(recommendations[2].ReasonEN, "gourmet persona") {
+		t.Errorf("reason = %q", recommendations[2].ReasonEN)
+	}
+}
@@ -21,25 +21,27 @@ func NewStaticGiftService(db *sql.DB) *StaticGiftService {
 }
 
 // GetStaticGiftSuggestion fetches a curated suggestion for a given persona and occasion
-// Returns the suggestion as a GiftSuggestion model ready for insertion into gift_suggestions table
-func (s *StaticGiftService) GetStaticGiftSuggestion(personaKey, occasionKey string) (*models.GiftSuggestion, error) {
+// Returns the suggestion as a GiftSuggestion model ready for insertion into gift_suggestions table,
+// written in the given language when the curated gift is translated in it
+func (s *StaticGiftService) GetStaticGiftSuggestion(personaKey, occasionKey, language string) (*models.GiftSuggestion, error) {
 	if s.DB == nil {
 		return nil, fmt.Errorf("database connection not available")
 	}
 
 	var suggestion models.GiftSuggestion
+	var staticGiftID string
 	var nameFR, nameEN sql.NullString
 	var descriptionFR, descriptionEN sql.NullString
 	var priceRange, category sql.NullString
 	var amazonAffiliateURL sql.NullString
 
 	err := s.DB.QueryRow(`
-		SELECT name_fr, name_en, description_fr, description_en,
+		SELECT id, name_fr, name_en, description_fr, description_en,
 		       price_range, category, amazon_affiliate_url
 		FROM static_gifts
 		WHERE persona_key = $1 AND occasion_key = $2
 	`, personaKey, occasionKey).Scan(
-		&nameFR, &nameEN,
+		&staticGiftID, &nameFR, &nameEN,
 		&descriptionFR, &descriptionEN,
 		&priceRange, &category,
 		&amazonAffiliateURL,
@@ -76,6 +78,20 @@ func (s *StaticGiftService) GetStaticGiftSuggestion(personaKey, occasionKey stri
 		suggestion.IsAffiliateLink = true
 	}
 
+	// Translations supersede the English and French columns
+	translations, err := loadStaticGiftTranslations(s.DB, staticGiftID)
+	if err != nil {
+		return nil, err
+	}
+	if len(translations) > 0 {
+		suggestion.Translations = translations
+		suggestion.Language = language
+		if _, ok := translations[language]; !ok {
+			suggestion.Language = DefaultGiftLanguage
+		}
+		SyncLegacyGiftFields(&suggestion)
+	}
+
 	// Set metadata
 	suggestion.CreationMode = "static"
 	now := time.Now()
@@ -0,0 +1,669 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"be-geoffray/config"
+	"be-geoffray/db"
+	"be-geoffray/models"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// webhookMaxAttempts is the number of delivery attempts before a delivery is marked as failed
+	webhookMaxAttempts = 8
+	// webhookBaseBackoff is the delay before the first retry, doubled on every following attempt
+	webhookBaseBackoff = 30 * time.Second
+	// webhookMaxBackoff caps the delay between two attempts
+	webhookMaxBackoff = 6 * time.Hour
+	// webhookLeaseDuration is how long a claimed delivery is hidden from other workers
+	webhookLeaseDuration = 5 * time.Minute
+	// webhookBatchSize is the number of due deliveries processed per worker tick
+	webhookBatchSize = 20
+)
+
+// ErrInvalidWebhookURL is returned for webhook URLs that are malformed or point to a forbidden address
+var ErrInvalidWebhookURL = errors.New("invalid webhook url")
+
+// blockedWebhookNetworks are the ranges net.IP has no predicate for: "this network", carrier-grade NAT,
+// and the local-use NAT64 prefix, whose IPv4 address can be embedded at several positions
+var blockedWebhookNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+	mustParseCIDR("64:ff9b:1::/48"),
+}
+
+// IPv6 ranges that embed an IPv4 address, which is checked like the address itself
+var (
+	nat64WebhookNetwork     = mustParseCIDR("64:ff9b::/96") // Well-known NAT64 prefix, the IPv4 address in the last 32 bits
+	sixToFourWebhookNetwork = mustParseCIDR("2002::/16")    // 6to4, the IPv4 address right after the prefix
+)
+
+// lookupWebhookHost resolves the host of a webhook URL, replaced in tests
+var lookupWebhookHost = func(ctx context.Context, host string) ([]net.IP, error) {
+	return net.DefaultResolver.LookupIP(ctx, "ip", host)
+}
+
+// WebhookService manages webhook registrations and delivers lifecycle events to them
+type WebhookService struct {
+	client *http.Client
+}
+
+// NewWebhookService creates a new instance of WebhookService
+func NewWebhookService() *WebhookService {
+	// Addresses are checked again when dialing, as the host may resolve differently than at registration
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			return checkWebhookIP(net.ParseIP(host))
+		},
+	}
+	return &WebhookService{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			// No proxy from the environment: the dialed address must be the receiver's
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: 5 * time.Second,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				// Never follow redirects, the receiver must answer on the registered URL
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// CreateWebhook registers a new webhook for a user, optionally scoped to a single event
+func (s *WebhookService) CreateWebhook(ownerID string, eventID *string, rawURL string, eventTypes []string) (*models.Webhook, error) {
+	if err := validateWebhookURL(rawURL); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEventTypes(eventTypes); err != nil {
+		return nil, err
+	}
+
+	// Event-scoped webhooks can only be registered by the event organizer
+	if eventID != nil && *eventID != "" {
+		if _, err := AuthorizeEventAction(*eventID, ownerID, EventActionManage); err != nil {
+			if err.Error() == "insufficient event access" {
+				return nil, errors.New("only the event creator can register event webhooks")
+			}
+			return nil, err
+		}
+	} else {
+		eventID = nil
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		log.Println("Error generating webhook secret:", err)
+		return nil, errors.New("failed to generate webhook secret")
+	}
+
+	webhook := models.Webhook{
+		OwnerID:    ownerID,
+		EventID:    eventID,
+		URL:        rawURL,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		Active:     true,
+	}
+
+	query := `
+		INSERT INTO webhooks (owner_id, event_id, url, secret, event_types, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`
+	err = db.DB.QueryRow(query, webhook.OwnerID, webhook.EventID, webhook.URL, webhook.Secret,
+		pq.Array(webhook.EventTypes), webhook.Active,
+	).Scan(&webhook.ID, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		log.Println("Error creating webhook:", err)
+		return nil, errors.New("failed to create webhook")
+	}
+
+	return &webhook, nil
+}
+
+// ListWebhooks returns all webhooks registered by a user (secrets are never returned)
+func (s *WebhookService) ListWebhooks(ownerID string) ([]models.Webhook, error) {
+	query := `
+		SELECT id, owner_id, event_id, url, event_types, active, created_at, updated_at
+		FROM webhooks
+		WHERE owner_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := db.DB.Query(query, ownerID)
+	if err != nil {
+		log.Println("Error fetching webhooks:", err)
+		return nil, errors.New("failed to fetch webhooks")
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			log.Println("Error scanning webhook:", err)
+			return nil, errors.New("failed to fetch webhooks")
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhook returns a single webhook owned by the user
+func (s *WebhookService) GetWebhook(webhookID string, ownerID string) (*models.Webhook, error) {
+	query := `
+		SELECT id, owner_id, event_id, url, event_types, active, created_at, updated_at
+		FROM webhooks
+		WHERE id = $1 AND owner_id = $2
+	`
+
+	webhook, err := scanWebhook(db.DB.QueryRow(query, webhookID, ownerID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("webhook not found")
+		}
+		log.Println("Error fetching webhook:", err)
+		return nil, errors.New("failed to fetch webhook")
+	}
+
+	return webhook, nil
+}
+
+// UpdateWebhook updates the URL, subscribed event types or active flag of a webhook
+func (s *WebhookService) UpdateWebhook(webhookID string, ownerID string, rawURL *string, eventTypes []string, active *bool) (*models.Webhook, error) {
+	webhook, err := s.GetWebhook(webhookID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if rawURL != nil {
+		if err := validateWebhookURL(*rawURL); err != nil {
+			return nil, err
+		}
+		webhook.URL = *rawURL
+	}
+	if eventTypes != nil {
+		if err := validateWebhookEventTypes(eventTypes); err != nil {
+			return nil, err
+		}
+		webhook.EventTypes = eventTypes
+	}
+	if active != nil {
+		webhook.Active = *active
+	}
+
+	query := `
+		UPDATE webhooks
+		SET url = $1, event_types = $2, active = $3, updated_at = NOW()
+		WHERE id = $4 AND owner_id = $5
+		RETURNING updated_at
+	`
+	err = db.DB.QueryRow(query, webhook.URL, pq.Array(webhook.EventTypes), webhook.Active, webhookID, ownerID).Scan(&webhook.UpdatedAt)
+	if err != nil {
+		log.Printf("Error updating webhook %s: %v", webhookID, err)
+		return nil, errors.New("failed to update webhook")
+	}
+
+	return webhook, nil
+}
+
+// DeleteWebhook removes a webhook and its delivery log
+func (s *WebhookService) DeleteWebhook(webhookID string, ownerID string) error {
+	result, err := db.DB.Exec(`DELETE FROM webhooks WHERE id = $1 AND owner_id = $2`, webhookID, ownerID)
+	if err != nil {
+		log.Printf("Error deleting webhook %s: %v", webhookID, err)
+		return errors.New("failed to delete webhook")
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return errors.New("webhook not found")
+	}
+
+	return nil
+}
+
+// ListDeliveries returns the most recent deliveries of a webhook owned by the user
+func (s *WebhookService) ListDeliveries(webhookID string, ownerID string, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(webhookID, ownerID); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	query := `
+		SELECT id, webhook_id, event_type, payload, status, attempts, last_status_code, last_error,
+			next_attempt_at, delivered_at, replay_of, created_at, updated_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := db.DB.Query(query, webhookID, limit)
+	if err != nil {
+		log.Println("Error fetching webhook deliveries:", err)
+		return nil, errors.New("failed to fetch webhook deliveries")
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			log.Println("Error scanning webhook delivery:", err)
+			return nil, errors.New("failed to fetch webhook deliveries")
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	return deliveries, nil
+}
+
+// ReplayDelivery queues a new delivery with the same payload as an earlier one
+func (s *WebhookService) ReplayDelivery(webhookID string, deliveryID string, ownerID string) (*models.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(webhookID, ownerID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event_type, payload, status, next_attempt_at, replay_of, created_at, updated_at)
+		SELECT webhook_id, event_type, payload, 'pending', NOW(), id, NOW(), NOW()
+		FROM webhook_deliveries
+		WHERE id = $1 AND webhook_id = $2
+		RETURNING id, webhook_id, event_type, payload, status, attempts, last_status_code, last_error,
+			next_attempt_at, delivered_at, replay_of, created_at, updated_at
+	`
+
+	delivery, err := scanWebhookDelivery(db.DB.QueryRow(query, deliveryID, webhookID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("delivery not found")
+		}
+		log.Printf("Error replaying webhook delivery %s: %v", deliveryID, err)
+		return nil, errors.New("failed to replay delivery")
+	}
+
+	return delivery, nil
+}
+
+// HandleLifecycleEvent queues a delivery for every active webhook subscribed to the event
+// It is registered as a lifecycle listener on startup
+func (s *WebhookService) HandleLifecycleEvent(event LifecycleEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error marshaling lifecycle event %s for webhooks: %v", event.ID, err)
+		return
+	}
+
+	// Match event-scoped webhooks, and account-wide webhooks whose owner takes part in the event
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event_type, payload, status, next_attempt_at, created_at, updated_at)
+		SELECT w.id, $2, $3, 'pending', NOW(), NOW(), NOW()
+		FROM webhooks w
+		WHERE w.active = true
+		AND $2 = ANY(w.event_types)
+		AND (
+			w.event_id = $1
+			OR (w.event_id IS NULL AND (
+				EXISTS (SELECT 1 FROM events e WHERE e.id = $1 AND e.creator_id = w.owner_id)
+				OR EXISTS (SELECT 1 FROM event_participants ep WHERE ep.event_id = $1 AND ep.user_id = w.owner_id)
+			))
+		)
+	`
+
+	result, err := db.DB.Exec(query, event.EventID, string(event.Type), payload)
+	if err != nil {
+		log.Printf("Error queuing webhook deliveries for %s on event %s: %v", event.Type, event.EventID, err)
+		return
+	}
+
+	if queued, _ := result.RowsAffected(); queued > 0 {
+		log.Printf("Queued %d webhook deliveries for %s on event %s", queued, event.Type, event.EventID)
+	}
+}
+
+// StartDeliveryWorker starts a background loop that delivers due webhook payloads
+// Deliveries are claimed with a lease so several replicas can run the worker concurrently
+func (s *WebhookService) StartDeliveryWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			s.processDueDeliveries()
+		}
+	}()
+}
+
+// processDueDeliveries claims a batch of due deliveries and attempts each of them once
+func (s *WebhookService) processDueDeliveries() {
+	query := `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + $1 * INTERVAL '1 second'
+		FROM webhooks w
+		WHERE d.webhook_id = w.id
+		AND d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.id, d.event_type, d.payload, d.attempts, w.url, w.secret, w.active
+	`
+
+	rows, err := db.DB.Query(query, int(webhookLeaseDuration.Seconds()), webhookBatchSize)
+	if err != nil {
+		log.Println("Error claiming webhook deliveries:", err)
+		return
+	}
+
+	type claimedDelivery struct {
+		id        string
+		eventType string
+		payload   []byte
+		attempts  int
+		url       string
+		secret    string
+		active    bool
+	}
+
+	var claimed []claimedDelivery
+	for rows.Next() {
+		var d claimedDelivery
+		if err := rows.Scan(&d.id, &d.eventType, &d.payload, &d.attempts, &d.url, &d.secret, &d.active); err != nil {
+			log.Println("Error scanning claimed webhook delivery:", err)
+			continue
+		}
+		claimed = append(claimed, d)
+	}
+	rows.Close()
+
+	for _, d := range claimed {
+		if !d.active {
+			s.recordDeliveryResult(d.id, d.attempts, nil, errors.New("webhook is disabled"), true)
+			continue
+		}
+		statusCode, err := s.deliver(d.url, d.secret, d.id, d.eventType, d.payload)
+		// A receiver on a forbidden address will not become allowed by retrying
+		s.recordDeliveryResult(d.id, d.attempts+1, statusCode, err, errors.Is(err, ErrInvalidWebhookURL))
+	}
+}
+
+// deliver sends a signed payload to the webhook URL and returns the response status code
+func (s *WebhookService) deliver(targetURL string, secret string, deliveryID string, eventType string, payload []byte) (*int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest("POST", targetURL, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Geoffray-Webhooks/1.0")
+	req.Header.Set("X-Geoffray-Event", eventType)
+	req.Header.Set("X-Geoffray-Delivery", deliveryID)
+	req.Header.Set("X-Geoffray-Timestamp", timestamp)
+	req.Header.Set("X-Geoffray-Signature", "sha256="+SignWebhookPayload(secret, timestamp, payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	statusCode := resp.StatusCode
+	if statusCode < 200 || statusCode >= 300 {
+		return &statusCode, fmt.Errorf("receiver returned status %d", statusCode)
+	}
+
+	return &statusCode, nil
+}
+
+// recordDeliveryResult stores the outcome of an attempt and schedules a retry when needed
+func (s *WebhookService) recordDeliveryResult(deliveryID string, attempts int, statusCode *int, deliveryErr error, permanent bool) {
+	var err error
+	if deliveryErr == nil {
+		_, err = db.DB.Exec(`
+			UPDATE webhook_deliveries
+			SET status = 'succeeded', attempts = $1, last_status_code = $2, last_error = NULL,
+				delivered_at = NOW(), updated_at = NOW()
+			WHERE id = $3`, attempts, statusCode, deliveryID)
+	} else if permanent || attempts >= webhookMaxAttempts {
+		_, err = db.DB.Exec(`
+			UPDATE webhook_deliveries
+			SET status = 'failed', attempts = $1, last_status_code = $2, last_error = $3, updated_at = NOW()
+			WHERE id = $4`, attempts, statusCode, deliveryErr.Error(), deliveryID)
+	} else {
+		nextAttempt := time.Now().Add(WebhookBackoff(attempts))
+		_, err = db.DB.Exec(`
+			UPDATE webhook_deliveries
+			SET attempts = $1, last_status_code = $2, last_error = $3, next_attempt_at = $4, updated_at = NOW()
+			WHERE id = $5`, attempts, statusCode, deliveryErr.Error(), nextAttempt, deliveryID)
+	}
+
+	if err != nil {
+		log.Printf("Error recording result of webhook delivery %s: %v", deliveryID, err)
+	}
+}
+
+// SignWebhookPayload computes the hex encoded HMAC-SHA256 of "timestamp.payload" with the webhook secret
+// Receivers recompute it to verify both the origin and the freshness of a delivery
+func SignWebhookPayload(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookBackoff returns the delay before the next attempt, doubling after each failed attempt
+func WebhookBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= webhookMaxBackoff {
+			return webhookMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// generateWebhookSecret creates a random signing secret for a webhook
+func generateWebhookSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(bytes), nil
+}
+
+// validateWebhookURL checks that a webhook URL is an absolute https URL, or http in development,
+// whose host only resolves to public addresses
+func validateWebhookURL(rawURL string) error {
+	parsedURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsedURL.Hostname() == "" {
+		return ErrInvalidWebhookURL
+	}
+	switch parsedURL.Scheme {
+	case "https":
+	case "http":
+		if !config.GetConfig().IsDevelopment() {
+			return fmt.Errorf("%w: https is required", ErrInvalidWebhookURL)
+		}
+	default:
+		return ErrInvalidWebhookURL
+	}
+
+	ips := []net.IP{net.ParseIP(parsedURL.Hostname())}
+	if ips[0] == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if ips, err = lookupWebhookHost(ctx, parsedURL.Hostname()); err != nil || len(ips) == 0 {
+			return fmt.Errorf("%w: host cannot be resolved", ErrInvalidWebhookURL)
+		}
+	}
+	for _, ip := range ips {
+		if err := checkWebhookIP(ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkWebhookIP rejects the addresses of the server itself and of internal networks:
+// loopback, private, link-local, multicast and unspecified addresses
+func checkWebhookIP(ip net.IP) error {
+	if ip == nil {
+		return ErrInvalidWebhookURL
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: address %s is not allowed", ErrInvalidWebhookURL, ip)
+	}
+	for _, network := range blockedWebhookNetworks {
+		if network.Contains(ip) {
+			return fmt.Errorf("%w: address %s is not allowed", ErrInvalidWebhookURL, ip)
+		}
+	}
+	if embedded := embeddedWebhookIPv4(ip); embedded != nil && checkWebhookIP(embedded) != nil {
+		return fmt.Errorf("%w: address %s embeds %s, which is not allowed", ErrInvalidWebhookURL, ip, embedded)
+	}
+	return nil
+}
+
+// embeddedWebhookIPv4 returns the IPv4 address carried by a NAT64 or 6to4 address, nil for other addresses
+func embeddedWebhookIPv4(ip net.IP) net.IP {
+	if ip.To4() != nil {
+		return nil
+	}
+	ip16 := ip.To16()
+	switch {
+	case ip16 == nil:
+		return nil
+	case nat64WebhookNetwork.Contains(ip16):
+		return net.IP(ip16[12:16])
+	case sixToFourWebhookNetwork.Contains(ip16):
+		return net.IP(ip16[2:6])
+	}
+	return nil
+}
+
+// mustParseCIDR parses a network known at compile time
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// validateWebhookEventTypes checks that at least one known event type is subscribed
+func validateWebhookEventTypes(eventTypes []string) error {
+	if len(eventTypes) == 0 {
+		return errors.New("at least one event type is required")
+	}
+	for _, eventType := range eventTypes {
+		if !IsKnownLifecycleEventType(eventType) {
+			return fmt.Errorf("unknown event type: %s", eventType)
+		}
+	}
+	return nil
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanWebhook scans a webhook row (without its secret)
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	var webhook models.Webhook
+	var eventID sql.NullString
+	var eventTypes pq.StringArray
+
+	err := row.Scan(
+		&webhook.ID, &webhook.OwnerID, &eventID, &webhook.URL, &eventTypes,
+		&webhook.Active, &webhook.CreatedAt, &webhook.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if eventID.Valid {
+		webhook.EventID = &eventID.String
+	}
+	webhook.EventTypes = []string(eventTypes)
+
+	return &webhook, nil
+}
+
+// scanWebhookDelivery scans a webhook delivery row
+func scanWebhookDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var lastStatusCode sql.NullInt64
+	var lastError, replayOf sql.NullString
+	var deliveredAt sql.NullTime
+	var payload []byte
+
+	err := row.Scan(
+		&delivery.ID, &delivery.WebhookID, &delivery.EventType, &payload, &delivery.Status,
+		&delivery.Attempts, &lastStatusCode, &lastError, &delivery.NextAttemptAt,
+		&deliveredAt, &replayOf, &delivery.CreatedAt, &delivery.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Payload = json.RawMessage(payload)
+	if lastStatusCode.Valid {
+		code := int(lastStatusCode.Int64)
+		delivery.LastStatusCode = &code
+	}
+	if lastError.Valid {
+		delivery.LastError = &lastError.String
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	if replayOf.Valid {
+		delivery.ReplayOf = &replayOf.String
+	}
+
+	return &delivery, nil
+}
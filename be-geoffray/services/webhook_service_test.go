@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"be-geoffray/config"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		payload   string
+		want      string
+	}{
+		{
+			name:      "known signature",
+			secret:    "whsec_test",
+			timestamp: "1700000000",
+			payload:   `{"type":"event.updated"}`,
+			want:      "c7f5c1244296aba6e1c6e629f279ff201e68d528f6ea1d4e228113f7c21033e4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SignWebhookPayload(tt.secret, tt.timestamp, []byte(tt.payload))
+			if got != tt.want {
+				t.Errorf("SignWebhookPayload() = %v, want %v", got, tt.want)
+			}
+			if len(got) != 64 {
+				t.Errorf("SignWebhookPayload() length = %d, want 64", len(got))
+			}
+			if SignWebhookPayload("other", tt.timestamp, []byte(tt.payload)) == got {
+				t.Error("SignWebhookPayload() should depend on the secret")
+			}
+			if SignWebhookPayload(tt.secret, "1700000001", []byte(tt.payload)) == got {
+				t.Error("SignWebhookPayload() should depend on the timestamp")
+			}
+		})
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{8, 64 * time.Minute},
+		{20, 6 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := WebhookBackoff(tt.attempts); got != tt.want {
+			t.Errorf("WebhookBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	cfg := config.GetConfig()
+	environment := cfg.Environment
+	t.Cleanup(func() { cfg.Environment = environment })
+
+	// Hosts resolve without a network: internal.example points to a private address
+	lookup := lookupWebhookHost
+	t.Cleanup(func() { lookupWebhookHost = lookup })
+	lookupWebhookHost = func(ctx context.Context, host string) ([]net.IP, error) {
+		switch host {
+		case "hooks.example.com":
+			return []net.IP{net.ParseIP("93.184.216.34")}, nil
+		case "internal.example":
+			return []net.IP{net.ParseIP("93.184.216.34"), net.ParseIP("10.1.2.3")}, nil
+		case "localhost":
+			return []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	tests := []struct {
+		name        string
+		environment string
+		url         string
+		wantErr     bool
+	}{
+		{"public https", "production", "https://hooks.example.com/geoffray", false},
+		{"public address", "production", "https://93.184.216.34/hook", false},
+		{"http in production", "production", "http://hooks.example.com/geoffray", true},
+		{"http in development", "development", "http://hooks.example.com/geoffray", false},
+		{"not http", "development", "ftp://hooks.example.com/geoffray", true},
+		{"no host", "production", "https:///geoffray", true},
+		{"unresolved host", "production", "https://unknown.example/hook", true},
+		{"localhost", "development", "http://localhost:5432", true},
+		{"loopback", "development", "http://127.0.0.1/hook", true},
+		{"ipv6 loopback", "production", "https://[::1]/hook", true},
+		{"cloud metadata", "development", "http://169.254.169.254/latest/meta-data", true},
+		{"private network", "production", "https://10.0.0.8/hook", true},
+		{"private network 192.168", "production", "https://192.168.1.10/hook", true},
+		{"host with a private address", "production", "https://internal.example/hook", true},
+		{"ipv6 link-local", "production", "https://[fe80::1]/hook", true},
+		{"ipv6 unique local", "production", "https://[fd00::1]/hook", true},
+		{"ipv4-mapped loopback", "production", "https://[::ffff:127.0.0.1]/hook", true},
+		{"multicast", "production", "https://224.0.0.1/hook", true},
+		{"unspecified", "development", "http://0.0.0.0:8080/hook", true},
+		{"carrier-grade nat", "production", "https://100.64.0.1/hook", true},
+		{"nat64 private network", "production", "https://[64:ff9b::10.0.0.1]/hook", true},
+		{"nat64 loopback", "production", "https://[64:ff9b::7f00:1]/hook", true},
+		{"nat64 cloud metadata", "production", "https://[64:ff9b::a9fe:a9fe]/hook", true},
+		{"nat64 public address", "production", "https://[64:ff9b::5db8:d822]/hook", false},
+		{"local-use nat64", "production", "https://[64:ff9b:1::a00:1]/hook", true},
+		{"6to4 private network", "production", "https://[2002:c0a8:10a::1]/hook", true},
+		{"6to4 loopback", "production", "https://[2002:7f00:1::1]/hook", true},
+		{"6to4 public address", "production", "https://[2002:5db8:d822::1]/hook", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Environment = tt.environment
+			err := validateWebhookURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateWebhookURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidWebhookURL) {
+				t.Errorf("validateWebhookURL(%q) error = %v, want ErrInvalidWebhookURL", tt.url, err)
+			}
+		})
+	}
+}
+
+func TestWebhookDeliveryRefusesInternalAddresses(t *testing.T) {
+	service := NewWebhookService()
+	// The dialer checks the address itself, whatever the URL was validated against
+	_, err := service.deliver("http://127.0.0.1:1/hook", "whsec_test", "delivery-1", "event.updated", []byte(`{}`))
+	if !errors.Is(err, ErrInvalidWebhookURL) {
+		t.Errorf("deliver() error = %v, want ErrInvalidWebhookURL", err)
+	}
+}
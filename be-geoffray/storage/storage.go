@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"be-geoffray/config"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// Storage stores uploaded files by key
+// Keys are slash separated paths such as "attachments/<event-id>/<attachment-id>"
+type Storage interface {
+	// Put stores the content read from r under key, replacing any existing object
+	Put(key string, r io.Reader) error
+	// Get opens the object stored under key
+	Get(key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key, deleting a missing object is not an error
+	Delete(key string) error
+}
+
+var (
+	once     sync.Once
+	instance Storage
+)
+
+// GetStorage returns the storage backend configured for the application
+func GetStorage() Storage {
+	once.Do(func() {
+		instance = NewLocalStorage(config.GetConfig().StorageLocalPath)
+	})
+	return instance
+}
+
+// LocalStorage stores objects as files under a root directory
+type LocalStorage struct {
+	root string
+}
+
+// NewLocalStorage creates a storage backend writing under root
+func NewLocalStorage(root string) *LocalStorage {
+	return &LocalStorage{root: root}
+}
+
+// path resolves a key to a file path, refusing keys that would escape the root directory
+func (s *LocalStorage) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if key == "" || strings.Contains(key, "..") || cleaned == "/" {
+		return "", errors.New("invalid storage key")
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
+
+// Put writes the object to a temporary file first so that readers never see partial content
+func (s *LocalStorage) Put(key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get opens the file stored under key
+func (s *LocalStorage) Get(key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete removes the file stored under key
+func (s *LocalStorage) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
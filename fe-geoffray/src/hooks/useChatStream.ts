@@ -16,11 +16,11 @@ export function useChatStream() {
       onComplete,
     }: {
       token: string;
-      chat_id?: string;
+      chat_id: string; // The event whose discussion the agent answers in
       message: string;
       onMessage: (chunk: string) => void;
       onError?: (err: any) => void;
-      onComplete?: () => void;
+      onComplete?: (messageId?: string) => void; // ID of the stored agent reply, when it was saved
     }) => {
       console.log('Attempting stream with react-native-event-source:', { token, chat_id, message });
       
@@ -29,6 +29,9 @@ export function useChatStream() {
         eventSourceRef.current = null;
       }
 
+      // Set by the final chunk once the reply is stored
+      let messageId: string | undefined;
+
       const url = `${apiConfig.baseUrl}/chat/stream`;
       const options = {
         method: 'POST',
@@ -63,7 +66,7 @@ export function useChatStream() {
                 eventSourceRef.current.close();
                 eventSourceRef.current = null;
               }
-              onComplete?.();
+              onComplete?.(messageId);
               return;
             }
             
@@ -87,6 +90,10 @@ export function useChatStream() {
               const jsonData = JSON.parse(messageData);
               console.log('Successfully parsed JSON:', jsonData);
               
+              if (jsonData.message_id) {
+                messageId = jsonData.message_id;
+              }
+              
               // Check for different possible formats
               if (jsonData.text) {
                 console.log('Found text field in JSON:', jsonData.text);
@@ -102,6 +109,9 @@ export function useChatStream() {
                 // Handle OpenAI-style non-streaming format
                 console.log('Found OpenAI message format:', jsonData.choices[0].message.content);
                 onMessage(jsonData.choices[0].message.content);
+              } else if (jsonData.choices) {
+                // The final chunk (finish_reason, empty delta) only carries metadata
+                console.log('Ignoring chunk without content:', jsonData.choices[0]?.finish_reason);
               } else {
                 // If we can't find a known field, send the whole message as a string
                 console.log('No recognized format in JSON, using stringified version');